@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// WhatsApp groups between 2 and this many media items into one album
+const maxAlbumItems = 5
+
+// AlbumConfig sends several images/videos per customer as one grouped album
+type AlbumConfig struct {
+	Columns   []string // CSV columns holding media file paths
+	Directory string   // Base directory with one folder per customer Code
+}
+
+// albumEnabled reports whether album sends are configured
+func albumEnabled() bool {
+	return len(config.Album.Columns) > 0 || config.Album.Directory != ""
+}
+
+// albumMediaForCustomer collects the customer's media files from the
+// configured columns, or from Directory/<Code>/ if no column is filled
+func albumMediaForCustomer(customer Customer) []string {
+	paths := []string{}
+	for _, column := range config.Album.Columns {
+		if path := customer.Fields[column]; path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) == 0 && config.Album.Directory != "" {
+		dir := filepath.Join(config.Album.Directory, customer.Code)
+		entries, err := os.ReadDir(dir)
+		if err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() && albumMediaType(entry.Name()) != "" {
+					paths = append(paths, filepath.Join(dir, entry.Name()))
+				}
+			}
+			sort.Strings(paths)
+		}
+	}
+
+	if len(paths) > maxAlbumItems {
+		log.Warning(fmt.Sprintf("%s has %d album items, only the first %d will be sent",
+			customer.CustomerName, len(paths), maxAlbumItems))
+		paths = paths[:maxAlbumItems]
+	}
+	return paths
+}
+
+// albumMediaType returns the upload type for a file based on its extension
+func albumMediaType(path string) whatsmeow.MediaType {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return whatsmeow.MediaImage
+	case ".mp4", ".3gp", ".mov":
+		return whatsmeow.MediaVideo
+	}
+	return ""
+}
+
+// buildAlbumItem uploads a file and wraps it in an image or video message
+func buildAlbumItem(client Sender, path string) (*waE2E.Message, error) {
+	mediaType := albumMediaType(path)
+	if mediaType == "" {
+		return nil, fmt.Errorf("unsupported album file type: %s", path)
+	}
+
+	media, err := uploadMediaFile(client, path, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	mimetype := http.DetectContentType(media.Data)
+
+	if mediaType == whatsmeow.MediaVideo {
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			Mimetype:      proto.String(mimetype),
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+		}}, nil
+	}
+	return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		URL:           proto.String(media.URL),
+		DirectPath:    proto.String(media.DirectPath),
+		MediaKey:      media.MediaKey,
+		Mimetype:      proto.String(mimetype),
+		FileEncSHA256: media.FileEncSHA256,
+		FileSHA256:    media.FileSHA256,
+		FileLength:    proto.Uint64(media.FileLength),
+	}}, nil
+}
+
+// sendAlbum sends the customer's media as an album. A single item is sent
+// on its own since WhatsApp only groups two or more.
+func sendAlbum(client Sender, customer ProcessedCustomer, phone string) error {
+	paths := albumMediaForCustomer(customer.Customer)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	items := make([]*waE2E.Message, 0, len(paths))
+	images, videos := 0, 0
+	for _, path := range paths {
+		item, err := buildAlbumItem(client, path)
+		if err != nil {
+			return err
+		}
+		if item.VideoMessage != nil {
+			videos++
+		} else {
+			images++
+		}
+		items = append(items, item)
+	}
+
+	ctx := context.Background()
+	jid := recipientJID(client, phone)
+
+	if len(items) == 1 {
+		_, err := client.SendMessage(ctx, jid, items[0])
+		return err
+	}
+
+	// The album message announces the item counts; each item then points back to it
+	resp, err := client.SendMessage(ctx, jid, &waE2E.Message{
+		AlbumMessage: &waE2E.AlbumMessage{
+			ExpectedImageCount: proto.Uint32(uint32(images)),
+			ExpectedVideoCount: proto.Uint32(uint32(videos)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start album: %w", err)
+	}
+	parentKey := client.BuildMessageKey(jid, types.EmptyJID, resp.ID)
+
+	for i, item := range items {
+		item.MessageContextInfo = &waE2E.MessageContextInfo{
+			MessageAssociation: &waE2E.MessageAssociation{
+				AssociationType:  waE2E.MessageAssociation_MEDIA_ALBUM.Enum(),
+				ParentMessageKey: parentKey,
+			},
+		}
+		if _, err := client.SendMessage(ctx, jid, item); err != nil {
+			return fmt.Errorf("failed to send album item %d/%d: %w", i+1, len(items), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TelegramConfig posts operator alerts to a Telegram chat through a bot
+type TelegramConfig struct {
+	BotToken string // From @BotFather; VENOM_TELEGRAM_TOKEN overrides it
+	ChatID   string // Chat, group or channel ID to post to
+}
+
+// remoteAlertsEnabled reports whether any chat alert target is configured
+func remoteAlertsEnabled() bool {
+	return config.Notify.SlackWebhook != "" || config.Notify.Telegram.ChatID != ""
+}
+
+// sendRemoteAlert posts an alert to every configured chat. It waits at most
+// a few seconds so a slow chat service can't hold up the campaign.
+func sendRemoteAlert(event, title, message string) {
+	if !remoteAlertsEnabled() {
+		return
+	}
+	if len(config.Notify.Events) > 0 && !containsFold(config.Notify.Events, event) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	host, _ := os.Hostname()
+	text := fmt.Sprintf("%s %s\n%s\n(%s, %s)", alertIcon(event), title, message, host, time.Now().Format("2006-01-02 15:04"))
+
+	if config.Notify.SlackWebhook != "" {
+		if err := postSlack(ctx, config.Notify.SlackWebhook, text); err != nil {
+			log.Warning(fmt.Sprintf("Slack alert failed: %v", err))
+		}
+	}
+	if config.Notify.Telegram.ChatID != "" {
+		if err := postTelegram(ctx, config.Notify.Telegram, text); err != nil {
+			log.Warning(fmt.Sprintf("Telegram alert failed: %v", err))
+		}
+	}
+}
+
+// alertIcon marks alerts by severity so they stand out in a busy chat
+func alertIcon(event string) string {
+	switch event {
+	case notifyFinished:
+		return "✅"
+	case notifyStarted:
+		return "🚀"
+	case notifyDailyLimit:
+		return "⏸"
+	}
+	return "🚨"
+}
+
+// containsFold reports whether list contains value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// postSlack sends text to a Slack incoming webhook
+func postSlack(ctx context.Context, webhook, text string) error {
+	body, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doAlertRequest(req)
+}
+
+// postTelegram sends text to a Telegram chat with the Bot API
+func postTelegram(ctx context.Context, settings TelegramConfig, text string) error {
+	token := settings.BotToken
+	if env := os.Getenv("VENOM_TELEGRAM_TOKEN"); env != "" {
+		token = env
+	}
+	if token == "" {
+		return fmt.Errorf("Notify.Telegram.BotToken (or VENOM_TELEGRAM_TOKEN) is required")
+	}
+
+	form := url.Values{}
+	form.Set("chat_id", settings.ChatID)
+	form.Set("text", text)
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doAlertRequest(req)
+}
+
+// doAlertRequest sends an alert request and checks the status
+func doAlertRequest(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Don't leak the bot token through the URL in the error
+		return fmt.Errorf("request to %s failed", req.URL.Host)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned HTTP %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
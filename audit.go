@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// auditLog appends one JSON line per send attempt to audit/audit-<date>.jsonl
+// for compliance reviews. Files are only ever appended to; a new one starts
+// each day.
+type auditLog struct {
+	mu         sync.Mutex
+	dir        string
+	date       string
+	file       *os.File
+	encoder    *json.Encoder
+	operator   string
+	configHash string
+}
+
+// audit is the open audit log, or nil when there is none
+var audit *auditLog
+
+// campaignID identifies this run in the audit log
+var campaignID string
+
+// secretKeyPattern matches config keys whose values must not be copied into the audit log
+var secretKeyPattern = regexp.MustCompile(`(?i)token|password|secret|webhook|dsn|passphrase`)
+
+// newCampaignID returns a unique, sortable ID for a campaign run
+func newCampaignID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// openAuditLog starts the audit log for a campaign and records the config
+// it runs with
+func openAuditLog(dir string) (*auditLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	operator := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		operator = u.Username
+	}
+	snapshot := configSnapshot()
+	data, _ := json.Marshal(snapshot)
+	sum := sha256.Sum256(data)
+
+	a := &auditLog{dir: dir, operator: operator, configHash: hex.EncodeToString(sum[:])}
+	if campaignID == "" {
+		campaignID = newCampaignID()
+	}
+	a.write(map[string]interface{}{
+		"event":       "campaign_started",
+		"config":      snapshot,
+		"config_hash": a.configHash,
+		"csv":         campaignCSV,
+	})
+	if a.file == nil {
+		return nil, fmt.Errorf("cannot write to %s", dir)
+	}
+	return a, nil
+}
+
+// configSnapshot returns the running config with secrets removed
+func configSnapshot() map[string]interface{} {
+	data, _ := json.Marshal(config)
+	var snapshot map[string]interface{}
+	json.Unmarshal(data, &snapshot)
+	redactSecrets(snapshot)
+	return snapshot
+}
+
+// redactSecrets blanks credential values anywhere in a decoded config,
+// including passwords inside URLs such as Distributed.Redis
+func redactSecrets(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			s, ok := inner.(string)
+			if !ok || s == "" {
+				redactSecrets(inner)
+				continue
+			}
+			if secretKeyPattern.MatchString(key) {
+				v[key] = "[redacted]"
+			} else if u, err := url.Parse(s); err == nil && u.User != nil {
+				v[key] = u.Redacted()
+			}
+		}
+	case []interface{}:
+		for _, inner := range v {
+			redactSecrets(inner)
+		}
+	}
+}
+
+// attempt records one send attempt to a customer
+func (a *auditLog) attempt(customer ProcessedCustomer, phone, message string, attempt int, err error) {
+	if a == nil {
+		return
+	}
+	sum := sha256.Sum256([]byte(message))
+	entry := map[string]interface{}{
+		"event":        "send_attempt",
+		"code":         customer.Code,
+		"recipient":    phone,
+		"attempt":      attempt + 1,
+		"message_hash": hex.EncodeToString(sum[:]),
+		"basis":        customer.ConsentBasis,
+		"status":       statusSent,
+	}
+	if err != nil {
+		entry["status"] = "failed"
+		entry["error"] = err.Error()
+	}
+	a.write(entry)
+}
+
+// write appends an entry with the fields common to every line, switching to
+// a new file when the date changes
+func (a *auditLog) write(entry map[string]interface{}) {
+	now := time.Now()
+	entry["time"] = now.Format(time.RFC3339)
+	entry["campaign_id"] = campaignID
+	entry["operator"] = a.operator
+	if _, ok := entry["config_hash"]; !ok {
+		entry["config_hash"] = a.configHash
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if date := now.Format("2006-01-02"); date != a.date || a.file == nil {
+		if a.file != nil {
+			a.file.Close()
+		}
+		path := filepath.Join(a.dir, "audit-"+date+".jsonl")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Error("Failed to open audit log", err)
+			a.file = nil
+			return
+		}
+		a.file, a.date = file, date
+		a.encoder = json.NewEncoder(file)
+		a.encoder.SetEscapeHTML(false)
+	}
+	if err := a.encoder.Encode(entry); err != nil {
+		log.Error("Failed to write audit log", err)
+	}
+}
+
+// close closes the current audit file
+func (a *auditLog) close() {
+	if a == nil || a.file == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.file.Close(); err != nil {
+		log.Error("Failed to close audit log", err)
+	}
+	a.file = nil
+}
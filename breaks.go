@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/muesli/cancelreader"
+	"golang.org/x/term"
+)
+
+// breakControl is a request to change the running batch break
+type breakControl int
+
+const (
+	breakSkip breakControl = iota
+	breakExtend
+	breakInterrupt // Ctrl+C while the countdown owns the keyboard
+)
+
+// breakExtendStep is how much each extend request adds to the break
+const breakExtendStep = time.Minute
+
+// breakControls carries skip/extend requests from the keyboard or the dashboard
+var breakControls = make(chan breakControl, 1)
+
+// requestBreakControl asks the running break to skip or extend; dropped if
+// a request is already waiting
+func requestBreakControl(c breakControl) {
+	select {
+	case breakControls <- c:
+	default:
+	}
+}
+
+// takeBatchBreak waits out a batch break with a live countdown. The operator
+// can press s to skip it or + to extend it. It returns false if the campaign
+// was cancelled during the break.
+func takeBatchBreak(ctx context.Context, d time.Duration) bool {
+	// Drop a stale request left from an earlier break
+	select {
+	case <-breakControls:
+	default:
+	}
+
+	// Raw mode stops newlines returning the cursor, so keys are only read
+	// while the countdown line is showing and released before logging
+	interactive := dashboard == nil && !plainOutput && term.IsTerminal(int(os.Stdin.Fd()))
+	stopKeys := func() {}
+	release := func() {
+		stopKeys()
+		stopKeys = func() {}
+		if interactive {
+			fmt.Fprintln(console)
+		}
+	}
+	if interactive {
+		stopKeys = readBreakKeys()
+	} else if dashboard == nil {
+		log.Info(fmt.Sprintf("Batch break: resuming at %s", time.Now().Add(d).Format("15:04:05")))
+	}
+
+	end := time.Now().Add(d)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		left := time.Until(end).Round(time.Second)
+		if left <= 0 {
+			break
+		}
+		web.setDelay(left, "batch break")
+		status.refresh(left, "batch break")
+		if dashboard != nil {
+			dashboard.setDelay(left, "batch break")
+		} else if interactive {
+			fmt.Fprintf(console, "\r  ⏸  %s %s  %s", tr("Batch break, resuming in"), left,
+				dim+tr("[s] skip  [+] add 1 minute")+colorReset+"   ")
+		}
+
+		select {
+		case <-ctx.Done():
+			release()
+			return false
+		case c := <-breakControls:
+			release()
+			switch c {
+			case breakSkip:
+				log.Info("Batch break skipped by operator")
+				return true
+			case breakInterrupt:
+				// The menu needs stdin back; the break keeps counting meanwhile
+				showInterruptMenu()
+				if ctx.Err() != nil {
+					return false
+				}
+			default:
+				end = end.Add(breakExtendStep)
+				log.Info(fmt.Sprintf("Batch break extended, resuming at %s", end.Format("15:04:05")))
+			}
+			if interactive {
+				stopKeys = readBreakKeys()
+			}
+		case <-ticker.C:
+		}
+	}
+
+	stopKeys()
+	if interactive {
+		fmt.Fprint(console, "\r"+strings.Repeat(" ", 80)+"\r")
+	}
+	return true
+}
+
+// readBreakKeys puts the terminal in raw mode and turns key presses into
+// break controls until the returned func is called
+func readBreakKeys() func() {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return func() {}
+	}
+	reader, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		term.Restore(fd, state)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1)
+		for {
+			if _, err := reader.Read(buf); err != nil {
+				return
+			}
+			switch buf[0] {
+			case 's', 'S':
+				requestBreakControl(breakSkip)
+			case '+', '=', 'e', 'E':
+				requestBreakControl(breakExtend)
+			case 'r', 'R':
+				// Logging now would stair-step in raw mode; the reload logs when applied
+				reloadRequested.Store(true)
+			case 3: // Ctrl+C doesn't raise SIGINT in raw mode
+				if interruptMenuEnabled() {
+					requestBreakControl(breakInterrupt)
+				} else if p, err := os.FindProcess(os.Getpid()); err == nil {
+					p.Signal(os.Interrupt)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		reader.Cancel()
+		<-done
+		reader.Close()
+		term.Restore(fd, state)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// AccountBudget is a linked number a campaign is split across, with
+// Accounts listing two or more of them
+type AccountBudget struct {
+	Profile    string // Linked number or Session.Profiles name
+	DailyLimit int    // Sends per day once warmed up (0 = DailyLimit)
+	LinkedOn   string // Date the number was linked, e.g. "2026-09-01", for its warm-up cap ("" = warmed up)
+}
+
+// warmupStages caps the daily sends of a recently linked number by its age
+var warmupStages = []struct {
+	days  int
+	daily int
+}{
+	{7, 20},
+	{14, 50},
+	{21, 100},
+	{28, 200},
+}
+
+// allocationDir holds each number's share of a split campaign
+const allocationDir = "data/allocation"
+
+// accountShare is one number's part of the campaign
+type accountShare struct {
+	profile   string
+	phone     string
+	stage     string // e.g. "warm-up day 9" or "warmed up"
+	limit     int    // Sends allowed today at its stage
+	sentToday int    // Already sent in the last 24 hours, by any run
+	customers []ProcessedCustomer
+}
+
+// remaining is what the number can still send today
+func (s accountShare) remaining() int {
+	return max(s.limit-s.sentToday, 0)
+}
+
+// sendingPlan splits a campaign across the numbers in Accounts
+type sendingPlan struct {
+	shares   []accountShare
+	deferred []ProcessedCustomer // Beyond every number's budget today
+	own      int                 // Index of the share this run sends
+}
+
+// plan is this campaign's split, or nil when sending from one number
+var plan *sendingPlan
+
+// accountCap returns a number's daily cap at its warm-up stage
+func accountCap(budget AccountBudget) (int, string) {
+	full := budget.DailyLimit
+	if full <= 0 {
+		full = config.DailyLimit
+	}
+	if budget.LinkedOn == "" {
+		return full, "warmed up"
+	}
+	linked, err := time.ParseInLocation("2006-01-02", budget.LinkedOn, time.Local)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Accounts: LinkedOn %q of %s is not a date (2006-01-02), treating it as warmed up", budget.LinkedOn, budget.Profile))
+		return full, "warmed up"
+	}
+	day := int(time.Since(linked).Hours()/24) + 1
+	for _, stage := range warmupStages {
+		if day <= stage.days {
+			return min(stage.daily, full), fmt.Sprintf("warm-up day %d", day)
+		}
+	}
+	return full, "warmed up"
+}
+
+// sentTodayFrom counts a number's sends in the last 24 hours in the shared quota database
+func sentTodayFrom(phone string) int {
+	if config.QuotaFile == "" {
+		return 0
+	}
+	if _, err := os.Stat(config.QuotaFile); err != nil {
+		return 0
+	}
+	q, err := openAccountQuota(config.QuotaFile, types.NewJID(phone, types.DefaultUserServer).String())
+	if err != nil {
+		log.Debug(fmt.Sprintf("Failed to read the quota of +%s: %v", phone, err))
+		return 0
+	}
+	defer q.close()
+	_, daily, _ := q.counts()
+	return daily
+}
+
+// planAllocation splits customers across Accounts in proportion to what
+// each number can still send today, in list order. This run sends the
+// share of Session.Profile, or of the first number when none is set.
+func planAllocation(customers []ProcessedCustomer) *sendingPlan {
+	p := &sendingPlan{own: -1}
+	budget := 0
+	for _, account := range config.Accounts {
+		limit, stage := accountCap(account)
+		phone := strings.TrimPrefix(resolveProfile(account.Profile), "+")
+		share := accountShare{profile: account.Profile, phone: phone, stage: stage, limit: limit, sentToday: sentTodayFrom(phone)}
+		p.shares = append(p.shares, share)
+		budget += share.remaining()
+	}
+
+	// Largest remainder: every number gets its proportional part, rounded
+	// so the parts add up, and never more than its budget
+	n := min(len(customers), budget)
+	counts := make([]int, len(p.shares))
+	assigned := 0
+	for i, share := range p.shares {
+		if budget > 0 {
+			counts[i] = n * share.remaining() / budget
+		}
+		assigned += counts[i]
+	}
+	for assigned < n {
+		best := -1
+		for i, share := range p.shares {
+			if counts[i] >= share.remaining() {
+				continue
+			}
+			if best < 0 || n*share.remaining()%budget > n*p.shares[best].remaining()%budget {
+				best = i
+			}
+		}
+		counts[best]++
+		assigned++
+	}
+
+	start := 0
+	for i := range p.shares {
+		p.shares[i].customers = customers[start : start+counts[i]]
+		start += counts[i]
+	}
+	p.deferred = customers[start:]
+
+	for i, share := range p.shares {
+		if config.Session.Profile == "" && len(share.customers) > 0 && p.own < 0 {
+			p.own = i
+		}
+		if config.Session.Profile != "" && strings.TrimPrefix(resolveProfile(config.Session.Profile), "+") == share.phone {
+			p.own = i
+		}
+	}
+	return p
+}
+
+// use points the session and the daily limit at this run's number, and
+// saves the other shares
+func (p *sendingPlan) use() {
+	if p == nil || p.own < 0 {
+		return
+	}
+	config.Session.Profile = p.shares[p.own].profile
+	config.DailyLimit = p.shares[p.own].limit
+	if !config.Simulate.Enabled {
+		p.save()
+	}
+}
+
+// ownShare keeps the customers in this run's share
+func (p *sendingPlan) ownShare(customers []ProcessedCustomer) []ProcessedCustomer {
+	if p == nil || p.own < 0 {
+		return customers
+	}
+	share := p.shares[p.own]
+	mine := make(map[string]bool, len(share.customers))
+	for _, c := range share.customers {
+		mine[c.FormattedPhone] = true
+	}
+	kept := customers[:0]
+	for _, c := range customers {
+		if mine[c.FormattedPhone] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// display prints the allocation as part of the execution plan
+func (p *sendingPlan) display() {
+	if p == nil {
+		return
+	}
+	fmt.Println("Allocation by Number:")
+	for i, share := range p.shares {
+		marker := " "
+		if i == p.own {
+			marker = "*"
+		}
+		fmt.Printf(" %s %-20s %5d customers  (%d of %d left today, %s)\n", marker,
+			truncateRunes(share.profile, 20), len(share.customers), share.remaining(), share.limit, share.stage)
+	}
+	if len(p.deferred) > 0 {
+		fmt.Printf("   %-20s %5d customers  (over today's budget)\n", "Left for tomorrow", len(p.deferred))
+	}
+	if p.own < 0 {
+		fmt.Println("   This run's number is not in Accounts; it sends the whole list")
+	} else {
+		fmt.Printf("   This run sends the * share; the others are saved in %s\n", allocationDir)
+	}
+}
+
+// save writes every share this run doesn't send, and the customers left
+// for tomorrow, as CSVs to run from their own numbers later
+func (p *sendingPlan) save() {
+	if p == nil || p.own < 0 {
+		return
+	}
+	os.MkdirAll(allocationDir, 0755)
+	date := time.Now().Format("20060102")
+	for i, share := range p.shares {
+		if i == p.own || len(share.customers) == 0 {
+			continue
+		}
+		path := filepath.Join(allocationDir, fmt.Sprintf("%s-%s.csv", date, safeFileName(share.profile)))
+		if err := writeAllocationCSV(path, share.customers); err != nil {
+			log.Error(fmt.Sprintf("Failed to save the share of %s", share.profile), err)
+			continue
+		}
+		log.Info(fmt.Sprintf("%d customers for %s saved to %s", len(share.customers), share.profile, path))
+	}
+	if len(p.deferred) > 0 {
+		path := filepath.Join(allocationDir, date+"-deferred.csv")
+		if err := writeAllocationCSV(path, p.deferred); err != nil {
+			log.Error("Failed to save the customers left for tomorrow", err)
+			return
+		}
+		log.Info(fmt.Sprintf("%d customers over today's budget saved to %s", len(p.deferred), path))
+	}
+}
+
+// writeAllocationCSV saves customers in the campaign CSV's own layout
+func writeAllocationCSV(path string, customers []ProcessedCustomer) error {
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write(append([]string{"Code", "CustomerName", "Phone", "Mobile"}, csvExtraColumns...))
+	for _, c := range customers {
+		row := []string{c.Code, c.CustomerName, c.Phone, c.Mobile}
+		for _, name := range csvExtraColumns {
+			row = append(row, c.Fields[name])
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
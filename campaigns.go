@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// campaignProfileDir holds the saved wizard answers, one JSON file per name
+const campaignProfileDir = "data/campaigns"
+
+var campaignProfileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// campaignProfile is a named set of wizard answers ("monthly-statement"),
+// so a recurring campaign runs without the interactive setup
+type campaignProfile struct {
+	Name      string
+	CSVFile   string
+	Templates []string
+
+	DelayMin       int
+	DelayMax       int
+	BatchSize      int
+	BatchDelay     int
+	MaxRetries     int
+	SkipDuplicates bool
+	QuoteReplies   bool
+	SkipGhosts     bool
+	DisappearAfter string
+
+	SessionProfile string // Linked number to send from, if one was chosen
+	SavedAt        time.Time
+}
+
+// activeCampaignProfile is the profile this run was started with, if any
+var activeCampaignProfile *campaignProfile
+
+// saveProfileName is set by --save-profile to store this run's answers
+var saveProfileName string
+
+// nonInteractive is set by --non-interactive, which 'venom watch' and
+// 'venom queue run' pass to the campaigns they start: nobody is there to
+// answer, so an unfinished run is resumed and duplicates keep their first
+// record
+var nonInteractive bool
+
+// campaignProfilePath returns where a named profile is stored
+func campaignProfilePath(name string) (string, error) {
+	if !campaignProfileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	if name == newProfile {
+		return "", fmt.Errorf("%q is reserved for linking a new number", name)
+	}
+	return filepath.Join(campaignProfileDir, name+".json"), nil
+}
+
+// loadCampaignProfile reads a saved campaign profile. It returns nil without
+// an error when no campaign profile has that name, so the name can still
+// mean a linked number.
+func loadCampaignProfile(name string) (*campaignProfile, error) {
+	path, err := campaignProfilePath(name)
+	if err != nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profile campaignProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("invalid campaign profile %s: %w", path, err)
+	}
+	if len(profile.Templates) == 0 {
+		return nil, fmt.Errorf("campaign profile %s lists no templates", path)
+	}
+	return &profile, nil
+}
+
+// saveCampaignProfile stores the current wizard answers under name
+func saveCampaignProfile(name string) error {
+	return writeCampaignProfile(&campaignProfile{
+		Name:           name,
+		CSVFile:        campaignCSV,
+		Templates:      selectedTemplates,
+		DelayMin:       config.DelayMin,
+		DelayMax:       config.DelayMax,
+		BatchSize:      config.BatchSize,
+		BatchDelay:     config.BatchDelay,
+		MaxRetries:     config.MaxRetries,
+		SkipDuplicates: config.SkipDuplicates,
+		QuoteReplies:   config.QuoteReplies.Enabled,
+		SkipGhosts:     config.SkipGhostNumbers,
+		DisappearAfter: config.DisappearAfter,
+		SessionProfile: config.Session.Profile,
+		SavedAt:        time.Now(),
+	})
+}
+
+// writeCampaignProfile stores a profile under its name
+func writeCampaignProfile(profile *campaignProfile) error {
+	path, err := campaignProfilePath(profile.Name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(campaignProfileDir, 0755)
+	return os.WriteFile(path, data, 0600)
+}
+
+// campaignProfileNames lists the saved campaign profiles, sorted
+func campaignProfileNames() []string {
+	names := []string{}
+	files, _ := filepath.Glob(filepath.Join(campaignProfileDir, "*.json"))
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyCampaignProfile takes the wizard answers from a saved profile
+func applyCampaignProfile(profile *campaignProfile) {
+	activeCampaignProfile = profile
+	campaignCSV = profile.CSVFile
+	selectedTemplates = profile.Templates
+	config.DelayMin, config.DelayMax = profile.DelayMin, profile.DelayMax
+	config.BatchSize, config.BatchDelay = profile.BatchSize, profile.BatchDelay
+	config.MaxRetries = profile.MaxRetries
+	config.SkipDuplicates = profile.SkipDuplicates
+	config.QuoteReplies.Enabled = config.QuoteReplies.Enabled || profile.QuoteReplies
+	config.SkipGhostNumbers = config.SkipGhostNumbers || profile.SkipGhosts
+	if profile.DisappearAfter != "" {
+		config.DisappearAfter = profile.DisappearAfter
+	}
+	config.Session.Profile = profile.SessionProfile
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// ChannelConfig configures broadcasting to a WhatsApp Channel (newsletter)
+// owned by the linked account, as an alternative to 1:1 messages
+type ChannelConfig struct {
+	JID        string // Channel JID, e.g. 120363000000000000@newsletter
+	InviteLink string // Channel link or invite code, used if JID is empty
+	Template   string // Template file to post; prompts for one if empty
+}
+
+// runChannelCommand posts campaign content to the configured WhatsApp Channel
+func runChannelCommand(args []string) error {
+	fs := flag.NewFlagSet("channel", flag.ContinueOnError)
+	list := fs.Bool("list", false, "list channels this account can post to and exit")
+	templateFile := fs.String("template", config.Channel.Template, "template file to post")
+	yes := fs.Bool("yes", false, "post without asking for confirmation")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name that owns the channel")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	defer closeSessionStore()
+	client, err := initializeWhatsApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+	}
+	defer client.Disconnect()
+
+	if *list {
+		return listOwnedChannels(client)
+	}
+
+	channel, err := resolveChannel(client)
+	if err != nil {
+		return err
+	}
+
+	post, err := loadBroadcastPost(*templateFile, "Channels")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("CHANNEL POST PREVIEW")
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Channel: %s (%d followers)\n", channel.ThreadMeta.Name.Text, channel.ThreadMeta.SubscriberCount)
+	fmt.Printf("Length: %d characters\n", len(post))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println(post)
+	fmt.Println(strings.Repeat("─", 60) + "\n")
+
+	if !*yes {
+		confirmPrompt := promptui.Select{
+			Label: "Post to channel?",
+			Items: []string{"Yes, post now", "No, exit"},
+		}
+		confirmIdx, _, err := confirmPrompt.Run()
+		if err != nil {
+			return err
+		}
+		if confirmIdx != 0 {
+			return fmt.Errorf("user cancelled")
+		}
+	}
+
+	resp, err := client.SendMessage(ctx, channel.ID, &waE2E.Message{
+		Conversation: proto.String(post),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post to channel: %w", err)
+	}
+
+	displaySuccess("Channel Post Sent",
+		fmt.Sprintf("Posted to %s (message %s, server ID %d)", channel.ThreadMeta.Name.Text, resp.ID, resp.ServerID))
+	return nil
+}
+
+// resolveChannel looks up the configured channel and checks we may post to it
+func resolveChannel(client *whatsmeow.Client) (*types.NewsletterMetadata, error) {
+	var (
+		channel *types.NewsletterMetadata
+		err     error
+	)
+
+	switch {
+	case config.Channel.JID != "":
+		jid, parseErr := types.ParseJID(config.Channel.JID)
+		if parseErr != nil || jid.Server != types.NewsletterServer {
+			return nil, fmt.Errorf("invalid channel JID %q", config.Channel.JID)
+		}
+		channel, err = client.GetNewsletterInfo(jid)
+	case config.Channel.InviteLink != "":
+		key := strings.TrimPrefix(config.Channel.InviteLink, "https://")
+		key = strings.TrimPrefix(key, "whatsapp.com/channel/")
+		channel, err = client.GetNewsletterInfoWithInvite(key)
+	default:
+		displayError("No Channel Configured",
+			"Set Channel.JID or Channel.InviteLink in the config file",
+			"Run 'venom channel --list' to see channels this account owns",
+			nil)
+		return nil, fmt.Errorf("no channel configured")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+
+	if !canPostToChannel(channel) {
+		return nil, fmt.Errorf("this account is not an owner or admin of channel %s", channel.ThreadMeta.Name.Text)
+	}
+	return channel, nil
+}
+
+// canPostToChannel reports whether the linked account owns or administers a channel
+func canPostToChannel(channel *types.NewsletterMetadata) bool {
+	if channel.ViewerMeta == nil {
+		return false
+	}
+	role := channel.ViewerMeta.Role
+	return role == types.NewsletterRoleOwner || role == types.NewsletterRoleAdmin
+}
+
+// listOwnedChannels prints the channels the linked account can post to
+func listOwnedChannels(client *whatsmeow.Client) error {
+	channels, err := client.GetSubscribedNewsletters()
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+
+	details := []string{}
+	for _, channel := range channels {
+		if canPostToChannel(channel) {
+			details = append(details, fmt.Sprintf("%s  %s (%d followers)",
+				channel.ID, channel.ThreadMeta.Name.Text, channel.ThreadMeta.SubscriberCount))
+		}
+	}
+
+	if len(details) == 0 {
+		displayWarning("No Channels", "This account doesn't own or administer any channels", nil)
+		return nil
+	}
+	displayInfo("Your Channels", "Put one of these JIDs in Channel.JID", details)
+	return nil
+}
+
+// loadBroadcastPost reads the text of a channel post or status from a
+// template file, or lets the operator pick one of the discovered templates.
+// audience names where it goes, e.g. "Channels".
+func loadBroadcastPost(templateFile, audience string) (string, error) {
+	var post string
+	if templateFile != "" {
+		content, err := os.ReadFile(templateFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read template: %w", err)
+		}
+		post = strings.TrimSpace(string(content))
+	} else {
+		templates, err := loadTemplatesFromFiles()
+		if err != nil {
+			return "", err
+		}
+		selected, err := selectTemplatesInteractive(templates)
+		if err != nil {
+			return "", err
+		}
+		post = selected[0]
+	}
+
+	if post == "" {
+		return "", fmt.Errorf("post is empty")
+	}
+
+	// Posts go to every follower or contact, so per-customer fields can't be filled in
+	if placeholders := placeholderPattern.FindAllString(post, -1); len(placeholders) > 0 {
+		displayError("Template Not Suitable For "+audience,
+			fmt.Sprintf("Template uses customer placeholders: %s", strings.Join(placeholders, ", ")),
+			"Use a template without personalization for "+strings.ToLower(audience),
+			nil)
+		return "", fmt.Errorf("template contains customer placeholders")
+	}
+	return post, nil
+}
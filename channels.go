@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// ChannelsConfig fans one campaign out across several transports
+// (whatsmeow, cloud, twilio, sms, email), picking one per customer
+type ChannelsConfig struct {
+	Enabled bool
+	Default string                  // Channel for customers no rule matches ("" = Transport)
+	Column  string                  // CSV column naming a customer's channel; checked before Rules
+	Rules   []ChannelRule           // The first matching rule picks the channel
+	Limits  map[string]ChannelLimit // Per-channel limits; HourlyLimit/DailyLimit still cap the whole campaign
+}
+
+// ChannelRule sends customers whose Field equals Equals through Channel,
+// e.g. {"Field": "HasWhatsApp", "Equals": "no", "Channel": "sms"}
+type ChannelRule struct {
+	Field   string // Code, CustomerName, Phone, Mobile, HasWhatsApp or an extra CSV column
+	Equals  string // Compared case-insensitively; "*" matches any non-empty value
+	Channel string
+}
+
+// ChannelLimit caps sends through one channel (0 = no limit)
+type ChannelLimit struct {
+	HourlyLimit int
+	DailyLimit  int
+}
+
+// customerField returns a built-in field or extra CSV column by name
+func customerField(customer Customer, name string) string {
+	switch name {
+	case "Code":
+		return customer.Code
+	case "CustomerName":
+		return customer.CustomerName
+	case "Phone":
+		return customer.Phone
+	case "Mobile":
+		return customer.Mobile
+	case "HasWhatsApp":
+		return customer.HasWhatsApp
+	}
+	return customer.Fields[name]
+}
+
+// defaultChannel is the channel used when no column or rule applies
+func defaultChannel() string {
+	name := config.Channels.Default
+	if name == "" {
+		name = config.Transport
+	}
+	if name == "" {
+		name = "whatsmeow"
+	}
+	return strings.ToLower(name)
+}
+
+// channelFor picks the channel a customer is sent through
+func channelFor(customer Customer) string {
+	if !config.Channels.Enabled {
+		return defaultChannel()
+	}
+
+	if config.Channels.Column != "" {
+		if name := strings.ToLower(strings.TrimSpace(customer.Fields[config.Channels.Column])); name != "" {
+			if _, ok := transports[name]; ok {
+				return name
+			}
+		}
+	}
+
+	for _, rule := range config.Channels.Rules {
+		value := strings.TrimSpace(customerField(customer, rule.Field))
+		if (rule.Equals == "*" && value != "") || strings.EqualFold(value, rule.Equals) {
+			return strings.ToLower(rule.Channel)
+		}
+	}
+	return defaultChannel()
+}
+
+// usedChannels lists every channel the config can route to, default first
+func usedChannels() []string {
+	seen := map[string]bool{defaultChannel(): true}
+	names := []string{defaultChannel()}
+	add := func(name string) {
+		name = strings.ToLower(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, rule := range config.Channels.Rules {
+		add(rule.Channel)
+	}
+	for name := range config.Channels.Limits {
+		add(name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// channelCounter counts sends through one channel for its limits
+type channelCounter struct {
+	hourly, daily       int
+	hourStart, dayStart time.Time
+	sent                int
+}
+
+// channelRouter implements Sender by passing each send to the channel
+// chosen for the customer in the send context. Channels named only in the
+// channel column are opened on first use.
+type channelRouter struct {
+	ctx context.Context
+
+	mu       sync.Mutex // Guards everything below; channels open while sending
+	channels map[string]Sender
+	names    []string // Opened channels, default first
+	closers  []func()
+	routes   map[string]string // Recipient number -> channel used for their message
+	counters map[string]*channelCounter
+}
+
+var _ Sender = (*channelRouter)(nil)
+
+// openChannelRouter connects every channel the rules can pick
+func openChannelRouter(ctx context.Context) (Sender, func(), error) {
+	r := &channelRouter{
+		ctx:      ctx,
+		channels: map[string]Sender{},
+		routes:   map[string]string{},
+		counters: map[string]*channelCounter{},
+	}
+
+	for _, name := range usedChannels() {
+		if _, err := r.open(name); err != nil {
+			r.close()
+			return nil, nil, err
+		}
+	}
+
+	details := []string{}
+	for _, name := range r.names {
+		limit := config.Channels.Limits[name]
+		line := name
+		if limit.HourlyLimit > 0 || limit.DailyLimit > 0 {
+			line += fmt.Sprintf(" (max %d/hour, %d/day)", limit.HourlyLimit, limit.DailyLimit)
+		}
+		details = append(details, line)
+	}
+	displayInfo("Channels", fmt.Sprintf("Routing customers across %d channels, default %s", len(r.names), r.names[0]), details)
+
+	return r, r.close, nil
+}
+
+// open connects a channel and adds it to the router
+func (r *channelRouter) open(name string) (Sender, error) {
+	sender, closeSender, err := openTransport(r.ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("channel %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if opened, ok := r.channels[name]; ok {
+		closeSender() // Another send opened it meanwhile
+		return opened, nil
+	}
+	r.channels[name] = sender
+	r.names = append(r.names, name)
+	r.closers = append(r.closers, closeSender)
+	r.counters[name] = &channelCounter{hourStart: time.Now(), dayStart: time.Now()}
+	return sender, nil
+}
+
+// primary returns the default channel, which uploads media
+func (r *channelRouter) primary() Sender {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.channels[r.names[0]]
+}
+
+// opened returns the open channels in order, default first
+func (r *channelRouter) opened() ([]string, []Sender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	senders := make([]Sender, len(r.names))
+	for i, name := range r.names {
+		senders[i] = r.channels[name]
+	}
+	return append([]string(nil), r.names...), senders
+}
+
+// close disconnects every channel and logs how many sends each handled
+func (r *channelRouter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, name := range r.names {
+		if counter := r.counters[name]; counter != nil && counter.sent > 0 {
+			log.Info(fmt.Sprintf("Channel %s: %d messages sent", name, counter.sent))
+		}
+		r.closers[i]()
+	}
+}
+
+// route returns the channel for a recipient, remembering the customer's
+// channel so follow-ups without a customer in the context go the same way
+func (r *channelRouter) route(ctx context.Context, to types.JID) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if customer, ok := customerFromContext(ctx); ok {
+		name := channelFor(customer)
+		r.routes[to.User] = name
+		return name
+	}
+	if name, ok := r.routes[to.User]; ok {
+		return name
+	}
+	return r.names[0]
+}
+
+// waitForLimit blocks while a channel is over its hourly or daily limit
+func (r *channelRouter) waitForLimit(ctx context.Context, name string) error {
+	limit := config.Channels.Limits[name]
+	warned := false
+
+	for {
+		r.mu.Lock()
+		counter := r.counters[name]
+		now := time.Now()
+		if now.Sub(counter.hourStart) >= time.Hour {
+			counter.hourly, counter.hourStart = 0, now
+		}
+		if now.Sub(counter.dayStart) >= 24*time.Hour {
+			counter.daily, counter.dayStart = 0, now
+		}
+
+		var wait time.Duration
+		if limit.DailyLimit > 0 && counter.daily >= limit.DailyLimit {
+			wait = counter.dayStart.Add(24 * time.Hour).Sub(now)
+		} else if limit.HourlyLimit > 0 && counter.hourly >= limit.HourlyLimit {
+			wait = counter.hourStart.Add(time.Hour).Sub(now)
+		}
+		r.mu.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+		if !warned {
+			emitEvent(eventRateLimitWait, map[string]interface{}{
+				"channel": name,
+				"reason":  fmt.Sprintf("Channel limit reached, waiting %s", wait.Round(time.Minute)),
+			})
+			clearProgress()
+			displayWarning("Channel Limit Reached",
+				fmt.Sprintf("The %s channel reached its limit, waiting %s", name, wait.Round(time.Minute)),
+				[]string{"Raise Channels.Limits in config.json to send faster"})
+			warned = true
+		}
+		if !sleepWithContext(ctx, min(wait, time.Minute)) {
+			return ctx.Err()
+		}
+	}
+}
+
+// isMediaMessage reports whether a message carries an upload
+func isMediaMessage(message *waE2E.Message) bool {
+	return message.GetImageMessage() != nil || message.GetVideoMessage() != nil ||
+		message.GetAudioMessage() != nil || message.GetDocumentMessage() != nil ||
+		message.GetAlbumMessage() != nil
+}
+
+func (r *channelRouter) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	name := r.route(ctx, to)
+	r.mu.Lock()
+	sender, ok := r.channels[name]
+	defaultName := r.names[0]
+	r.mu.Unlock()
+	if !ok {
+		var err error
+		if sender, err = r.open(name); err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+	// Media is uploaded through the default channel, so only it can send it
+	if isMediaMessage(message) && name != defaultName {
+		return whatsmeow.SendResponse{}, fmt.Errorf("%s: media follow-ups are only sent through the default channel %s", name, defaultName)
+	}
+
+	if err := r.waitForLimit(ctx, name); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	resp, err := sender.SendMessage(ctx, to, message, extra...)
+	if err == nil {
+		r.mu.Lock()
+		counter := r.counters[name]
+		counter.hourly++
+		counter.daily++
+		counter.sent++
+		r.mu.Unlock()
+	}
+	return resp, err
+}
+
+// IsOnWhatsApp checks numbers through the first WhatsApp channel; with none
+// open every number is reachable
+func (r *channelRouter) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	names, senders := r.opened()
+	for i, name := range names {
+		if isWhatsAppTransport(name) {
+			return senders[i].IsOnWhatsApp(phones)
+		}
+	}
+	return senders[0].IsOnWhatsApp(phones)
+}
+
+func (r *channelRouter) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	r.mu.Lock()
+	sender, ok := r.channels[r.routes[jid.User]]
+	if !ok {
+		sender = r.channels[r.names[0]]
+	}
+	r.mu.Unlock()
+	return sender.SendChatPresence(jid, state, media)
+}
+
+func (r *channelRouter) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return r.primary().Upload(ctx, plaintext, mediaType)
+}
+
+func (r *channelRouter) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return r.primary().BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (r *channelRouter) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return r.primary().BuildMessageKey(chat, sender, id)
+}
+
+// IsConnected reports whether every channel is connected
+func (r *channelRouter) IsConnected() bool {
+	_, senders := r.opened()
+	for _, sender := range senders {
+		if !sender.IsConnected() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *channelRouter) IsLoggedIn() bool {
+	_, senders := r.opened()
+	for _, sender := range senders {
+		if !sender.IsLoggedIn() {
+			return false
+		}
+	}
+	return true
+}
+
+// Connect reconnects the channels that dropped
+func (r *channelRouter) Connect() error {
+	names, senders := r.opened()
+	for i, name := range names {
+		if sender := senders[i]; !sender.IsConnected() {
+			if err := sender.Connect(); err != nil {
+				return fmt.Errorf("channel %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetSniffSize is how much of the file is inspected to guess its encoding
+const charsetSniffSize = 64 * 1024
+
+// decodeCSVInput wraps r so it yields UTF-8, converting UTF-16 and
+// Windows-1256 exports (common from older ERP systems). The encoding comes
+// from CSVEncoding, or is detected from the BOM and content when "auto".
+func decodeCSVInput(r io.Reader) (io.Reader, string, error) {
+	buffered := bufio.NewReaderSize(r, charsetSniffSize)
+
+	name := strings.ToLower(config.CSVEncoding)
+	if name == "" || name == "auto" {
+		head, err := buffered.Peek(charsetSniffSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, "", err
+		}
+		name = detectCharset(head)
+	}
+
+	var enc encoding.Encoding
+	switch name {
+	case "utf-8", "utf8":
+		// Drop a UTF-8 BOM so it doesn't end up in the first header name
+		if head, _ := buffered.Peek(3); bytes.Equal(head, []byte{0xEF, 0xBB, 0xBF}) {
+			buffered.Discard(3)
+		}
+		return buffered, "utf-8", nil
+	case "utf-16le":
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case "utf-16be":
+		enc = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case "utf-16":
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case "windows-1256", "cp1256":
+		enc = charmap.Windows1256
+	default:
+		return nil, "", fmt.Errorf("unsupported CSV encoding %q", config.CSVEncoding)
+	}
+
+	return transform.NewReader(buffered, enc.NewDecoder()), name, nil
+}
+
+// detectCharset guesses the encoding of the start of a file
+func detectCharset(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	}
+
+	// UTF-16 without a BOM: ASCII text leaves every other byte zero
+	evenZeros, oddZeros := 0, 0
+	for i, b := range head {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	if len(head) >= 4 {
+		if oddZeros > len(head)/4 && evenZeros < oddZeros/4 {
+			return "utf-16le"
+		}
+		if evenZeros > len(head)/4 && oddZeros < evenZeros/4 {
+			return "utf-16be"
+		}
+	}
+
+	// Ignore a rune cut off at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	if utf8.Valid(head) {
+		return "utf-8"
+	}
+	return "windows-1256"
+}
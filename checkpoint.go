@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+const checkpointFile = "data/checkpoint.json"
+
+// checkpointJournal lists, one per line, customers handled since the
+// checkpoint was last written in full. Appending a line per send is cheap;
+// rewriting the whole checkpoint every checkpointCompactEvery sends keeps
+// the journal short.
+const (
+	checkpointJournal      = "data/checkpoint.journal"
+	checkpointCompactEvery = 500
+)
+
+// campaignCheckpoint records which customers a campaign has already handled,
+// so an interrupted run can pick up where it stopped. The send loop and the
+// signal handler both write it, so methods hold mu.
+type campaignCheckpoint struct {
+	mu sync.Mutex
+
+	CSVFile   string
+	Total     int
+	Done      map[string]bool // Formatted phones already sent to (or failed)
+	StartedAt time.Time
+	UpdatedAt time.Time
+
+	journal   io.WriteCloser // Open while sending
+	journaled int            // Lines in the journal
+}
+
+var checkpoint *campaignCheckpoint
+
+// newCheckpoint starts a fresh checkpoint for a campaign
+func newCheckpoint(csvFile string, total int) *campaignCheckpoint {
+	return &campaignCheckpoint{
+		CSVFile:   csvFile,
+		Total:     total,
+		Done:      make(map[string]bool),
+		StartedAt: time.Now(),
+	}
+}
+
+// loadCheckpoint reads the checkpoint left by a previous run, if any
+func loadCheckpoint() (*campaignCheckpoint, error) {
+	data, err := readDataFile(checkpointFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var cp campaignCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", checkpointFile, err)
+	}
+	if cp.Done == nil {
+		cp.Done = make(map[string]bool)
+	}
+
+	// A run that stopped between full saves left the rest in the journal
+	journal, err := readDataFile(checkpointJournal)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, phone := range strings.Split(string(journal), "\n") {
+		if phone != "" {
+			cp.Done[phone] = true
+		}
+	}
+	if info, err := os.Stat(checkpointJournal); err == nil && info.ModTime().After(cp.UpdatedAt) {
+		cp.UpdatedAt = info.ModTime()
+	}
+	return &cp, nil
+}
+
+// markDone records a handled customer in the journal, saving the whole
+// checkpoint when the journal is full or can't be written
+func (cp *campaignCheckpoint) markDone(phone string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Done[phone] = true
+	if cp.journal != nil && cp.journaled < checkpointCompactEvery {
+		if _, err := fmt.Fprintln(cp.journal, phone); err == nil {
+			cp.journaled++
+			return
+		}
+	}
+
+	if err := cp.write(); err != nil {
+		log.Error("Failed to save checkpoint", err)
+		return
+	}
+	journal, err := createDataFile(checkpointJournal)
+	if err != nil {
+		log.Error("Failed to open checkpoint journal", err)
+		return
+	}
+	cp.journal = journal
+}
+
+// save writes the checkpoint atomically and empties the journal, which it
+// now includes
+func (cp *campaignCheckpoint) save() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.write()
+}
+
+// handled returns how many customers the checkpoint has as done
+func (cp *campaignCheckpoint) handled() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return len(cp.Done)
+}
+
+// write is save for callers already holding mu
+func (cp *campaignCheckpoint) write() error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(checkpointFile), 0755)
+	if err := writeDataFile(checkpointFile, data); err != nil {
+		return err
+	}
+	cp.closeJournal()
+	os.Remove(checkpointJournal)
+	return nil
+}
+
+// closeJournal closes the journal if it is open
+func (cp *campaignCheckpoint) closeJournal() {
+	if cp.journal != nil {
+		cp.journal.Close()
+		cp.journal, cp.journaled = nil, 0
+	}
+}
+
+// clear removes the checkpoint once the campaign has finished
+func (cp *campaignCheckpoint) clear() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.closeJournal()
+	os.Remove(checkpointJournal)
+	return os.Remove(checkpointFile)
+}
+
+// resumeFromCheckpoint offers to skip customers an interrupted run of the
+// same CSV already handled, and sets up the checkpoint for this run
+func resumeFromCheckpoint(csvFile string, customers []ProcessedCustomer) []ProcessedCustomer {
+	previous, err := loadCheckpoint()
+	if err != nil {
+		log.Warning(err.Error())
+	}
+
+	if previous != nil && previous.CSVFile == csvFile && len(previous.Done) > 0 {
+		remaining := make([]ProcessedCustomer, 0, len(customers))
+		for _, c := range customers {
+			if !previous.Done[c.FormattedPhone] {
+				remaining = append(remaining, c)
+			}
+		}
+
+		if askResume(previous, fmt.Sprintf("Resume (send to the remaining %d)", len(remaining))) {
+			checkpoint = previous
+			log.Info(fmt.Sprintf("Resuming: skipping %d customers already handled", len(customers)-len(remaining)))
+			return remaining
+		}
+	}
+
+	checkpoint = newCheckpoint(csvFile, len(customers))
+	return customers
+}
+
+// resumeStreamedCheckpoint is resumeFromCheckpoint for a list sent in
+// chunks: it asks before any chunk is loaded, and skipCheckpointed then
+// filters each chunk
+func resumeStreamedCheckpoint(csvFile string, total int) {
+	previous, err := loadCheckpoint()
+	if err != nil {
+		log.Warning(err.Error())
+	}
+
+	if previous != nil && previous.CSVFile == csvFile && len(previous.Done) > 0 {
+		if askResume(previous, fmt.Sprintf("Resume (skip the %d already handled)", len(previous.Done))) {
+			checkpoint = previous
+			log.Info(fmt.Sprintf("Resuming: skipping %d customers already handled", len(previous.Done)))
+			return
+		}
+	}
+
+	checkpoint = newCheckpoint(csvFile, total)
+}
+
+// askResume asks whether to resume the previous run's checkpoint, and
+// always resumes with --non-interactive
+func askResume(previous *campaignCheckpoint, resumeLabel string) bool {
+	if nonInteractive {
+		log.Info(fmt.Sprintf("Resuming the unfinished campaign from %s without asking (--non-interactive)",
+			previous.UpdatedAt.Format("2006-01-02 15:04")))
+		return true
+	}
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("Unfinished campaign from %s: %d of %d done. Resume?",
+			previous.UpdatedAt.Format("2006-01-02 15:04"), len(previous.Done), previous.Total),
+		Items: []string{
+			resumeLabel,
+			"Start over",
+		},
+	}
+	idx, _, err := prompt.Run()
+	return err == nil && idx == 0
+}
+
+// skipCheckpointed leaves out customers the checkpoint has as handled
+func skipCheckpointed(customers []ProcessedCustomer) []ProcessedCustomer {
+	if checkpoint == nil {
+		return customers
+	}
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+	remaining := customers[:0]
+	for _, c := range customers {
+		if !checkpoint.Done[c.FormattedPhone] {
+			remaining = append(remaining, c)
+		}
+	}
+	return remaining
+}
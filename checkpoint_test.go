@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCheckpointJournal(t *testing.T) {
+	cp := newCheckpoint("customers.csv", 3*checkpointCompactEvery)
+	sent := checkpointCompactEvery + 10
+	for i := 0; i < sent; i++ {
+		cp.markDone(fmt.Sprintf("2010%08d", i))
+	}
+	if cp.journaled >= checkpointCompactEvery {
+		t.Errorf("journal has %d lines, want it rewritten after %d", cp.journaled, checkpointCompactEvery)
+	}
+
+	// A run killed now left some customers only in the journal
+	loaded, err := loadCheckpoint()
+	if err != nil || loaded == nil {
+		t.Fatalf("loadCheckpoint() = %v, %v", loaded, err)
+	}
+	if len(loaded.Done) != sent {
+		t.Errorf("loaded %d done, want %d", len(loaded.Done), sent)
+	}
+
+	cp.clear()
+	for _, path := range []string{checkpointFile, checkpointJournal} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left after clear: %v", path, err)
+		}
+	}
+}
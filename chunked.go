@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errChunksStopped ends the CSV read once the campaign stopped mid-list
+var errChunksStopped = errors.New("campaign stopped")
+
+// loadCampaignCSV loads the customer list. A list longer than ChunkSize is
+// only counted: customers then holds its first chunk, and sendInChunks
+// reads the rest while sending, so memory doesn't grow with the list.
+func loadCampaignCSV(filename string) (customers []Customer, rows int, err error) {
+	err = streamCSV(filename, func(line int, customer Customer) error {
+		rows++
+		if config.ChunkSize > 0 && rows > config.ChunkSize {
+			return nil
+		}
+		customers = append(customers, customer)
+		if eventStream != nil {
+			fields := customerEventFields(customer)
+			fields["line"] = line
+			emitEvent(eventRowLoaded, fields)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rows == 0 {
+		return nil, 0, fmt.Errorf("CSV file is empty or has no data rows")
+	}
+	return customers, rows, nil
+}
+
+// previewCandidate returns the first customer of a chunk who would be sent
+// to, without recording the others as skipped
+func previewCandidate(customers []Customer) (ProcessedCustomer, bool) {
+	for _, customer := range customers {
+		if shouldSkipCustomer(customer) || !validateCustomerData(customer) {
+			continue
+		}
+		selected := selectBestPhone(customer)
+		formatted, isValid, _ := validateAndFormatPhone(selected)
+		if isValid && !isSuppressed(formatted) {
+			return ProcessedCustomer{Customer: customer, SelectedPhone: selected, FormattedPhone: formatted, IsValid: true}, true
+		}
+	}
+	return ProcessedCustomer{}, false
+}
+
+// sendInChunks sends to a list too long to hold in memory. It starts with
+// the chunk loadCampaignCSV kept, then reads the CSV again, and pre-checks,
+// validates and sends ChunkSize customers at a time. progress.Total starts
+// as the row count and drops as rows are skipped.
+func sendInChunks(ctx context.Context, client Sender, first []Customer) {
+	log.Info(fmt.Sprintf("Starting to send messages to up to %d customers, %d at a time", progress.Total, config.ChunkSize))
+	sendStartedAt = time.Now()
+	stalls := &stallWatch{}
+	seen := newSeenCustomers() // Duplicates are skipped across chunks
+	sent := 0
+	chunkNum := 0
+
+	// send handles one chunk; false means the campaign stopped
+	send := func(chunk []Customer) bool {
+		chunkNum++
+		skippedSeen = make(map[string]bool) // Skips repeat only within a chunk
+		log.Info(fmt.Sprintf("Chunk %d: %d customers", chunkNum, len(chunk)))
+		if voucherEnabled() {
+			if err := checkVouchers(chunk); err != nil {
+				log.Error("Vouchers can't be sent", err)
+				failWith(exitCSV)
+				return false
+			}
+		}
+		if config.PreCheckNumbers {
+			chunk = preCheckWhatsAppNumbers(ctx, client, chunk)
+			if ctx.Err() != nil {
+				return false
+			}
+		}
+		processed := validateCustomers(chunk, seen)
+		if config.SkipGhostNumbers {
+			processed = filterGhostNumbers(ctx, client, processed)
+		}
+		processed = skipCheckpointed(processed)
+
+		// Rows that won't be sent no longer count toward the total
+		progress.Total -= len(chunk) - len(processed)
+		if !sendCustomers(ctx, client, processed, sent, stalls) {
+			return false
+		}
+		sent += len(processed)
+		return true
+	}
+
+	if !send(first) {
+		return
+	}
+	chunk := make([]Customer, 0, config.ChunkSize)
+	row := 0
+	err := streamCSV(campaignCSV, func(line int, customer Customer) error {
+		row++
+		if row <= len(first) {
+			return nil // Sent as the first chunk
+		}
+		if eventStream != nil {
+			fields := customerEventFields(customer)
+			fields["line"] = line
+			emitEvent(eventRowLoaded, fields)
+		}
+		chunk = append(chunk, customer)
+		if len(chunk) < config.ChunkSize {
+			return nil
+		}
+		if !send(chunk) {
+			return errChunksStopped
+		}
+		chunk = chunk[:0]
+		return nil
+	}, nil)
+	if errors.Is(err, errChunksStopped) {
+		return
+	}
+	if err != nil {
+		log.Error("Failed to read the rest of the CSV, stopping; progress saved", err)
+		failWith(exitCSV)
+		return
+	}
+	if len(chunk) > 0 && !send(chunk) {
+		return
+	}
+	finishSending()
+}
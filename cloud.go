@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// CloudConfig configures Meta's WhatsApp Business Cloud API transport
+type CloudConfig struct {
+	Token         string   // Permanent access token; VENOM_CLOUD_TOKEN overrides it
+	PhoneNumberID string   // Sender's phone number ID from the Meta app dashboard
+	APIVersion    string   // Graph API version, e.g. "v21.0"
+	Template      string   // Approved template name; empty sends free-form text (24h window only)
+	Language      string   // Template language code, e.g. "ar" or "en_US"
+	Params        []string // Body parameters in order, with placeholders, e.g. "{CustomerName}"
+}
+
+// cloudSender sends through the Cloud API. It implements Sender so the
+// campaign runs unchanged; features the API lacks (polls, presence, number
+// checks) degrade as documented on each method.
+type cloudSender struct {
+	settings CloudConfig
+	token    string
+	http     *http.Client
+}
+
+var _ Sender = (*cloudSender)(nil)
+
+// customerKey carries the customer being sent to through SendMessage's context
+type customerKey struct{}
+
+// withCustomer attaches the customer to a send context so transports that
+// need per-customer data (like Cloud API template parameters) can read it
+func withCustomer(ctx context.Context, customer Customer) context.Context {
+	return context.WithValue(ctx, customerKey{}, customer)
+}
+
+// customerFromContext returns the customer attached by withCustomer
+func customerFromContext(ctx context.Context) (Customer, bool) {
+	customer, ok := ctx.Value(customerKey{}).(Customer)
+	return customer, ok
+}
+
+func init() {
+	registerTransport("cloud", true, func(ctx context.Context) (Sender, func(), error) {
+		sender, err := newCloudSender(config.Cloud)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info(fmt.Sprintf("Sending through the WhatsApp Cloud API (phone number ID %s)", config.Cloud.PhoneNumberID))
+		if config.OptOut.AppendFooter || isPollCampaign() {
+			log.Warning("Replies, votes and opt-outs aren't received with the cloud transport; handle them via your webhook")
+		}
+		return sender, func() {}, nil
+	})
+}
+
+// newCloudSender checks the Cloud API settings and returns the transport
+func newCloudSender(settings CloudConfig) (*cloudSender, error) {
+	token := settings.Token
+	if env := os.Getenv("VENOM_CLOUD_TOKEN"); env != "" {
+		token = env
+	}
+	if token == "" || settings.PhoneNumberID == "" {
+		return nil, fmt.Errorf("Cloud.Token (or VENOM_CLOUD_TOKEN) and Cloud.PhoneNumberID are required for the cloud transport")
+	}
+	if settings.Template != "" && settings.Language == "" {
+		return nil, fmt.Errorf("Cloud.Language is required when Cloud.Template is set")
+	}
+	if settings.APIVersion == "" {
+		settings.APIVersion = "v21.0"
+	}
+	return &cloudSender{
+		settings: settings,
+		token:    token,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// cloudError is the error body returned by the Graph API
+type cloudError struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Details struct {
+			Details string `json:"details"`
+		} `json:"error_data"`
+	} `json:"error"`
+}
+
+// Graph API error codes that mean the recipient can't get WhatsApp messages
+var cloudUndeliverableCodes = map[int]bool{
+	131026: true, // Message undeliverable
+	131030: true, // Recipient not in allowed list (test numbers)
+}
+
+// Graph API error codes that mean the business account can't send any more
+var cloudBlockedCodes = map[int]bool{
+	190:    true, // Access token expired or revoked
+	368:    true, // Temporarily blocked for policy violations
+	131031: true, // Account locked
+}
+
+// do sends a request to the Graph API and decodes the JSON reply into out
+func (c *cloudSender) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	url := fmt.Sprintf("https://graph.facebook.com/%s/%s", c.settings.APIVersion, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		var apiErr cloudError
+		json.Unmarshal(data, &apiErr)
+		if cloudUndeliverableCodes[apiErr.Error.Code] {
+			return fmt.Errorf("cloud api: recipient not on WhatsApp (%d: %s)", apiErr.Error.Code, apiErr.Error.Message)
+		}
+		err := fmt.Errorf("cloud api: HTTP %d", resp.StatusCode)
+		if apiErr.Error.Message != "" {
+			err = fmt.Errorf("cloud api: %s (code %d) %s", apiErr.Error.Message, apiErr.Error.Code, apiErr.Error.Details.Details)
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || cloudBlockedCodes[apiErr.Error.Code] {
+			return accountBlockedError{err}
+		}
+		return err
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// cloudPayload converts a whatsmeow message into a Cloud API message body
+func (c *cloudSender) cloudPayload(ctx context.Context, to string, message *waE2E.Message) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                to,
+	}
+
+	switch {
+	case message.GetConversation() != "" || message.GetExtendedTextMessage() != nil:
+		text := message.GetConversation()
+		if text == "" {
+			text = message.GetExtendedTextMessage().GetText()
+		}
+		if c.settings.Template == "" {
+			payload["type"] = "text"
+			payload["text"] = map[string]interface{}{"body": text}
+			break
+		}
+
+		// Business-initiated messages must use an approved template
+		customer, _ := customerFromContext(ctx)
+		params := []map[string]string{}
+		for _, param := range c.settings.Params {
+			params = append(params, map[string]string{
+				"type": "text",
+				"text": renderPlaceholders(param, ProcessedCustomer{Customer: customer}),
+			})
+		}
+		template := map[string]interface{}{
+			"name":     c.settings.Template,
+			"language": map[string]string{"code": c.settings.Language},
+		}
+		if len(params) > 0 {
+			template["components"] = []map[string]interface{}{{"type": "body", "parameters": params}}
+		}
+		payload["type"] = "template"
+		payload["template"] = template
+
+	case message.GetImageMessage() != nil:
+		payload["type"] = "image"
+		payload["image"] = map[string]string{"id": message.GetImageMessage().GetURL(), "caption": message.GetImageMessage().GetCaption()}
+	case message.GetVideoMessage() != nil:
+		payload["type"] = "video"
+		payload["video"] = map[string]string{"id": message.GetVideoMessage().GetURL(), "caption": message.GetVideoMessage().GetCaption()}
+	case message.GetAudioMessage() != nil:
+		payload["type"] = "audio"
+		payload["audio"] = map[string]string{"id": message.GetAudioMessage().GetURL()}
+	case message.GetDocumentMessage() != nil:
+		payload["type"] = "document"
+		payload["document"] = map[string]string{"id": message.GetDocumentMessage().GetURL(), "filename": message.GetDocumentMessage().GetFileName()}
+	case message.GetLocationMessage() != nil:
+		loc := message.GetLocationMessage()
+		payload["type"] = "location"
+		payload["location"] = map[string]interface{}{
+			"latitude":  loc.GetDegreesLatitude(),
+			"longitude": loc.GetDegreesLongitude(),
+			"name":      loc.GetName(),
+			"address":   loc.GetAddress(),
+		}
+	case message.GetPollCreationMessage() != nil:
+		return nil, fmt.Errorf("cloud api: poll campaigns aren't supported, use an interactive template instead")
+	default:
+		return nil, fmt.Errorf("cloud api: unsupported message type")
+	}
+	return payload, nil
+}
+
+// SendMessage posts the message to the Cloud API messages endpoint
+func (c *cloudSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	// Albums are sent as separate media messages; the album header has no Cloud API equivalent
+	if message.GetAlbumMessage() != nil {
+		return whatsmeow.SendResponse{Timestamp: time.Now(), ID: "cloud-album"}, nil
+	}
+
+	payload, err := c.cloudPayload(ctx, to.User, message)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	body, _ := json.Marshal(payload)
+
+	var reply struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.settings.PhoneNumberID+"/messages", "application/json", bytes.NewReader(body), &reply); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	resp := whatsmeow.SendResponse{Timestamp: time.Now()}
+	if len(reply.Messages) > 0 {
+		resp.ID = types.MessageID(reply.Messages[0].ID)
+	}
+	return resp, nil
+}
+
+// IsOnWhatsApp can't be answered by the Cloud API (the contacts endpoint was
+// retired), so every number is reported as registered; undeliverable
+// numbers surface as send errors instead.
+func (c *cloudSender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	results := make([]types.IsOnWhatsAppResponse, 0, len(phones))
+	for _, phone := range phones {
+		user := strings.TrimPrefix(phone, "+")
+		results = append(results, types.IsOnWhatsAppResponse{
+			Query: phone,
+			JID:   types.NewJID(user, types.DefaultUserServer),
+			IsIn:  true,
+		})
+	}
+	return results, nil
+}
+
+// SendChatPresence is a no-op: the Cloud API has no typing presence
+func (c *cloudSender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+// Upload stores media with the Cloud API. The returned URL holds the media
+// ID, which SendMessage passes back to the API.
+func (c *cloudSender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	mimetype := http.DetectContentType(plaintext)
+	if mediaType == whatsmeow.MediaAudio {
+		mimetype = "audio/ogg"
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("messaging_product", "whatsapp")
+	form.WriteField("type", mimetype)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="upload"`)
+	header.Set("Content-Type", mimetype)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return whatsmeow.UploadResponse{}, err
+	}
+	part.Write(plaintext)
+	form.Close()
+
+	var reply struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.settings.PhoneNumberID+"/media", form.FormDataContentType(), &body, &reply); err != nil {
+		return whatsmeow.UploadResponse{}, err
+	}
+	return whatsmeow.UploadResponse{URL: reply.ID, ObjectID: reply.ID, FileLength: uint64(len(plaintext))}, nil
+}
+
+func (c *cloudSender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return (&whatsmeow.Client{}).BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (c *cloudSender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return &waCommon.MessageKey{
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(id),
+		RemoteJID: proto.String(chat.String()),
+	}
+}
+
+// The Cloud API is stateless HTTP, so it is always "connected"
+func (c *cloudSender) IsConnected() bool { return true }
+func (c *cloudSender) IsLoggedIn() bool  { return true }
+func (c *cloudSender) Connect() error    { return nil }
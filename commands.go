@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runSubcommand dispatches a non-interactive subcommand.
+// It reports whether name was a known command.
+func runSubcommand(name string, args []string) (bool, error) {
+	switch name {
+	case "check":
+		return true, runCheckCommand(args)
+	case "channel":
+		return true, runChannelCommand(args)
+	case "validate":
+		return true, runValidateCommand(args)
+	case "session":
+		return true, runSessionCommand(args)
+	case "retry-failed":
+		return true, runRetryFailedCommand(args)
+	case "lookup":
+		return true, runLookupCommand(args)
+	case "purge":
+		return true, runPurgeCommand(args)
+	case "queue":
+		return true, runQueueCommand(args)
+	case "watch":
+		return true, runWatchCommand(args)
+	case "edit":
+		return true, runEditCommand(args)
+	case "revoke":
+		return true, runRevokeCommand(args)
+	case "engagement":
+		return true, runEngagementCommand(args)
+	case "template-stats":
+		return true, runTemplateStatsCommand(args)
+	case "groups":
+		return true, runGroupsCommand(args)
+	case "story":
+		return true, runStoryCommand(args)
+	case "help", "-h", "--help":
+		printUsage()
+		return true, nil
+	}
+	return false, nil
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Println(bold + "Usage:" + colorReset + " venom [command] [flags]")
+	fmt.Println()
+	fmt.Println("Run without a command to start the interactive messaging wizard.")
+	fmt.Println()
+	fmt.Println(bold + "Commands:" + colorReset)
+	fmt.Println("  check    Verify WhatsApp presence for every customer and write an annotated CSV")
+	fmt.Println("  channel  Post a template to a WhatsApp Channel owned by this account")
+	fmt.Println("  validate Check the customer CSV for problems (--fix to repair them) and write a per-row report")
+	fmt.Println("  session  List linked numbers, or export/import the session as an encrypted file")
+	fmt.Println("  retry-failed  Re-run the last campaign's templates against data/failed-customers.csv")
+	fmt.Println("  lookup   Show every message sent to a phone number or customer code")
+	fmt.Println("  purge    Erase a customer's data (--phone) or old records (--older-than 180d) with a certificate")
+	fmt.Println("  queue    Queue campaign profiles and run them one after another (add, list, move, cancel, run)")
+	fmt.Println("  watch    Run a campaign profile against every CSV dropped into incoming/")
+	fmt.Println("  edit     Correct a typo in the last campaign's messages (--find, --replace) within WhatsApp's edit window")
+	fmt.Println("  revoke   Delete the last campaign's messages for everyone (--results for an older one)")
+	fmt.Println("  engagement  Score customers by deliveries, reads and replies; export to CSV (--segment engaged|dead)")
+	fmt.Println("  template-stats  Compare delivery, read and reply rates of each template across campaigns")
+	fmt.Println("  groups   Create a WhatsApp group per segment (--by Segment) and export the JIDs and invite links")
+	fmt.Println("  story    Schedule text or image statuses and post them when due (add, list, cancel, run)")
+	fmt.Println("  help     Show this help")
+	fmt.Println()
+	fmt.Println(bold + "Global flags:" + colorReset)
+	fmt.Println("  --plain          Plain line-oriented output: no colors, progress bars or dashboard (also --quiet)")
+	fmt.Println("  --output ndjson  Write lifecycle events to stdout as JSON lines; other output goes to stderr")
+	fmt.Println("  --ui-lang ar     Show menus, messages and reports in Arabic")
+	fmt.Println()
+	fmt.Println(bold + "Exit status:" + colorReset)
+	fmt.Println("  0 finished          1 error              2 bad flags")
+	fmt.Println("  3 interrupted       4 config error       5 CSV error")
+	fmt.Println("  6 login required    7 finished with failures")
+	fmt.Println("  8 stopped by rate limits")
+	fmt.Println()
+	fmt.Println(dim + "Run 'venom <command> -h' for command flags." + colorReset)
+}
+
+// parseWizardFlags parses flags given to the interactive wizard (no subcommand)
+func parseWizardFlags(args []string) error {
+	fs := flag.NewFlagSet("venom", flag.ContinueOnError)
+	fs.StringVar(&config.CSVDelimiter, "delimiter", config.CSVDelimiter, "CSV delimiter: auto, ',', ';', tab or '|'")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "saved campaign profile to run, or linked number or profile name to send from (\"new\" links another)")
+	fs.StringVar(&saveProfileName, "save-profile", "", "save this run's wizard answers as a named campaign profile")
+	fs.BoolVar(&nonInteractive, "non-interactive", false, "never ask: resume an unfinished run and keep the first of duplicate records")
+	fs.StringVar(&config.Web.Listen, "web", config.Web.Listen, "serve a browser dashboard on this address while sending, e.g. 127.0.0.1:8080")
+	fs.StringVar(&config.Status.Listen, "status", config.Status.Listen, "serve progress as JSON on GET /status at this address while sending, e.g. 127.0.0.1:9100")
+	csvFile := fs.String("csv", "", "customer CSV (path, https:// or sftp:// URL, Google Sheet or sql://<query>) to send to, instead of the campaign profile's")
+	fs.BoolVar(&ignoreConsent, "ignore-consent", false, "also message customers without recorded consent (logged in the audit log)")
+	fs.BoolVar(&config.QuoteReplies.Enabled, "quote-replies", config.QuoteReplies.Enabled, "send each message as a reply to the customer's last message to us, if recent")
+	fs.BoolVar(&config.SkipGhostNumbers, "skip-ghosts", config.SkipGhostNumbers, "skip numbers with no profile picture and no name (likely dead or virtual)")
+	disappear := fs.String("disappear", "", "make this campaign's messages disappear after 24h, 7d or 90d")
+	fs.StringVar(&config.Pprof, "pprof", config.Pprof, "serve Go runtime profiles on this address while running, e.g. localhost:6060")
+	fs.BoolVar(&config.Simulate.Enabled, "simulate", config.Simulate.Enabled, "run the whole campaign against a simulated WhatsApp; nothing is sent")
+	fs.Usage = func() {
+		printUsage()
+		fmt.Println()
+		fmt.Println(bold + "Wizard flags:" + colorReset)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// A saved campaign profile takes precedence over a linked number of the same name
+	profile, err := loadCampaignProfile(config.Session.Profile)
+	if err != nil {
+		log.Error("Failed to load campaign profile", err)
+		return err
+	}
+	if profile != nil {
+		applyCampaignProfile(profile)
+	}
+	if *csvFile != "" {
+		campaignCSV = *csvFile
+	}
+	if *disappear != "" {
+		config.DisappearAfter = *disappear
+	}
+	if saveProfileName != "" {
+		if _, err := campaignProfilePath(saveProfileName); err != nil {
+			log.Error("Cannot save campaign profile", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// runCheckCommand runs the WhatsApp pre-check on its own, without the messaging wizard
+func runCheckCommand(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	csvPath := fs.String("csv", "customers.csv", "customer CSV file to check")
+	outPath := fs.String("out", "data/customers_checked.csv", "where to write the annotated CSV")
+	fs.StringVar(&config.CSVDelimiter, "delimiter", config.CSVDelimiter, "CSV delimiter: auto, ',', ';', tab or '|'")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name to check with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if isRemoteCSV(*csvPath) {
+		local, err := fetchRemoteCSV(*csvPath)
+		if err != nil {
+			return err
+		}
+		*csvPath = local
+	}
+
+	if _, err := os.Stat(*csvPath); os.IsNotExist(err) {
+		displayError("File Not Found",
+			fmt.Sprintf("Cannot find CSV file: %s", *csvPath),
+			"Pass the customer file with --csv",
+			[]string{"Example: venom check --csv customers.csv --out checked.csv"})
+		return fmt.Errorf("CSV file not found")
+	}
+
+	customers, err := loadCSV(*csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to load CSV: %w", err)
+	}
+	if len(customers) == 0 {
+		return fmt.Errorf("no customers found in CSV")
+	}
+	log.Info(fmt.Sprintf("Loaded %d customers from %s", len(customers), *csvPath))
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	defer closeSessionStore()
+	client, err := initializeWhatsApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+	}
+	defer client.Disconnect()
+
+	customers = preCheckWhatsAppNumbers(ctx, client, customers)
+
+	if err := saveCustomersWithWhatsAppStatus(customers, *outPath); err != nil {
+		return fmt.Errorf("failed to save checked CSV: %w", err)
+	}
+
+	yes, no, unchecked := 0, 0, 0
+	for _, c := range customers {
+		switch c.HasWhatsApp {
+		case "yes":
+			yes++
+		case "no":
+			no++
+		default:
+			unchecked++
+		}
+	}
+
+	displaySuccess("Check Complete",
+		fmt.Sprintf("Saved %s — %d on WhatsApp, %d not on WhatsApp, %d unchecked",
+			*outPath, yes, no, unchecked))
+	return nil
+}
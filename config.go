@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultConfigFile is read at startup if present; VENOM_CONFIG overrides the path
+const defaultConfigFile = "config.json"
+
+// configFilePath returns the config file to load
+func configFilePath() string {
+	if path := os.Getenv("VENOM_CONFIG"); path != "" {
+		return path
+	}
+	return defaultConfigFile
+}
+
+// loadConfigFile overlays settings from a JSON file onto the built-in defaults.
+// Keys match Config field names (case-insensitive); omitted keys keep their defaults.
+// A missing file is not an error unless it was named explicitly via VENOM_CONFIG.
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && os.Getenv("VENOM_CONFIG") == "" {
+			return nil
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := checkReserveHeadroom(config.ReserveHeadroom); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	log.Info(fmt.Sprintf("Loaded configuration from %s", path))
+	return nil
+}
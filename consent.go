@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// ConsentConfig controls consent enforcement. Consent comes from the CSV
+// columns or, failing that, the consents table in the history database.
+type ConsentConfig struct {
+	Required     bool   // Skip customers without recorded consent (--ignore-consent overrides)
+	DateColumn   string // CSV column holding when consent was given
+	SourceColumn string // CSV column holding how it was given, e.g. "in-store form"
+}
+
+// ignoreConsent is set by --ignore-consent to send without recorded consent
+var ignoreConsent bool
+
+// consentRecord is a customer's recorded consent
+type consentRecord struct {
+	Date   string
+	Source string
+}
+
+// consents holds the consents table by formatted phone
+var consents map[string]consentRecord
+
+// loadConsents reads the consents table from the history database. The
+// table is created empty if missing so it's easy to find and fill.
+func loadConsents() (map[string]consentRecord, error) {
+	records := make(map[string]consentRecord)
+	if _, err := os.Stat(config.History.File); os.IsNotExist(err) {
+		return records, nil
+	}
+	h, err := openHistory(config.History.File)
+	if err != nil {
+		return nil, err
+	}
+	defer h.close()
+
+	rows, err := h.db.Query(`SELECT phone, IFNULL(date, ''), IFNULL(source, '') FROM consents`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var phone string
+		var record consentRecord
+		if err := rows.Scan(&phone, &record.Date, &record.Source); err != nil {
+			return nil, err
+		}
+		if cleaned := cleanPhoneNumber(phone); cleaned != "" {
+			records[formatPhoneNumber(cleaned)] = record
+		}
+	}
+	return records, rows.Err()
+}
+
+// consentBasis describes the recorded consent for a customer, or returns
+// "" if there is none
+func consentBasis(customer Customer, formattedPhone string) string {
+	record := consentRecord{
+		Date:   strings.TrimSpace(customer.Fields[config.Consent.DateColumn]),
+		Source: strings.TrimSpace(customer.Fields[config.Consent.SourceColumn]),
+	}
+	where := "CSV"
+	if record.Date == "" && record.Source == "" {
+		record, where = consents[formattedPhone], "consents table"
+	}
+	if record.Date == "" && record.Source == "" {
+		return ""
+	}
+
+	basis := "consent"
+	if record.Source != "" {
+		basis += " via " + record.Source
+	}
+	if record.Date != "" {
+		basis += " on " + record.Date
+	}
+	return basis + " (" + where + ")"
+}
+
+// checkConsent returns the basis for messaging a customer and whether the
+// send may go ahead
+func checkConsent(customer Customer, formattedPhone string) (string, bool) {
+	if basis := consentBasis(customer, formattedPhone); basis != "" {
+		return basis, true
+	}
+	if !config.Consent.Required {
+		return "consent not required by config", true
+	}
+	if ignoreConsent {
+		return "no recorded consent; sent with --ignore-consent", true
+	}
+	return "", false
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
+)
+
+// DashboardConfig controls the live terminal dashboard shown while sending
+type DashboardConfig struct {
+	Enabled  bool // Show the dashboard when running in a terminal
+	LogLines int  // Lines kept in the scrolling log pane
+}
+
+// dashboard is the running dashboard, or nil when sends print line by line
+var dashboard *campaignDashboard
+
+// dashboardStats is a snapshot of the campaign taken by the send loop
+type dashboardStats struct {
+	Current, Total              int
+	Name                        string
+	Successful, Failed, Skipped int
+	HourlySent, DailySent       int
+	HourlyLimit, DailyLimit     int
+	Delay                       time.Duration
+	Status                      string
+}
+
+type (
+	dashboardStatsMsg dashboardStats
+	dashboardLogMsg   string
+	dashboardTickMsg  time.Time
+)
+
+// campaignDashboard runs the TUI and captures everything printed to the
+// console into its log pane while the campaign sends
+type campaignDashboard struct {
+	program *tea.Program
+	stats   dashboardStats
+	paused  atomic.Bool
+
+	stdout   *os.File // The real terminal
+	partial  []byte   // Console output after the last newline
+	finished chan struct{}
+}
+
+// startDashboard takes over the terminal until stop is called. abort cancels
+// the campaign. It returns nil when the dashboard is off or stdout isn't a terminal.
+func startDashboard(abort context.CancelFunc) *campaignDashboard {
+	if !config.Dashboard.Enabled || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil
+	}
+
+	d := &campaignDashboard{
+		stdout:   os.Stdout,
+		finished: make(chan struct{}),
+	}
+	model := dashboardModel{
+		dash:     d,
+		abort:    abort,
+		started:  time.Now(),
+		maxLines: max(config.Dashboard.LogLines, 10),
+	}
+	d.program = tea.NewProgram(model, tea.WithOutput(d.stdout), tea.WithAltScreen())
+
+	// Every line printed to the console during the campaign goes to the log pane
+	console.redirect(d)
+
+	go func() {
+		defer close(d.finished)
+		if _, err := d.program.Run(); err != nil {
+			fmt.Fprintln(d.stdout, "Dashboard error:", err)
+		}
+	}()
+	return d
+}
+
+// stop restores the terminal and sends console output back to stdout
+func (d *campaignDashboard) stop() {
+	if d == nil {
+		return
+	}
+	console.redirect(nil)
+	d.program.Quit()
+	<-d.finished
+}
+
+// Write takes console output and shows each complete line in the log pane.
+// The console's lock serializes calls, and lines of any length pass through.
+func (d *campaignDashboard) Write(p []byte) (int, error) {
+	d.partial = append(d.partial, p...)
+	for {
+		i := bytes.IndexByte(d.partial, '\n')
+		if i < 0 {
+			break
+		}
+		for _, line := range strings.Split(string(d.partial[:i]), "\r") {
+			if strings.TrimSpace(ansi.Strip(line)) != "" {
+				d.program.Send(dashboardLogMsg(line))
+			}
+		}
+		d.partial = d.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// update sends the latest counters to the dashboard
+func (d *campaignDashboard) update(current, total int, name string) {
+	if d == nil {
+		return
+	}
+	d.stats.Current, d.stats.Total, d.stats.Name = current, total, name
+	d.stats.Status = ""
+	d.refresh()
+}
+
+// setDelay shows the wait before the next send
+func (d *campaignDashboard) setDelay(delay time.Duration, status string) {
+	if d == nil {
+		return
+	}
+	d.stats.Delay, d.stats.Status = delay, status
+	d.refresh()
+}
+
+// refresh copies the shared counters into a snapshot for the TUI goroutine
+func (d *campaignDashboard) refresh() {
+	d.stats.Successful, d.stats.Failed, d.stats.Skipped = progress.Successful, progress.Failed, progress.Skipped
+	d.stats.HourlySent, d.stats.DailySent = progress.HourlySent, progress.DailySent
+	d.stats.HourlyLimit, d.stats.DailyLimit = config.HourlyLimit, config.DailyLimit
+	d.program.Send(dashboardStatsMsg(d.stats))
+}
+
+// waitWhilePaused blocks while the operator has paused the campaign from the
+// dashboard, the web dashboard or the interrupt menu. It returns false if
+// the campaign was aborted meanwhile.
+func waitWhilePaused(ctx context.Context) bool {
+	for operatorPaused.Load() || (dashboard != nil && dashboard.paused.Load()) || web.isPaused() {
+		status.refresh(0, "paused")
+		if !sleepWithContext(ctx, 500*time.Millisecond) {
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// dashboardModel is the bubbletea model behind the dashboard
+type dashboardModel struct {
+	dash     *campaignDashboard
+	abort    context.CancelFunc
+	started  time.Time
+	stats    dashboardStats
+	logs     []string
+	maxLines int
+	width    int
+	height   int
+	confirm  bool // Waiting for y/n after an abort request
+	aborted  bool
+}
+
+func dashboardTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return dashboardTickMsg(t) })
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return dashboardTick()
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case dashboardTickMsg:
+		return m, dashboardTick()
+	case dashboardStatsMsg:
+		m.stats = dashboardStats(msg)
+	case dashboardLogMsg:
+		m.logs = append(m.logs, string(msg))
+		if len(m.logs) > m.maxLines {
+			m.logs = m.logs[len(m.logs)-m.maxLines:]
+		}
+	case tea.KeyMsg:
+		if m.confirm {
+			switch msg.String() {
+			case "y", "Y":
+				m.confirm, m.aborted = false, true
+				m.dash.paused.Store(false)
+				m.abort()
+			default:
+				m.confirm = false
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "p", " ":
+			m.dash.paused.Store(!m.dash.paused.Load())
+		case "s":
+			if m.stats.Status == "batch break" {
+				requestBreakControl(breakSkip)
+			}
+		case "+", "=":
+			if m.stats.Status == "batch break" {
+				requestBreakControl(breakExtend)
+			}
+		case "r":
+			requestConfigReload()
+		case "a", "q", "ctrl+c":
+			if !m.aborted {
+				m.confirm = true
+			}
+		}
+	}
+	return m, nil
+}
+
+// bar draws used out of total as a filled bar
+func bar(used, total, width int, color string) string {
+	filled := 0
+	if total > 0 {
+		filled = min(used*width/total, width)
+	}
+	return color + strings.Repeat("█", filled) + colorReset + colorGray + strings.Repeat("░", width-filled) + colorReset
+}
+
+// gauge draws a rate limit bar that turns yellow, then red, near the limit
+func gauge(used, limit, width int) string {
+	color := colorBrightGreen
+	if limit > 0 && used*100 >= limit*90 {
+		color = colorRed
+	} else if limit > 0 && used*100 >= limit*70 {
+		color = colorYellow
+	}
+	return bar(used, limit, width, color)
+}
+
+func (m dashboardModel) View() string {
+	s := m.stats
+	width := max(m.width, 60)
+	var b strings.Builder
+
+	title := " 📱 Venom Bulk Messaging "
+	switch {
+	case m.aborted:
+		title += colorRed + "— stopping…" + colorReset
+	case m.dash.paused.Load():
+		title += colorYellow + "— PAUSED" + colorReset
+	}
+	b.WriteString(bold + title + colorReset + "\n")
+	b.WriteString(strings.Repeat("─", width) + "\n")
+
+	// Progress and counters
+	percent := 0.0
+	if s.Total > 0 {
+		percent = float64(s.Current) / float64(s.Total) * 100
+	}
+	b.WriteString(fmt.Sprintf(" Progress  %s %5.1f%%  (%d/%d)\n", bar(s.Current, s.Total, 30, colorBrightGreen), percent, s.Current, s.Total))
+	b.WriteString(fmt.Sprintf(" Sent %s%d%s   Failed %s%d%s   Skipped %s%d%s\n",
+		colorGreen, s.Successful, colorReset, colorRed, s.Failed, colorReset, colorYellow, s.Skipped, colorReset))
+
+	// Current customer, wait and ETA
+	elapsed := time.Since(m.started)
+	eta := "—"
+	if s.Total > 0 {
+		eta = formatETA(max(s.Current-1, 0), s.Total)
+	}
+	b.WriteString(fmt.Sprintf(" Current   %s\n", s.Name))
+	wait := s.Delay.Round(100 * time.Millisecond).String()
+	if s.Status != "" {
+		wait += " (" + s.Status + ")"
+	}
+	b.WriteString(fmt.Sprintf(" Delay     %s   Elapsed %s   %s\n", wait, elapsed.Round(time.Second), eta))
+
+	// Rate limit gauges
+	b.WriteString(fmt.Sprintf(" Hourly    %s %d/%d\n", gauge(s.HourlySent, s.HourlyLimit, 30), s.HourlySent, s.HourlyLimit))
+	b.WriteString(fmt.Sprintf(" Daily     %s %d/%d\n", gauge(s.DailySent, s.DailyLimit, 30), s.DailySent, s.DailyLimit))
+	b.WriteString(strings.Repeat("─", width) + "\n")
+
+	// Log pane fills the rest of the screen
+	paneHeight := max(m.height-12, 3)
+	logs := m.logs
+	if len(logs) > paneHeight {
+		logs = logs[len(logs)-paneHeight:]
+	}
+	for _, line := range logs {
+		b.WriteString(ansi.Truncate(line, width, "…") + colorReset + "\n")
+	}
+	for i := len(logs); i < paneHeight; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(strings.Repeat("─", width) + "\n")
+	if m.confirm {
+		b.WriteString(colorRed + bold + " Abort the campaign? Progress is saved. (y/n)" + colorReset)
+	} else {
+		keys := " [p] pause/resume   [r] reload config   [a] abort"
+		if s.Status == "batch break" {
+			keys += "   [s] skip break   [+] add 1 minute"
+		}
+		b.WriteString(dim + keys + colorReset)
+	}
+	return b.String()
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// DataEncryptionConfig keeps customer data (data/*.csv, the checkpoint and
+// the history database) encrypted at rest. The key comes from
+// VENOM_DATA_KEY, the OS keyring, or is asked for once per run.
+type DataEncryptionConfig struct {
+	Enabled        bool
+	KeyringService string // Keyring entry service name; account is "data-key" ("" = don't use the keyring)
+}
+
+// dataKeyCheckFile holds a known value sealed with the data key, so a
+// mistyped passphrase is caught before anything is written with it
+const dataKeyCheckFile = "data/.datakey"
+
+var dataKeyCheck = []byte("venom data key")
+
+// dataKeys caches the data passphrase and the keys derived from it, since
+// scrypt is too slow to run for every checkpoint save
+var dataKeys struct {
+	mu         sync.Mutex
+	passphrase string
+	salt       []byte            // salt used for everything written by this run
+	derived    map[string][]byte // salt -> key
+}
+
+// dataEncryptionEnabled reports whether new data files are written encrypted
+func dataEncryptionEnabled() bool {
+	return config.DataEncryption.Enabled
+}
+
+// dataPassphrase returns the data passphrase, looking it up on first use
+func dataPassphrase() (string, error) {
+	if dataKeys.passphrase != "" {
+		return dataKeys.passphrase, nil
+	}
+
+	passphrase := os.Getenv("VENOM_DATA_KEY")
+	if passphrase == "" {
+		passphrase = keyringPassphrase(config.DataEncryption.KeyringService)
+	}
+
+	check, checkErr := os.ReadFile(dataKeyCheckFile)
+	if passphrase == "" {
+		var err error
+		passphrase, err = promptPassphrase("Data passphrase", checkErr != nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if checkErr == nil {
+		plain, err := decryptWithPassphrase(check, passphrase)
+		if err != nil || !bytes.Equal(plain, dataKeyCheck) {
+			return "", fmt.Errorf("wrong data passphrase (checked against %s)", dataKeyCheckFile)
+		}
+	} else {
+		sealed, err := encryptWithPassphrase(dataKeyCheck, passphrase)
+		if err != nil {
+			return "", err
+		}
+		os.MkdirAll(filepath.Dir(dataKeyCheckFile), 0755)
+		if err := os.WriteFile(dataKeyCheckFile, sealed, 0600); err != nil {
+			return "", err
+		}
+	}
+
+	dataKeys.passphrase = passphrase
+	return passphrase, nil
+}
+
+// keyringPassphrase reads the data passphrase from the OS keyring, or
+// returns "" when there is no keyring tool or entry
+func keyringPassphrase(service string) string {
+	if service == "" {
+		return ""
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", "data-key", "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", "data-key")
+	default:
+		return ""
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(out), "\r\n")
+}
+
+// dataKey returns the key for salt, deriving it the first time
+func dataKey(salt []byte) ([]byte, error) {
+	dataKeys.mu.Lock()
+	defer dataKeys.mu.Unlock()
+
+	passphrase, err := dataPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := dataKeys.derived[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if dataKeys.derived == nil {
+		dataKeys.derived = make(map[string][]byte)
+	}
+	dataKeys.derived[string(salt)] = key
+	return key, nil
+}
+
+// runSalt returns the salt for everything written by this run, and its key
+func runSalt() (salt, key []byte, err error) {
+	dataKeys.mu.Lock()
+	if dataKeys.salt == nil {
+		dataKeys.salt = make([]byte, saltSize)
+		if _, err := rand.Read(dataKeys.salt); err != nil {
+			dataKeys.salt = nil
+			dataKeys.mu.Unlock()
+			return nil, nil, err
+		}
+	}
+	salt = dataKeys.salt
+	dataKeys.mu.Unlock()
+
+	key, err = dataKey(salt)
+	return salt, key, err
+}
+
+// encryptData seals data with the data key
+func encryptData(data []byte) ([]byte, error) {
+	salt, key, err := runSalt()
+	if err != nil {
+		return nil, err
+	}
+	return sealWithKey(data, key, salt)
+}
+
+// recordMagic starts a data file written a record at a time by sealedFile.
+// Layout: magic | salt | records, each a 4-byte big-endian length followed
+// by nonce | ciphertext, sealed with the record's position as additional
+// data so records can't be reordered or dropped from the middle.
+var recordMagic = []byte("VENOMENC2")
+
+// isEncrypted reports whether data starts like a sealed data file
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic) || bytes.HasPrefix(data, recordMagic)
+}
+
+// dataCipher returns AES-256-GCM under key
+func dataCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// recordData is the additional data sealed into the record at index
+func recordData(index uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, recordMagic...), index)
+}
+
+// splitRecords returns the salt of a record file and its complete records.
+// A record cut short by a crash mid-write ends the list; tail is its offset.
+func splitRecords(data []byte) (salt []byte, records [][]byte, tail int, err error) {
+	if !bytes.HasPrefix(data, recordMagic) || len(data) < len(recordMagic)+saltSize {
+		return nil, nil, 0, fmt.Errorf("encrypted file is truncated")
+	}
+	tail = len(recordMagic) + saltSize
+	salt = data[len(recordMagic):tail]
+	for len(data)-tail >= 4 {
+		n := int(binary.BigEndian.Uint32(data[tail:]))
+		if len(data)-tail-4 < n {
+			break
+		}
+		records = append(records, data[tail+4:tail+4+n])
+		tail += 4 + n
+	}
+	return salt, records, tail, nil
+}
+
+// openRecords decrypts a file written by sealedFile
+func openRecords(data []byte) ([]byte, error) {
+	salt, records, _, err := splitRecords(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := dataKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := dataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var plain []byte
+	for i, record := range records {
+		if len(record) < aead.NonceSize() {
+			return nil, fmt.Errorf("encrypted file is truncated")
+		}
+		nonce, sealed := record[:aead.NonceSize()], record[aead.NonceSize():]
+		if plain, err = aead.Open(plain, nonce, sealed, recordData(uint64(i))); err != nil {
+			return nil, fmt.Errorf("wrong passphrase or corrupted file")
+		}
+	}
+	return plain, nil
+}
+
+// decryptData opens data sealed by encryptData or sealedFile; plaintext is
+// returned as is, so files written before encryption was turned on still read
+func decryptData(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, recordMagic) {
+		return openRecords(data)
+	}
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, nil
+	}
+	salt, rest, err := sealedSalt(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := dataKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	return openWithKey(rest, key)
+}
+
+// readDataFile reads a data file, decrypting it if needed
+func readDataFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// openDataFile opens a data file for reading. Plaintext files are streamed;
+// encrypted ones are decrypted into memory.
+func openDataFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(len(encryptedMagic)); !isEncrypted(head) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, file}, nil
+	}
+	file.Close()
+
+	data, err := readDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// writeDataFile writes a data file atomically, encrypted when enabled
+func writeDataFile(path string, data []byte) error {
+	if dataEncryptionEnabled() {
+		sealed, err := encryptData(data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// sealedFile appends each write to a data file as its own encrypted record,
+// so what is on disk is always complete up to the last write and a write
+// costs the same however large the file has grown
+type sealedFile struct {
+	file  *os.File
+	aead  cipher.AEAD
+	index uint64 // Records already in the file
+}
+
+func (f *sealedFile) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, err
+	}
+	record := make([]byte, 4, 4+len(nonce)+len(p)+f.aead.Overhead())
+	record = append(record, nonce...)
+	record = f.aead.Seal(record, nonce, p, recordData(f.index))
+	binary.BigEndian.PutUint32(record, uint32(len(record)-4))
+	if _, err := f.file.Write(record); err != nil {
+		return 0, err
+	}
+	f.index++
+	return len(p), nil
+}
+
+func (f *sealedFile) Close() error {
+	return f.file.Close()
+}
+
+// createSealedFile starts an empty record file at path
+func createSealedFile(path string) (*sealedFile, error) {
+	salt, key, err := runSalt()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := dataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(append(append([]byte{}, recordMagic...), salt...)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sealedFile{file: file, aead: aead}, nil
+}
+
+// createDataFile creates a data file for writing, encrypted when enabled
+func createDataFile(path string) (io.WriteCloser, error) {
+	if !dataEncryptionEnabled() {
+		return os.Create(path)
+	}
+	return createSealedFile(path)
+}
+
+// appendDataFile opens a data file for appending, encrypted when enabled.
+// An existing plaintext or whole-file encrypted file is converted to
+// records first.
+func appendDataFile(path string) (io.WriteCloser, error) {
+	if !dataEncryptionEnabled() {
+		if data, err := os.ReadFile(path); err == nil && isEncrypted(data) {
+			return nil, fmt.Errorf("%s is encrypted; enable DataEncryption to add to it", path)
+		}
+		return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return createSealedFile(path)
+	}
+	if !bytes.HasPrefix(data, recordMagic) {
+		plain, err := decryptData(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		tmp := path + ".tmp"
+		f, err := createSealedFile(tmp)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(plain); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return nil, err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return nil, err
+		}
+		return f, nil
+	}
+
+	// Carry on after the last complete record, dropping one cut short
+	salt, records, tail, err := splitRecords(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := dataKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := dataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(int64(tail)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sealedFile{file: file, aead: aead, index: uint64(len(records))}, nil
+}
+
+// encryptedDB is a sqlite database kept encrypted at rest. It is decrypted
+// into a private directory under workRoot while open and sealed back on close.
+// A crash loses what was written since it was opened.
+type encryptedDB struct {
+	path     string
+	workDir  string
+	workFile string
+}
+
+// openDataDB returns the file sqlite should open for a data database. For
+// an encrypted (or to-be-encrypted) database that is a decrypted working
+// copy; db is nil when the file is used directly.
+func openDataDB(path string) (string, *encryptedDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, err
+	}
+	encrypted := isEncrypted(data)
+	if !encrypted && !dataEncryptionEnabled() {
+		return path, nil, nil
+	}
+
+	plain, err := decryptData(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	db := &encryptedDB{path: path}
+	if db.workDir, err = makeWorkDir("venom-data-"); err != nil {
+		return "", nil, err
+	}
+	db.workFile = filepath.Join(db.workDir, filepath.Base(path))
+	if len(plain) > 0 {
+		if err := os.WriteFile(db.workFile, plain, 0600); err != nil {
+			os.RemoveAll(db.workDir)
+			return "", nil, err
+		}
+	}
+	return db.workFile, db, nil
+}
+
+// close seals the working copy back and removes it. The copy is kept if it
+// can't be sealed, so nothing is lost.
+func (db *encryptedDB) close() {
+	if db == nil {
+		return
+	}
+	data, err := os.ReadFile(db.workFile)
+	if err == nil {
+		var sealed []byte
+		if sealed, err = encryptData(data); err == nil {
+			tmp := db.path + ".tmp"
+			if err = os.WriteFile(tmp, sealed, 0600); err == nil {
+				err = os.Rename(tmp, db.path)
+			}
+		}
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Error("Failed to encrypt "+db.path+", keeping working copy in "+db.workDir, err)
+		return
+	}
+	os.RemoveAll(db.workDir)
+}
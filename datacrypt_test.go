@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSealedFileAppend(t *testing.T) {
+	t.Setenv("VENOM_DATA_KEY", "test passphrase")
+	config.DataEncryption.Enabled = true
+	defer func() { config.DataEncryption.Enabled = false }()
+	os.MkdirAll("data", 0755)
+	path := "data/sealed.csv"
+	os.WriteFile(path, []byte("code,phone\n"), 0644)
+
+	// A plaintext file is converted on the first append, then added to
+	for _, row := range []string{"1,201000000001\n", "2,201000000002\n"} {
+		file, err := appendDataFile(path)
+		if err != nil {
+			t.Fatalf("appendDataFile() error: %v", err)
+		}
+		if _, err := file.Write([]byte(row)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+		file.Close()
+	}
+
+	raw, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(raw, recordMagic) || bytes.Contains(raw, []byte("201000000001")) {
+		t.Fatalf("file isn't sealed records: %q", raw)
+	}
+	want := "code,phone\n1,201000000001\n2,201000000002\n"
+	if got, err := readDataFile(path); err != nil || string(got) != want {
+		t.Errorf("readDataFile() = %q, %v, want %q", got, err, want)
+	}
+
+	// A record cut short by a crash is dropped, and appends carry on after the rest
+	os.WriteFile(path, raw[:len(raw)-5], 0600)
+	if got, err := readDataFile(path); err != nil || string(got) != "code,phone\n1,201000000001\n" {
+		t.Errorf("readDataFile() of a torn file = %q, %v", got, err)
+	}
+	file, err := appendDataFile(path)
+	if err != nil {
+		t.Fatalf("appendDataFile() of a torn file: %v", err)
+	}
+	file.Write([]byte("3,201000000003\n"))
+	file.Close()
+	if got, err := readDataFile(path); err != nil || string(got) != "code,phone\n1,201000000001\n3,201000000003\n" {
+		t.Errorf("readDataFile() after appending to a torn file = %q, %v", got, err)
+	}
+
+	// Records can't be swapped around
+	raw, _ = os.ReadFile(path)
+	salt, records, _, _ := splitRecords(raw)
+	swapped := append(append([]byte{}, recordMagic...), salt...)
+	for _, i := range []int{1, 0, 2} {
+		swapped = append(swapped, byte(len(records[i])>>24), byte(len(records[i])>>16), byte(len(records[i])>>8), byte(len(records[i])))
+		swapped = append(swapped, records[i]...)
+	}
+	if _, err := decryptData(swapped); err == nil {
+		t.Error("decryptData() accepted reordered records")
+	}
+}
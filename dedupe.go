@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+)
+
+// arabicLetterVariants maps letter variants that are commonly typed
+// interchangeably to a single canonical form
+var arabicLetterVariants = map[rune]rune{
+	'أ': 'ا',
+	'إ': 'ا',
+	'آ': 'ا',
+	'ٱ': 'ا',
+	'ة': 'ه',
+	'ى': 'ي',
+	'ؤ': 'و',
+	'ئ': 'ي',
+}
+
+// normalizeArabicName reduces a name to a comparable form: diacritics and
+// tatweel removed, letter variants unified, case and spacing folded
+func normalizeArabicName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'ً' && r <= 'ْ', r == 'ـ': // tashkeel, tatweel
+			continue
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			b.WriteRune(' ')
+		default:
+			if canonical, ok := arabicLetterVariants[r]; ok {
+				r = canonical
+			}
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// lastDigits returns the last n digits of a phone number, ignoring formatting
+func lastDigits(phone string, n int) string {
+	cleaned := cleanPhoneNumber(phone)
+	if len(cleaned) < n {
+		return ""
+	}
+	return cleaned[len(cleaned)-n:]
+}
+
+// findFuzzyDuplicates groups customers that share a normalized name and the
+// last 9 digits of any of their numbers. Only groups with 2+ records are returned.
+func findFuzzyDuplicates(customers []Customer) [][]int {
+	groupOf := make(map[string]int) // match key -> group index
+	groups := [][]int{}
+
+	for i, c := range customers {
+		name := normalizeArabicName(c.CustomerName)
+		if name == "" {
+			continue
+		}
+
+		group := -1
+		keys := []string{}
+		for _, phone := range []string{c.Mobile, c.Phone} {
+			suffix := lastDigits(phone, 9)
+			if suffix == "" {
+				continue
+			}
+			key := name + "|" + suffix
+			keys = append(keys, key)
+			if g, ok := groupOf[key]; ok && group == -1 {
+				group = g
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		if group == -1 {
+			group = len(groups)
+			groups = append(groups, []int{})
+		}
+		groups[group] = append(groups[group], i)
+		for _, key := range keys {
+			if _, ok := groupOf[key]; !ok {
+				groupOf[key] = group
+			}
+		}
+	}
+
+	duplicates := [][]int{}
+	for _, g := range groups {
+		if len(g) > 1 {
+			duplicates = append(duplicates, g)
+		}
+	}
+	return duplicates
+}
+
+// resolveFuzzyDuplicates detects near-duplicate customers and keeps one record
+// per group. With DuplicateWinner "ask" the operator picks which one wins,
+// when there is a terminal to ask on.
+func resolveFuzzyDuplicates(customers []Customer) []Customer {
+	if !config.FuzzyDedupe {
+		return customers
+	}
+
+	groups := findFuzzyDuplicates(customers)
+	if len(groups) == 0 {
+		return customers
+	}
+
+	displayWarning("Possible Duplicate Customers",
+		fmt.Sprintf("Found %d groups of records that look like the same customer", len(groups)),
+		[]string{
+			"Matched by normalized name and the last 9 digits of the number",
+			"Only one record per group will receive a message",
+		})
+
+	drop := make(map[int]bool)
+	duplicateOf := make(map[int]string)
+	mode := config.DuplicateWinner
+	if mode == "ask" && (nonInteractive || !term.IsTerminal(int(os.Stdin.Fd()))) {
+		log.Info("Nobody to ask which duplicate to keep; keeping the first record of each group")
+		mode = "first"
+	}
+
+	for n, group := range groups {
+		winner := group[0]
+
+		switch mode {
+		case "last":
+			winner = group[len(group)-1]
+		case "ask":
+			items := make([]string, 0, len(group)+2)
+			for _, idx := range group {
+				c := customers[idx]
+				items = append(items, fmt.Sprintf("Keep %s - %s (Mobile: %s, Phone: %s)",
+					c.Code, c.CustomerName, c.Mobile, c.Phone))
+			}
+			items = append(items, "Keep all records (not duplicates)")
+			items = append(items, colorYellow+"Keep first record for all remaining groups"+colorReset)
+
+			prompt := promptui.Select{
+				Label: fmt.Sprintf("Duplicate group %d/%d", n+1, len(groups)),
+				Items: items,
+			}
+			idx, _, err := prompt.Run()
+			if err != nil || idx == len(group)+1 {
+				mode = "first"
+				break
+			}
+			if idx == len(group) {
+				continue
+			}
+			winner = group[idx]
+		}
+
+		for _, idx := range group {
+			if idx != winner {
+				drop[idx] = true
+				duplicateOf[idx] = fmt.Sprintf("Duplicate of %s (%s)", customers[winner].CustomerName, customers[winner].Code)
+				log.Warning(fmt.Sprintf("Skipping %s (%s) - Duplicate of %s (%s)",
+					customers[idx].CustomerName, customers[idx].Code,
+					customers[winner].CustomerName, customers[winner].Code))
+			}
+		}
+	}
+
+	kept := make([]Customer, 0, len(customers)-len(drop))
+	for i, c := range customers {
+		if drop[i] {
+			recordSkip(c, skipDuplicate, duplicateOf[i])
+			progress.Skipped++
+			progress.Duplicates++
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	log.Info(fmt.Sprintf("Removed %d near-duplicate records", len(drop)))
+	return kept
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// delimiterCandidates are the separators seen in real exports, in order of preference on ties
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// csvDelimiter returns the delimiter to parse the CSV with: the configured
+// CSVDelimiter, or the candidate that occurs most often in the header line
+func csvDelimiter(r *bufio.Reader) (rune, error) {
+	switch strings.ToLower(config.CSVDelimiter) {
+	case "", "auto":
+	case "tab", `\t`:
+		return '\t', nil
+	default:
+		d, size := utf8.DecodeRuneInString(config.CSVDelimiter)
+		if size != len(config.CSVDelimiter) || d == '"' || d == '\r' || d == '\n' {
+			return 0, fmt.Errorf("invalid CSV delimiter %q", config.CSVDelimiter)
+		}
+		return d, nil
+	}
+
+	head, err := r.Peek(r.Size())
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return 0, err
+	}
+	header, _, _ := strings.Cut(string(head), "\n")
+	return detectDelimiter(header), nil
+}
+
+// detectDelimiter picks the most frequent candidate outside quoted fields
+func detectDelimiter(header string) rune {
+	counts := make(map[rune]int)
+	inQuotes := false
+	for _, r := range header {
+		if r == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if !inQuotes {
+			counts[r]++
+		}
+	}
+
+	best := ','
+	for _, d := range delimiterCandidates {
+		if counts[d] > counts[best] {
+			best = d
+		}
+	}
+	return best
+}
+
+// delimiterName returns a readable name for a delimiter
+func delimiterName(d rune) string {
+	if d == '\t' {
+		return "tab"
+	}
+	return fmt.Sprintf("'%c'", d)
+}
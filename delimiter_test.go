@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		name, header string
+		want         rune
+	}{
+		{"comma", "Code,CustomerName,Phone,Mobile", ','},
+		{"semicolon", "Code;CustomerName;Phone;Mobile", ';'},
+		{"tab", "Code\tCustomerName\tPhone\tMobile", '\t'},
+		{"pipe", "Code|CustomerName|Phone|Mobile", '|'},
+		{"commas inside quotes don't count", `"Name, Last";"Name, First";Phone`, ';'},
+		{"most frequent wins", "a;b;c,d,e,f", ','},
+		{"tie prefers comma", "Code;Name,Phone", ','},
+		{"tie prefers semicolon over tab", "Code;Name\tPhone", ';'},
+		{"single column", "Phone", ','},
+		{"empty", "", ','},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectDelimiter(tt.header); got != tt.want {
+				t.Errorf("detectDelimiter(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
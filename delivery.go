@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Delivery states, in the order WhatsApp reports them
+const (
+	statusSent      = "sent"
+	statusDelivered = "delivered"
+	statusRead      = "read"
+)
+
+// deliveryTracker follows the receipts for messages sent by the campaign
+type deliveryTracker struct {
+	mu       sync.Mutex
+	customer map[types.MessageID]Customer
+	status   map[types.MessageID]string
+}
+
+var deliveries = &deliveryTracker{
+	customer: make(map[types.MessageID]Customer),
+	status:   make(map[types.MessageID]string),
+}
+
+// track registers a sent campaign message
+func (t *deliveryTracker) track(id types.MessageID, customer Customer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.customer[id] = customer
+	t.status[id] = statusSent
+}
+
+// handleReceipt advances the status of tracked messages. Receipts can
+// arrive out of order, so a message never moves back from read to delivered.
+func (t *deliveryTracker) handleReceipt(evt *events.Receipt) {
+	var status string
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		status = statusDelivered
+	case types.ReceiptTypeRead, types.ReceiptTypePlayed:
+		status = statusRead
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, id := range evt.MessageIDs {
+		current, ours := t.status[id]
+		if !ours || current == statusRead {
+			continue
+		}
+		t.status[id] = status
+		history.updateStatus(string(id), status)
+		log.Debug(fmt.Sprintf("Message to %s %s", t.customer[id].CustomerName, status))
+	}
+}
+
+// statusOf returns the delivery state of a tracked message, or ""
+func (t *deliveryTracker) statusOf(id types.MessageID) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status[id]
+}
+
+// counts returns how many tracked messages are in each state
+func (t *deliveryTracker) counts() (sent, delivered, read int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, status := range t.status {
+		switch status {
+		case statusRead:
+			read++
+		case statusDelivered:
+			delivered++
+		default:
+			sent++
+		}
+	}
+	return sent, delivered, read
+}
+
+// displaySummary prints delivery and read counts for the campaign
+func (t *deliveryTracker) displaySummary() {
+	sent, delivered, read := t.counts()
+	total := sent + delivered + read
+	if total == 0 {
+		return
+	}
+
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println("DELIVERY")
+	fmt.Printf("Awaiting Delivery:  %d\n", sent)
+	fmt.Printf("Delivered (unread): %d\n", delivered)
+	fmt.Printf("Read:               %d (%.1f%%)\n", read, float64(read)/float64(total)*100)
+	fmt.Println(strings.Repeat("─", 60))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// disappearingTimers are the timers WhatsApp offers, in seconds
+var disappearingTimers = map[string]uint32{
+	"24h": uint32((24 * time.Hour).Seconds()),
+	"7d":  uint32((7 * 24 * time.Hour).Seconds()),
+	"90d": uint32((90 * 24 * time.Hour).Seconds()),
+}
+
+// validateDisappearAfter checks the campaign's disappearing timer
+func validateDisappearAfter() error {
+	if config.DisappearAfter == "" {
+		return nil
+	}
+	if _, ok := disappearingTimers[strings.ToLower(config.DisappearAfter)]; !ok {
+		return fmt.Errorf("DisappearAfter must be 24h, 7d or 90d, not %q", config.DisappearAfter)
+	}
+	if config.Transport != "whatsmeow" || config.Channels.Enabled {
+		displayWarning("Disappearing Messages",
+			fmt.Sprintf("Messages disappear after %s only when sent from a linked number", config.DisappearAfter),
+			[]string{"The Cloud API, Twilio and email send them as normal messages"})
+	}
+	return nil
+}
+
+// withExpiration marks a campaign message to disappear after DisappearAfter,
+// e.g. for a promo code that is only valid this week. The customer's chat
+// settings are left alone; only this message disappears.
+func withExpiration(message *waE2E.Message) *waE2E.Message {
+	seconds, ok := disappearingTimers[strings.ToLower(config.DisappearAfter)]
+	if !ok {
+		return message
+	}
+	contextInfo := &waE2E.ContextInfo{
+		Expiration:                proto.Uint32(seconds),
+		EphemeralSettingTimestamp: proto.Int64(time.Now().Unix()),
+	}
+
+	switch {
+	case message.GetConversation() != "":
+		return &waE2E.Message{
+			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+				Text:        message.Conversation,
+				ContextInfo: contextInfo,
+			},
+		}
+	case message.GetExtendedTextMessage() != nil:
+		extended := message.ExtendedTextMessage
+		if extended.ContextInfo == nil {
+			extended.ContextInfo = contextInfo
+		} else {
+			extended.ContextInfo.Expiration = contextInfo.Expiration
+			extended.ContextInfo.EphemeralSettingTimestamp = contextInfo.EphemeralSettingTimestamp
+		}
+	case message.GetPollCreationMessage() != nil:
+		message.PollCreationMessage.ContextInfo = contextInfo
+	}
+	return message
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DistributedConfig lets several instances, each with its own WhatsApp
+// session, share one campaign through Redis. Every worker walks the same
+// customer list and claims a customer before sending, so each is messaged once.
+type DistributedConfig struct {
+	Redis      string // e.g. "redis://:password@10.0.0.5:6379/0" ("" = off); VENOM_REDIS_URL overrides
+	Campaign   string // Shared campaign name; defaults to the campaign profile or CSV file name
+	Worker     string // This worker's name in claims; defaults to the host and working directory
+	DailyLimit int    // Sends per day across all workers (0 = no shared limit)
+}
+
+// claimTTL is how long a claim holds while its send is in flight. A worker
+// that dies mid-send releases the customer to the others after this.
+const claimTTL = time.Hour
+
+// doneTTL keeps finished claims long enough to cover any campaign
+const doneTTL = 30 * 24 * time.Hour
+
+// distributedCampaign coordinates this worker with the others
+type distributedCampaign struct {
+	redis     *redisClient
+	campaign  string
+	worker    string
+	claimed   int
+	elsewhere int
+}
+
+// workers is the shared campaign, or nil when running alone
+var workers *distributedCampaign
+
+// joinDistributedCampaign connects to Redis if a shared campaign is configured
+func joinDistributedCampaign() (*distributedCampaign, error) {
+	address := config.Distributed.Redis
+	if env := os.Getenv("VENOM_REDIS_URL"); env != "" {
+		address = env
+	}
+	if address == "" {
+		return nil, nil
+	}
+	client, err := dialRedis(address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach Redis: %w", err)
+	}
+
+	d := &distributedCampaign{redis: client, campaign: config.Distributed.Campaign, worker: config.Distributed.Worker}
+	if d.campaign == "" {
+		d.campaign = strings.TrimSuffix(filepath.Base(campaignCSV), filepath.Ext(campaignCSV))
+		if activeCampaignProfile != nil {
+			d.campaign = activeCampaignProfile.Name
+		}
+	}
+	if d.worker == "" {
+		d.worker = defaultWorkerName()
+	}
+	log.Info(fmt.Sprintf("Joined shared campaign %q as worker %s", d.campaign, d.worker))
+	return d, nil
+}
+
+// defaultWorkerName names this worker after its host and working directory.
+// It stays the same when the worker is restarted, so a resumed run can take
+// back the claims it left behind; two instances can't share a directory,
+// as they would share the session database too.
+func defaultWorkerName() string {
+	host, _ := os.Hostname()
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	h := fnv.New32a()
+	h.Write([]byte(dir))
+	return fmt.Sprintf("%s-%08x", host, h.Sum32())
+}
+
+func (d *distributedCampaign) key(parts ...string) string {
+	return "venom:" + d.campaign + ":" + strings.Join(parts, ":")
+}
+
+// claim reserves a customer for this worker. It returns false if another
+// worker has it, and an error if Redis can't say (the send must not go ahead).
+// A claim this worker already holds, left by an interrupted run or by a
+// replayed SET whose first attempt got through, counts as claimed.
+func (d *distributedCampaign) claim(phone string) (bool, error) {
+	if d == nil {
+		return true, nil
+	}
+	key := d.key("claim", phone)
+	ttl := strconv.FormatInt(claimTTL.Milliseconds(), 10)
+	reply, err := d.redis.do("SET", key, d.worker, "NX", "PX", ttl)
+	if err != nil {
+		return false, err
+	}
+	if reply == nil {
+		owner, err := d.redis.do("GET", key)
+		if err != nil {
+			return false, err
+		}
+		if owner != d.worker {
+			d.elsewhere++
+			return false, nil
+		}
+		d.redis.do("PEXPIRE", key, ttl)
+	}
+	d.claimed++
+	return true, nil
+}
+
+// releaseScript deletes a claim only while this worker still holds it
+const releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+
+// release gives back a claim that wasn't sent, so the resumed run or
+// another worker can take the customer instead of waiting out claimTTL
+func (d *distributedCampaign) release(phone string) {
+	if d == nil {
+		return
+	}
+	if _, err := d.redis.do("EVAL", releaseScript, "1", d.key("claim", phone), d.worker); err != nil {
+		log.Warning(fmt.Sprintf("Could not release %s in the shared campaign: %v", phone, err))
+		return
+	}
+	d.claimed--
+}
+
+// finish marks a claimed customer as handled for good
+func (d *distributedCampaign) finish(phone string, success bool) {
+	if d == nil {
+		return
+	}
+	status := statusSent
+	if !success {
+		status = "failed"
+	}
+	if _, err := d.redis.do("SET", d.key("claim", phone), status+" by "+d.worker,
+		"PX", strconv.FormatInt(doneTTL.Milliseconds(), 10)); err != nil {
+		log.Warning(fmt.Sprintf("Could not record %s in the shared campaign: %v", phone, err))
+	}
+}
+
+// quotaKey counts today's sends across every worker and campaign
+func quotaKey() string {
+	return "venom:sent:" + time.Now().Format("2006-01-02")
+}
+
+// quotaLeft reports whether the shared daily limit still allows a send.
+// Workers check before they count, so together they can overshoot the limit
+// by up to one send each.
+func (d *distributedCampaign) quotaLeft() (bool, int) {
+	if d == nil || config.Distributed.DailyLimit <= 0 {
+		return true, 0
+	}
+	reply, err := d.redis.do("GET", quotaKey())
+	if err != nil {
+		log.Warning(fmt.Sprintf("Could not read the shared daily count: %v", err))
+		return false, 0
+	}
+	sent := 0
+	if s, ok := reply.(string); ok {
+		sent, _ = strconv.Atoi(s)
+	}
+	return sent < config.Distributed.DailyLimit, sent
+}
+
+// countSend adds a successful send to the shared daily count
+func (d *distributedCampaign) countSend() {
+	if d == nil || config.Distributed.DailyLimit <= 0 {
+		return
+	}
+	key := quotaKey()
+	if _, err := d.redis.do("INCR", key); err != nil {
+		log.Warning(fmt.Sprintf("Could not update the shared daily count: %v", err))
+		return
+	}
+	d.redis.do("EXPIRE", key, strconv.Itoa(int((48 * time.Hour).Seconds())))
+}
+
+// close reports this worker's share and disconnects
+func (d *distributedCampaign) close() {
+	if d == nil {
+		return
+	}
+	log.Info(fmt.Sprintf("Shared campaign %q: this worker took %d customer(s), %d were handled by other workers",
+		d.campaign, d.claimed, d.elsewhere))
+	d.redis.close()
+}
+
+// redisClient speaks just enough of the Redis protocol (RESP) for claims and
+// counters, so no client library is needed. It reconnects once on error and
+// replays the command if running it twice is harmless.
+type redisClient struct {
+	mu       sync.Mutex
+	address  string
+	password string
+	db       string
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+// dialRedis connects to a redis://[:password@]host[:port][/db] URL
+func dialRedis(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, errors.New("invalid Redis URL (use redis://host:6379/0)")
+	}
+	c := &redisClient{address: u.Host, db: strings.TrimPrefix(u.Path, "/")}
+	if _, _, err := net.SplitHostPort(c.address); err != nil {
+		c.address = net.JoinHostPort(c.address, "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect opens the connection, authenticates and selects the database
+func (c *redisClient) connect() error {
+	conn, err := net.DialTimeout("tcp", c.address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	if c.password != "" {
+		if _, err := c.roundTrip("AUTH", c.password); err != nil {
+			c.close()
+			return err
+		}
+	}
+	if c.db != "" && c.db != "0" {
+		if _, err := c.roundTrip("SELECT", c.db); err != nil {
+			c.close()
+			return err
+		}
+	}
+	return nil
+}
+
+// notReplayable lists commands that must not be sent again after a lost
+// reply: the first attempt may have reached Redis, and a counter would
+// count the same send twice
+var notReplayable = map[string]bool{"INCR": true, "INCRBY": true, "DECR": true, "DECRBY": true}
+
+// do runs one command, reconnecting once if the connection was lost
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(args...)
+	if _, isNet := err.(net.Error); isNet || err == errRedisClosed {
+		c.close()
+		if notReplayable[strings.ToUpper(args[0])] {
+			return nil, err
+		}
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+		reply, err = c.roundTrip(args...)
+	}
+	return reply, err
+}
+
+// errRedisClosed means the server hung up between commands
+var errRedisClosed = errors.New("redis connection closed")
+
+// roundTrip writes a command as an array of bulk strings and reads the reply
+func (c *redisClient) roundTrip(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply parses one RESP reply. Nil replies come back as nil.
+func (c *redisClient) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		if len(line) == 0 {
+			return nil, errRedisClosed
+		}
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected Redis reply %q", line)
+}
+
+// close drops the connection
+func (c *redisClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
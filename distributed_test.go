@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedisReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"simple string", "+OK\r\n", "OK", false},
+		{"error", "-ERR wrong type\r\n", nil, true},
+		{"integer", ":42\r\n", int64(42), false},
+		{"negative integer", ":-3\r\n", int64(-3), false},
+		{"bulk string", "$5\r\nhello\r\n", "hello", false},
+		{"bulk string with CRLF inside", "$4\r\na\r\nb\r\n", "a\r\nb", false},
+		{"empty bulk string", "$0\r\n\r\n", "", false},
+		{"nil bulk string", "$-1\r\n", nil, false},
+		{"array", "*3\r\n$3\r\nfoo\r\n:1\r\n$-1\r\n", []interface{}{"foo", int64(1), nil}, false},
+		{"nested array", "*2\r\n*1\r\n+a\r\n:2\r\n", []interface{}{[]interface{}{"a"}, int64(2)}, false},
+		{"empty array", "*0\r\n", []interface{}{}, false},
+		{"nil array", "*-1\r\n", nil, false},
+		{"bad integer", ":x\r\n", nil, true},
+		{"bad bulk length", "$x\r\n", nil, true},
+		{"truncated bulk string", "$5\r\nhel", nil, true},
+		{"truncated array", "*2\r\n+a\r\n", nil, true},
+		{"empty line", "\r\n", nil, true},
+		{"unknown type", "?what\r\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &redisClient{reader: bufio.NewReader(strings.NewReader(tt.input))}
+			got, err := c.readReply()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readReply() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readReply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisReadReplyClosed(t *testing.T) {
+	c := &redisClient{reader: bufio.NewReader(strings.NewReader(""))}
+	if _, err := c.readReply(); !errors.Is(err, errRedisClosed) {
+		t.Errorf("readReply() on a closed connection = %v, want %v", err, errRedisClosed)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// DocumentConfig renders a PDF for each customer (a statement, a voucher)
+// from a template and their CSV fields, and sends it after the message
+type DocumentConfig struct {
+	Template  string // .html (converted with Converter) or .txt (laid out as plain pages); placeholders as in messages
+	FileName  string // Name the customer sees, e.g. "Statement-{Code}.pdf"
+	Caption   string // Text under the document; placeholders allowed
+	Converter string // HTML to PDF command: "wkhtmltopdf", "chromium", ...; found on PATH if empty
+}
+
+// documentEnabled reports whether per-customer documents are configured
+func documentEnabled() bool {
+	return config.Document.Template != ""
+}
+
+// htmlConverters are the HTML to PDF tools looked for on PATH, in order
+var htmlConverters = []string{"wkhtmltopdf", "chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+// isHTMLDocument reports whether the document template is HTML
+func isHTMLDocument() bool {
+	ext := strings.ToLower(filepath.Ext(config.Document.Template))
+	return ext == ".html" || ext == ".htm"
+}
+
+// prepareDocuments checks the template and, for HTML, the converter before
+// anything is sent
+func prepareDocuments() error {
+	template, err := os.ReadFile(config.Document.Template)
+	if err != nil {
+		return fmt.Errorf("document template: %w", err)
+	}
+	if !isHTMLDocument() {
+		if r, found := firstNonWinAnsi(strings.TrimPrefix(string(template), "\ufeff")); found {
+			return fmt.Errorf("document template %s contains %q, which plain text PDFs can't show; use an .html template for Arabic", config.Document.Template, r)
+		}
+		return nil
+	}
+	converter, err := htmlConverter()
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Rendering documents with %s", converter))
+	return nil
+}
+
+// htmlConverter returns the path of the HTML to PDF tool to use
+func htmlConverter() (string, error) {
+	if config.Document.Converter != "" {
+		return exec.LookPath(config.Document.Converter)
+	}
+	for _, name := range htmlConverters {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no HTML to PDF converter found; install wkhtmltopdf or Chromium, or set Document.Converter")
+}
+
+// renderDocument fills a customer's fields into the template and returns the PDF
+func renderDocument(customer ProcessedCustomer) ([]byte, error) {
+	template, err := os.ReadFile(config.Document.Template)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTMLDocument() {
+		text := renderPlaceholders(strings.TrimPrefix(string(template), "\ufeff"), customer)
+		if r, found := firstNonWinAnsi(text); found {
+			return nil, fmt.Errorf("text contains %q, which plain text PDFs can't show; use an .html template for Arabic", r)
+		}
+		return textPDF(text), nil
+	}
+
+	// Customer data is text, not markup
+	escaped := customer
+	escaped.CustomerName = html.EscapeString(customer.CustomerName)
+	escaped.Code = html.EscapeString(customer.Code)
+	escaped.Fields = make(map[string]string, len(customer.Fields))
+	for name, value := range customer.Fields {
+		escaped.Fields[name] = html.EscapeString(value)
+	}
+
+	dir, err := os.MkdirTemp("", "venom-document-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	input, output := filepath.Join(dir, "document.html"), filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(input, []byte(renderPlaceholders(string(template), escaped)), 0600); err != nil {
+		return nil, err
+	}
+
+	converter, err := htmlConverter()
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if strings.Contains(filepath.Base(converter), "wkhtmltopdf") {
+		args = []string{"--quiet", "--enable-local-file-access", input, output}
+	} else {
+		args = []string{"--headless", "--disable-gpu", "--no-pdf-header-footer", "--print-to-pdf=" + output, "file://" + input}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, converter, args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(converter), err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output)
+}
+
+// sendDocument renders the customer's PDF, uploads it and sends it as a document
+func sendDocument(client Sender, customer ProcessedCustomer, phone string) error {
+	data, err := renderDocument(customer)
+	if err != nil {
+		return fmt.Errorf("failed to render document: %w", err)
+	}
+
+	// Every document is different, so uploads aren't cached like shared media
+	upload, err := client.Upload(context.Background(), data, whatsmeow.MediaDocument)
+	if err != nil {
+		return fmt.Errorf("failed to upload document: %w", err)
+	}
+
+	fileName := renderPlaceholders(config.Document.FileName, customer)
+	if fileName == "" {
+		fileName = customer.Code + ".pdf"
+	}
+	fileName = safeFileName(strings.TrimSuffix(fileName, ".pdf")) + ".pdf"
+	document := &waE2E.DocumentMessage{
+		URL:           proto.String(upload.URL),
+		DirectPath:    proto.String(upload.DirectPath),
+		MediaKey:      upload.MediaKey,
+		Mimetype:      proto.String("application/pdf"),
+		FileEncSHA256: upload.FileEncSHA256,
+		FileSHA256:    upload.FileSHA256,
+		FileLength:    proto.Uint64(upload.FileLength),
+		FileName:      proto.String(fileName),
+		Title:         proto.String(fileName),
+	}
+	if caption := renderPlaceholders(config.Document.Caption, customer); caption != "" {
+		document.Caption = proto.String(caption)
+	}
+
+	jid := recipientJID(client, phone)
+	if _, err := client.SendMessage(context.Background(), jid, &waE2E.Message{DocumentMessage: document}); err != nil {
+		return fmt.Errorf("failed to send document: %w", err)
+	}
+	log.Debug(fmt.Sprintf("Sent %s (%d bytes) to %s", fileName, len(data), customer.CustomerName))
+	return nil
+}
+
+// textPDF lays plain text out on A4 pages in Helvetica. The standard PDF
+// fonts only cover Latin text; use an HTML template for Arabic.
+func textPDF(text string) []byte {
+	const (
+		fontSize    = 11
+		leading     = 15
+		marginLeft  = 56
+		top         = 786
+		linesOnPage = 48
+		wrapAt      = 90
+	)
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		for utf8.RuneCountInString(line) > wrapAt {
+			runes := []rune(line)
+			cut := strings.LastIndex(string(runes[:wrapAt]), " ")
+			if cut <= 0 {
+				cut = len(string(runes[:wrapAt]))
+			}
+			lines = append(lines, line[:cut])
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		lines = append(lines, line)
+	}
+
+	var pages [][]string
+	for len(lines) > linesOnPage {
+		pages = append(pages, lines[:linesOnPage])
+		lines = lines[linesOnPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content per page
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, leading, marginLeft, top)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// isWinAnsi reports whether textPDF can show a character
+func isWinAnsi(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r < 0x7f) || (r >= 0xa0 && r <= 0xff) || r == '€'
+}
+
+// firstNonWinAnsi returns the first character in text that textPDF can't show
+func firstNonWinAnsi(text string) (rune, bool) {
+	for _, r := range text {
+		if !isWinAnsi(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// pdfString escapes a line for a PDF string literal in WinAnsi encoding.
+// Callers reject text with other characters first (see firstNonWinAnsi);
+// any left over become '?'.
+func pdfString(line string) string {
+	var b strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
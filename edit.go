@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// editWindow is how long after sending WhatsApp still accepts an edit
+const editWindow = 15 * time.Minute
+
+// sentMessage is a message from a results file that may be corrected
+type sentMessage struct {
+	customer  string
+	phone     string
+	messageID types.MessageID
+	sentAt    time.Time
+	text      string
+}
+
+// runEditCommand corrects a typo in messages a campaign already sent by
+// editing them in place, instead of sending everyone a correction
+func runEditCommand(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	resultsPath := fs.String("results", "", "results CSV of the campaign to correct (default: the newest in data/)")
+	find := fs.String("find", "", "text to replace in each sent message")
+	replace := fs.String("replace", "", "corrected text")
+	yes := fs.Bool("yes", false, "edit without asking for confirmation")
+	dbPath := fs.String("db", config.History.File, "send history database with the sent messages' text")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name the campaign was sent from")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+	if *find == "" {
+		failWith(exitUsage)
+		return fmt.Errorf("--find is required: the text to correct")
+	}
+
+	if *resultsPath == "" {
+		latest, err := latestResultsCSV()
+		if err != nil {
+			failWith(exitCSV)
+			return err
+		}
+		*resultsPath = latest
+	}
+	sent, err := readSentMessages(*resultsPath)
+	if err != nil {
+		failWith(exitCSV)
+		return err
+	}
+
+	h, err := openHistory(*dbPath)
+	if err != nil {
+		return fmt.Errorf("the sent messages' text comes from the send history: %w", err)
+	}
+	defer h.close()
+
+	var edits []sentMessage
+	var unchanged, expired, multipart, unknown int
+	for _, m := range sent {
+		text, ok := h.sentText(string(m.messageID))
+		switch {
+		case !ok:
+			unknown++
+		case !strings.Contains(text, *find):
+			unchanged++
+		case time.Since(m.sentAt) > editWindow:
+			expired++
+		case len(splitLongMessage(text)) > 1:
+			multipart++ // Only the first part's ID is known
+		default:
+			m.text = text
+			edits = append(edits, m)
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("EDIT PREVIEW")
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Results: %s (%d sent)\n", *resultsPath, len(sent))
+	fmt.Printf("Replace: %q → %q\n", *find, *replace)
+	fmt.Printf("To edit: %d message(s)\n", len(edits))
+	if unchanged > 0 {
+		fmt.Printf("%sNo match: %d%s\n", dim, unchanged, colorReset)
+	}
+	if expired > 0 {
+		fmt.Printf("%sToo old to edit (over %d minutes): %d%s\n", colorYellow, int(editWindow.Minutes()), expired, colorReset)
+	}
+	if multipart > 0 {
+		fmt.Printf("%sSent in several parts, can't be edited: %d%s\n", colorYellow, multipart, colorReset)
+	}
+	if unknown > 0 {
+		fmt.Printf("%sNot in the send history: %d%s\n", colorYellow, unknown, colorReset)
+	}
+	if len(edits) > 0 {
+		fmt.Println(strings.Repeat("─", 60))
+		fmt.Println(strings.ReplaceAll(edits[0].text, *find, *replace))
+	}
+	fmt.Println(strings.Repeat("─", 60) + "\n")
+
+	if len(edits) == 0 {
+		displayInfo("Nothing To Edit", "No sent message can be corrected", nil)
+		return nil
+	}
+	if !*yes {
+		confirmPrompt := promptui.Select{
+			Label: fmt.Sprintf("Edit %d sent message(s)?", len(edits)),
+			Items: []string{"Yes, edit now", "No, exit"},
+		}
+		confirmIdx, _, err := confirmPrompt.Run()
+		if err != nil {
+			return err
+		}
+		if confirmIdx != 0 {
+			return fmt.Errorf("user cancelled")
+		}
+	}
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	defer closeSessionStore()
+	client, err := initializeWhatsApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+	}
+	defer client.Disconnect()
+
+	edited, failed := 0, 0
+	for i, m := range edits {
+		if ctx.Err() != nil {
+			break
+		}
+		if time.Since(m.sentAt) > editWindow {
+			log.Warning(fmt.Sprintf("Edit window closed for %s (+%s)", m.customer, m.phone))
+			failed++
+			continue
+		}
+		corrected := strings.ReplaceAll(m.text, *find, *replace)
+		jid := recipientJID(client, m.phone)
+		_, err := client.SendMessage(context.Background(), jid,
+			client.BuildEdit(jid, m.messageID, &waE2E.Message{Conversation: proto.String(corrected)}))
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to edit message to %s (+%s)", m.customer, m.phone), err)
+			failed++
+			continue
+		}
+		h.recordEdit(string(m.messageID), corrected)
+		edited++
+		log.Success(fmt.Sprintf("Edited message to %s (+%s)", m.customer, m.phone))
+		if i < len(edits)-1 {
+			time.Sleep(time.Duration(1500+rand.Intn(2000)) * time.Millisecond)
+		}
+	}
+
+	if failed > 0 {
+		failWith(exitCompletedWithFailures)
+		displayWarning("Edits Finished With Failures",
+			fmt.Sprintf("Edited %d message(s), %d failed", edited, failed), nil)
+		return nil
+	}
+	displaySuccess("Messages Edited", fmt.Sprintf("Corrected %d sent message(s)", edited))
+	return nil
+}
+
+// latestResultsCSV finds the results CSV of the most recent campaign
+func latestResultsCSV() (string, error) {
+	matches, _ := filepath.Glob(filepath.Join("data", "results-*.csv"))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no results CSV in data/; give one with --results")
+	}
+	sort.Strings(matches) // Named by start time
+	return matches[len(matches)-1], nil
+}
+
+// readSentMessages returns the successful sends listed in a results CSV
+func readSentMessages(path string) ([]sentMessage, error) {
+	file, err := openDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	column := make(map[string]int)
+	for i, name := range records[0] {
+		column[name] = i
+	}
+	for _, name := range []string{"CustomerName", "SentTo", "Status", "Time", "MessageID"} {
+		if _, ok := column[name]; !ok {
+			return nil, fmt.Errorf("%s has no %s column; it was written by an older version", path, name)
+		}
+	}
+
+	var sent []sentMessage
+	for _, record := range records[1:] {
+		if len(record) != len(records[0]) || record[column["Status"]] != "sent" || record[column["MessageID"]] == "" {
+			continue
+		}
+		sentAt, err := time.ParseInLocation("2006-01-02 15:04:05", record[column["Time"]], time.Local)
+		if err != nil {
+			continue
+		}
+		sent = append(sent, sentMessage{
+			customer:  record[column["CustomerName"]],
+			phone:     record[column["SentTo"]],
+			messageID: types.MessageID(record[column["MessageID"]]),
+			sentAt:    sentAt,
+		})
+	}
+	return sent, nil
+}
+
+// sentText returns the text recorded for a sent message
+func (h *sendHistory) sentText(messageID string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var text string
+	err := h.db.QueryRow(`SELECT message FROM sends WHERE message_id = ? ORDER BY id DESC LIMIT 1`, messageID).Scan(&text)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Debug(fmt.Sprintf("Failed to look up sent message: %v", err))
+		}
+		return "", false
+	}
+	return text, true
+}
+
+// recordEdit stores a message's corrected text, so lookups and duplicate
+// checks see what the customer now reads
+func (h *sendHistory) recordEdit(messageID, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.db.Exec(`UPDATE sends SET message = ?, message_hash = ? WHERE message_id = ?`,
+		text, messageHash(text), messageID); err != nil {
+		log.Debug(fmt.Sprintf("Failed to record edit in send history: %v", err))
+	}
+}
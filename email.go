@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// EmailConfig configures the email channel (SMTP)
+type EmailConfig struct {
+	Host     string // SMTP server, e.g. "smtp.office365.com"
+	Port     int    // SMTP port (587 for STARTTLS)
+	Username string // SMTP login; empty sends without authentication
+	Password string // SMTP password; VENOM_SMTP_PASSWORD overrides it
+	From     string // Sender address, e.g. "Well Pharmacy <offers@example.com>"
+	Subject  string // Subject line, with placeholders
+	Column   string // CSV column holding the customer's email address
+}
+
+// emailSender sends the rendered message as a plain-text email. The address
+// comes from the customer attached to the send context, not the phone number.
+type emailSender struct {
+	settings EmailConfig
+	password string
+	sent     int
+}
+
+var _ Sender = (*emailSender)(nil)
+
+func init() {
+	registerTransport("email", false, func(ctx context.Context) (Sender, func(), error) {
+		sender, err := newEmailSender(config.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info(fmt.Sprintf("Sending email through %s from %s", sender.settings.Host, sender.settings.From))
+		return sender, func() {}, nil
+	})
+}
+
+// smtpPassword returns the SMTP password, preferring VENOM_SMTP_PASSWORD
+func smtpPassword(settings EmailConfig) string {
+	if env := os.Getenv("VENOM_SMTP_PASSWORD"); env != "" {
+		return env
+	}
+	return settings.Password
+}
+
+// sendSMTP delivers a complete message to the recipients through the
+// configured SMTP server
+func sendSMTP(settings EmailConfig, password string, recipients []string, msg []byte) error {
+	if settings.Port == 0 {
+		settings.Port = 587
+	}
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, password, settings.Host)
+	}
+	from := settings.From
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		from = parsed.Address
+	}
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+	return smtp.SendMail(addr, auth, from, recipients, msg)
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+// newEmailSender checks the SMTP settings and returns the transport
+func newEmailSender(settings EmailConfig) (*emailSender, error) {
+	password := smtpPassword(settings)
+	if settings.Host == "" || settings.From == "" {
+		return nil, fmt.Errorf("Email.Host and Email.From are required for the email channel")
+	}
+	if settings.Port == 0 {
+		settings.Port = 587
+	}
+	if settings.Column == "" {
+		settings.Column = "Email"
+	}
+	return &emailSender{settings: settings, password: password}, nil
+}
+
+// emailBody returns the text to email for a message
+func emailBody(message *waE2E.Message) (string, error) {
+	switch {
+	case message.GetConversation() != "":
+		return message.GetConversation(), nil
+	case message.GetExtendedTextMessage() != nil:
+		return message.GetExtendedTextMessage().GetText(), nil
+	case message.GetLocationMessage() != nil:
+		loc := message.GetLocationMessage()
+		return fmt.Sprintf("%s\n%s\nhttps://maps.google.com/?q=%f,%f",
+			loc.GetName(), loc.GetAddress(), loc.GetDegreesLatitude(), loc.GetDegreesLongitude()), nil
+	case message.GetPollCreationMessage() != nil:
+		return "", fmt.Errorf("email: poll campaigns aren't supported")
+	}
+	return "", fmt.Errorf("email: media messages aren't supported")
+}
+
+// SendMessage emails the message to the address in the customer's Email column
+func (e *emailSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	customer, ok := customerFromContext(ctx)
+	address := strings.TrimSpace(customer.Fields[e.settings.Column])
+	if !ok || address == "" {
+		return whatsmeow.SendResponse{}, fmt.Errorf("email: no address in column %s", e.settings.Column)
+	}
+
+	text, err := emailBody(message)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	subject := renderPlaceholders(e.settings.Subject, ProcessedCustomer{Customer: customer})
+
+	e.sent++
+	id := fmt.Sprintf("venom-%d-%d", time.Now().UnixNano(), e.sent)
+	domain := "localhost"
+	if at := strings.LastIndex(e.settings.From, "@"); at >= 0 {
+		domain = strings.Trim(e.settings.From[at+1:], "> ")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.settings.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", id, domain)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64(&msg, []byte(text))
+
+	if err := sendSMTP(e.settings, e.password, []string{address}, msg.Bytes()); err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("email: %w", err)
+	}
+	return whatsmeow.SendResponse{Timestamp: time.Now(), ID: types.MessageID(id)}, nil
+}
+
+// IsOnWhatsApp reports every number as reachable; email doesn't depend on WhatsApp
+func (e *emailSender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	results := make([]types.IsOnWhatsAppResponse, 0, len(phones))
+	for _, phone := range phones {
+		user := strings.TrimPrefix(phone, "+")
+		results = append(results, types.IsOnWhatsAppResponse{
+			Query: phone,
+			JID:   types.NewJID(user, types.DefaultUserServer),
+			IsIn:  true,
+		})
+	}
+	return results, nil
+}
+
+func (e *emailSender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+func (e *emailSender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return whatsmeow.UploadResponse{}, fmt.Errorf("email: media uploads aren't supported")
+}
+
+func (e *emailSender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return (&whatsmeow.Client{}).BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (e *emailSender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return &waCommon.MessageKey{
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(id),
+		RemoteJID: proto.String(chat.String()),
+	}
+}
+
+func (e *emailSender) IsConnected() bool { return true }
+func (e *emailSender) IsLoggedIn() bool  { return true }
+func (e *emailSender) Connect() error    { return nil }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// engagementReplyDays is how soon after a message a customer's reply
+	// counts as a reply to it
+	engagementReplyDays = 7
+	// deadAfterSends is how many messages must go undelivered before a
+	// number counts as dead
+	deadAfterSends = 3
+)
+
+// Engagement segments
+const (
+	segmentEngaged = "engaged"
+	segmentPassive = "passive"
+	segmentDead    = "dead"
+)
+
+// customerEngagement is one number's response to every campaign sent to it
+type customerEngagement struct {
+	Phone, Code, Name              string
+	Sent, Delivered, Read, Replied int
+	Failed                         int
+	LastSent, LastReply            string
+	Score                          int
+	Segment                        string
+}
+
+// score rates a customer 0-100: reading a message counts twice as much as
+// receiving it, and replying as much as reading
+func (e *customerEngagement) score() {
+	e.Score = 0
+	if e.Sent > 0 {
+		e.Score = (20*e.Delivered + 40*e.Read + 40*e.Replied) / e.Sent
+	}
+	switch {
+	case e.Delivered == 0 && e.Replied == 0 && e.Sent+e.Failed >= deadAfterSends:
+		e.Segment = segmentDead
+	case e.Score >= 50:
+		e.Segment = segmentEngaged
+	default:
+		e.Segment = segmentPassive
+	}
+}
+
+// computeEngagement aggregates the send history per number. A send whose
+// receipts never arrived stays "sent", so scores are only as good as the
+// receipts collected while campaigns ran.
+func (h *sendHistory) computeEngagement() ([]customerEngagement, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rows, err := h.db.Query(`SELECT s.sent_to, IFNULL(s.code, ''), IFNULL(s.name, ''), s.status, s.sent_at,
+		(SELECT MIN(i.received_at) FROM inbound i WHERE i.phone = s.sent_to
+			AND julianday(i.received_at) BETWEEN julianday(s.sent_at) AND julianday(s.sent_at) + ?)
+		FROM sends s
+		WHERE IFNULL(s.sent_to, '') != '' AND s.status != ?
+		ORDER BY s.sent_at, s.id`, engagementReplyDays, statusRevoked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPhone := make(map[string]*customerEngagement)
+	for rows.Next() {
+		var phone, code, name, status, sentAt string
+		var reply sql.NullString
+		if err := rows.Scan(&phone, &code, &name, &status, &sentAt, &reply); err != nil {
+			return nil, err
+		}
+		e := byPhone[phone]
+		if e == nil {
+			e = &customerEngagement{Phone: phone}
+			byPhone[phone] = e
+		}
+		// The latest campaign has the freshest customer details
+		if code != "" {
+			e.Code = code
+		}
+		if name != "" {
+			e.Name = name
+		}
+		if status == "failed" {
+			e.Failed++
+			continue
+		}
+		e.Sent++
+		e.LastSent = sentAt
+		switch status {
+		case statusRead:
+			e.Read++
+			e.Delivered++
+		case statusDelivered:
+			e.Delivered++
+		}
+		if reply.Valid {
+			e.Replied++
+			// A reply proves the message arrived even if its receipt didn't
+			if status == statusSent {
+				e.Delivered++
+			}
+			e.LastReply = reply.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	scores := make([]customerEngagement, 0, len(byPhone))
+	for _, e := range byPhone {
+		e.score()
+		scores = append(scores, *e)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Phone < scores[j].Phone
+	})
+	return scores, nil
+}
+
+// saveEngagement replaces the stored scores
+func (h *sendHistory) saveEngagement(scores []customerEngagement) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now().Format(time.RFC3339)
+	return withTx(h.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM engagement`); err != nil {
+			return err
+		}
+		for _, e := range scores {
+			_, err := tx.Exec(`INSERT INTO engagement
+				(phone, code, name, sent, delivered, read, replied, failed, last_sent, last_reply, score, segment, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				e.Phone, e.Code, e.Name, e.Sent, e.Delivered, e.Read, e.Replied, e.Failed,
+				e.LastSent, e.LastReply, e.Score, e.Segment, now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveEngagementCSV writes the scores in customer CSV layout plus the
+// engagement columns, so a segment can be fed straight into a campaign
+func saveEngagementCSV(scores []customerEngagement, path string) error {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"Code", "CustomerName", "Phone", "Mobile", "Sent", "Delivered", "Read", "Replied",
+		"Failed", "LastSent", "LastReply", "Score", "Segment"})
+	for _, e := range scores {
+		writer.Write([]string{e.Code, e.Name, e.Phone, "",
+			strconv.Itoa(e.Sent), strconv.Itoa(e.Delivered), strconv.Itoa(e.Read), strconv.Itoa(e.Replied),
+			strconv.Itoa(e.Failed), e.LastSent, e.LastReply, strconv.Itoa(e.Score), e.Segment})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// runEngagementCommand scores every customer by how they responded to past
+// campaigns, so marketing can target engaged customers and drop dead numbers
+func runEngagementCommand(args []string) error {
+	fs := flag.NewFlagSet("engagement", flag.ContinueOnError)
+	dbPath := fs.String("db", config.History.File, "send history database")
+	outPath := fs.String("out", "data/engagement.csv", "where to write the scores")
+	segment := fs.String("segment", "", "only export this segment: engaged, passive or dead")
+	minScore := fs.Int("min-score", 0, "only export customers scoring at least this (0-100)")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+	switch *segment {
+	case "", segmentEngaged, segmentPassive, segmentDead:
+	default:
+		failWith(exitUsage)
+		return fmt.Errorf("unknown segment %q: use engaged, passive or dead", *segment)
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		failWith(exitConfig)
+		return fmt.Errorf("no send history at %s", *dbPath)
+	}
+	h, err := openHistory(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer h.close()
+
+	scores, err := h.computeEngagement()
+	if err != nil {
+		return fmt.Errorf("failed to score customers: %w", err)
+	}
+	if err := h.saveEngagement(scores); err != nil {
+		return fmt.Errorf("failed to store engagement scores: %w", err)
+	}
+	if len(scores) == 0 {
+		displayInfo("No Engagement Data", "Nothing was sent yet", nil)
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var export []customerEngagement
+	for _, e := range scores {
+		counts[e.Segment]++
+		if (*segment == "" || e.Segment == *segment) && e.Score >= *minScore {
+			export = append(export, e)
+		}
+	}
+	if err := saveEngagementCSV(export, *outPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *outPath, err)
+	}
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("CUSTOMER ENGAGEMENT")
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Customers scored: %d\n", len(scores))
+	fmt.Printf("%sEngaged: %d%s  (score 50+)\n", colorGreen, counts[segmentEngaged], colorReset)
+	fmt.Printf("Passive: %d\n", counts[segmentPassive])
+	fmt.Printf("%sDead:    %d%s  (%d+ messages, none delivered)\n", colorRed, counts[segmentDead], colorReset, deadAfterSends)
+	fmt.Println(strings.Repeat("─", 60) + "\n")
+	displaySuccess("Engagement Scored", fmt.Sprintf("Wrote %d customer(s) to %s", len(export), *outPath))
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ErrorReportConfig sends panics and ERROR-level log entries to Sentry or
+// to a webhook, so crashes on an unattended server get noticed
+type ErrorReportConfig struct {
+	SentryDSN   string // Sentry project DSN; SENTRY_DSN overrides it
+	Webhook     string // URL to POST each error to as JSON
+	Environment string // e.g. "production", shown with each error
+	MaxEvents   int    // Errors reported per run; later ones are only logged
+}
+
+var (
+	errorReportingOn bool
+	errorReportsSent atomic.Int32
+	errorWebhooks    sync.WaitGroup
+)
+
+// initErrorReporting connects to Sentry if a DSN is configured
+func initErrorReporting() {
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		config.ErrorReport.SentryDSN = dsn
+	}
+	settings := config.ErrorReport
+	if settings.SentryDSN != "" {
+		err := sentry.Init(sentry.ClientOptions{
+			Dsn:         settings.SentryDSN,
+			Environment: settings.Environment,
+		})
+		if err != nil {
+			log.Warning(fmt.Sprintf("Sentry unavailable: %v", err))
+			settings.SentryDSN = ""
+			config.ErrorReport.SentryDSN = ""
+		}
+	}
+	errorReportingOn = settings.SentryDSN != "" || settings.Webhook != ""
+}
+
+// errorContext describes the running campaign for an error report
+func errorContext() map[string]interface{} {
+	host, _ := os.Hostname()
+	fields := map[string]interface{}{"host": host}
+	if campaignCSV != "" {
+		fields["csv"] = filepath.Base(campaignCSV)
+	}
+	if activeCampaignProfile != nil {
+		fields["profile"] = activeCampaignProfile.Name
+	}
+	if progress != nil && progress.Total > 0 {
+		fields["total"] = progress.Total
+		fields["processed"] = progress.Processed
+		fields["successful"] = progress.Successful
+		fields["failed"] = progress.Failed
+	}
+	return fields
+}
+
+// reportError sends an ERROR-level log entry to the configured targets
+func reportError(message string, err error) {
+	if !errorReportingOn || !takeErrorReport() {
+		return
+	}
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+	if config.ErrorReport.SentryDSN != "" {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetContext("campaign", errorContext())
+			if err != nil {
+				scope.SetExtra("message", message)
+				sentry.CaptureException(err)
+			} else {
+				sentry.CaptureMessage(message)
+			}
+		})
+	}
+	postErrorWebhook("error", message, "")
+}
+
+// takeErrorReport counts a report against MaxEvents, so a run failing every
+// send doesn't flood the error tracker
+func takeErrorReport() bool {
+	limit := config.ErrorReport.MaxEvents
+	return limit <= 0 || int(errorReportsSent.Add(1)) <= limit
+}
+
+// reportPanic reports a panic in the main goroutine, then lets it crash the
+// program as before. Deferred at the top of main.
+func reportPanic() {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if errorReportingOn {
+		message := fmt.Sprintf("panic: %v", p)
+		if config.ErrorReport.SentryDSN != "" {
+			sentry.WithScope(func(scope *sentry.Scope) {
+				scope.SetContext("campaign", errorContext())
+				scope.SetLevel(sentry.LevelFatal)
+				sentry.CurrentHub().Recover(p)
+			})
+		}
+		postErrorWebhook("fatal", message, string(debug.Stack()))
+		flushErrorReports()
+	}
+	panic(p)
+}
+
+// postErrorWebhook posts an error to the webhook in the background
+func postErrorWebhook(level, message, stack string) {
+	if config.ErrorReport.Webhook == "" {
+		return
+	}
+	payload := map[string]interface{}{
+		"level":       level,
+		"message":     message,
+		"time":        time.Now().Format(time.RFC3339),
+		"environment": config.ErrorReport.Environment,
+		"campaign":    errorContext(),
+	}
+	if stack != "" {
+		payload["stack"] = stack
+	}
+	body, _ := json.Marshal(payload)
+
+	errorWebhooks.Add(1)
+	go func() {
+		defer errorWebhooks.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.ErrorReport.Webhook, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			// Not log.Error: that would report the failure to report
+			log.Warning(fmt.Sprintf("Error webhook failed: %v", err))
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// flushErrorReports waits briefly for queued reports before the program exits
+func flushErrorReports() {
+	if !errorReportingOn {
+		return
+	}
+	if config.ErrorReport.SentryDSN != "" {
+		sentry.Flush(5 * time.Second)
+	}
+	done := make(chan struct{})
+	go func() {
+		errorWebhooks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+	}
+}
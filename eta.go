@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// sendStartedAt is when the send loop started, for pacing the ETA
+var sendStartedAt time.Time
+
+// estimateRemaining estimates how long the remaining sends will take. Until a
+// few customers are done it uses the configured delays; after that it uses
+// the pace observed so far, which includes retries, breaks and limit waits.
+func estimateRemaining(done, total int) time.Duration {
+	remaining := total - done
+	if remaining <= 0 {
+		return 0
+	}
+
+	if done < 3 || sendStartedAt.IsZero() {
+		perMessage := (config.DelayMin + config.DelayMax) / 2
+		if config.BatchSize > 0 {
+			perMessage += config.BatchDelay / config.BatchSize
+		}
+		return time.Duration(remaining*perMessage) * time.Millisecond
+	}
+
+	pace := time.Since(sendStartedAt) / time.Duration(done)
+	return pace * time.Duration(remaining)
+}
+
+// formatETA describes the time left and the finish time, flagging a finish
+// after closing time when sending is limited to business hours
+func formatETA(done, total int) string {
+	left := estimateRemaining(done, total)
+	finish := time.Now().Add(left)
+	text := fmt.Sprintf("ETA %s (~%s)", left.Round(time.Minute), finish.Format("15:04"))
+
+	if config.BusinessHoursOnly {
+		closing := time.Date(finish.Year(), finish.Month(), finish.Day(), businessHoursEnd, 0, 0, 0, finish.Location())
+		if finish.After(closing) || finish.YearDay() != time.Now().YearDay() {
+			text += " " + tr("after closing")
+		}
+	}
+	return text
+}
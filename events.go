@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// eventRouter dispatches whatsmeow events to the subsystems that subscribed
+// to their type. Handlers run in registration order on whatsmeow's event
+// goroutine, so they must not block for long.
+type eventRouter struct {
+	mu       sync.RWMutex
+	handlers []func(evt interface{})
+}
+
+// sessionLoggedOut is set when WhatsApp unlinks the device mid-run
+var sessionLoggedOut atomic.Bool
+
+// on registers fn for events of type T, e.g. on(router, func(*events.Receipt) {...})
+func on[T any](r *eventRouter, fn func(T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, func(evt interface{}) {
+		if v, ok := evt.(T); ok {
+			fn(v)
+		}
+	})
+}
+
+// dispatch passes an event to every matching handler. A panicking handler is
+// logged and doesn't stop the others or crash the campaign.
+func (r *eventRouter) dispatch(evt interface{}) {
+	r.mu.RLock()
+	handlers := r.handlers
+	r.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Error(fmt.Sprintf("Event handler for %T panicked", evt), fmt.Errorf("%v", p))
+				}
+			}()
+			handler(evt)
+		}()
+	}
+}
+
+// newEventRouter wires the application's subsystems to client events
+func newEventRouter(client *whatsmeow.Client) *eventRouter {
+	r := &eventRouter{}
+
+	// Incoming messages: poll votes, opt-out keywords, replies to quote later
+	// and staff using the phone
+	on(r, func(evt *events.Message) {
+		if polls != nil && evt.Message.GetPollUpdateMessage() != nil {
+			polls.handleVote(client, evt)
+		}
+	})
+	on(r, func(evt *events.Message) {
+		handleOptOut(client, evt)
+	})
+	on(r, func(evt *events.Message) {
+		recordInbound(client, evt)
+	})
+	on(r, func(evt *events.Message) {
+		manual.handleMessage(client, evt)
+	})
+
+	// Calls on the number pause sending
+	on(r, func(evt *events.CallOffer) { manual.handleCall(evt) })
+	on(r, func(evt *events.CallOfferNotice) { manual.handleCall(evt) })
+	on(r, func(evt *events.CallAccept) { manual.handleCall(evt) })
+	on(r, func(evt *events.CallTerminate) { manual.handleCall(evt) })
+	on(r, func(evt *events.CallReject) { manual.handleCall(evt) })
+
+	// Recipients of an invite campaign joining the group
+	on(r, func(evt *events.GroupInfo) {
+		if invites != nil {
+			invites.handleJoin(client, evt)
+		}
+	})
+
+	// Delivery and read receipts for campaign messages
+	on(r, deliveries.handleReceipt)
+
+	// Presence is only interesting when debugging
+	on(r, func(evt *events.Presence) {
+		log.Debug(fmt.Sprintf("Presence: %s unavailable=%v", evt.From.User, evt.Unavailable))
+	})
+
+	// Connection state: a reconnect wakes the watchdog, the rest is logged
+	on(r, func(evt *events.Connected) {
+		log.Debug("Connected to WhatsApp")
+		signalConnectionChange()
+	})
+	on(r, func(evt *events.Disconnected) {
+		log.Warning("Disconnected from WhatsApp")
+	})
+	on(r, func(evt *events.KeepAliveTimeout) {
+		log.Warning(fmt.Sprintf("Keepalive timed out (%d in a row)", evt.ErrorCount))
+	})
+	on(r, func(evt *events.StreamReplaced) {
+		log.Warning("Session was opened elsewhere; this connection was replaced")
+	})
+
+	// Logout: the watchdog stops waiting for a reconnect that can't happen
+	on(r, func(evt *events.LoggedOut) {
+		if standbySession.isIdleStandby(client) {
+			log.Warning(fmt.Sprintf("Standby number was logged out (%s); there is no standby any more", evt.Reason.String()))
+			return
+		}
+		sessionLoggedOut.Store(true)
+		signalConnectionChange()
+		log.Error("Logged out by WhatsApp", fmt.Errorf("reason: %s", evt.Reason.String()))
+		go notifyOperator(notifyLoggedOut, "Logged out by WhatsApp",
+			fmt.Sprintf("The linked device was removed (%s); relink it by scanning the QR code", evt.Reason.String()))
+	})
+
+	return r
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEventRouterDispatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		evt   interface{}
+		calls []string
+	}{
+		{"receipt reaches only receipt handlers, in order", &events.Receipt{}, []string{"receipt 1", "receipt 2", "receipt 3"}},
+		{"message reaches only message handlers", &events.Message{}, []string{"message"}},
+		{"value type doesn't match pointer handlers", events.Receipt{}, nil},
+		{"unsubscribed type is ignored", &events.Presence{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			r := &eventRouter{}
+			on(r, func(*events.Receipt) { calls = append(calls, "receipt 1") })
+			on(r, func(*events.Message) { calls = append(calls, "message") })
+			on(r, func(*events.Receipt) { calls = append(calls, "receipt 2") })
+			on(r, func(*events.Receipt) { calls = append(calls, "receipt 3") })
+
+			r.dispatch(tt.evt)
+			if !slices.Equal(calls, tt.calls) {
+				t.Errorf("handlers called %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestEventRouterRecoversFromPanic(t *testing.T) {
+	var calls []string
+	r := &eventRouter{}
+	on(r, func(*events.Receipt) { calls = append(calls, "before") })
+	on(r, func(*events.Receipt) { panic("handler bug") })
+	on(r, func(*events.Receipt) { calls = append(calls, "after") })
+
+	r.dispatch(&events.Receipt{})
+	if want := []string{"before", "after"}; !slices.Equal(calls, want) {
+		t.Errorf("handlers called %v, want %v", calls, want)
+	}
+}
+
+func TestReceiptHandler(t *testing.T) {
+	receipt := func(kind types.ReceiptType, ids ...types.MessageID) *events.Receipt {
+		return &events.Receipt{Type: kind, MessageIDs: ids}
+	}
+	tests := []struct {
+		name     string
+		receipts []*events.Receipt
+		want     string
+	}{
+		{"no receipt", nil, statusSent},
+		{"delivered", []*events.Receipt{receipt(types.ReceiptTypeDelivered, "A")}, statusDelivered},
+		{"read", []*events.Receipt{receipt(types.ReceiptTypeRead, "A")}, statusRead},
+		{"played voice note counts as read", []*events.Receipt{receipt(types.ReceiptTypePlayed, "A")}, statusRead},
+		{"late delivered doesn't undo read", []*events.Receipt{
+			receipt(types.ReceiptTypeRead, "A"), receipt(types.ReceiptTypeDelivered, "A"),
+		}, statusRead},
+		{"other receipt types are ignored", []*events.Receipt{receipt(types.ReceiptTypeSender, "A")}, statusSent},
+		{"several messages in one receipt", []*events.Receipt{receipt(types.ReceiptTypeDelivered, "X", "A")}, statusDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &deliveryTracker{
+				customer: make(map[types.MessageID]Customer),
+				status:   make(map[types.MessageID]string),
+			}
+			tracker.track("A", Customer{Code: "C1"})
+			r := &eventRouter{}
+			on(r, tracker.handleReceipt)
+			for _, evt := range tt.receipts {
+				r.dispatch(evt)
+			}
+			if got := tracker.statusOf("A"); got != tt.want {
+				t.Errorf("status %q, want %q", got, tt.want)
+			}
+			if got := tracker.statusOf("X"); got != "" {
+				t.Errorf("untracked message got status %q", got)
+			}
+		})
+	}
+}
+
+func TestOptOutHandler(t *testing.T) {
+	defer func(keywords []string, confirmation string, list *suppressionList) {
+		config.OptOut.Keywords, config.OptOut.Confirmation, suppressions = keywords, confirmation, list
+	}(config.OptOut.Keywords, config.OptOut.Confirmation, suppressions)
+	config.OptOut.Keywords = []string{"STOP", "إلغاء"}
+	config.OptOut.Confirmation = ""
+
+	sender := types.NewJID("201001234567", types.DefaultUserServer)
+	message := func(text string, edit func(*types.MessageInfo)) *events.Message {
+		evt := &events.Message{
+			Info:    types.MessageInfo{MessageSource: types.MessageSource{Sender: sender, Chat: sender}},
+			Message: &waE2E.Message{Conversation: proto.String(text)},
+		}
+		if edit != nil {
+			edit(&evt.Info)
+		}
+		return evt
+	}
+	tests := []struct {
+		name       string
+		evt        *events.Message
+		suppressed bool
+	}{
+		{"keyword", message("STOP", nil), true},
+		{"keyword in another case and spacing", message("  stop ", nil), true},
+		{"Arabic keyword", message("إلغاء", nil), true},
+		{"keyword inside a sentence", message("please stop sending", nil), false},
+		{"our own message", message("STOP", func(info *types.MessageInfo) { info.IsFromMe = true }), false},
+		{"group message", message("STOP", func(info *types.MessageInfo) { info.IsGroup = true }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suppressions = &suppressionList{path: filepath.Join(t.TempDir(), "suppression.csv"), phones: make(map[string]string)}
+			r := &eventRouter{}
+			on(r, func(evt *events.Message) { handleOptOut(nil, evt) })
+			r.dispatch(tt.evt)
+			if got := suppressions.Contains(sender.User); got != tt.suppressed {
+				t.Errorf("suppressed %v, want %v", got, tt.suppressed)
+			}
+		})
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	defer func(standby *standbySender) { standbySession = standby }(standbySession)
+
+	tests := []struct {
+		name      string
+		standby   *standbySender
+		loggedOut bool
+	}{
+		{"sending number", nil, true},
+		// The router's client is the idle standby number
+		{"idle standby", &standbySender{}, false},
+		{"standby already sending", &standbySender{switched: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionLoggedOut.Store(false)
+			standbySession = tt.standby
+			newEventRouter(nil).dispatch(&events.LoggedOut{Reason: events.ConnectFailureLoggedOut})
+			if got := sessionLoggedOut.Load(); got != tt.loggedOut {
+				t.Errorf("session logged out %v, want %v", got, tt.loggedOut)
+			}
+			if tt.standby != nil && !tt.loggedOut && !tt.standby.lost {
+				t.Error("idle standby not marked lost")
+			}
+		})
+	}
+	sessionLoggedOut.Store(false)
+}
+
+func TestConnectedWakesWatchdog(t *testing.T) {
+	select {
+	case <-connectionChanged:
+	default:
+	}
+	newEventRouter(nil).dispatch(&events.Connected{})
+	select {
+	case <-connectionChanged:
+	default:
+		t.Error("reconnect didn't wake the watchdog")
+	}
+}
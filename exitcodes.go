@@ -0,0 +1,26 @@
+package main
+
+// Exit statuses, so wrapper scripts and schedulers can react without
+// parsing log text. They are listed in 'venom help'.
+const (
+	exitOK                    = 0
+	exitError                 = 1 // Anything not covered below
+	exitUsage                 = 2 // Bad command-line flags
+	exitInterrupted           = 3 // Stopped before every customer was handled
+	exitConfig                = 4 // Invalid config, templates or input files
+	exitCSV                   = 5 // Customer CSV missing, unreadable or without valid rows
+	exitLoginRequired         = 6 // No linked WhatsApp session; scan the QR code
+	exitCompletedWithFailures = 7 // Every customer handled, but some sends failed
+	exitRateLimited           = 8 // Stopped while held back by rate limits
+)
+
+// exitStatus is what the campaign exits with
+var exitStatus = exitOK
+
+// failWith records why the campaign is ending badly. The first cause wins,
+// so a later generic status doesn't hide the real reason.
+func failWith(code int) {
+	if exitStatus == exitOK {
+		exitStatus = code
+	}
+}
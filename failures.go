@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Failure reasons recorded in MessageResult.FailureReason
+const (
+	failureNotOnWhatsApp = "not-on-whatsapp"
+	failureTimeout       = "timeout"
+	failureDisconnected  = "disconnected"
+	failureRateLimited   = "rate-limited"
+	failureUnknown       = "unknown"
+)
+
+// failureReasons lists the reasons in the order the report shows them
+var failureReasons = []string{failureNotOnWhatsApp, failureTimeout, failureDisconnected, failureRateLimited, failureUnknown}
+
+// classifyFailure buckets a send error. Transports report errors as plain
+// text, so anything but whatsmeow's own errors is matched by wording.
+func classifyFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+	if isNotOnWhatsAppError(err) {
+		return failureNotOnWhatsApp
+	}
+	switch {
+	case errors.Is(err, whatsmeow.ErrIQTimedOut), errors.Is(err, whatsmeow.ErrMessageTimedOut),
+		errors.Is(err, context.DeadlineExceeded):
+		return failureTimeout
+	case errors.Is(err, whatsmeow.ErrNotConnected), errors.Is(err, whatsmeow.ErrNotLoggedIn):
+		return failureDisconnected
+	}
+
+	msg := strings.ToLower(err.Error())
+	markers := []struct {
+		reason string
+		words  []string
+	}{
+		{failureRateLimited, []string{"rate-overlimit", "rate limit", "too many", "429"}},
+		{failureTimeout, []string{"timed out", "timeout"}},
+		{failureDisconnected, []string{"not connected", "connection", "websocket", "eof", "logged out"}},
+	}
+	for _, m := range markers {
+		for _, word := range m.words {
+			if strings.Contains(msg, word) {
+				return m.reason
+			}
+		}
+	}
+	return failureUnknown
+}
+
+// displayFailureBreakdown prints how many sends failed for each reason
+func displayFailureBreakdown() {
+	counts := make(map[string]int)
+	total := 0
+	forEachResult(func(r MessageResult) {
+		if !r.Success {
+			counts[r.FailureReason]++
+			total++
+		}
+	})
+	if total == 0 {
+		return
+	}
+	for _, reason := range failureReasons {
+		if counts[reason] > 0 {
+			fmt.Printf("  - %-16s%d (%.0f%%)\n", reason+":", counts[reason], float64(counts[reason])/float64(total)*100)
+		}
+	}
+}
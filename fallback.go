@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// fallbackSender sends through the primary transport until it is logged
+// out or banned, then switches to the fallback for the rest of the campaign
+type fallbackSender struct {
+	primary, fallback         Sender
+	primaryName, fallbackName string
+
+	mu       sync.Mutex
+	switched bool
+}
+
+var _ Sender = (*fallbackSender)(nil)
+
+// accountBlockedError wraps a transport error that means the sending
+// account itself was refused, e.g. a revoked Cloud API token
+type accountBlockedError struct{ error }
+
+func (e accountBlockedError) Unwrap() error { return e.error }
+
+// isBanError reports whether a send error means the account can no longer
+// send: the session is gone, or WhatsApp refused it as not authorized
+func isBanError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var blocked accountBlockedError
+	switch {
+	case sessionLoggedOut.Load(),
+		errors.Is(err, whatsmeow.ErrNotLoggedIn),
+		errors.Is(err, whatsmeow.ErrIQNotAuthorized),
+		errors.Is(err, whatsmeow.ErrIQForbidden),
+		errors.As(err, &blocked):
+		return true
+	}
+	code := serverErrorCode(err)
+	return code == 401 || code == 403
+}
+
+// serverErrorCode returns the code of a "server returned error <code>"
+// send error, or 0
+func serverErrorCode(err error) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if errors.Unwrap(err) == whatsmeow.ErrServerReturnedError {
+			code, _ := strconv.Atoi(strings.TrimPrefix(err.Error(), whatsmeow.ErrServerReturnedError.Error()+" "))
+			return code
+		}
+	}
+	return 0
+}
+
+// active returns the transport to use now, switching over if the primary
+// session has been logged out
+func (f *fallbackSender) active() Sender {
+	if sessionLoggedOut.Load() {
+		f.switchOver("session was logged out")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.switched {
+		return f.fallback
+	}
+	return f.primary
+}
+
+// switchOver moves to the fallback transport, once
+func (f *fallbackSender) switchOver(reason string) {
+	f.mu.Lock()
+	if f.switched {
+		f.mu.Unlock()
+		return
+	}
+	f.switched = true
+	f.mu.Unlock()
+
+	// Media uploaded to the primary's servers can't be reused by the fallback
+	resetMediaCache()
+
+	clearProgress()
+	displayWarning("Switching Transport",
+		fmt.Sprintf("%s %s, continuing through %s", f.primaryName, reason, f.fallbackName),
+		[]string{"The rest of the campaign is sent through the fallback"})
+}
+
+func (f *fallbackSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	sender := f.active()
+	resp, err := sender.SendMessage(ctx, to, message, extra...)
+	if err == nil || sender == f.fallback || !isBanError(err) {
+		return resp, err
+	}
+
+	f.switchOver(fmt.Sprintf("can no longer send (%v)", err))
+	return f.fallback.SendMessage(ctx, to, message, extra...)
+}
+
+func (f *fallbackSender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	return f.active().IsOnWhatsApp(phones)
+}
+
+func (f *fallbackSender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return f.active().SendChatPresence(jid, state, media)
+}
+
+func (f *fallbackSender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return f.active().Upload(ctx, plaintext, mediaType)
+}
+
+func (f *fallbackSender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return f.active().BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (f *fallbackSender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return f.active().BuildMessageKey(chat, sender, id)
+}
+
+func (f *fallbackSender) IsConnected() bool { return f.active().IsConnected() }
+func (f *fallbackSender) IsLoggedIn() bool  { return f.active().IsLoggedIn() }
+func (f *fallbackSender) Connect() error    { return f.active().Connect() }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func TestIsBanError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"not logged in", whatsmeow.ErrNotLoggedIn, true},
+		{"wrapped not logged in", fmt.Errorf("send: %w", whatsmeow.ErrNotLoggedIn), true},
+		{"server 401", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 401), true},
+		{"server 403", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 403), true},
+		{"server 479", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 479), false},
+		{"not authorized query", &whatsmeow.IQError{Code: 401, Text: "not-authorized"}, true},
+		{"blocked cloud account", accountBlockedError{errors.New("cloud api: HTTP 401")}, true},
+		{"401 in a phone number", errors.New("201040155 is not on WhatsApp"), false},
+		{"timeout", whatsmeow.ErrMessageTimedOut, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBanError(tt.err); got != tt.want {
+				t.Errorf("isBanError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// downloadDir holds local copies of customer lists fetched from a server
+const downloadDir = "data/downloads"
+
+// isRemoteCSV reports whether a CSV path is an https://, sftp:// or gsheet://
+// URL, or a sql:// database query
+func isRemoteCSV(csvPath string) bool {
+	lower := strings.ToLower(csvPath)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "sftp://") ||
+		strings.HasPrefix(lower, "gsheet://") || isSQLSource(csvPath)
+}
+
+// fetchRemoteCSV downloads a customer list from an https:// or sftp:// URL
+// into data/downloads and returns the local path; Google Sheets URLs and
+// database queries are handed to fetchSheet and fetchSQLSource. Credentials come from the environment, never from
+// the URL saved in a profile:
+//
+//	VENOM_SOURCE_TOKEN       bearer token for https
+//	VENOM_SOURCE_USER        user for https basic auth or sftp
+//	VENOM_SOURCE_PASSWORD    password for https basic auth or sftp
+//	VENOM_SOURCE_KEY         private key file for sftp (default ~/.ssh/id_ed25519 or id_rsa)
+//	VENOM_SOURCE_KNOWN_HOSTS known_hosts file for sftp (default ~/.ssh/known_hosts)
+func fetchRemoteCSV(rawURL string) (string, error) {
+	if isSQLSource(rawURL) {
+		return fetchSQLSource(rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid CSV URL: %w", err)
+	}
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return "", fmt.Errorf("put the password in VENOM_SOURCE_PASSWORD, not in the CSV URL")
+		}
+	}
+	if spreadsheetID, sheet, ok := parseSheetURL(u); ok {
+		return fetchSheet(spreadsheetID, sheet)
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("CSV URL %s does not name a file", u.Redacted())
+	}
+
+	log.Info(fmt.Sprintf("Downloading customer list from %s...", u.Redacted()))
+	var data []byte
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		data, err = fetchHTTPS(u)
+	case "sftp":
+		data, err = fetchSFTP(u)
+	default:
+		err = fmt.Errorf("unsupported CSV URL scheme %q (use https:// or sftp://)", u.Scheme)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// One stable local name per server and file, so an interrupted campaign
+	// resumes from its checkpoint when the same URL is fetched again
+	local := filepath.Join(downloadDir, u.Hostname()+"-"+name)
+	if err := os.MkdirAll(downloadDir, 0700); err != nil {
+		return "", err
+	}
+	if err := writeDataFile(local, data); err != nil {
+		return "", fmt.Errorf("failed to save downloaded CSV: %w", err)
+	}
+	log.Success(fmt.Sprintf("Downloaded %s (%d bytes) to %s", name, len(data), local))
+	return local, nil
+}
+
+// fetchHTTPS downloads u with a bearer token or basic auth from the environment
+func fetchHTTPS(u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("VENOM_SOURCE_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if user := os.Getenv("VENOM_SOURCE_USER"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("VENOM_SOURCE_PASSWORD"))
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fetchSFTP downloads u over SFTP, checking the server against known_hosts
+func fetchSFTP(u *url.URL) ([]byte, error) {
+	home, _ := os.UserHomeDir()
+
+	user := u.User.Username()
+	if env := os.Getenv("VENOM_SOURCE_USER"); user == "" && env != "" {
+		user = env
+	}
+	if user == "" {
+		return nil, fmt.Errorf("no SFTP user: put it in the URL (sftp://user@host/path) or VENOM_SOURCE_USER")
+	}
+
+	knownHostsFile := os.Getenv("VENOM_SOURCE_KNOWN_HOSTS")
+	if knownHostsFile == "" {
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check the SFTP server's host key: %w (add it with ssh-keyscan)", err)
+	}
+
+	var auth []ssh.AuthMethod
+	keyFiles := []string{filepath.Join(home, ".ssh", "id_ed25519"), filepath.Join(home, ".ssh", "id_rsa")}
+	if env := os.Getenv("VENOM_SOURCE_KEY"); env != "" {
+		keyFiles = []string{env}
+	}
+	for _, file := range keyFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Skipping SSH key %s: %v", file, err))
+			continue
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if password := os.Getenv("VENOM_SOURCE_PASSWORD"); password != "" {
+		auth = append(auth, ssh.Password(password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("no SFTP credentials: set VENOM_SOURCE_KEY or VENOM_SOURCE_PASSWORD")
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "22")
+	}
+	conn, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SFTP connection failed: %w", err)
+	}
+	defer conn.Close()
+
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	w, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("server has no SFTP subsystem: %w", err)
+	}
+
+	s := &sftpReader{w: w, r: bufio.NewReader(r)}
+	return s.download(u.Path)
+}
+
+// SFTP version 3 packet types; only what a single download needs
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpRead    = 5
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpData    = 103
+
+	sftpStatusEOF = 1
+	sftpOpenRead  = 1
+	sftpChunk     = 32 * 1024
+)
+
+// sftpReader speaks just enough SFTP to read one file, one request at a time
+type sftpReader struct {
+	w      io.Writer
+	r      *bufio.Reader
+	nextID uint32
+}
+
+// download opens remotePath, reads it to the end and closes it
+func (s *sftpReader) download(remotePath string) ([]byte, error) {
+	if err := s.send(sftpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return nil, err
+	}
+	if kind, _, err := s.receive(); err != nil {
+		return nil, err
+	} else if kind != sftpVersion {
+		return nil, fmt.Errorf("sftp: unexpected reply %d to init", kind)
+	}
+
+	open := sftpString(nil, remotePath)
+	open = binary.BigEndian.AppendUint32(open, sftpOpenRead)
+	open = binary.BigEndian.AppendUint32(open, 0) // no attributes
+	kind, body, err := s.request(sftpOpen, open)
+	if err != nil {
+		return nil, err
+	}
+	if kind != sftpHandle {
+		return nil, sftpError(kind, body, remotePath)
+	}
+	handle, _, err := sftpReadString(body)
+	if err != nil {
+		return nil, err
+	}
+	defer s.request(sftpClose, sftpString(nil, string(handle)))
+
+	var data []byte
+	for {
+		read := sftpString(nil, string(handle))
+		read = binary.BigEndian.AppendUint64(read, uint64(len(data)))
+		read = binary.BigEndian.AppendUint32(read, sftpChunk)
+		kind, body, err := s.request(sftpRead, read)
+		if err != nil {
+			return nil, err
+		}
+		if kind == sftpStatus && len(body) >= 4 && binary.BigEndian.Uint32(body) == sftpStatusEOF {
+			return data, nil
+		}
+		if kind != sftpData {
+			return nil, sftpError(kind, body, remotePath)
+		}
+		chunk, _, err := sftpReadString(body)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+}
+
+// request sends a packet with a fresh request id and returns the reply to it
+func (s *sftpReader) request(kind byte, payload []byte) (byte, []byte, error) {
+	s.nextID++
+	id := s.nextID
+	if err := s.send(kind, append(binary.BigEndian.AppendUint32(nil, id), payload...)); err != nil {
+		return 0, nil, err
+	}
+	replyKind, body, err := s.receive()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(body) < 4 || binary.BigEndian.Uint32(body) != id {
+		return 0, nil, errors.New("sftp: reply to the wrong request")
+	}
+	return replyKind, body[4:], nil
+}
+
+// send writes one length-prefixed packet
+func (s *sftpReader) send(kind byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, kind)
+	_, err := s.w.Write(append(packet, payload...))
+	return err
+}
+
+// receive reads one packet
+func (s *sftpReader) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("sftp: %w", err)
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 1 || size > 1<<20 {
+		return 0, nil, fmt.Errorf("sftp: bad packet length %d", size)
+	}
+	body := make([]byte, size-1)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, fmt.Errorf("sftp: %w", err)
+	}
+	return header[4], body, nil
+}
+
+// sftpString appends s as an SFTP string (uint32 length, then bytes)
+func sftpString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint32(b, uint32(len(s))), s...)
+}
+
+// sftpReadString reads an SFTP string from the front of b
+func sftpReadString(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, errors.New("sftp: short packet")
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint32(len(b)-4) < n {
+		return nil, nil, errors.New("sftp: short packet")
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
+
+// sftpError turns a STATUS reply into an error naming the file
+func sftpError(kind byte, body []byte, remotePath string) error {
+	if kind != sftpStatus || len(body) < 4 {
+		return fmt.Errorf("sftp: unexpected reply %d for %s", kind, remotePath)
+	}
+	code := binary.BigEndian.Uint32(body)
+	if msg, _, err := sftpReadString(body[4:]); err == nil && len(msg) > 0 {
+		return fmt.Errorf("sftp: %s: %s", remotePath, msg)
+	}
+	return fmt.Errorf("sftp: %s: status %d", remotePath, code)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// sftpPacket builds a length-prefixed SFTP packet
+func sftpPacket(kind byte, body []byte) []byte {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(body)+1))
+	return append(append(packet, kind), body...)
+}
+
+// sftpReply builds the reply to request id
+func sftpReply(kind byte, id uint32, body []byte) []byte {
+	return sftpPacket(kind, append(binary.BigEndian.AppendUint32(nil, id), body...))
+}
+
+// sftpStatusBody is a STATUS reply body with code and message
+func sftpStatusBody(code uint32, msg string) []byte {
+	return sftpString(binary.BigEndian.AppendUint32(nil, code), msg)
+}
+
+func TestSFTPDownload(t *testing.T) {
+	var server bytes.Buffer
+	server.Write(sftpPacket(sftpVersion, binary.BigEndian.AppendUint32(nil, 3)))
+	server.Write(sftpReply(sftpHandle, 1, sftpString(nil, "h1")))
+	server.Write(sftpReply(sftpData, 2, sftpString(nil, "Code,Name\n")))
+	server.Write(sftpReply(sftpData, 3, sftpString(nil, "1,Ali\n")))
+	server.Write(sftpReply(sftpStatus, 4, sftpStatusBody(sftpStatusEOF, "EOF")))
+	server.Write(sftpReply(sftpStatus, 5, sftpStatusBody(0, "OK")))
+
+	var sent bytes.Buffer
+	s := &sftpReader{w: &sent, r: bufio.NewReader(&server)}
+	data, err := s.download("/exports/customers.csv")
+	if err != nil {
+		t.Fatalf("download() error = %v", err)
+	}
+	if string(data) != "Code,Name\n1,Ali\n" {
+		t.Errorf("download() = %q", data)
+	}
+
+	// INIT, OPEN, three READs and CLOSE
+	kinds := []byte{}
+	for r := bufio.NewReader(&sent); ; {
+		kind, _, err := (&sftpReader{r: r}).receive()
+		if err != nil {
+			break
+		}
+		kinds = append(kinds, kind)
+	}
+	if want := []byte{sftpInit, sftpOpen, sftpRead, sftpRead, sftpRead, sftpClose}; !bytes.Equal(kinds, want) {
+		t.Errorf("sent packets %v, want %v", kinds, want)
+	}
+}
+
+func TestSFTPDownloadErrors(t *testing.T) {
+	version := sftpPacket(sftpVersion, binary.BigEndian.AppendUint32(nil, 3))
+	tests := []struct {
+		name    string
+		replies [][]byte
+		wantErr string
+	}{
+		{"missing file", [][]byte{version, sftpReply(sftpStatus, 1, sftpStatusBody(2, "No such file"))}, "No such file"},
+		{"status without message", [][]byte{version, sftpReply(sftpStatus, 1, binary.BigEndian.AppendUint32(nil, 3))}, "status 3"},
+		{"no version reply", [][]byte{sftpReply(sftpHandle, 1, nil)}, "unexpected reply"},
+		{"reply to another request", [][]byte{version, sftpReply(sftpHandle, 7, sftpString(nil, "h1"))}, "wrong request"},
+		{"connection closed", [][]byte{version}, "EOF"},
+		{"truncated packet", [][]byte{version, sftpReply(sftpHandle, 1, sftpString(nil, "h1"))[:8]}, "EOF"},
+		{"zero length packet", [][]byte{version, {0, 0, 0, 0, 0}}, "bad packet length"},
+		{"oversized packet", [][]byte{version, {0x10, 0, 0, 0, sftpData}}, "bad packet length"},
+		{"short handle", [][]byte{version, sftpReply(sftpHandle, 1, []byte{0, 0, 0, 9, 'h'})}, "short packet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sftpReader{w: &bytes.Buffer{}, r: bufio.NewReader(bytes.NewReader(bytes.Join(tt.replies, nil)))}
+			_, err := s.download("/customers.csv")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("download() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
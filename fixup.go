@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/manifoldco/promptui"
+)
+
+// RowFix is a proposed correction to one field of a customer row
+type RowFix struct {
+	Field  string
+	Old    string
+	New    string
+	Reason string
+}
+
+// trailingDigitsPattern matches digits left at the end of a name by exports
+// that glue the customer code or an index onto it (e.g. "Ahmed Ali 1234")
+var trailingDigitsPattern = regexp.MustCompile(`[\s\-_#.]*[0-9٠-٩]+$`)
+
+// fixPhone repairs the common ways a phone column gets mangled. It returns
+// the repaired number and why, or no reasons if nothing needed fixing.
+func fixPhone(phone string) (string, []string) {
+	reasons := []string{}
+
+	converted := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '٠' && r <= '٩': // Arabic-Indic
+			return '0' + (r - '٠')
+		case r >= '۰' && r <= '۹': // Extended Arabic-Indic (Persian/Urdu keyboards)
+			return '0' + (r - '۰')
+		}
+		return r
+	}, phone)
+	if converted != phone {
+		reasons = append(reasons, "Arabic digits converted")
+	}
+
+	digits := cleanPhoneNumber(converted)
+	if strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		reasons = append(reasons, "00 prefix removed")
+	}
+
+	// Spreadsheets drop the leading 0 of local numbers stored as numbers
+	localLength := config.PhoneLength - len(config.CountryCode)
+	if len(digits) == localLength && !strings.HasPrefix(digits, "0") && !strings.HasPrefix(digits, config.CountryCode) {
+		digits = "0" + digits
+		reasons = append(reasons, "leading 0 restored")
+	}
+
+	return digits, reasons
+}
+
+// fixName strips digits trailing a customer name
+func fixName(name string) (string, []string) {
+	fixed := strings.TrimSpace(trailingDigitsPattern.ReplaceAllString(name, ""))
+	if fixed == name || !strings.ContainsFunc(fixed, unicode.IsLetter) {
+		return name, nil
+	}
+	return fixed, []string{"trailing digits removed"}
+}
+
+// proposeFixes lists the automatic corrections available for a row. Phone
+// fixes are only proposed when the result passes validation.
+func proposeFixes(customer Customer) []RowFix {
+	fixes := []RowFix{}
+
+	if fixed, reasons := fixName(customer.CustomerName); len(reasons) > 0 {
+		fixes = append(fixes, RowFix{"CustomerName", customer.CustomerName, fixed, strings.Join(reasons, ", ")})
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"Phone", customer.Phone},
+		{"Mobile", customer.Mobile},
+	} {
+		if field.value == "" {
+			continue
+		}
+		fixed, reasons := fixPhone(field.value)
+		if len(reasons) == 0 {
+			continue
+		}
+		if _, valid, _ := validateAndFormatPhone(fixed); valid {
+			fixes = append(fixes, RowFix{field.name, field.value, fixed, strings.Join(reasons, ", ")})
+		}
+	}
+
+	return fixes
+}
+
+// applyFix sets the fixed field on a customer
+func applyFix(customer *Customer, fix RowFix) {
+	switch fix.Field {
+	case "CustomerName":
+		customer.CustomerName = fix.New
+	case "Phone":
+		customer.Phone = fix.New
+	case "Mobile":
+		customer.Mobile = fix.New
+	}
+}
+
+// fixCustomers runs the fix-up pass over every row. With auto set every
+// proposed fix is applied; otherwise each row is confirmed interactively.
+// It returns the number of rows changed.
+func fixCustomers(customers []Customer, lines []int, auto bool) (int, error) {
+	changed := 0
+
+	for i := range customers {
+		fixes := proposeFixes(customers[i])
+		if len(fixes) == 0 {
+			continue
+		}
+
+		if !auto {
+			fmt.Printf("\n%sLine %d%s  %s (%s)\n", bold, lines[i], colorReset, customers[i].CustomerName, customers[i].Code)
+			for _, fix := range fixes {
+				fmt.Printf("  %-12s %q → %q  %s(%s)%s\n", fix.Field, fix.Old, fix.New, dim, fix.Reason, colorReset)
+			}
+
+			prompt := promptui.Select{
+				Label: "Fix this row?",
+				Items: []string{"Apply fix", "Apply this and all remaining fixes", "Edit manually", "Skip this row"},
+			}
+			choice, _, err := prompt.Run()
+			if err != nil {
+				return changed, err
+			}
+
+			switch choice {
+			case 1:
+				auto = true
+			case 2:
+				for j, fix := range fixes {
+					edit := promptui.Prompt{Label: fix.Field, Default: fix.New, AllowEdit: true}
+					value, err := edit.Run()
+					if err != nil {
+						return changed, err
+					}
+					fixes[j].New = strings.TrimSpace(value)
+				}
+			case 3:
+				continue
+			}
+		}
+
+		for _, fix := range fixes {
+			applyFix(&customers[i], fix)
+			log.Info(fmt.Sprintf("Line %d: %s %q → %q (%s)", lines[i], fix.Field, fix.Old, fix.New, fix.Reason))
+		}
+		changed++
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// openConversation returns the newest message phone sent us within
+// History.OpenChatHours. A canned template would talk over that
+// conversation, so the customer is left to staff instead.
+func (h *sendHistory) openConversation(phone string) *inboundMessage {
+	if h == nil || config.History.OpenChatHours <= 0 {
+		return nil
+	}
+	since := time.Now().Add(-time.Duration(config.History.OpenChatHours) * time.Hour)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var msg inboundMessage
+	var receivedAt string
+	err := h.db.QueryRow(`SELECT received_at, text FROM inbound
+		WHERE phone = ? AND received_at >= ? ORDER BY received_at DESC LIMIT 1`,
+		phone, since.Format(time.RFC3339)).Scan(&receivedAt, &msg.text)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Debug(fmt.Sprintf("Failed to look up recent conversations: %v", err))
+		}
+		return nil
+	}
+	msg.phone = phone
+	msg.receivedAt, _ = time.Parse(time.RFC3339, receivedAt)
+	return &msg
+}
+
+// addFollowUp appends a customer with an open conversation to
+// History.FollowUpFile, with their last message, to answer by hand
+func addFollowUp(customer ProcessedCustomer, last *inboundMessage) {
+	path := config.History.FollowUpFile
+	if path == "" || config.Simulate.Enabled {
+		return
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	_, statErr := os.Stat(path)
+	file, err := appendDataFile(path)
+	if err != nil {
+		log.Error("Failed to open the follow-up file", err)
+		return
+	}
+	writer := csv.NewWriter(file)
+	if os.IsNotExist(statErr) {
+		header := append([]string{"Code", "CustomerName", "Phone", "Mobile"}, csvExtraColumns...)
+		writer.Write(append(header, "LastMessageAt", "LastMessage"))
+	}
+	row := []string{customer.Code, customer.CustomerName, customer.Phone, customer.Mobile}
+	for _, name := range csvExtraColumns {
+		row = append(row, customer.Fields[name])
+	}
+	writer.Write(append(row, last.receivedAt.Format("2006-01-02 15:04"), last.text))
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		log.Error("Failed to write the follow-up file", err)
+		return
+	}
+	if err := file.Close(); err != nil {
+		log.Error("Failed to write the follow-up file", err)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ghostCheckBatch is how many numbers are looked up per profile query
+const ghostCheckBatch = 50
+
+// filterGhostNumbers leaves out numbers with neither a profile picture nor a
+// name: mostly abandoned or virtual numbers, whose reports and blocks hurt
+// the sender's standing. A picture hidden by privacy settings looks the same
+// as none, so customers we know by a push name are always kept.
+func filterGhostNumbers(ctx context.Context, client Sender, customers []ProcessedCustomer) []ProcessedCustomer {
+	cli, ok := whatsAppClient(client)
+	if !ok {
+		log.Info("Profile filtering needs a linked WhatsApp number, sending to everyone")
+		return customers
+	}
+
+	kept := make([]ProcessedCustomer, 0, len(customers))
+	ghosts := 0
+	for start := 0; start < len(customers); start += ghostCheckBatch {
+		batch := customers[start:min(start+ghostCheckBatch, len(customers))]
+		if ctx.Err() != nil {
+			return append(kept, customers[start:]...)
+		}
+
+		jids := make([]types.JID, len(batch))
+		for i, customer := range batch {
+			jids[i] = types.NewJID(customer.FormattedPhone, types.DefaultUserServer)
+		}
+		infos, err := cli.GetUserInfo(jids)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Profile check failed, %d numbers left unfiltered: %v", len(customers)-start, err))
+			return append(kept, customers[start:]...)
+		}
+
+		for i, customer := range batch {
+			info, found := infos[jids[i]]
+			if !found || info.PictureID != "" || info.VerifiedName != nil || hasKnownName(cli, jids[i]) {
+				kept = append(kept, customer)
+				continue
+			}
+			log.Warning(fmt.Sprintf("Skipping %s - +%s has no profile picture or name", customer.CustomerName, customer.FormattedPhone))
+			recordSkip(customer.Customer, skipGhostNumber, "No profile picture or name on WhatsApp")
+			progress.Skipped++
+			ghosts++
+		}
+	}
+	if ghosts > 0 {
+		log.Info(fmt.Sprintf("Left out %d number(s) without a profile picture or name", ghosts))
+	}
+	return kept
+}
+
+// hasKnownName reports whether this device has seen a push or business name for jid
+func hasKnownName(cli *whatsmeow.Client, jid types.JID) bool {
+	contact, err := cli.Store.Contacts.GetContact(context.Background(), jid)
+	return err == nil && contact.Found && (contact.PushName != "" || contact.BusinessName != "")
+}
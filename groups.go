@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// WhatsApp caps group names at 25 characters and groups at 1024 members,
+// the creator included. Members are added a few at a time, like sends.
+const (
+	groupNameLimit  = 25
+	groupMemberCap  = 1023
+	groupAddBatch   = 20
+	groupInviteCode = 403 // Participant error when privacy settings forbid adding them
+)
+
+// segmentGroup is one segment of the CSV and the group made for it
+type segmentGroup struct {
+	segment   string
+	name      string
+	customers []ProcessedCustomer
+	jid       types.JID
+	link      string
+	added     int
+	invited   int
+}
+
+// groupMember is the outcome for one customer
+type groupMember struct {
+	segment  string
+	customer ProcessedCustomer
+	status   string // "added", "invite link", "not on WhatsApp", "group full" or the error
+}
+
+// runGroupsCommand creates a WhatsApp group per segment of the customer CSV
+// and exports the group JIDs and invite links for later group campaigns
+func runGroupsCommand(args []string) error {
+	fs := flag.NewFlagSet("groups", flag.ContinueOnError)
+	csvPath := fs.String("csv", "customers.csv", "customer CSV file")
+	column := fs.String("by", "Segment", "CSV column holding each customer's segment or tags")
+	separator := fs.String("sep", "", "split the column on this separator, for several tags per customer")
+	only := fs.String("only", "", "create only the groups of these segments (comma-separated)")
+	prefix := fs.String("prefix", "", "text before each segment in the group name")
+	inviteOnly := fs.Bool("invite-only", false, "add nobody; share the invite links instead")
+	outPath := fs.String("out", "data/groups.csv", "where to write the group JIDs and invite links")
+	yes := fs.Bool("yes", false, "create the groups without asking for confirmation")
+	fs.StringVar(&config.CSVDelimiter, "delimiter", config.CSVDelimiter, "CSV delimiter: auto, ',', ';', tab or '|'")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name that creates the groups")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	customers, err := loadCSV(*csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to load CSV: %w", err)
+	}
+	header := ""
+	for _, name := range csvExtraColumns {
+		if strings.EqualFold(name, *column) {
+			header = name
+		}
+	}
+	if header == "" {
+		return fmt.Errorf("%s has no %q column; pick one with --by", *csvPath, *column)
+	}
+
+	groups := segmentCustomers(processCustomers(customers), header, *separator, *only, *prefix)
+	if len(groups) == 0 {
+		return fmt.Errorf("no customers have a %s value", header)
+	}
+	displayGroupPlan(groups, *inviteOnly)
+
+	if !*yes {
+		confirmPrompt := promptui.Select{
+			Label: fmt.Sprintf("Create %d groups?", len(groups)),
+			Items: []string{"Yes, create them", "No, exit"},
+		}
+		confirmIdx, _, err := confirmPrompt.Run()
+		if err != nil {
+			return err
+		}
+		if confirmIdx != 0 {
+			return fmt.Errorf("user cancelled")
+		}
+	}
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	defer closeSessionStore()
+	client, err := initializeWhatsApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+	}
+	defer client.Disconnect()
+
+	var members []groupMember
+	created := 0
+	for _, g := range groups {
+		outcome, err := createSegmentGroup(ctx, client, g, *inviteOnly)
+		members = append(members, outcome...)
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to create the group for %q", g.segment), err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		created++
+		log.Success(fmt.Sprintf("%s: %d added, %d to invite by link", g.name, g.added, g.invited))
+	}
+
+	if err := saveGroups(*outPath, groups, members); err != nil {
+		return fmt.Errorf("failed to save groups: %w", err)
+	}
+	displaySuccess("Groups Created",
+		fmt.Sprintf("Created %d of %d groups; JIDs and invite links saved to %s", created, len(groups), *outPath))
+	return ctx.Err()
+}
+
+// segmentCustomers buckets customers by the segment column, in name order
+func segmentCustomers(customers []ProcessedCustomer, column, separator, only, prefix string) []*segmentGroup {
+	wanted := make(map[string]bool)
+	for _, s := range strings.Split(only, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			wanted[strings.ToLower(s)] = true
+		}
+	}
+
+	bySegment := make(map[string]*segmentGroup)
+	for _, c := range customers {
+		values := []string{c.Fields[column]}
+		if separator != "" {
+			values = strings.Split(c.Fields[column], separator)
+		}
+		for _, segment := range values {
+			segment = strings.TrimSpace(segment)
+			key := strings.ToLower(segment)
+			if segment == "" || (len(wanted) > 0 && !wanted[key]) {
+				continue
+			}
+			g := bySegment[key]
+			if g == nil {
+				g = &segmentGroup{segment: segment, name: truncateRunes(prefix+segment, groupNameLimit)}
+				bySegment[key] = g
+			}
+			g.customers = append(g.customers, c)
+		}
+	}
+
+	groups := make([]*segmentGroup, 0, len(bySegment))
+	for _, g := range bySegment {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	return groups
+}
+
+// displayGroupPlan lists the groups about to be created
+func displayGroupPlan(groups []*segmentGroup, inviteOnly bool) {
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("GROUPS TO CREATE")
+	fmt.Println(strings.Repeat("─", 60))
+	for _, g := range groups {
+		note := ""
+		if len(g.customers) > groupMemberCap {
+			note = fmt.Sprintf("  (only the first %d fit)", groupMemberCap)
+		}
+		fmt.Printf("  %-25s %5d customers%s\n", g.name, len(g.customers), note)
+	}
+	fmt.Println(strings.Repeat("─", 60))
+	if inviteOnly {
+		fmt.Println("Nobody is added; customers get the invite link")
+	} else {
+		fmt.Println("Customers are added where their privacy settings allow; the rest get the invite link")
+	}
+	fmt.Println()
+}
+
+// createSegmentGroup creates g's group, adds its customers in batches and
+// fetches its invite link
+func createSegmentGroup(ctx context.Context, client *whatsmeow.Client, g *segmentGroup, inviteOnly bool) ([]groupMember, error) {
+	var members []groupMember
+	var jids []types.JID
+	byPhone := make(map[string]ProcessedCustomer)
+	for _, c := range g.customers {
+		if c.HasWhatsApp == "no" {
+			members = append(members, groupMember{g.segment, c, "not on WhatsApp"})
+			continue
+		}
+		if len(jids) == groupMemberCap {
+			members = append(members, groupMember{g.segment, c, "group full"})
+			continue
+		}
+		jids = append(jids, types.NewJID(c.FormattedPhone, types.DefaultUserServer))
+		byPhone[c.FormattedPhone] = c
+	}
+
+	info, err := client.CreateGroup(ctx, whatsmeow.ReqCreateGroup{Name: g.name})
+	if err != nil {
+		return members, err
+	}
+	g.jid = info.JID
+	if g.link, err = client.GetGroupInviteLink(g.jid, false); err != nil {
+		log.Warning(fmt.Sprintf("No invite link for %s: %v", g.name, err))
+	}
+
+	record := func(participants []types.GroupParticipant) {
+		for _, p := range participants {
+			phone := p.JID.User
+			if p.PhoneNumber.User != "" {
+				phone = p.PhoneNumber.User
+			}
+			c, ok := byPhone[phone]
+			if !ok {
+				continue
+			}
+			delete(byPhone, phone)
+			switch p.Error {
+			case 0:
+				g.added++
+				members = append(members, groupMember{g.segment, c, "added"})
+			case groupInviteCode:
+				g.invited++
+				members = append(members, groupMember{g.segment, c, "invite link"})
+			default:
+				members = append(members, groupMember{g.segment, c, fmt.Sprintf("error %d", p.Error)})
+			}
+		}
+	}
+
+	for start := 0; start < len(jids) && !inviteOnly; start += groupAddBatch {
+		batch := jids[start:min(start+groupAddBatch, len(jids))]
+		participants, err := client.UpdateGroupParticipants(g.jid, batch, whatsmeow.ParticipantChangeAdd)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Failed to add %d customers to %s: %v", len(batch), g.name, err))
+		}
+		record(participants)
+		if start+groupAddBatch < len(jids) && !sleepWithContext(ctx, time.Duration(getRandomDelay(false))*time.Millisecond) {
+			break
+		}
+	}
+
+	// Whoever wasn't added joins by link
+	for _, jid := range jids {
+		if c, ok := byPhone[jid.User]; ok {
+			g.invited++
+			members = append(members, groupMember{g.segment, c, "invite link"})
+		}
+	}
+	return members, nil
+}
+
+// saveGroups writes one row per group to path, and each customer's outcome
+// next to it with a "-members" suffix
+func saveGroups(path string, groups []*segmentGroup, members []groupMember) error {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	links := make(map[string]string)
+
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"Segment", "GroupName", "GroupJID", "InviteLink", "Added", "ToInvite"})
+	for _, g := range groups {
+		if g.jid.IsEmpty() {
+			continue
+		}
+		links[g.segment] = g.link
+		writer.Write([]string{g.segment, g.name, g.jid.String(), g.link, fmt.Sprint(g.added), fmt.Sprint(g.invited)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	membersPath := strings.TrimSuffix(path, filepath.Ext(path)) + "-members.csv"
+	file, err = createDataFile(membersPath)
+	if err != nil {
+		return err
+	}
+	writer = csv.NewWriter(file)
+	writer.Write([]string{"Segment", "Code", "CustomerName", "Phone", "Status", "InviteLink"})
+	for _, m := range members {
+		link := ""
+		if m.status == "invite link" {
+			link = links[m.segment]
+		}
+		writer.Write([]string{m.segment, m.customer.Code, m.customer.CustomerName, m.customer.FormattedPhone, m.status, link})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// HistoryConfig controls the send history kept across campaigns
+type HistoryConfig struct {
+	Enabled       bool   // Record every send with its template and delivery status
+	File          string // SQLite database, e.g. "data/history.db"
+	DedupeDays    int    // Skip a message identical to one sent to the same number within this many days (0 = off)
+	OpenChatHours int    // Skip customers who messaged us within this many hours (0 = off)
+	FollowUpFile  string // CSV listing those customers for staff to answer ("" = only skip them)
+}
+
+// errAlreadyDelivered starts the MessageResult error of a message skipped
+// because the customer already got the exact same text
+const errAlreadyDelivered = "same message already sent"
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS sends (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	campaign   TEXT NOT NULL,
+	sent_at    TEXT NOT NULL,
+	code       TEXT,
+	name       TEXT,
+	phone      TEXT,
+	mobile     TEXT,
+	sent_to    TEXT,
+	template   TEXT,
+	message    TEXT,
+	status     TEXT NOT NULL,
+	error      TEXT,
+	message_id TEXT
+);
+CREATE INDEX IF NOT EXISTS sends_code ON sends(code);
+CREATE INDEX IF NOT EXISTS sends_sent_to ON sends(sent_to);
+CREATE INDEX IF NOT EXISTS sends_message_id ON sends(message_id);
+CREATE TABLE IF NOT EXISTS inbound (
+	message_id  TEXT PRIMARY KEY,
+	received_at TEXT NOT NULL,
+	phone       TEXT NOT NULL,
+	sender      TEXT,
+	text        TEXT
+);
+CREATE INDEX IF NOT EXISTS inbound_phone ON inbound(phone, received_at);
+CREATE TABLE IF NOT EXISTS engagement (
+	phone      TEXT PRIMARY KEY,
+	code       TEXT,
+	name       TEXT,
+	sent       INTEGER NOT NULL,
+	delivered  INTEGER NOT NULL,
+	read       INTEGER NOT NULL,
+	replied    INTEGER NOT NULL,
+	failed     INTEGER NOT NULL,
+	last_sent  TEXT,
+	last_reply TEXT,
+	score      INTEGER NOT NULL,
+	segment    TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS engagement_score ON engagement(score);
+CREATE TABLE IF NOT EXISTS consents (
+	phone  TEXT PRIMARY KEY,
+	date   TEXT,
+	source TEXT
+);
+`
+
+// sendHistory records campaign sends in the history database
+type sendHistory struct {
+	db       *sql.DB
+	sealed   *encryptedDB
+	campaign string
+	mu       sync.Mutex
+}
+
+// history is the open send history, or nil when it is off or unavailable
+var history *sendHistory
+
+// openHistory opens (and creates if needed) the history database
+func openHistory(path string) (*sendHistory, error) {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, sealed, err := openDataDB(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+file+"?_busy_timeout=5000")
+	if err != nil {
+		sealed.close()
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err == nil {
+		err = addMessageHashes(db)
+		if err == nil {
+			err = addSentFrom(db)
+		}
+	}
+	if err != nil {
+		db.Close()
+		sealed.close()
+		return nil, fmt.Errorf("failed to prepare history database %s: %w", path, err)
+	}
+	return &sendHistory{db: db, sealed: sealed}, nil
+}
+
+// addMessageHashes adds the message_hash column to a history database made
+// before it existed, hashing the messages already recorded
+func addMessageHashes(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('sends') WHERE name = 'message_hash'`).Scan(&found); err != nil {
+		return err
+	}
+	if found > 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`ALTER TABLE sends ADD COLUMN message_hash TEXT`); err != nil {
+		return err
+	}
+	rows, err := tx.Query(`SELECT id, message FROM sends WHERE message IS NOT NULL AND message != ''`)
+	if err != nil {
+		return err
+	}
+	hashes := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var message string
+		if err := rows.Scan(&id, &message); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes[id] = messageHash(message)
+	}
+	rows.Close()
+	for id, hash := range hashes {
+		if _, err := tx.Exec(`UPDATE sends SET message_hash = ? WHERE id = ?`, hash, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS sends_message_hash ON sends(sent_to, message_hash)`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addSentFrom adds the sent_from column, our number each message went out
+// from, to a history database made before it existed
+func addSentFrom(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('sends') WHERE name = 'sent_from'`).Scan(&found); err != nil {
+		return err
+	}
+	if found > 0 {
+		return nil
+	}
+	_, err := db.Exec(`ALTER TABLE sends ADD COLUMN sent_from TEXT`)
+	return err
+}
+
+// messageHash identifies a rendered message's exact text
+func messageHash(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:])
+}
+
+// startCampaignHistory opens the history for a campaign that is about to send
+func startCampaignHistory() {
+	if !config.History.Enabled || config.Simulate.Enabled {
+		return
+	}
+	h, err := openHistory(config.History.File)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Send history unavailable: %v", err))
+		return
+	}
+	name := filepath.Base(campaignCSV)
+	if activeCampaignProfile != nil {
+		name = activeCampaignProfile.Name
+	}
+	h.campaign = time.Now().Format("2006-01-02 15:04") + " " + name
+	history = h
+	h.flushPendingInbound()
+
+	// Limits are per account, so sends by earlier runs count against them
+	now := time.Now()
+	progress.HourlySent = h.sentSince(now.Add(-time.Hour))
+	progress.DailySent = h.sentSince(now.Add(-24 * time.Hour))
+	if progress.DailySent > 0 {
+		log.Info(fmt.Sprintf("%d message(s) already sent in the last 24 hours count towards the daily limit", progress.DailySent))
+	}
+}
+
+// sentSince counts messages that went out after t
+func (h *sendHistory) sentSince(t time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var n int
+	if err := h.db.QueryRow(`SELECT COUNT(*) FROM sends WHERE status != 'failed' AND sent_at >= ?`,
+		t.Format(time.RFC3339)).Scan(&n); err != nil {
+		log.Debug(fmt.Sprintf("Failed to count recent sends: %v", err))
+	}
+	return n
+}
+
+// record stores one send result
+func (h *sendHistory) record(result MessageResult) {
+	if h == nil {
+		return
+	}
+	status := statusSent
+	if !result.Success {
+		status = "failed"
+	}
+	c := result.Customer
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.db.Exec(`INSERT INTO sends
+		(campaign, sent_at, code, name, phone, mobile, sent_to, template, message, status, error, message_id, message_hash, sent_from)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.campaign, result.Timestamp.Format(time.RFC3339), c.Code, c.CustomerName, c.Phone, c.Mobile,
+		result.SentTo, result.Template, result.Message, status, result.Error, result.MessageID, messageHash(result.Message),
+		result.SentFrom)
+	if err != nil {
+		log.Debug(fmt.Sprintf("Failed to record send history: %v", err))
+	}
+}
+
+// alreadyDelivered reports when the exact same message last went out to
+// phone within History.DedupeDays, so re-running a campaign file by mistake
+// doesn't message anyone twice
+func (h *sendHistory) alreadyDelivered(phone, message string) (time.Time, bool) {
+	if h == nil || config.History.DedupeDays <= 0 {
+		return time.Time{}, false
+	}
+	since := time.Now().AddDate(0, 0, -config.History.DedupeDays)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var sentAt string
+	err := h.db.QueryRow(`SELECT sent_at FROM sends
+		WHERE sent_to = ? AND message_hash = ? AND status NOT IN ('failed', ?) AND sent_at >= ?
+		ORDER BY sent_at DESC LIMIT 1`,
+		phone, messageHash(message), statusRevoked, since.Format(time.RFC3339)).Scan(&sentAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Debug(fmt.Sprintf("Failed to check send history: %v", err))
+		}
+		return time.Time{}, false
+	}
+	when, _ := time.Parse(time.RFC3339, sentAt)
+	return when, true
+}
+
+// updateStatus moves a sent message to delivered or read
+func (h *sendHistory) updateStatus(messageID, status string) {
+	if h == nil || messageID == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.db.Exec(`UPDATE sends SET status = ? WHERE message_id = ? AND status NOT IN (?, ?)`,
+		status, messageID, statusRead, statusRevoked); err != nil {
+		log.Debug(fmt.Sprintf("Failed to update send history: %v", err))
+	}
+}
+
+// close closes the history database
+func (h *sendHistory) close() {
+	if h == nil {
+		return
+	}
+	h.db.Close()
+	h.sealed.close()
+}
+
+// historyEntry is one row of the send history
+type historyEntry struct {
+	Campaign, SentAt, Code, Name, SentTo string
+	Template, Message, Status, Error     string
+}
+
+// lookupHistory returns every send to a customer code or phone number, oldest first
+func lookupHistory(db *sql.DB, query string) ([]historyEntry, error) {
+	phone := formatPhoneNumber(strings.TrimPrefix(strings.TrimSpace(query), "+"))
+	rows, err := db.Query(`SELECT campaign, sent_at, IFNULL(code, ''), IFNULL(name, ''), IFNULL(sent_to, ''),
+		IFNULL(template, ''), IFNULL(message, ''), status, IFNULL(error, '')
+		FROM sends
+		WHERE code = ? OR sent_to = ? OR phone = ? OR mobile = ?
+		ORDER BY sent_at, id`, query, phone, query, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []historyEntry
+	for rows.Next() {
+		var e historyEntry
+		if err := rows.Scan(&e.Campaign, &e.SentAt, &e.Code, &e.Name, &e.SentTo,
+			&e.Template, &e.Message, &e.Status, &e.Error); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// runLookupCommand shows every message sent to a customer, e.g. when they
+// complain about spam
+func runLookupCommand(args []string) error {
+	fs := flag.NewFlagSet("lookup", flag.ContinueOnError)
+	dbPath := fs.String("db", config.History.File, "send history database")
+	full := fs.Bool("full", false, "print the full text of each message")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: venom lookup [flags] <phone|code>")
+		return fmt.Errorf("give one phone number or customer code")
+	}
+	query := fs.Arg(0)
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		return fmt.Errorf("no send history at %s", *dbPath)
+	}
+	h, err := openHistory(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer h.close()
+
+	entries, err := lookupHistory(h.db, query)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		displayInfo("No Messages Found", fmt.Sprintf("Nothing was ever sent to %s", query), nil)
+		return nil
+	}
+
+	fmt.Println(bold + fmt.Sprintf("Messages sent to %s (%s)", entries[0].Name, query) + colorReset)
+	fmt.Println(strings.Repeat("─", 60))
+	for _, e := range entries {
+		when := e.SentAt
+		if t, err := time.Parse(time.RFC3339, e.SentAt); err == nil {
+			when = t.Format("2006-01-02 15:04")
+		}
+		color := colorGreen
+		if e.Status == "failed" {
+			color = colorRed
+		}
+		fmt.Printf("%s  %s%-9s%s  +%s  %s\n", when, color, e.Status, colorReset, e.SentTo, dim+e.Campaign+colorReset)
+		fmt.Printf("  Template: %s\n", templateSummary(e.Template))
+		if e.Error != "" {
+			fmt.Printf("  Error:    %s\n", e.Error)
+		}
+		if *full {
+			fmt.Println("  " + strings.ReplaceAll(e.Message, "\n", "\n  "))
+		}
+	}
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("%d message(s)\n", len(entries))
+	return nil
+}
+
+// templateSummary shortens a template to its first line
+func templateSummary(template string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(template), "\n")
+	if utf8.RuneCountInString(line) > 60 {
+		line = string([]rune(line)[:57]) + "..."
+	}
+	return line
+}
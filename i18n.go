@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// uiLanguage is the console language: "en" (default) or "ar" (--ui-lang ar)
+var uiLanguage = "en"
+
+// rtlMark makes terminals that honour it lay the line out right to left
+const rtlMark = "\u200f"
+
+// uiStrings translates console text, keyed by the English text or format
+// string. Text without an entry is shown in English.
+var uiStrings = map[string]map[string]string{
+	"ar": {
+		// Message boxes
+		" ✗ ERROR ":    " ✗ خطأ ",
+		" ⚠ WARNING ":  " ⚠ تحذير ",
+		" ✓ SUCCESS ":  " ✓ تم بنجاح ",
+		"What to do:":  "ما العمل:",
+		"Suggestions:": "اقتراحات:",
+		"Tips:":        "نصائح:",
+
+		// Box titles
+		"File Not Found":                     "الملف غير موجود",
+		"Switching Transport":                "تبديل وسيلة الإرسال",
+		"Simulation Mode":                    "وضع المحاكاة",
+		"Rate Limit Reached":                 "تم بلوغ حد الإرسال",
+		"Pre-Check Stopped":                  "توقف الفحص المسبق",
+		"Possible Rate Limiting":             "احتمال تقييد الإرسال",
+		"Possible Duplicate Customers":       "عملاء مكررون محتملون",
+		"Outside Business Hours":             "خارج ساعات العمل",
+		"No Templates Found":                 "لا توجد قوالب",
+		"No Linked Numbers":                  "لا توجد أرقام مرتبطة",
+		"No Channels":                        "لا توجد قنوات",
+		"Don't Run Both Copies":              "لا تشغّل النسختين معاً",
+		"Connection Lost":                    "انقطع الاتصال",
+		"Channel Limit Reached":              "تم بلوغ حد القناة",
+		"CSV Has Warnings":                   "ملف CSV به تحذيرات",
+		"Templates Selected":                 "تم اختيار القوالب",
+		"Template Selected":                  "تم اختيار القالب",
+		"Session Imported":                   "تم استيراد الجلسة",
+		"Session Exported":                   "تم تصدير الجلسة",
+		"Cleaned CSV Saved":                  "تم حفظ ملف CSV المصحح",
+		"Check Complete":                     "اكتمل الفحص",
+		"Channel Post Sent":                  "تم النشر في القناة",
+		"CSV Valid":                          "ملف CSV سليم",
+		"Your Channels":                      "قنواتك",
+		"Using Defaults":                     "استخدام الإعدادات الافتراضية",
+		"Template Configuration":             "إعداد القوالب",
+		"Rate Limit Status":                  "حالة حدود الإرسال",
+		"Pre-flight Checks Passed":           "نجحت فحوص ما قبل الإرسال",
+		"Message Languages":                  "لغات الرسائل",
+		"Linked Numbers":                     "الأرقام المرتبطة",
+		"Fix-up Summary":                     "ملخص التصحيح",
+		"Collecting Poll Votes":              "جمع أصوات الاستطلاع",
+		"Channels":                           "القنوات",
+		"Template Not Suitable For Channels": "القالب غير مناسب للقنوات",
+		"Session Already Exists":             "الجلسة موجودة بالفعل",
+		"Profile Not Found":                  "الملف الشخصي غير موجود",
+		"Pre-flight Check Failed":            "فشل فحص ما قبل الإرسال",
+		"No Channel Configured":              "لم يتم إعداد قناة",
+		"Logged Out":                         "تم تسجيل الخروج",
+		"Empty Message":                      "رسالة فارغة",
+		"Connection Not Restored":            "لم يعد الاتصال",
+		"CSV Has Errors":                     "ملف CSV به أخطاء",
+
+		// Common box text
+		"Pausing until business hours resume":                  "الإيقاف مؤقتاً حتى بدء ساعات العمل",
+		"Press Ctrl+C to cancel":                               "اضغط Ctrl+C للإلغاء",
+		"Press Ctrl+C to stop":                                 "اضغط Ctrl+C للإيقاف",
+		"Pausing to respect rate limits":                       "إيقاف مؤقت لاحترام حدود الإرسال",
+		"This prevents account blocking":                       "هذا يمنع حظر الحساب",
+		"Progress will resume automatically":                   "سيستأنف الإرسال تلقائياً",
+		"Pausing sends until WhatsApp reconnects":              "إيقاف الإرسال حتى يعود اتصال واتساب",
+		"Progress is saved; sending resumes automatically":     "تم حفظ التقدم وسيستأنف الإرسال تلقائياً",
+		"Current time is outside business hours (9 AM - 9 PM)": "الوقت الحالي خارج ساعات العمل (9 صباحاً - 9 مساءً)",
+		"Please check the file path and try again":             "تحقق من مسار الملف وحاول مجدداً",
+		"Ensure the file exists in the specified location":     "تأكد من وجود الملف في المكان المحدد",
+		"Check for typos in the filename":                      "تحقق من كتابة اسم الملف",
+		"Use absolute path if relative path doesn't work":      "استخدم المسار الكامل إذا لم ينجح المسار النسبي",
+		"Cannot find CSV file: %s":                             "تعذر العثور على ملف CSV: %s",
+		"Using %d message template(s) in permutation mode":     "استخدام %d قالب رسائل بالتناوب",
+		"Each customer will receive a different template":      "يتلقى كل عميل قالباً مختلفاً",
+		"Templates rotate automatically":                       "تتناوب القوالب تلقائياً",
+		"Ensures message variety":                              "يضمن تنوع الرسائل",
+		"Using all %d templates in permutation mode":           "استخدام كل القوالب (%d) بالتناوب",
+		"Using built-in Arabic message templates":              "استخدام قوالب الرسائل العربية المدمجة",
+		"%d default templates available":                       "%d قوالب افتراضية متاحة",
+		"Using template %d":                                    "استخدام القالب %d",
+
+		// Wizard
+		"\n📝 Message Templates Found":                  "\n📝 تم العثور على قوالب رسائل",
+		"Found %d template files":                      "تم العثور على %d ملف قوالب",
+		"Template %d: %s":                              "القالب %d: %s",
+		"✓ Use ALL templates (Recommended)":            "✓ استخدام كل القوالب (موصى به)",
+		"⚙ Use built-in default templates":             "⚙ استخدام القوالب الافتراضية المدمجة",
+		"Select templates to use":                      "اختر القوالب المستخدمة",
+		"\n⚙️  Configuration Setup":                    "\n⚙️  إعداد الحملة",
+		"Let's configure your bulk messaging campaign": "لنقم بإعداد حملة الرسائل",
+		"CSV File Path":                                "مسار ملف CSV",
+		"  ✓ CSV file found":                           "  ✓ تم العثور على ملف CSV",
+		"  ✓ CSV will be downloaded":                   "  ✓ سيتم تنزيل ملف CSV",
+		"(%d bytes)":                                   "(%d بايت)",
+		"Configuration Mode":                           "طريقة الإعداد",
+		"Quick Start (Recommended defaults)":           "بدء سريع (الإعدادات الموصى بها)",
+		"Custom Configuration (Advanced)":              "إعداد مخصص (متقدم)",
+		"\n✓ Using recommended defaults":               "\n✓ استخدام الإعدادات الموصى بها",
+		"Ready to start?":                              "جاهز للبدء؟",
+		"Yes, start sending":                           "نعم، ابدأ الإرسال",
+		"No, exit":                                     "لا، خروج",
+		"\n🔧 Custom Configuration":                     "\n🔧 إعداد مخصص",
+		"Delay between messages (seconds, min-max)":    "المهلة بين الرسائل (ثوانٍ، أدنى-أقصى)",
+		"format should be: min-max (e.g., 5-12)":       "الصيغة: أدنى-أقصى (مثال 5-12)",
+		"Messages per batch":                           "عدد الرسائل في الدفعة",
+		"must be between 1 and 50":                     "يجب أن يكون بين 1 و 50",
+		"Break between batches (seconds)":              "الاستراحة بين الدفعات (ثوانٍ)",
+		"must be at least 30 seconds":                  "يجب ألا تقل عن 30 ثانية",
+		"Skip duplicate phone numbers?":                "تخطي الأرقام المكررة؟",
+		"Yes (Recommended)":                            "نعم (موصى به)",
+		"No":                                           "لا",
+		"Max retry attempts per message":               "أقصى عدد لمحاولات الإعادة لكل رسالة",
+		"must be between 0 and 5":                      "يجب أن يكون بين 0 و 5",
+		"\n✓ Configuration updated":                    "\n✓ تم تحديث الإعدادات",
+
+		// Current configuration
+		"\n📋 Current Configuration:":                 "\n📋 الإعدادات الحالية:",
+		"  Delay Between Messages:  %d-%d seconds\n": "  المهلة بين الرسائل:      %d-%d ثانية\n",
+		"  Batch Size:              %d messages\n":   "  حجم الدفعة:              %d رسالة\n",
+		"  Batch Break:             %d seconds\n":    "  استراحة الدفعة:          %d ثانية\n",
+		"  Max Retries:             %d attempts\n":   "  أقصى محاولات:            %d\n",
+		"  Skip Duplicates:         %v\n":            "  تخطي المكرر:             %v\n",
+		"  Skip Invalid Numbers:    %v\n":            "  تخطي الأرقام غير الصحيحة: %v\n",
+		"  Pre-Check Numbers:       %v\n":            "  فحص الأرقام مسبقاً:      %v\n",
+		"  Country Code:            +%s\n":           "  رمز الدولة:              +%s\n",
+		"  Allowed Prefixes:        %s\n":            "  البادئات المسموحة:       %s\n",
+
+		"after closing": "بعد موعد الإغلاق",
+
+		// Batch break
+		"Batch break, resuming in":   "استراحة الدفعة، الاستئناف خلال",
+		"[s] skip  [+] add 1 minute": "[s] تخطي  [+] إضافة دقيقة",
+
+		// Interrupt menu
+		"Interrupted. What now?":    "تم الإيقاف المؤقت. ماذا تريد؟",
+		"Pause":                     "إيقاف مؤقت",
+		"Paused":                    "متوقف مؤقتاً",
+		"Resume":                    "استئناف",
+		"Abort and save checkpoint": "إلغاء وحفظ نقطة الاستئناف",
+		"Continue":                  "متابعة",
+
+		"Reload delays and limits from config": "إعادة تحميل المهلات والحدود من ملف الإعدادات",
+
+		// Preview, progress and reports
+		"MESSAGE PREVIEW":         "معاينة الرسالة",
+		"To: %s\n":                "إلى: %s\n",
+		"Phone: %s\n":             "الهاتف: %s\n",
+		"Length: %d characters\n": "الطول: %d حرفاً\n",
+		"Parts:  %d (longer than %d characters)\n":  "الأجزاء: %d (أطول من %d حرفاً)\n",
+		"[%d/%d] Processing: %s\n":                  "[%d/%d] جارٍ الإرسال: %s\n",
+		"\r[%s] %.1f%% (%d/%d) - Processing: %-30s": "\r[%s] %.1f%% (%d/%d) - جارٍ الإرسال: %-30s",
+		"CURRENT STATISTICS":                        "الإحصائيات الحالية",
+		"Processed:     %d/%d\n":                    "تمت معالجة:    %d/%d\n",
+		"Successful:    %d\n":                       "نجح:           %d\n",
+		"Failed:        %d\n":                       "فشل:           %d\n",
+		"Skipped:       %d\n":                       "تم تخطيه:      %d\n",
+		"  - Duplicates: %d\n":                      "  - مكرر:      %d\n",
+		"Success Rate:  %.2f%%\n":                   "نسبة النجاح:   %.2f%%\n",
+		"EXECUTION SUMMARY":                         "ملخص التنفيذ",
+		"Start Time:         %s\n":                  "وقت البدء:          %s\n",
+		"End Time:           %s\n":                  "وقت الانتهاء:       %s\n",
+		"Duration:           %s\n":                  "المدة:              %s\n",
+		"Total Customers:    %d\n":                  "إجمالي العملاء:     %d\n",
+		"Successful Sends:   %d (%.2f%%)\n":         "رسائل ناجحة:        %d (%.2f%%)\n",
+		"Failed Sends:       %d\n":                  "رسائل فاشلة:        %d\n",
+		"Skipped Customers:  %d\n":                  "عملاء تم تخطيهم:    %d\n",
+		"  - Duplicates:     %d\n":                  "  - مكرر:           %d\n",
+		"Average Delay:      %.2fs\n":               "متوسط المهلة:       %.2f ث\n",
+	},
+}
+
+// setUILanguage selects the console language, falling back to English
+func setUILanguage(lang string) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if _, ok := uiStrings[lang]; ok {
+		uiLanguage = lang
+		return
+	}
+	uiLanguage = "en"
+}
+
+// tr returns the console translation of text
+func tr(text string) string {
+	if translated, ok := uiStrings[uiLanguage][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// trf formats the translation of a format string
+func trf(format string, args ...interface{}) string {
+	return fmt.Sprintf(tr(format), args...)
+}
+
+// trAll translates each line of a list
+func trAll(lines []string) []string {
+	translated := make([]string, len(lines))
+	for i, line := range lines {
+		translated[i] = tr(line)
+	}
+	return translated
+}
+
+// isRTLText reports whether text is mostly written right to left
+func isRTLText(text string) bool {
+	rtl, ltr := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Arabic, unicode.Hebrew):
+			rtl++
+		case unicode.IsLetter(r):
+			ltr++
+		}
+	}
+	return rtl > ltr
+}
+
+// layoutRTL right-aligns right-to-left lines within width and marks them so
+// bidi-aware terminals order them correctly; other lines are left alone
+func layoutRTL(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !isRTLText(line) {
+			continue
+		}
+		pad := max(width-ansi.StringWidth(line), 0)
+		lines[i] = strings.Repeat(" ", pad) + rtlMark + line
+	}
+	return strings.Join(lines, "\n")
+}
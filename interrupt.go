@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+)
+
+var (
+	// sendingCampaign is set while messages are going out; Ctrl+C only opens
+	// the interrupt menu then
+	sendingCampaign atomic.Bool
+
+	// operatorPaused holds the send loop while the interrupt menu is open
+	operatorPaused atomic.Bool
+
+	// interruptCancel cancels the campaign context when the operator aborts
+	interruptCancel context.CancelFunc
+)
+
+// interruptMenuEnabled reports whether Ctrl+C should ask before stopping.
+// The dashboard handles its own keys and plain or piped runs can't answer.
+func interruptMenuEnabled() bool {
+	return sendingCampaign.Load() && dashboard == nil && !plainOutput && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// showInterruptMenu asks the operator what an interrupt should do. The send
+// loop holds while the menu is open; it returns once the campaign continues
+// or has been cancelled. A second Ctrl+C at the menu aborts.
+func showInterruptMenu() {
+	operatorPaused.Store(true)
+	defer operatorPaused.Store(false)
+
+	clearProgress()
+	fmt.Fprintln(console)
+	prompt := promptui.Select{
+		Label: tr("Interrupted. What now?"),
+		Items: trAll([]string{"Pause", "Abort and save checkpoint", "Continue", "Reload delays and limits from config"}),
+	}
+	idx, _, err := prompt.Run()
+	if err != nil {
+		abortCampaign()
+		return
+	}
+
+	switch idx {
+	case 0:
+		log.Warning("Campaign paused by operator")
+		resume := promptui.Select{
+			Label: tr("Paused"),
+			Items: trAll([]string{"Resume", "Abort and save checkpoint"}),
+		}
+		idx, _, err := resume.Run()
+		if err != nil || idx == 1 {
+			abortCampaign()
+			return
+		}
+		log.Info("Campaign resumed")
+	case 1:
+		abortCampaign()
+	case 3:
+		requestConfigReload()
+	default:
+		log.Info("Continuing campaign")
+	}
+}
+
+// abortCampaign saves the checkpoint and stops the campaign
+func abortCampaign() {
+	if checkpoint != nil {
+		if err := checkpoint.save(); err != nil {
+			log.Error("Failed to save checkpoint", err)
+		} else {
+			log.Info(fmt.Sprintf("Checkpoint saved: %d customers done; run again to resume", checkpoint.handled()))
+		}
+	}
+	log.Warning("Campaign aborted by operator")
+	if interruptCancel != nil {
+		interruptCancel()
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// InviteConfig configures invite campaigns (CampaignType "invite")
+type InviteConfig struct {
+	Link         string // Group (chat.whatsapp.com/...) or channel (whatsapp.com/channel/...) invite link
+	ResultsFile  string // CSV that sends and joins are appended to
+	TrackMinutes int    // Keep listening for joins this long after the last send
+}
+
+const (
+	groupLinkPrefix   = "https://chat.whatsapp.com/"
+	channelLinkPrefix = "https://whatsapp.com/channel/"
+)
+
+// invitePreview is the link preview shown under every invite
+type invitePreview struct {
+	link        string
+	title       string
+	description string
+	thumbnail   []byte
+	group       types.JID // Empty for a channel, whose followers can't be tracked
+}
+
+// inviteTracker records who was sent the invite and who joined after
+type inviteTracker struct {
+	preview invitePreview
+
+	mu         sync.Mutex
+	recipients map[string]Customer // Phone -> customer sent the link
+	joined     map[string]bool
+	file       io.WriteCloser
+	writer     *csv.Writer
+}
+
+var invites *inviteTracker
+
+// isInviteCampaign reports whether the campaign sends an invite link
+func isInviteCampaign() bool {
+	return config.CampaignType == "invite"
+}
+
+// validateInviteConfig checks the invite section before any sends happen
+func validateInviteConfig() error {
+	link := strings.TrimSpace(config.Invite.Link)
+	if !strings.HasPrefix(link, groupLinkPrefix) && !strings.HasPrefix(link, channelLinkPrefix) {
+		return fmt.Errorf("Invite.Link must be a group (%s...) or channel (%s...) link", groupLinkPrefix, channelLinkPrefix)
+	}
+	config.Invite.Link = link
+	return nil
+}
+
+// newInviteTracker looks up the group or channel behind the link for its
+// preview and opens the results CSV
+func newInviteTracker(client Sender, resultsFile string) (*inviteTracker, error) {
+	t := &inviteTracker{
+		preview:    invitePreview{link: config.Invite.Link, title: config.Invite.Link},
+		recipients: make(map[string]Customer),
+		joined:     make(map[string]bool),
+	}
+	if real, ok := whatsAppClient(client); ok {
+		if err := t.preview.load(real); err != nil {
+			return nil, err
+		}
+	}
+
+	os.MkdirAll(filepath.Dir(resultsFile), 0755)
+	_, statErr := os.Stat(resultsFile)
+	file, err := appendDataFile(resultsFile)
+	if err != nil {
+		return nil, err
+	}
+	t.file, t.writer = file, csv.NewWriter(file)
+	if os.IsNotExist(statErr) {
+		t.writer.Write([]string{"Timestamp", "Code", "CustomerName", "Phone", "Event", "Link"})
+		t.writer.Flush()
+	}
+	return t, nil
+}
+
+// load fills the preview from the group or channel the link points to
+func (p *invitePreview) load(client *whatsmeow.Client) error {
+	var pictureURL string
+	if code, ok := strings.CutPrefix(p.link, groupLinkPrefix); ok {
+		info, err := client.GetGroupInfoFromLink(code)
+		if err != nil {
+			return fmt.Errorf("failed to look up the group invite: %w", err)
+		}
+		p.title, p.description, p.group = info.Name, info.Topic, info.JID
+		if picture, err := client.GetProfilePictureInfo(info.JID, &whatsmeow.GetProfilePictureParams{Preview: true}); err == nil && picture != nil {
+			pictureURL = picture.URL
+		}
+		if !isGroupMember(client, info) {
+			log.Warning(fmt.Sprintf("This number isn't in %q, so joins can't be tracked", info.Name))
+			p.group = types.EmptyJID
+		}
+	} else {
+		key := strings.TrimPrefix(p.link, channelLinkPrefix)
+		channel, err := client.GetNewsletterInfoWithInvite(key)
+		if err != nil {
+			return fmt.Errorf("failed to look up the channel invite: %w", err)
+		}
+		p.title, p.description = channel.ThreadMeta.Name.Text, channel.ThreadMeta.Description.Text
+		if pictureURL = channel.ThreadMeta.Preview.URL; pictureURL == "" && channel.ThreadMeta.Preview.DirectPath != "" {
+			pictureURL = "https://mmg.whatsapp.net" + channel.ThreadMeta.Preview.DirectPath
+		}
+		log.Info("Channel followers aren't reported to the sender, so joins can't be tracked")
+	}
+
+	if pictureURL != "" {
+		thumbnail, err := downloadThumbnail(pictureURL)
+		if err != nil {
+			log.Debug(fmt.Sprintf("No invite thumbnail: %v", err))
+		}
+		p.thumbnail = thumbnail
+	}
+	log.Info(fmt.Sprintf("Inviting to %q", p.title))
+	return nil
+}
+
+// isGroupMember reports whether the linked number is in the group and so
+// receives its join events
+func isGroupMember(client *whatsmeow.Client, info *types.GroupInfo) bool {
+	if client.Store.ID == nil {
+		return false
+	}
+	for _, participant := range info.Participants {
+		if participant.JID.User == client.Store.ID.User || participant.PhoneNumber.User == client.Store.ID.User ||
+			(client.Store.LID.User != "" && participant.JID.User == client.Store.LID.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadThumbnail fetches the small picture shown in the preview
+func downloadThumbnail(url string) ([]byte, error) {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// withInviteLink adds the invite link to a rendered message that doesn't
+// already contain it
+func withInviteLink(message string) string {
+	if strings.Contains(message, config.Invite.Link) {
+		return message
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + config.Invite.Link
+}
+
+// inviteMessage turns text with the invite link into a message with its preview
+func (t *inviteTracker) inviteMessage(text string) *waE2E.Message {
+	p := t.preview
+	return &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:                  proto.String(text),
+			MatchedText:           proto.String(p.link),
+			Title:                 proto.String(p.title),
+			Description:           proto.String(p.description),
+			JPEGThumbnail:         p.thumbnail,
+			PreviewType:           waE2E.ExtendedTextMessage_NONE.Enum(),
+			InviteLinkGroupTypeV2: waE2E.ExtendedTextMessage_DEFAULT.Enum(),
+		},
+	}
+}
+
+// track remembers that a customer was sent the link
+func (t *inviteTracker) track(customer Customer, phone string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recipients[phone] = customer
+	t.writer.Write([]string{time.Now().Format("2006-01-02 15:04:05"), customer.Code, customer.CustomerName, phone, "sent", t.preview.link})
+	t.writer.Flush()
+}
+
+// handleJoin records recipients joining the invited group
+func (t *inviteTracker) handleJoin(client *whatsmeow.Client, evt *events.GroupInfo) {
+	if t.preview.group.IsEmpty() || evt.JID != t.preview.group {
+		return
+	}
+	for _, jid := range evt.Join {
+		phone := jid.User
+		if lidPhone := phoneForLID(client, jid); lidPhone != "" {
+			phone = lidPhone
+		}
+
+		t.mu.Lock()
+		customer, ours := t.recipients[phone]
+		first := ours && !t.joined[phone]
+		if first {
+			t.joined[phone] = true
+			t.writer.Write([]string{evt.Timestamp.Format("2006-01-02 15:04:05"), customer.Code, customer.CustomerName, phone, "joined", t.preview.link})
+			t.writer.Flush()
+		}
+		t.mu.Unlock()
+
+		if first {
+			log.Info(fmt.Sprintf("%s joined %s", customer.CustomerName, t.preview.title))
+		}
+	}
+}
+
+// collectJoins keeps the client connected for TrackMinutes so late joins are recorded
+func (t *inviteTracker) collectJoins(ctx context.Context) {
+	if config.Invite.TrackMinutes <= 0 || t.preview.group.IsEmpty() {
+		return
+	}
+
+	displayInfo("Tracking Group Joins",
+		fmt.Sprintf("Listening for joins for %d minutes", config.Invite.TrackMinutes),
+		[]string{"Press Ctrl+C to stop early", "Joins are saved to " + config.Invite.ResultsFile})
+
+	sleepWithContext(ctx, time.Duration(config.Invite.TrackMinutes)*time.Minute)
+}
+
+// displayResults prints how many recipients joined and closes the results file
+func (t *inviteTracker) displayResults() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Println("INVITE RESULTS")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("Invited To:   %s\n", t.preview.title)
+	fmt.Printf("Invites Sent: %d\n", len(t.recipients))
+	if t.preview.group.IsEmpty() {
+		fmt.Println("Joined:       not tracked")
+	} else {
+		rate := 0.0
+		if len(t.recipients) > 0 {
+			rate = float64(len(t.joined)) / float64(len(t.recipients)) * 100
+		}
+		fmt.Printf("Joined:       %d (%.1f%%)\n", len(t.joined), rate)
+	}
+	fmt.Println(strings.Repeat("=", 60) + "\n")
+
+	t.writer.Flush()
+	t.file.Close()
+}
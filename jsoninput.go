@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isJSONInput reports whether a customer file is a JSON array or NDJSON export
+func isJSONInput(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json", ".ndjson", ".jsonl":
+		return true
+	}
+	return false
+}
+
+// jsonFieldNames maps normalised JSON keys to the customer fields they fill
+var jsonFieldNames = map[string]string{
+	"code":         "Code",
+	"id":           "Code",
+	"customername": "CustomerName",
+	"name":         "CustomerName",
+	"phone":        "Phone",
+	"mobile":       "Mobile",
+	"cell":         "Mobile",
+	"haswhatsapp":  "HasWhatsApp",
+}
+
+// streamJSON reads recipients from a JSON array of objects or from NDJSON
+// (one object per line) and passes each to fn like a CSV row. Keys such as
+// "code", "name", "phone" and "mobile" fill the customer fields; every other
+// key, with nested objects flattened as "address.city", becomes an extra
+// column usable as a template placeholder.
+func streamJSON(filename string, fn func(line int, customer Customer) error, onBadRow func(line int, problem string)) error {
+	file, err := openDataFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input, _, err := decodeCSVInput(file)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewReader(input)
+
+	csvHeader = append([]string{}, expectedHeader...)
+	csvExtraColumns = nil
+	extraSeen := make(map[string]bool)
+	handle := func(line int, raw []byte) error {
+		customer, err := jsonCustomer(raw)
+		if err != nil {
+			log.Warning(fmt.Sprintf("%s line %d: skipping record: %v", filename, line, err))
+			if onBadRow != nil {
+				onBadRow(line, fmt.Sprintf("Malformed record: %v", err))
+			}
+			return nil
+		}
+		// Keys can differ between records; columns are added as they appear
+		names := make([]string, 0, len(customer.Fields))
+		for name := range customer.Fields {
+			if !extraSeen[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			extraSeen[name] = true
+			csvExtraColumns = append(csvExtraColumns, name)
+			csvHeader = append(csvHeader, name)
+		}
+		return fn(line, customer)
+	}
+
+	first, err := firstNonSpace(buffered)
+	if err == io.EOF {
+		return fmt.Errorf("%s is empty or has no records", filename)
+	}
+	if err != nil {
+		return err
+	}
+	if first == '[' {
+		return streamJSONArray(buffered, handle)
+	}
+
+	// NDJSON: a bad line is skipped, the rest still load
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if err := handle(line, text); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// streamJSONArray decodes the objects of a JSON array one at a time, passing
+// each with the line it starts on
+func streamJSONArray(r io.Reader, handle func(line int, raw []byte) error) error {
+	counter := &newlineCounter{r: r}
+	decoder := json.NewDecoder(counter)
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		start := decoder.InputOffset() - int64(len(raw))
+		if err := handle(counter.lineAt(start), raw); err != nil {
+			return err
+		}
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+// jsonCustomer converts one JSON object into a Customer
+func jsonCustomer(raw []byte) (Customer, error) {
+	if raw[0] != '{' {
+		return Customer{}, fmt.Errorf("record is not an object")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		return Customer{}, err
+	}
+
+	flat := make(map[string]string)
+	flattenJSON("", record, flat)
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // "code" wins over "id", "customerName" over "name"
+
+	customer := Customer{Fields: make(map[string]string)}
+	normalise := strings.NewReplacer("_", "", " ", "", "-", "")
+	for _, key := range keys {
+		value := flat[key]
+		var target *string
+		switch jsonFieldNames[normalise.Replace(strings.ToLower(key))] {
+		case "Code":
+			target = &customer.Code
+		case "CustomerName":
+			target, value = &customer.CustomerName, sanitizeText(value)
+		case "Phone":
+			target = &customer.Phone
+		case "Mobile":
+			target = &customer.Mobile
+		case "HasWhatsApp":
+			target, value = &customer.HasWhatsApp, strings.ToLower(value)
+		}
+		if target != nil && *target == "" {
+			*target = value
+		} else {
+			customer.Fields[key] = value
+		}
+	}
+	return customer, nil
+}
+
+// flattenJSON turns nested objects into dotted keys and every value into text
+func flattenJSON(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenJSON(key, inner, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = strings.TrimSpace(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if text, ok := item.(string); ok {
+				parts = append(parts, text)
+			} else {
+				encoded, _ := json.Marshal(item)
+				parts = append(parts, string(encoded))
+			}
+		}
+		out[prefix] = strings.Join(parts, ", ")
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// firstNonSpace peeks at the first byte that isn't whitespace
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		head, err := r.Peek(n)
+		if len(head) < n {
+			return 0, err
+		}
+		if b := head[n-1]; b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, nil
+		}
+	}
+}
+
+// newlineCounter remembers where the newlines it has read are, so a byte
+// offset can be turned into a line number
+type newlineCounter struct {
+	r        io.Reader
+	read     int64
+	newlines []int64
+}
+
+func (c *newlineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt returns the 1-based line of a byte offset already read
+func (c *newlineCounter) lineAt(offset int64) int {
+	return sort.Search(len(c.newlines), func(i int) bool { return c.newlines[i] >= offset }) + 1
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	languageTemplates   = map[string][]string{} // language code -> templates from templates/<lang>/
+	languageTemplateIdx = map[string]int{}      // language code -> next template in rotation
+)
+
+// loadLanguageTemplates loads every templates/<lang>/ directory, keyed by lowercase directory name
+func loadLanguageTemplates() map[string][]string {
+	result := map[string][]string{}
+
+	entries, err := os.ReadDir("templates")
+	if err != nil {
+		return result
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		files, err := templateFilesIn(filepath.Join("templates", entry.Name()))
+		if err != nil {
+			continue
+		}
+		if templates := readTemplateFiles(files); len(templates) > 0 {
+			result[strings.ToLower(entry.Name())] = templates
+		}
+	}
+
+	return result
+}
+
+// customerLanguage returns the customer's language code from the Language column
+func customerLanguage(customer Customer) string {
+	lang := strings.ToLower(strings.TrimSpace(customer.Fields[config.LanguageColumn]))
+	if lang == "" {
+		lang = strings.ToLower(config.DefaultLanguage)
+	}
+	return lang
+}
+
+// templateForCustomer rotates through the customer's language templates,
+// falling back to the selected templates when their language has none
+func templateForCustomer(customer Customer) string {
+	lang := customerLanguage(customer)
+	templates, ok := languageTemplates[lang]
+	if !ok {
+		return getNextTemplateInPermutation()
+	}
+
+	idx := languageTemplateIdx[lang]
+	languageTemplateIdx[lang] = (idx + 1) % len(templates)
+	return templates[idx]
+}
+
+// displayLanguageCoverage summarizes customers per language and warns
+// about languages that will fall back to the default templates
+func displayLanguageCoverage(customers []Customer) {
+	if len(languageTemplates) == 0 {
+		return
+	}
+
+	counts := map[string]int{}
+	for _, c := range customers {
+		counts[customerLanguage(c)]++
+	}
+
+	langs := make([]string, 0, len(counts))
+	for lang := range counts {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	details := []string{}
+	missing := 0
+	for _, lang := range langs {
+		name := lang
+		if name == "" {
+			name = "(none)"
+		}
+		if templates, ok := languageTemplates[lang]; ok {
+			details = append(details, fmt.Sprintf("%s: %d customers, %d templates", name, counts[lang], len(templates)))
+		} else {
+			details = append(details, fmt.Sprintf("%s: %d customers, using default templates", name, counts[lang]))
+			missing += counts[lang]
+		}
+	}
+
+	displayInfo("Message Languages",
+		fmt.Sprintf("Templates found for %d languages", len(languageTemplates)), details)
+
+	if missing > 0 {
+		log.Warning(fmt.Sprintf("%d customers have no templates in their language and will get the default templates", missing))
+	}
+}
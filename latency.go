@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the report's latency histogram
+var latencyBuckets = []time.Duration{
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// latencyCreepFactor is how much slower the last quarter of a campaign's
+// sends must be than the first before the report warns of throttling
+const latencyCreepFactor = 2
+
+// latencyWindow is how many calls sendLatencies keeps from the start and
+// from the end of a campaign; past that it only counts them
+const latencyWindow = 10000
+
+// latencyResolution is the ratio between neighbouring buckets of the fine
+// histogram percentiles come from once there are too many calls to keep
+const latencyResolution = 1.02
+
+// latencyFineBuckets covers 1µs to well over an hour at latencyResolution
+const latencyFineBuckets = 1200
+
+// sendLatencies times every SendMessage call of the campaign message. Rising
+// latency is usually the first sign of server-side throttling, well before
+// sends start failing. Memory stays the same however long the campaign is.
+type sendLatencies struct {
+	mu     sync.Mutex
+	calls  int
+	head   []time.Duration // The first latencyWindow calls, in send order
+	tail   []time.Duration // Ring of the calls since, up to latencyWindow
+	next   int             // Slot of tail the next call goes into
+	counts []int           // Calls per latencyBuckets bucket
+	fine   []int           // Calls per latencyFineBucket, for percentiles
+}
+
+// latencies is the current campaign's send latencies
+var latencies = &sendLatencies{}
+
+// observe records one call's duration
+func (l *sendLatencies) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts == nil {
+		l.counts = make([]int, len(latencyBuckets)+1)
+		l.fine = make([]int, latencyFineBuckets)
+	}
+	l.calls++
+	i, _ := slices.BinarySearch(latencyBuckets, d)
+	l.counts[i]++
+	l.fine[latencyFineBucket(d)]++
+	switch {
+	case len(l.head) < latencyWindow:
+		l.head = append(l.head, d)
+	case len(l.tail) < latencyWindow:
+		l.tail = append(l.tail, d)
+	default:
+		l.tail[l.next] = d
+		l.next = (l.next + 1) % latencyWindow
+	}
+}
+
+// latencyFineBucket returns the fine histogram bucket of d: bucket i holds
+// latencies up to latencyResolution^i microseconds
+func latencyFineBucket(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	return min(int(math.Ceil(math.Log(us)/math.Log(latencyResolution))), latencyFineBuckets-1)
+}
+
+// percentiles returns the p50, p95 and p99 latencies, zero before any send.
+// They are exact up to latencyWindow calls and within 2% after.
+func (l *sendLatencies) percentiles() (p50, p95, p99 time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.calls == 0 {
+		return 0, 0, 0
+	}
+	if l.calls > len(l.head) {
+		return l.finePercentile(50), l.finePercentile(95), l.finePercentile(99)
+	}
+	sorted := slices.Clone(l.head)
+	slices.Sort(sorted)
+	return percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99)
+}
+
+// finePercentile returns the nearest-rank percentile p from the fine
+// histogram, as the upper bound of its bucket
+func (l *sendLatencies) finePercentile(p int) time.Duration {
+	rank := max((p*l.calls+99)/100, 1)
+	seen := 0
+	for i, n := range l.fine {
+		if seen += n; seen >= rank {
+			return time.Duration(math.Pow(latencyResolution, float64(i)) * float64(time.Microsecond))
+		}
+	}
+	return 0
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// creep compares the median latency of the last quarter of sends with the
+// first, or of the last and first latencyWindow sends of a longer campaign.
+// It reports false with too few sends to tell.
+func (l *sendLatencies) creep() (first, last time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	window := min(l.calls/4, latencyWindow)
+	if window < 5 {
+		return 0, 0, false
+	}
+	// The calls kept, in send order; the tail ring starts at next once full
+	kept := slices.Concat(l.head, l.tail[l.next:], l.tail[:l.next])
+	median := func(samples []time.Duration) time.Duration {
+		sorted := slices.Clone(samples)
+		slices.Sort(sorted)
+		return percentile(sorted, 50)
+	}
+	first, last = median(kept[:window]), median(kept[len(kept)-window:])
+	return first, last, last >= first*latencyCreepFactor
+}
+
+// displaySummary prints the latency percentiles and histogram
+func (l *sendLatencies) displaySummary() {
+	l.mu.Lock()
+	calls, counts := l.calls, slices.Clone(l.counts)
+	l.mu.Unlock()
+	if calls == 0 {
+		return
+	}
+	p50, p95, p99 := l.percentiles()
+
+	fmt.Println("SEND LATENCY")
+	fmt.Printf("p50 %s   p95 %s   p99 %s   (%d calls)\n", formatLatency(p50), formatLatency(p95), formatLatency(p99), calls)
+	for i, n := range counts {
+		label := "> " + formatLatency(latencyBuckets[len(latencyBuckets)-1])
+		if i < len(latencyBuckets) {
+			label = "≤ " + formatLatency(latencyBuckets[i])
+		}
+		bar := strings.Repeat("█", (n*30+calls-1)/calls)
+		fmt.Printf("  %-7s %5d %s\n", label, n, bar)
+	}
+	if first, last, creeping := l.creep(); creeping {
+		fmt.Printf("%sLatency rose from %s to %s over the campaign; WhatsApp may be throttling this number%s\n",
+			colorYellow, formatLatency(first), formatLatency(last), colorReset)
+	}
+	fmt.Println(strings.Repeat("─", 60))
+}
+
+// formatLatency shows a latency in milliseconds or seconds
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
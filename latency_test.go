@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		sorted := make([]time.Duration, len(values))
+		for i, v := range values {
+			sorted[i] = time.Duration(v) * time.Millisecond
+		}
+		return sorted
+	}
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      int
+		want   time.Duration
+	}{
+		{"single sample", ms(40), 50, 40 * time.Millisecond},
+		{"single sample p99", ms(40), 99, 40 * time.Millisecond},
+		{"median of ten", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 50, 5 * time.Millisecond},
+		{"p95 of ten is the largest", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 95, 10 * time.Millisecond},
+		{"p0 is the smallest", ms(1, 2, 3), 0, time.Millisecond},
+		{"p100 is the largest", ms(1, 2, 3), 100, 3 * time.Millisecond},
+		{"p50 of a hundred", ms(hundred...), 50, 50 * time.Millisecond},
+		{"p95 of a hundred", ms(hundred...), 95, 95 * time.Millisecond},
+		{"p99 of a hundred", ms(hundred...), 99, 99 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(p%d) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatencyPercentilesPastWindow(t *testing.T) {
+	l := &sendLatencies{}
+	calls := 3 * latencyWindow
+	for i := 1; i <= calls; i++ {
+		l.observe(time.Duration(i) * time.Millisecond)
+	}
+	p50, p95, p99 := l.percentiles()
+	for _, c := range []struct {
+		p         int
+		got, want time.Duration
+	}{
+		{50, p50, time.Duration(calls/2) * time.Millisecond},
+		{95, p95, time.Duration(calls*95/100) * time.Millisecond},
+		{99, p99, time.Duration(calls*99/100) * time.Millisecond},
+	} {
+		if c.got < c.want || float64(c.got) > float64(c.want)*latencyResolution {
+			t.Errorf("p%d = %v, want %v within %.0f%%", c.p, c.got, c.want, (latencyResolution-1)*100)
+		}
+	}
+	if len(l.head)+len(l.tail) > 2*latencyWindow {
+		t.Errorf("kept %d calls, want at most %d", len(l.head)+len(l.tail), 2*latencyWindow)
+	}
+	if first, last, creeping := l.creep(); !creeping || first >= last {
+		t.Errorf("creep() = %v, %v, %v; want a rise", first, last, creeping)
+	}
+}
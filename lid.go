@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// recipientJID is the chat to send a phone number's messages to. Accounts
+// that hide their number are addressed by their LID (hidden user ID) once
+// the mapping is known; everyone else, and every other transport, by number.
+func recipientJID(client Sender, phone string) types.JID {
+	pn := types.NewJID(phone, types.DefaultUserServer)
+	cli, ok := whatsAppClient(client)
+	if !ok || cli.Store.GetLID().IsEmpty() {
+		return pn
+	}
+	lid, err := cli.Store.LIDs.GetLIDForPN(context.Background(), pn)
+	if err != nil || lid.IsEmpty() {
+		return pn
+	}
+	return lid
+}
+
+// onWhatsAppByPhone indexes IsOnWhatsApp results by the number each one
+// answers. The server returns them in its own order and drops entries it
+// can't parse, so they can't be paired with the query list by position.
+func onWhatsAppByPhone(results []types.IsOnWhatsAppResponse) map[string]types.IsOnWhatsAppResponse {
+	byPhone := make(map[string]types.IsOnWhatsAppResponse, len(results))
+	for _, result := range results {
+		if result.Query != "" {
+			byPhone[strings.TrimPrefix(result.Query, "+")] = result
+		}
+	}
+	return byPhone
+}
+
+// rememberLIDs stores the LIDs that IsOnWhatsApp returned as canonical IDs,
+// so later sends and receipts resolve without another lookup. The session
+// database keeps them across runs.
+func rememberLIDs(client Sender, results []types.IsOnWhatsAppResponse) {
+	cli, ok := whatsAppClient(client)
+	if !ok {
+		return
+	}
+	var mappings []store.LIDMapping
+	for phone, result := range onWhatsAppByPhone(results) {
+		if result.IsIn && result.JID.Server == types.HiddenUserServer {
+			mappings = append(mappings, store.LIDMapping{
+				LID: result.JID.ToNonAD(),
+				PN:  types.NewJID(phone, types.DefaultUserServer),
+			})
+		}
+	}
+	if len(mappings) == 0 {
+		return
+	}
+	if err := cli.Store.LIDs.PutManyLIDMappings(context.Background(), mappings); err != nil {
+		log.Debug(fmt.Sprintf("Failed to cache LID mappings: %v", err))
+		return
+	}
+	log.Debug(fmt.Sprintf("Cached %d LID mapping(s)", len(mappings)))
+}
+
+// phoneForLID returns the phone number behind a LID, if the mapping is known
+func phoneForLID(client *whatsmeow.Client, lid types.JID) string {
+	if client == nil || lid.Server != types.HiddenUserServer {
+		return ""
+	}
+	pn, err := client.Store.LIDs.GetPNForLID(context.Background(), lid.ToNonAD())
+	if err != nil || pn.IsEmpty() {
+		return ""
+	}
+	return pn.User
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// LocationConfig appends a map pin of the customer's branch after each message
+type LocationConfig struct {
+	Enabled      bool
+	BranchesFile string         // CSV with Branch,Name,Address,Latitude,Longitude columns
+	Default      BranchLocation // Used when the customer has no known Branch
+}
+
+// BranchLocation is a pharmacy branch shown as a location pin
+type BranchLocation struct {
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+}
+
+// branches maps a Branch column value to its location
+var branches = map[string]BranchLocation{}
+
+// loadBranches reads the branches file configured in Location.BranchesFile
+func loadBranches(filename string) (map[string]BranchLocation, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]BranchLocation)
+	for i, record := range records {
+		if i == 0 || len(record) < 5 {
+			continue // header or incomplete row
+		}
+
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		lng, lngErr := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		if latErr != nil || lngErr != nil {
+			log.Warning(fmt.Sprintf("Skipping branch on line %d - invalid coordinates", i+1))
+			continue
+		}
+
+		result[strings.TrimSpace(record[0])] = BranchLocation{
+			Name:      strings.TrimSpace(record[1]),
+			Address:   strings.TrimSpace(record[2]),
+			Latitude:  lat,
+			Longitude: lng,
+		}
+	}
+
+	return result, nil
+}
+
+// branchForCustomer returns the location pin for a customer, falling back to the default branch
+func branchForCustomer(customer Customer) (BranchLocation, bool) {
+	if branch, ok := branches[customer.Fields["Branch"]]; ok {
+		return branch, true
+	}
+
+	def := config.Location.Default
+	if def.Latitude == 0 && def.Longitude == 0 {
+		return BranchLocation{}, false
+	}
+	return def, true
+}
+
+// sendBranchLocation sends the customer's branch as a location message.
+// Failures are logged but don't count against the customer's main message.
+func sendBranchLocation(client Sender, customer ProcessedCustomer, phone string) {
+	branch, ok := branchForCustomer(customer.Customer)
+	if !ok {
+		log.Warning(fmt.Sprintf("No branch location for %s (Branch: %q)", customer.CustomerName, customer.Fields["Branch"]))
+		return
+	}
+
+	jid := recipientJID(client, phone)
+	_, err := client.SendMessage(context.Background(), jid, &waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(branch.Latitude),
+			DegreesLongitude: proto.Float64(branch.Longitude),
+			Name:             proto.String(branch.Name),
+			Address:          proto.String(branch.Address),
+		},
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to send branch location to %s", customer.CustomerName), err)
+	}
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,16 +21,17 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mdp/qrterminal/v3"
 	"go.mau.fi/whatsmeow"
-	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
 
 	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
 )
 
-// Color codes for console output
-const (
+// Color codes for console output; cleared by --plain
+var (
 	colorReset   = "\033[0m"
 	colorRed     = "\033[31m"
 	colorGreen   = "\033[32m"
@@ -100,7 +105,7 @@ func (l *logger) log(level, color, message string) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 
 	// Console output with color
-	fmt.Printf("%s[%s] %-7s%s %s\n", color, timestamp, level, colorReset, message)
+	fmt.Fprintf(console, "%s[%s] %-7s%s %s\n", color, timestamp, level, colorReset, message)
 
 	// File output
 	if l.logFile != nil {
@@ -144,6 +149,9 @@ func (l *logger) Error(message string, err error) {
 		timestamp := time.Now().Format("2006-01-02 15:04:05")
 		l.errorFile.WriteString(fmt.Sprintf("[%s] %s\n", timestamp, errorMsg))
 	}
+
+	// And to the error tracker, if one is configured
+	reportError(message, err)
 }
 
 // Debug logs a debug message
@@ -171,6 +179,8 @@ type Customer struct {
 	Phone        string
 	Mobile       string
 	HasWhatsApp  string // "yes", "no", or "" (unchecked)
+
+	Fields map[string]string // Extra CSV columns by header name (e.g. "Branch")
 }
 
 // ProcessedCustomer represents a validated customer
@@ -180,15 +190,29 @@ type ProcessedCustomer struct {
 	FormattedPhone  string
 	IsValid         bool
 	ValidationError string
+	ConsentBasis    string // Why this customer may be messaged, for the audit log
 }
 
 // MessageResult represents the result of sending a message
 type MessageResult struct {
-	Customer   ProcessedCustomer
-	Success    bool
-	Timestamp  time.Time
-	Error      string
-	RetryCount int
+	Customer      ProcessedCustomer
+	Success       bool
+	Timestamp     time.Time
+	Error         string
+	FailureReason string // Bucket of Error, e.g. "not-on-whatsapp" (see failures.go)
+	RetryCount    int
+
+	SentTo       string // Formatted number the message was (last) sent to
+	UsedFallback bool   // True if SentTo is the customer's other number
+	SentFrom     string // Our number it went out from, e.g. the standby's after a switch
+
+	Template      string // Template the message was rendered from
+	TemplateLabel string // Its place in the rotation, e.g. "#2" (see templateLabel)
+	Message       string // Rendered text
+	MessageID     string // WhatsApp ID of the first part, for delivery receipts
+
+	ProfileName  string // Name the number has on WhatsApp, with CheckProfileNames
+	NameMismatch bool   // ProfileName has nothing in common with CustomerName
 }
 
 // Config holds application configuration
@@ -202,13 +226,21 @@ type Config struct {
 	MaxRetries      int
 	CountryCode     string
 	PhoneLength     int
+	AllowedPrefixes []string // Formatted numbers must start with one of these (empty allows any)
 	SkipInvalid     bool
 	PreferMobile    bool
+	PhoneFallback   bool // Try the other number if the selected one isn't on WhatsApp
 	ContinueOnError bool
 	SaveFailed      bool
-	SkipDuplicates  bool // Skip duplicate phone numbers
-	PreCheckNumbers bool // Pre-check all numbers before sending
-	CheckDelay      int  // Delay between checks (milliseconds)
+	SkipDuplicates  bool   // Skip duplicate phone numbers
+	DedupeColumn    string // With SkipDuplicates, also skip rows repeating this column, e.g. "Code" ("" = phone only)
+	PreCheckNumbers bool   // Pre-check all numbers before sending
+	CheckDelay      int    // Delay between checks (milliseconds)
+	CheckBatchSize  int    // Numbers per IsOnWhatsApp call
+	CheckBatchMin   int    // Smallest batch size when backing off after errors
+	CheckDelayMax   int    // Longest delay between checks when backing off (milliseconds)
+	CheckMaxErrors  int    // Consecutive check errors before giving up
+	CheckWorkers    int    // Batches checked at the same time
 
 	// Anti-blocking features
 	HourlyLimit       int     // Max messages per hour
@@ -217,6 +249,152 @@ type Config struct {
 	SimulateTyping    bool    // Simulate typing before sending
 	AddJitter         bool    // Add random micro-delays
 	LongPauseChance   float32 // Chance of taking a long pause (0.0-1.0)
+	ReserveHeadroom   float64 // Share of HourlyLimit left for staff using the phone (0.0 to below 1.0, 0 = off)
+	ManualCoolDown    int     // Seconds to pause after a call or a burst of messages from the phone (0 = off)
+
+	// Campaign type: "text" (default), "poll" or "invite"
+	CampaignType string
+	Poll         PollConfig
+	Invite       InviteConfig
+
+	// WhatsApp Channel broadcasting
+	Channel ChannelConfig
+
+	// Branch location pin appended to each message
+	Location LocationConfig
+
+	// Voice note sent after each message
+	VoiceNote VoiceNoteConfig
+
+	// Media album sent after each message
+	Album AlbumConfig
+
+	// Per-language templates (templates/<lang>/)
+	LanguageColumn  string // CSV column with the customer's language code
+	DefaultLanguage string // Language for customers with an empty column ("" = selected templates)
+	DateFormat      string // Go time layout for the {Date} placeholder
+
+	// Unsubscribe footer and suppression list
+	OptOut OptOutConfig
+
+	// CSV input encoding: "auto", "utf-8", "utf-16" or "windows-1256"
+	CSVEncoding string
+	// CSV delimiter: "auto", ",", ";", "tab" or "|"
+	CSVDelimiter string
+
+	// Clean names and templates (zero-width chars, presentation forms, mojibake)
+	SanitizeText bool
+
+	// Message length guard
+	AbortOnEmpty bool // Stop the campaign if a message renders empty
+	SplitLength  int  // Split messages longer than this many characters into two sends (0 = never)
+
+	// Near-duplicate detection
+	FuzzyDedupe     bool   // Detect the same customer under slightly different names/numbers
+	DuplicateWinner string // Which near-duplicate record to keep: "ask", "first" or "last"
+
+	// Session database (SQLite file by default)
+	Session SessionConfig
+
+	// Pause and reconnect when the connection drops mid-campaign
+	Watchdog WatchdogConfig
+
+	// Account health check before the first send
+	Preflight PreflightConfig
+
+	// Fake transport for training and dry runs (--simulate)
+	Simulate SimulateConfig
+
+	// Transport: "whatsmeow" (linked personal/business number, default), "cloud" or "twilio"
+	Transport         string
+	FallbackTransport string // Used for the rest of the campaign if Transport is logged out or banned
+	Cloud             CloudConfig
+	Twilio            TwilioConfig
+
+	// Fan out across channels (WhatsApp, Cloud API, SMS, email) per customer
+	Channels ChannelsConfig
+	Email    EmailConfig
+
+	// Live terminal dashboard while sending
+	Dashboard DashboardConfig
+	// Browser dashboard while sending (--web)
+	Web WebConfig
+
+	// Line-oriented output without colors or progress bars (--plain)
+	PlainOutput bool
+	// Output format: "text" (default) or "ndjson" for one JSON event per line on stdout
+	Output string
+	// Console language for menus, messages and reports: "en" or "ar" (--ui-lang)
+	UILanguage string
+
+	// Operator notifications on completion, daily limit or stall
+	Notify NotifyConfig
+
+	// Email the HTML report and results CSV when the campaign finishes
+	ReportEmail ReportEmailConfig
+
+	// Every send across campaigns, for 'venom lookup'
+	History HistoryConfig
+
+	// Consent required before messaging
+	Consent ConsentConfig
+	// Append-only audit log of every send attempt, one JSONL file per day ("" = off)
+	AuditDir string
+	// Per-account send counts shared by every process on this machine, so
+	// HourlyLimit and DailyLimit hold across concurrent campaigns ("" = off)
+	QuotaFile string
+
+	// Encrypt customer data, checkpoints and send history at rest
+	DataEncryption DataEncryptionConfig
+
+	// Share one campaign between several instances through Redis
+	Distributed DistributedConfig
+
+	// Folder ingestion for 'venom watch'
+	Watch WatchConfig
+
+	// Google Sheets as the customer list, with send status written back
+	Sheets SheetsConfig
+	// Customers straight from the pharmacy database (sql://<query name>)
+	SQLSource SQLSourceConfig
+
+	// Reply to each customer's last message instead of writing cold
+	QuoteReplies QuoteRepliesConfig
+
+	// Disappearing campaign messages: "24h", "7d" or "90d" (empty = off)
+	DisappearAfter string
+
+	// Per-customer PDF (statement, voucher) sent after each message
+	Document DocumentConfig
+
+	// Promo image with the customer's name, code or QR drawn on it
+	PersonalImage PersonalImageConfig
+
+	// Voucher code from the CSV sent as a QR code or barcode image
+	Voucher VoucherConfig
+
+	// Record each recipient's WhatsApp name and warn when it doesn't match CustomerName
+	CheckProfileNames bool
+	// Skip numbers with neither a profile picture nor a name (likely dead or virtual)
+	SkipGhostNumbers bool
+
+	// Progress as JSON on GET /status (--status) and in data/progress.json
+	Status StatusConfig
+
+	// OpenTelemetry traces and metrics exported over OTLP
+	Telemetry TelemetryConfig
+
+	// Panics and errors sent to Sentry or a webhook
+	ErrorReport ErrorReportConfig
+
+	// Serve Go runtime profiles on this address, e.g. "localhost:6060" (--pprof)
+	Pprof string
+
+	// Lists longer than this are loaded, validated and sent a chunk at a time (0 = always whole)
+	ChunkSize int
+
+	// Linked numbers a campaign is split across by each one's daily budget
+	Accounts []AccountBudget
 }
 
 // ProgressTracker tracks messaging progress
@@ -228,7 +406,9 @@ type ProgressTracker struct {
 	Skipped    int
 	Duplicates int // Count of duplicate phone numbers
 	StartTime  time.Time
-	Delays     []int
+	DelayTotal int // Sum of the delays between sends (ms), for the average
+	DelayCount int
+	Remaining  int // Customers the send loop has not handled yet
 
 	// Rate limiting
 	HourlySent    int
@@ -248,13 +428,20 @@ var (
 		MaxRetries:      3,
 		CountryCode:     "20",
 		PhoneLength:     12,
+		AllowedPrefixes: []string{"2010", "2011", "2012", "2015"}, // Egyptian mobile operators
 		SkipInvalid:     true,
 		PreferMobile:    true,
+		PhoneFallback:   true,
 		ContinueOnError: true,
 		SaveFailed:      true,
 		SkipDuplicates:  true,  // Skip duplicate phone numbers by default
 		PreCheckNumbers: false, // Don't pre-check by default (to avoid rate limiting)
 		CheckDelay:      2000,  // 2 seconds between checks
+		CheckBatchSize:  50,    // Check 50 numbers at a time
+		CheckBatchMin:   5,     // Back off down to 5 numbers per check
+		CheckDelayMax:   60000, // Back off up to 1 minute between checks
+		CheckMaxErrors:  6,     // Give up after 6 failed checks in a row
+		CheckWorkers:    3,     // Three batches in flight
 
 		// Anti-blocking defaults
 		HourlyLimit:       100,  // Max 100 messages per hour
@@ -263,11 +450,184 @@ var (
 		SimulateTyping:    true, // Simulate typing
 		AddJitter:         true, // Add random micro-delays
 		LongPauseChance:   0.05, // 5% chance of long pause
+		ManualCoolDown:    120,  // Two minutes off after a call or a burst of staff messages
+
+		// Campaign defaults
+		CampaignType: "text",
+		Poll: PollConfig{
+			SelectableCount: 1,
+			ResultsFile:     "data/poll-results.csv",
+		},
+		Invite: InviteConfig{
+			ResultsFile:  "data/invite-results.csv",
+			TrackMinutes: 30,
+		},
+
+		// Language defaults
+		LanguageColumn: "Language",
+		DateFormat:     "02/01/2006",
+
+		// Opt-out defaults
+		OptOut: OptOutConfig{
+			AppendFooter:    false,
+			Footer:          "للإلغاء أرسل {Keyword}",
+			Keywords:        []string{"وقف", "الغاء", "stop"},
+			Confirmation:    "تم إلغاء اشتراكك ولن تصلك رسائل أخرى.",
+			SuppressionFile: "data/suppression-list.csv",
+			IndexAbove:      100000,
+		},
+
+		CSVEncoding:  "auto",
+		CSVDelimiter: "auto",
+		SanitizeText: true,
+
+		// Message length defaults
+		AbortOnEmpty: true,
+		SplitLength:  0,
+
+		// Near-duplicate defaults
+		FuzzyDedupe:     true,
+		DuplicateWinner: "first", // Unattended runs can't be asked which record wins
+
+		// Session store defaults
+		Session: SessionConfig{
+			Driver:        "sqlite3",
+			DSN:           "file:whatsapp_session.db?_foreign_keys=on",
+			EncryptedFile: "whatsapp_session.db.enc",
+			Platform:      "desktop",
+		},
+
+		// Reconnect defaults
+		Watchdog: WatchdogConfig{
+			BackoffMin:     5000,   // Check again after 5 seconds
+			BackoffMax:     300000, // Back off up to 5 minutes between attempts
+			MaxWaitMinutes: 30,     // Stop after 30 minutes offline
+		},
+
+		// Pre-flight defaults
+		Preflight: PreflightConfig{
+			Enabled:  true,
+			SelfPing: true,
+			Message:  "✅ Venom pre-flight check",
+		},
+
+		// Simulation defaults
+		Simulate: SimulateConfig{
+			LatencyMin:        300,
+			LatencyMax:        1500,
+			FailureRate:       0.05, // 5% transient send failures
+			NotOnWhatsAppRate: 0.10, // 10% of numbers have no WhatsApp
+		},
+
+		// Transport defaults
+		Transport: "whatsmeow",
+		Cloud: CloudConfig{
+			APIVersion: "v21.0",
+			Language:   "ar",
+		},
+
+		// Channel defaults
+		Channels: ChannelsConfig{
+			Column: "Channel",
+		},
+		Email: EmailConfig{
+			Port:    587,
+			Subject: "رسالة جديدة لك يا {CustomerName}",
+			Column:  "Email",
+		},
+
+		// Dashboard defaults
+		Dashboard: DashboardConfig{
+			Enabled:  true,
+			LogLines: 200,
+		},
+
+		// Notification defaults
+		Notify: NotifyConfig{
+			Desktop:     true,
+			Bell:        true,
+			StallErrors: 10,  // 10 failed sends in a row
+			SpikeRate:   0.5, // Half of the last 20 sends failed
+		},
+
+		// Report email defaults
+		ReportEmail: ReportEmailConfig{
+			Subject: "Venom campaign report",
+		},
+
+		// Send history defaults
+		History: HistoryConfig{
+			Enabled:       true,
+			File:          "data/history.db",
+			DedupeDays:    3,  // Guards against running the same file twice
+			OpenChatHours: 48, // Customers mid-conversation get a person, not a template
+			FollowUpFile:  "data/manual-follow-up.csv",
+		},
+
+		// Consent defaults
+		Consent: ConsentConfig{
+			Required:     true,
+			DateColumn:   "ConsentDate",
+			SourceColumn: "ConsentSource",
+		},
+		AuditDir:  "audit",
+		QuotaFile: "data/quota.db",
+
+		// Data encryption defaults
+		DataEncryption: DataEncryptionConfig{
+			KeyringService: "venom",
+		},
+
+		// Watch folder defaults
+		Watch: WatchConfig{
+			Dir:          "incoming",
+			ProcessedDir: "processed",
+			RejectedDir:  "rejected",
+			Interval:     30,
+		},
+
+		// Google Sheets defaults
+		Sheets: SheetsConfig{
+			StatusColumn: "Status",
+		},
+
+		// Quoted reply defaults
+		QuoteReplies: QuoteRepliesConfig{
+			WithinDays: 14,
+		},
+
+		// Per-customer document defaults
+		Document: DocumentConfig{
+			FileName: "Document-{Code}.pdf",
+		},
+
+		// Voucher defaults
+		Voucher: VoucherConfig{
+			Column: "Voucher",
+		},
+
+		// Progress file for following a campaign from another terminal
+		Status: StatusConfig{
+			File:     "data/progress.json",
+			Interval: 5,
+		},
+
+		// Telemetry defaults
+		Telemetry: TelemetryConfig{
+			ServiceName: "venom",
+		},
+
+		// Error reporting defaults
+		ErrorReport: ErrorReportConfig{
+			MaxEvents: 50,
+		},
+
+		// Stream lists of more than 50,000 customers
+		ChunkSize: 50000,
 	}
 
 	progress = &ProgressTracker{
 		StartTime:     time.Now(),
-		Delays:        []int{},
 		LastHourReset: time.Now(),
 		LastDayReset:  time.Now(),
 	}
@@ -280,79 +640,89 @@ var (
 
 	log                    *logger
 	failedCustomers        []Customer
+	csvHeader              []string // Header of the last CSV read
+	csvExtraColumns        []string // Extra CSV column names, in file order
 	selectedTemplates      []string // User-selected message templates
 	templatePermutationIdx int      // Current template index for permutation
 )
 
 // displayError shows a professional error message with context and suggestions
 func displayError(title, message, action string, suggestions []string) {
-	fmt.Println()
-	fmt.Println(bgRed + colorWhite + bold + " ✗ ERROR " + colorReset)
-	fmt.Println(colorBrightRed + "┌─ " + title + colorReset)
-	fmt.Println(colorRed + "│" + colorReset)
-	fmt.Println(colorRed + "│  " + colorReset + message)
-	fmt.Println(colorRed + "│" + colorReset)
+	title, message, action, suggestions = tr(title), tr(message), tr(action), trAll(suggestions)
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, bgRed+colorWhite+bold+tr(" ✗ ERROR ")+colorReset)
+	fmt.Fprintln(console, colorBrightRed+"┌─ "+title+colorReset)
+	fmt.Fprintln(console, colorRed+"│"+colorReset)
+	fmt.Fprintln(console, colorRed+"│  "+colorReset+message)
+	fmt.Fprintln(console, colorRed+"│"+colorReset)
 
 	if action != "" {
-		fmt.Println(colorRed + "├─ " + colorYellow + "What to do:" + colorReset)
-		fmt.Println(colorRed + "│  " + colorReset + action)
-		fmt.Println(colorRed + "│" + colorReset)
+		fmt.Fprintln(console, colorRed+"├─ "+colorYellow+tr("What to do:")+colorReset)
+		fmt.Fprintln(console, colorRed+"│  "+colorReset+action)
+		fmt.Fprintln(console, colorRed+"│"+colorReset)
 	}
 
 	if len(suggestions) > 0 {
-		fmt.Println(colorRed + "├─ " + colorCyan + "Suggestions:" + colorReset)
+		fmt.Fprintln(console, colorRed+"├─ "+colorCyan+tr("Suggestions:")+colorReset)
 		for _, suggestion := range suggestions {
-			fmt.Println(colorRed + "│  " + colorReset + dim + "• " + suggestion + colorReset)
+			fmt.Fprintln(console, colorRed+"│  "+colorReset+dim+"• "+suggestion+colorReset)
 		}
-		fmt.Println(colorRed + "│" + colorReset)
+		fmt.Fprintln(console, colorRed+"│"+colorReset)
 	}
 
-	fmt.Println(colorRed + "└─" + strings.Repeat("─", 58) + colorReset)
-	fmt.Println()
+	fmt.Fprintln(console, colorRed+"└─"+strings.Repeat("─", 58)+colorReset)
+	fmt.Fprintln(console)
 }
 
 // displayWarning shows a professional warning message
 func displayWarning(title, message string, tips []string) {
-	fmt.Println()
-	fmt.Println(bgYellow + colorWhite + bold + " ⚠ WARNING " + colorReset)
-	fmt.Println(colorYellow + "┌─ " + title + colorReset)
-	fmt.Println(colorYellow + "│  " + colorReset + message)
+	title, message, tips = tr(title), tr(message), trAll(tips)
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, bgYellow+colorWhite+bold+tr(" ⚠ WARNING ")+colorReset)
+	fmt.Fprintln(console, colorYellow+"┌─ "+title+colorReset)
+	fmt.Fprintln(console, colorYellow+"│  "+colorReset+message)
 
 	if len(tips) > 0 {
-		fmt.Println(colorYellow + "│" + colorReset)
-		fmt.Println(colorYellow + "├─ " + colorCyan + "Tips:" + colorReset)
+		fmt.Fprintln(console, colorYellow+"│"+colorReset)
+		fmt.Fprintln(console, colorYellow+"├─ "+colorCyan+tr("Tips:")+colorReset)
 		for _, tip := range tips {
-			fmt.Println(colorYellow + "│  " + colorReset + dim + "• " + tip + colorReset)
+			fmt.Fprintln(console, colorYellow+"│  "+colorReset+dim+"• "+tip+colorReset)
 		}
 	}
 
-	fmt.Println(colorYellow + "└─" + strings.Repeat("─", 58) + colorReset)
-	fmt.Println()
+	fmt.Fprintln(console, colorYellow+"└─"+strings.Repeat("─", 58)+colorReset)
+	fmt.Fprintln(console)
 }
 
 // displaySuccess shows a professional success message
 func displaySuccess(title, message string) {
-	fmt.Println()
-	fmt.Println(bgGreen + colorWhite + bold + " ✓ SUCCESS " + colorReset)
-	fmt.Println(colorBrightGreen + "┌─ " + title + colorReset)
-	fmt.Println(colorGreen + "│  " + colorReset + message)
-	fmt.Println(colorGreen + "└─" + strings.Repeat("─", 58) + colorReset)
-	fmt.Println()
+	title, message = tr(title), tr(message)
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, bgGreen+colorWhite+bold+tr(" ✓ SUCCESS ")+colorReset)
+	fmt.Fprintln(console, colorBrightGreen+"┌─ "+title+colorReset)
+	fmt.Fprintln(console, colorGreen+"│  "+colorReset+message)
+	fmt.Fprintln(console, colorGreen+"└─"+strings.Repeat("─", 58)+colorReset)
+	fmt.Fprintln(console)
 }
 
 // displayInfo shows a professional info message
 func displayInfo(title, message string, details []string) {
-	fmt.Println()
-	fmt.Println(colorBrightCyan + "ℹ " + bold + title + colorReset)
-	fmt.Println(colorCyan + "  " + colorReset + message)
+	title, message, details = tr(title), tr(message), trAll(details)
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colorBrightCyan+"ℹ "+bold+title+colorReset)
+	fmt.Fprintln(console, colorCyan+"  "+colorReset+message)
 
 	if len(details) > 0 {
-		fmt.Println()
+		fmt.Fprintln(console)
 		for _, detail := range details {
-			fmt.Println(colorCyan + "  • " + colorReset + dim + detail + colorReset)
+			fmt.Fprintln(console, colorCyan+"  • "+colorReset+dim+detail+colorReset)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(console)
 }
 
 // displayProgress shows a professional progress indicator
@@ -364,52 +734,63 @@ func displayProgressBar(current, total int, label string) {
 	bar := colorBrightGreen + strings.Repeat("█", filled) + colorReset +
 		colorGray + strings.Repeat("░", barWidth-filled) + colorReset
 
-	fmt.Printf("\r  %s [%s] %.1f%% (%d/%d)", label, bar, percentage, current, total)
+	if plainOutput {
+		// One line per tenth, so logs don't get a line per item
+		if step := max(total/10, 1); current%step == 0 || current == total {
+			fmt.Fprintf(console, "  %s %.1f%% (%d/%d)\n", label, percentage, current, total)
+		}
+		return
+	}
+
+	fmt.Fprintf(console, "\r  %s [%s] %.1f%% (%d/%d)", label, bar, percentage, current, total)
 
 	if current == total {
-		fmt.Println() // New line when complete
+		fmt.Fprintln(console) // New line when complete
 	}
 }
 
 // loadTemplatesFromFiles reads all .txt and .md files in current directory
 func loadTemplatesFromFiles() ([]string, error) {
-	templates := make([]string, 0)
-	templateFiles := make([]string, 0)
-
 	// Read current directory
-	files, err := os.ReadDir(".")
+	templateFiles, err := templateFilesIn(".")
+	if err != nil {
+		return nil, err
+	}
+
+	// Also check templates/ directory if it exists
+	if _, err := os.Stat("templates"); err == nil {
+		if files, err := templateFilesIn("templates"); err == nil {
+			templateFiles = append(templateFiles, files...)
+		}
+	}
+
+	return readTemplateFiles(templateFiles), nil
+}
+
+// templateFilesIn lists the .txt and .md files in a directory
+func templateFilesIn(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	// Find all .txt and .md files
+	templateFiles := make([]string, 0)
 	for _, file := range files {
 		if file.IsDir() {
 			continue
 		}
 		name := file.Name()
 		if strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".md") {
-			templateFiles = append(templateFiles, name)
+			templateFiles = append(templateFiles, filepath.Join(dir, name))
 		}
 	}
+	return templateFiles, nil
+}
 
-	// Also check templates/ directory if it exists
-	if _, err := os.Stat("templates"); err == nil {
-		templateDir, err := os.ReadDir("templates")
-		if err == nil {
-			for _, file := range templateDir {
-				if file.IsDir() {
-					continue
-				}
-				name := file.Name()
-				if strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".md") {
-					templateFiles = append(templateFiles, "templates/"+name)
-				}
-			}
-		}
-	}
+// readTemplateFiles reads the content of each template file, skipping empty ones
+func readTemplateFiles(templateFiles []string) []string {
+	templates := make([]string, 0)
 
-	// Read content from each file
 	for _, filename := range templateFiles {
 		content, err := os.ReadFile(filename)
 		if err != nil {
@@ -418,14 +799,17 @@ func loadTemplatesFromFiles() ([]string, error) {
 		}
 
 		// Skip empty files
-		text := strings.TrimSpace(string(content))
+		text := strings.TrimSpace(sanitizeText(string(content)))
 		if text != "" {
+			if strings.HasSuffix(filename, ".md") {
+				markdownTemplates[text] = true
+			}
 			templates = append(templates, text)
 			log.Info(fmt.Sprintf("Loaded template from: %s (%d chars)", filename, len(text)))
 		}
 	}
 
-	return templates, nil
+	return templates
 }
 
 // selectTemplatesInteractive allows user to select which templates to use
@@ -441,10 +825,10 @@ func selectTemplatesInteractive(templates []string) ([]string, error) {
 		return messageTemplates, nil
 	}
 
-	fmt.Println(bold + colorBrightCyan + "\n📝 Message Templates Found" + colorReset)
-	fmt.Println(colorCyan + strings.Repeat("─", 60) + colorReset)
-	fmt.Println(dim + fmt.Sprintf("Found %d template files", len(templates)) + colorReset)
-	fmt.Println()
+	fmt.Fprintln(console, bold+colorBrightCyan+tr("\n📝 Message Templates Found")+colorReset)
+	fmt.Fprintln(console, colorCyan+strings.Repeat("─", 60)+colorReset)
+	fmt.Fprintln(console, dim+trf("Found %d template files", len(templates))+colorReset)
+	fmt.Fprintln(console)
 
 	// Show preview of each template
 	templatePreviews := make([]string, len(templates))
@@ -455,15 +839,18 @@ func selectTemplatesInteractive(templates []string) ([]string, error) {
 		}
 		// Replace newlines with space for preview
 		preview = strings.ReplaceAll(preview, "\n", " ")
-		templatePreviews[i] = fmt.Sprintf("Template %d: %s", i+1, preview)
+		templatePreviews[i] = trf("Template %d: %s", i+1, preview)
+		if isRTLText(preview) {
+			templatePreviews[i] = rtlMark + templatePreviews[i]
+		}
 	}
 
 	// Add option to use all templates
-	templatePreviews = append(templatePreviews, colorBrightGreen+"✓ Use ALL templates (Recommended)"+colorReset)
-	templatePreviews = append(templatePreviews, colorYellow+"⚙ Use built-in default templates"+colorReset)
+	templatePreviews = append(templatePreviews, colorBrightGreen+tr("✓ Use ALL templates (Recommended)")+colorReset)
+	templatePreviews = append(templatePreviews, colorYellow+tr("⚙ Use built-in default templates")+colorReset)
 
 	prompt := promptui.Select{
-		Label: "Select templates to use",
+		Label: tr("Select templates to use"),
 		Items: templatePreviews,
 		Size:  10,
 	}
@@ -476,7 +863,7 @@ func selectTemplatesInteractive(templates []string) ([]string, error) {
 	// Use all templates
 	if idx == len(templates) {
 		displaySuccess("Templates Selected",
-			fmt.Sprintf("Using all %d templates in permutation mode", len(templates)))
+			trf("Using all %d templates in permutation mode", len(templates)))
 		return templates, nil
 	}
 
@@ -485,14 +872,14 @@ func selectTemplatesInteractive(templates []string) ([]string, error) {
 		displayInfo("Using Defaults",
 			"Using built-in Arabic message templates",
 			[]string{
-				fmt.Sprintf("%d default templates available", len(messageTemplates)),
+				trf("%d default templates available", len(messageTemplates)),
 			})
 		return messageTemplates, nil
 	}
 
 	// Use single selected template
 	displaySuccess("Template Selected",
-		fmt.Sprintf("Using template %d", idx+1))
+		trf("Using template %d", idx+1))
 	return []string{templates[idx]}, nil
 }
 
@@ -510,7 +897,9 @@ func getNextTemplateInPermutation() string {
 // displayWelcomeBanner displays a beautiful welcome banner
 func displayWelcomeBanner() {
 	// Clear screen for clean start
-	fmt.Print("\033[H\033[2J")
+	if !plainOutput {
+		fmt.Fprint(console, "\033[H\033[2J")
+	}
 
 	banner := `
 ╔══════════════════════════════════════════════════════════════╗
@@ -522,36 +911,36 @@ func displayWelcomeBanner() {
 ║                                                              ║
 ╚══════════════════════════════════════════════════════════════╝
 `
-	fmt.Println(bold + colorBrightCyan + banner + colorReset)
+	fmt.Fprintln(console, bold+colorBrightCyan+banner+colorReset)
 
 	// Display safety notice
-	fmt.Println(colorYellow + "⚠️  Important:" + colorReset + " This tool sends bulk messages. Use responsibly.")
-	fmt.Println(dim + "   Follow WhatsApp's terms of service and local regulations." + colorReset)
-	fmt.Println()
+	fmt.Fprintln(console, colorYellow+"⚠️  Important:"+colorReset+" This tool sends bulk messages. Use responsibly.")
+	fmt.Fprintln(console, dim+"   Follow WhatsApp's terms of service and local regulations."+colorReset)
+	fmt.Fprintln(console)
 }
 
 // configureInteractive prompts user for configuration
 func configureInteractive() error {
-	fmt.Println(bold + colorBrightCyan + "\n⚙️  Configuration Setup" + colorReset)
-	fmt.Println(colorCyan + strings.Repeat("─", 60) + colorReset)
-	fmt.Println(dim + "Let's configure your bulk messaging campaign" + colorReset)
-	fmt.Println()
+	fmt.Fprintln(console, bold+colorBrightCyan+tr("\n⚙️  Configuration Setup")+colorReset)
+	fmt.Fprintln(console, colorCyan+strings.Repeat("─", 60)+colorReset)
+	fmt.Fprintln(console, dim+tr("Let's configure your bulk messaging campaign")+colorReset)
+	fmt.Fprintln(console)
 
 	// CSV File selection
 	csvPrompt := promptui.Prompt{
-		Label:   "CSV File Path",
-		Default: "customers.csv",
+		Label:   tr("CSV File Path"),
+		Default: campaignCSV,
 	}
 	csvFile, err := csvPrompt.Run()
 	if err != nil {
 		return err
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(csvFile); os.IsNotExist(err) {
-		fmt.Println()
+	// Check if file exists; a URL is downloaded once configuration is done
+	if _, err := os.Stat(csvFile); os.IsNotExist(err) && !isRemoteCSV(csvFile) {
+		fmt.Fprintln(console)
 		displayError("File Not Found",
-			fmt.Sprintf("Cannot find CSV file: %s", csvFile),
+			trf("Cannot find CSV file: %s", csvFile),
 			"Please check the file path and try again",
 			[]string{
 				"Ensure the file exists in the specified location",
@@ -561,18 +950,24 @@ func configureInteractive() error {
 		return fmt.Errorf("CSV file not found")
 	}
 
+	campaignCSV = csvFile
+
 	// Show file info
-	fileInfo, _ := os.Stat(csvFile)
-	fmt.Printf(colorBrightGreen+"  ✓ CSV file found"+colorReset+": %s "+dim+"(%d bytes)"+colorReset+"\n",
-		csvFile, fileInfo.Size())
+	if isRemoteCSV(csvFile) {
+		fmt.Fprintf(console, colorBrightGreen+tr("  ✓ CSV will be downloaded")+colorReset+": %s\n", csvFile)
+	} else {
+		fileInfo, _ := os.Stat(csvFile)
+		fmt.Fprintf(console, colorBrightGreen+tr("  ✓ CSV file found")+colorReset+": %s "+dim+tr("(%d bytes)")+colorReset+"\n",
+			csvFile, fileInfo.Size())
+	}
 
 	// Configuration mode selection
 	modePrompt := promptui.Select{
-		Label: "Configuration Mode",
-		Items: []string{
+		Label: tr("Configuration Mode"),
+		Items: trAll([]string{
 			"Quick Start (Recommended defaults)",
 			"Custom Configuration (Advanced)",
-		},
+		}),
 	}
 	modeIdx, _, err := modePrompt.Run()
 	if err != nil {
@@ -586,14 +981,14 @@ func configureInteractive() error {
 		}
 	} else {
 		// Use defaults
-		fmt.Println(colorCyan + "\n✓ Using recommended defaults" + colorReset)
+		fmt.Fprintln(console, colorCyan+tr("\n✓ Using recommended defaults")+colorReset)
 		displayCurrentConfig()
 	}
 
 	// Confirmation
 	confirmPrompt := promptui.Select{
-		Label: "Ready to start?",
-		Items: []string{"Yes, start sending", "No, exit"},
+		Label: tr("Ready to start?"),
+		Items: trAll([]string{"Yes, start sending", "No, exit"}),
 	}
 	confirmIdx, _, err := confirmPrompt.Run()
 	if err != nil {
@@ -604,22 +999,22 @@ func configureInteractive() error {
 		return fmt.Errorf("user cancelled")
 	}
 
-	fmt.Println()
+	fmt.Fprintln(console)
 	return nil
 }
 
 // customConfiguration allows user to customize settings
 func customConfiguration() error {
-	fmt.Println(colorYellow + "\n🔧 Custom Configuration" + colorReset)
+	fmt.Fprintln(console, colorYellow+tr("\n🔧 Custom Configuration")+colorReset)
 
 	// Delay between messages
 	delayPrompt := promptui.Prompt{
-		Label:   "Delay between messages (seconds, min-max)",
+		Label:   tr("Delay between messages (seconds, min-max)"),
 		Default: "5-12",
 		Validate: func(input string) error {
 			parts := strings.Split(input, "-")
 			if len(parts) != 2 {
-				return fmt.Errorf("format should be: min-max (e.g., 5-12)")
+				return fmt.Errorf("%s", tr("format should be: min-max (e.g., 5-12)"))
 			}
 			return nil
 		},
@@ -636,12 +1031,12 @@ func customConfiguration() error {
 
 	// Batch size
 	batchPrompt := promptui.Prompt{
-		Label:   "Messages per batch",
+		Label:   tr("Messages per batch"),
 		Default: "20",
 		Validate: func(input string) error {
 			val, err := strconv.Atoi(input)
 			if err != nil || val < 1 || val > 50 {
-				return fmt.Errorf("must be between 1 and 50")
+				return fmt.Errorf("%s", tr("must be between 1 and 50"))
 			}
 			return nil
 		},
@@ -654,12 +1049,12 @@ func customConfiguration() error {
 
 	// Batch delay
 	batchDelayPrompt := promptui.Prompt{
-		Label:   "Break between batches (seconds)",
+		Label:   tr("Break between batches (seconds)"),
 		Default: "120",
 		Validate: func(input string) error {
 			val, err := strconv.Atoi(input)
 			if err != nil || val < 30 {
-				return fmt.Errorf("must be at least 30 seconds")
+				return fmt.Errorf("%s", tr("must be at least 30 seconds"))
 			}
 			return nil
 		},
@@ -673,8 +1068,8 @@ func customConfiguration() error {
 
 	// Skip duplicates
 	skipDupPrompt := promptui.Select{
-		Label: "Skip duplicate phone numbers?",
-		Items: []string{"Yes (Recommended)", "No"},
+		Label: tr("Skip duplicate phone numbers?"),
+		Items: trAll([]string{"Yes (Recommended)", "No"}),
 	}
 	skipDupIdx, _, err := skipDupPrompt.Run()
 	if err != nil {
@@ -684,12 +1079,12 @@ func customConfiguration() error {
 
 	// Max retries
 	retryPrompt := promptui.Prompt{
-		Label:   "Max retry attempts per message",
+		Label:   tr("Max retry attempts per message"),
 		Default: "3",
 		Validate: func(input string) error {
 			val, err := strconv.Atoi(input)
 			if err != nil || val < 0 || val > 5 {
-				return fmt.Errorf("must be between 0 and 5")
+				return fmt.Errorf("%s", tr("must be between 0 and 5"))
 			}
 			return nil
 		},
@@ -700,7 +1095,7 @@ func customConfiguration() error {
 	}
 	config.MaxRetries, _ = strconv.Atoi(retryStr)
 
-	fmt.Println(colorGreen + "\n✓ Configuration updated" + colorReset)
+	fmt.Fprintln(console, colorGreen+tr("\n✓ Configuration updated")+colorReset)
 	displayCurrentConfig()
 
 	return nil
@@ -708,23 +1103,68 @@ func customConfiguration() error {
 
 // displayCurrentConfig shows current configuration
 func displayCurrentConfig() {
-	fmt.Println(colorCyan + "\n📋 Current Configuration:" + colorReset)
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf("  Delay Between Messages:  %d-%d seconds\n", config.DelayMin/1000, config.DelayMax/1000)
-	fmt.Printf("  Batch Size:              %d messages\n", config.BatchSize)
-	fmt.Printf("  Batch Break:             %d seconds\n", config.BatchDelay/1000)
-	fmt.Printf("  Max Retries:             %d attempts\n", config.MaxRetries)
-	fmt.Printf("  Skip Duplicates:         %v\n", config.SkipDuplicates)
-	fmt.Printf("  Skip Invalid Numbers:    %v\n", config.SkipInvalid)
-	fmt.Printf("  Pre-Check Numbers:       %v\n", config.PreCheckNumbers)
-	fmt.Printf("  Country Code:            +%s\n", config.CountryCode)
-	fmt.Println(strings.Repeat("─", 60))
+	fmt.Fprintln(console, colorCyan+tr("\n📋 Current Configuration:")+colorReset)
+	fmt.Fprintln(console, strings.Repeat("─", 60))
+	fmt.Fprint(console, trf("  Delay Between Messages:  %d-%d seconds\n", config.DelayMin/1000, config.DelayMax/1000))
+	fmt.Fprint(console, trf("  Batch Size:              %d messages\n", config.BatchSize))
+	fmt.Fprint(console, trf("  Batch Break:             %d seconds\n", config.BatchDelay/1000))
+	fmt.Fprint(console, trf("  Max Retries:             %d attempts\n", config.MaxRetries))
+	fmt.Fprint(console, trf("  Skip Duplicates:         %v\n", config.SkipDuplicates))
+	fmt.Fprint(console, trf("  Skip Invalid Numbers:    %v\n", config.SkipInvalid))
+	fmt.Fprint(console, trf("  Pre-Check Numbers:       %v\n", config.PreCheckNumbers))
+	fmt.Fprint(console, trf("  Country Code:            +%s\n", config.CountryCode))
+	if len(config.AllowedPrefixes) > 0 {
+		fmt.Fprint(console, trf("  Allowed Prefixes:        %s\n", strings.Join(config.AllowedPrefixes, ", ")))
+	}
+	fmt.Fprintln(console, strings.Repeat("─", 60))
 }
 
 func main() {
 	// Initialize logger
 	log = NewLogger()
 
+	// Load settings from config file, if any
+	if err := loadConfigFile(configFilePath()); err != nil {
+		log.Error("Failed to load config file", err)
+		os.Exit(exitConfig)
+	}
+
+	// Report crashes and errors from here on
+	initErrorReporting()
+	defer reportPanic()
+
+	// --plain/--quiet apply to every command, so handle them first
+	os.Args = applyOutputFlags(os.Args)
+
+	// Run a subcommand instead of the wizard if one was given
+	if len(os.Args) > 1 {
+		if handled, err := runSubcommand(os.Args[1], os.Args[2:]); handled {
+			if err != nil {
+				log.Error(fmt.Sprintf("Command %q failed", os.Args[1]), err)
+				failWith(exitError)
+			}
+			closeSessionStore()
+			flushErrorReports()
+			os.Exit(exitStatus)
+		}
+	}
+
+	// Wizard flags
+	if err := parseWizardFlags(os.Args[1:]); err != nil {
+		os.Exit(exitUsage)
+	}
+	startProfiling()
+
+	runCampaign()
+	closeSessionStore()
+	flushErrorReports()
+	os.Exit(exitStatus)
+}
+
+// runCampaign runs the wizard and the campaign, recording why it stopped
+// early with failWith
+func runCampaign() {
+
 	// Display welcome banner
 	displayWelcomeBanner()
 
@@ -735,141 +1175,553 @@ func main() {
 		log.Warning(fmt.Sprintf("Could not scan templates: %v", err))
 	}
 
-	// Let user select templates
-	selectedTemplates, err = selectTemplatesInteractive(fileTemplates)
-	if err != nil {
-		log.Error("Template selection failed", err)
-		return
+	// Let user select templates; a retry or campaign profile reuses saved ones
+	if activeCampaignProfile != nil {
+		log.Info(fmt.Sprintf("Campaign profile %q: %d template(s), saved %s", activeCampaignProfile.Name,
+			len(selectedTemplates), activeCampaignProfile.SavedAt.Format("2006-01-02")))
+	} else if retryFailedRun {
+		log.Info(fmt.Sprintf("Reusing the %d template(s) of the previous campaign", len(selectedTemplates)))
+	} else {
+		selectedTemplates, err = selectTemplatesInteractive(fileTemplates)
+		if err != nil {
+			log.Error("Template selection failed", err)
+			if errors.Is(err, promptui.ErrInterrupt) {
+				failWith(exitInterrupted)
+			}
+			failWith(exitConfig)
+			return
+		}
 	}
 
+	// Load per-language templates from templates/<lang>/
+	languageTemplates = loadLanguageTemplates()
+
 	// Show template info
 	displayInfo("Template Configuration",
-		fmt.Sprintf("Using %d message template(s) in permutation mode", len(selectedTemplates)),
+		trf("Using %d message template(s) in permutation mode", len(selectedTemplates)),
 		[]string{
 			"Each customer will receive a different template",
 			"Templates rotate automatically",
 			"Ensures message variety",
 		})
 
-	// Interactive configuration
-	if err := configureInteractive(); err != nil {
+	// Poll campaigns need a question and options before anything is sent
+	if isPollCampaign() {
+		if err := validatePollConfig(); err != nil {
+			log.Error("Invalid poll configuration", err)
+			failWith(exitConfig)
+			return
+		}
+	}
+	if isInviteCampaign() {
+		if err := validateInviteConfig(); err != nil {
+			log.Error("Invalid invite configuration", err)
+			failWith(exitConfig)
+			return
+		}
+	}
+
+	if err := validateDisappearAfter(); err != nil {
+		log.Error("Invalid disappearing timer", err)
+		failWith(exitConfig)
+		return
+	}
+
+	// Interactive configuration, unless a campaign profile already answered it
+	if activeCampaignProfile != nil {
+		if isRemoteCSV(campaignCSV) {
+			// Downloaded below, once the profile has been saved with its URL
+		} else if _, err := os.Stat(campaignCSV); err != nil {
+			log.Error("CSV file of the campaign profile not found", err)
+			failWith(exitCSV)
+			return
+		}
+		displayCurrentConfig()
+	} else if err := configureInteractive(); err != nil {
 		log.Error("Configuration failed", err)
+		if errors.Is(err, promptui.ErrInterrupt) {
+			failWith(exitInterrupted)
+		}
+		failWith(exitConfig)
 		return
 	}
 
+	if saveProfileName != "" {
+		if err := saveCampaignProfile(saveProfileName); err != nil {
+			log.Error("Failed to save campaign profile", err)
+		} else {
+			log.Success(fmt.Sprintf("Saved campaign profile %q; run it with --profile %s", saveProfileName, saveProfileName))
+		}
+	}
+
+	// Fetch a customer list that lives on a server
+	if isRemoteCSV(campaignCSV) {
+		local, err := fetchRemoteCSV(campaignCSV)
+		if err != nil {
+			log.Error("Failed to download CSV", err)
+			failWith(exitCSV)
+			return
+		}
+		campaignCSV = local
+	}
+
 	// Setup graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := setupShutdownContext()
 	defer cancel()
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		log.Warning("Shutdown signal received, cleaning up...")
-		cancel()
-	}()
+	// Trace the campaign when running in our infrastructure
+	defer startTelemetry(ctx)()
+	ctx, campaignSpan := startSpan(ctx, "campaign", attribute.String("csv", filepath.Base(campaignCSV)))
+	defer campaignSpan.End()
+
+	// Load branch locations for location pins
+	if config.Location.Enabled && config.Location.BranchesFile != "" {
+		branches, err = loadBranches(config.Location.BranchesFile)
+		if err != nil {
+			log.Error("Failed to load branches file", err)
+			failWith(exitConfig)
+			return
+		}
+		log.Info(fmt.Sprintf("Loaded %d branch locations", len(branches)))
+	}
+
+	// Make sure the shared voice note exists before connecting
+	if config.VoiceNote.File != "" {
+		if _, err := os.Stat(config.VoiceNote.File); err != nil {
+			log.Error("Voice note file not found", err)
+			failWith(exitConfig)
+			return
+		}
+	}
+
+	// Per-customer documents need their template and converter before connecting
+	if documentEnabled() {
+		if err := prepareDocuments(); err != nil {
+			log.Error("Document generation unavailable", err)
+			failWith(exitConfig)
+			return
+		}
+	}
+
+	// Personalized images need their base image and font before connecting
+	if personalImageEnabled() {
+		if err := preparePersonalImage(); err != nil {
+			log.Error("Image personalization unavailable", err)
+			failWith(exitConfig)
+			return
+		}
+	}
+
+	// Load suppression list (opted-out numbers)
+	suppressions, err = loadSuppressionList(config.OptOut.SuppressionFile)
+	if err != nil {
+		log.Error("Failed to load suppression list", err)
+		failWith(exitConfig)
+		return
+	}
 
 	// Load CSV
-	customers, err := loadCSV("customers.csv")
+	_, loadSpan := startSpan(ctx, "load")
+	customers, rows, err := loadCampaignCSV(campaignCSV)
 	if err != nil {
+		endSpan(loadSpan, err)
 		log.Error("Failed to load CSV", err)
+		failWith(exitCSV)
 		return
 	}
 
 	if len(customers) == 0 {
+		endSpan(loadSpan, fmt.Errorf("no customers in CSV"))
 		log.Error("No customers found in CSV", nil)
+		failWith(exitCSV)
 		return
 	}
+	loadSpan.SetAttributes(attribute.Int("customers", rows))
+
+	// Lists longer than ChunkSize are validated and sent a chunk at a time
+	chunked := rows > len(customers)
+	streamResults = chunked
+	if chunked {
+		log.Info(fmt.Sprintf("%d customers in CSV, loading %d at a time", rows, config.ChunkSize))
+	} else {
+		log.Info(fmt.Sprintf("Loaded %d customers from CSV", len(customers)))
+	}
+
+	if voucherEnabled() {
+		if err := checkVouchers(customers); err != nil {
+			endSpan(loadSpan, err)
+			log.Error("Vouchers can't be sent", err)
+			failWith(exitCSV)
+			return
+		}
+	}
+
+	// Resolve near-duplicate customer records
+	if !chunked {
+		customers = resolveFuzzyDuplicates(customers)
+	} else if config.FuzzyDedupe {
+		log.Warning("Near-duplicate detection needs the whole list and is skipped when loading it in chunks")
+	}
 
-	log.Info(fmt.Sprintf("Loaded %d customers from CSV", len(customers)))
+	// Show which languages will receive their own templates
+	displayLanguageCoverage(customers)
+	loadSpan.End()
 
-	// Process and validate customers
-	processedCustomers := processCustomers(customers)
-	if len(processedCustomers) == 0 {
-		log.Error("No valid customers to process", nil)
+	// Consents recorded outside the CSV
+	consents, err = loadConsents()
+	if err != nil {
+		log.Error("Failed to load consents", err)
+		failWith(exitConfig)
 		return
 	}
 
-	log.Info(fmt.Sprintf("Valid customers ready: %d", len(processedCustomers)))
+	// Process and validate customers; chunks are validated as they are sent
+	var processedCustomers []ProcessedCustomer
+	if chunked {
+		if len(config.Accounts) > 1 {
+			log.Warning("Lists loaded in chunks are not split across Accounts; this run sends all of it")
+		}
+		displayExecutionPlan(rows, uncheckedShare(customers))
+		if first, ok := previewCandidate(customers); ok {
+			previewMessage(first)
+		}
+	} else {
+		_, validateSpan := startSpan(ctx, "validate", attribute.Int("customers", len(customers)))
+		processedCustomers = processCustomers(customers)
+		validateSpan.SetAttributes(attribute.Int("valid", len(processedCustomers)))
+		validateSpan.End()
+		if len(processedCustomers) == 0 {
+			log.Error("No valid customers to process", nil)
+			failWith(exitCSV)
+			return
+		}
+
+		log.Info(fmt.Sprintf("Valid customers ready: %d", len(processedCustomers)))
+
+		// Split the list across the linked numbers by what each can send today
+		if len(config.Accounts) > 1 {
+			plan = planAllocation(processedCustomers)
+			plan.use()
+			processedCustomers = plan.ownShare(processedCustomers)
+		}
 
-	// Display execution plan
-	displayExecutionPlan(len(processedCustomers))
+		// Display execution plan
+		displayExecutionPlan(len(processedCustomers), uncheckedShare(customers))
 
-	// Preview first message
-	if len(processedCustomers) > 0 {
-		previewMessage(processedCustomers[0])
+		// Preview first message
+		if len(processedCustomers) > 0 {
+			previewMessage(processedCustomers[0])
+		}
 	}
 
 	// Wait before starting
 	log.Info("Starting in 5 seconds...")
 	time.Sleep(5 * time.Second)
 
-	// Initialize WhatsApp client
-	client, err := initializeWhatsApp(ctx)
+	// Connect the configured transport (WhatsApp session, Cloud API or simulation)
+	client, closeSender, err := openSender(ctx)
 	if err != nil {
 		log.Error("Failed to initialize WhatsApp", err)
+		if errors.Is(err, errLoginRequired) {
+			failWith(exitLoginRequired)
+		}
+		failWith(exitError)
 		return
 	}
-	defer client.Disconnect()
+	defer closeSender()
+
+	// Make sure the session can send before touching the customer list
+	if real, ok := whatsAppClient(client); ok && config.Preflight.Enabled {
+		if err := runPreflightCheck(ctx, real); err != nil {
+			displayError("Pre-flight Check Failed", err.Error(),
+				"Fix the WhatsApp session before starting the campaign",
+				[]string{
+					"Open WhatsApp > Linked devices on the phone and check this device is listed",
+					"Delete the session file and scan the QR code again if it was unlinked",
+				})
+			failWith(exitLoginRequired)
+			return
+		}
+	}
+
+	// Share the hourly and daily limits with other processes using this account
+	if account := senderAccount(client); account != "" && config.QuotaFile != "" && !config.Simulate.Enabled {
+		if quota, err = openAccountQuota(config.QuotaFile, account); err != nil {
+			log.Warning(fmt.Sprintf("Shared quota unavailable, limits apply to this run only: %v", err))
+		}
+		defer quota.close()
+	}
 
 	// Pre-check numbers if enabled
-	if config.PreCheckNumbers {
+	if config.PreCheckNumbers && !chunked {
 		log.Info("Pre-checking all numbers on WhatsApp...")
-		customers = preCheckWhatsAppNumbers(ctx, client, customers)
-
-		// Save updated CSV with has_whatsapp column
-		if err := saveCustomersWithWhatsAppStatus(customers); err != nil {
-			log.Error("Failed to save updated CSV", err)
-		} else {
-			log.Success("Updated CSV saved with WhatsApp status")
+		checkCtx, checkSpan := startSpan(ctx, "pre-check", attribute.Int("customers", len(customers)))
+		customers = preCheckWhatsAppNumbers(checkCtx, client, customers)
+		checkSpan.End()
+
+		// Save updated CSV with has_whatsapp column (simulated results are never saved)
+		if !config.Simulate.Enabled {
+			if err := saveCustomersWithWhatsAppStatus(customers, "data/customers_checked.csv"); err != nil {
+				log.Error("Failed to save updated CSV", err)
+			} else {
+				log.Success("Updated CSV saved with WhatsApp status")
+			}
 		}
 
 		// Re-process customers after pre-check
-		processedCustomers = processCustomers(customers)
+		processedCustomers = plan.ownShare(processCustomers(customers))
 		log.Info(fmt.Sprintf("After pre-check: %d valid customers", len(processedCustomers)))
 	}
 
+	// Leave out numbers that look dead or virtual
+	if config.SkipGhostNumbers && !chunked {
+		processedCustomers = filterGhostNumbers(ctx, client, processedCustomers)
+	}
+
+	// Skip customers an interrupted run already handled
+	if !config.Simulate.Enabled && chunked {
+		resumeStreamedCheckpoint(campaignCSV, rows)
+	} else if !config.Simulate.Enabled {
+		processedCustomers = resumeFromCheckpoint(campaignCSV, processedCustomers)
+		if len(processedCustomers) == 0 {
+			log.Success("Every customer was already handled in the previous run")
+			checkpoint.clear()
+			return
+		}
+	}
+
 	// Initialize progress
 	progress.Total = len(processedCustomers)
+	if chunked {
+		progress.Total = rows
+	}
 
-	// Send messages
-	sendMessagesToCustomers(ctx, client, processedCustomers)
-
-	// Generate report
-	generateReport()
+	// Open poll results before the first poll goes out
+	if isPollCampaign() {
+		var err error
+		polls, err = newPollTracker(config.Poll.ResultsFile)
+		if err != nil {
+			log.Error("Failed to open poll results file", err)
+			failWith(exitError)
+			return
+		}
+	}
 
-	// Save failed customers
-	if config.SaveFailed && len(failedCustomers) > 0 {
-		saveFailedCustomers(failedCustomers)
+	// Look up the invited group or channel for the link preview
+	if isInviteCampaign() {
+		var err error
+		invites, err = newInviteTracker(client, config.Invite.ResultsFile)
+		if err != nil {
+			log.Error("Failed to prepare the invite", err)
+			failWith(exitError)
+			return
+		}
 	}
 
-	log.Success("Bulk messaging completed")
+	// Record every result as it happens and what was sent, for retry-failed
+	// (simulated runs leave no files)
+	if !config.Simulate.Enabled {
+		if !retryFailedRun {
+			if err := saveCampaignRecord(campaignCSV); err != nil {
+				log.Warning(fmt.Sprintf("Could not save campaign templates for retry-failed: %v", err))
+			}
+		}
+		if err := openResultsCSV(); err != nil && streamResults {
+			log.Error("Results CSV unavailable; a chunked run keeps its results only there", err)
+			failWith(exitConfig)
+			return
+		} else if err != nil {
+			log.Warning(fmt.Sprintf("Results CSV unavailable: %v", err))
+		}
+	} else if streamResults {
+		if err := openTemporaryResultsCSV(); err != nil {
+			log.Error("Temporary results file unavailable", err)
+			failWith(exitConfig)
+			return
+		}
+		defer removeTemporaryResults()
+	}
+	startCampaignHistory()
+	defer history.close()
+	if !config.Simulate.Enabled && config.AuditDir != "" {
+		if audit, err = openAuditLog(config.AuditDir); err != nil {
+			log.Warning(fmt.Sprintf("Audit log unavailable: %v", err))
+		}
+		defer audit.close()
+	}
+	if workers, err = joinDistributedCampaign(); err != nil {
+		log.Error("Shared campaign unavailable", err)
+		failWith(exitConfig)
+		return
+	}
+	defer workers.close()
+
+	// Send messages, supervised from the dashboard when running in a terminal
+	emitEvent(eventCampaignStarted, map[string]interface{}{"total": progress.Total, "simulate": config.Simulate.Enabled})
+	if remoteAlertsEnabled() {
+		sendRemoteAlert(notifyStarted, "Campaign started",
+			fmt.Sprintf("Sending to %d customers, %s", progress.Total, formatETA(0, progress.Total)))
+	}
+	web = startWebDashboard()
+	defer web.stop()
+	status = startStatusServer()
+	defer status.stop()
+	dashboard = startDashboard(cancel)
+	sendingCampaign.Store(true)
+	if chunked {
+		sendInChunks(ctx, client, customers)
+	} else {
+		sendMessagesToCustomers(ctx, client, processedCustomers)
+	}
+	sendingCampaign.Store(false)
+	dashboard.stop()
+	dashboard = nil
+	web.finish()
+	status.refresh(0, "finished")
+
+	// Wait for late poll votes and show the tally
+	if polls != nil {
+		polls.collectVotes(ctx)
+		polls.displayResults()
+	}
+
+	// Wait for late joins and show how many recipients joined
+	if invites != nil {
+		invites.collectJoins(ctx)
+		invites.displayResults()
+	}
+
+	// Generate report
+	generateReport()
+
+	// Save failed customers, or drop a stale list once nobody is left to retry
+	failed := len(failedCustomers)
+	if streamResults {
+		failed = closeFailedCustomers()
+	}
+	if config.SaveFailed && len(failedCustomers) > 0 && !config.Simulate.Enabled {
+		saveFailedCustomers(failedCustomers)
+	} else if failed == 0 && !config.Simulate.Enabled && progress.Remaining == 0 && ctx.Err() == nil {
+		os.Remove(failedCustomersFile)
+	}
+
+	// Save skipped customers so the source records can be fixed
+	if streamResults {
+		closeSkippedCustomers()
+	} else if len(skippedCustomers) > 0 {
+		saveSkippedCustomers(skippedCustomers)
+	}
+
+	// Numbers whose WhatsApp name suggests they belong to someone else
+	if config.CheckProfileNames {
+		reportNameMismatches()
+	}
+
+	// Write each row's outcome back to the Google Sheet it came from
+	if sheetSource != nil && !config.Simulate.Enabled {
+		if err := sheetSource.writeStatuses(); err != nil {
+			log.Error("Failed to write send status to the Google Sheet", err)
+		}
+	}
+
+	// Send the outcome to management; a dry run has nothing to report
+	if !config.Simulate.Enabled {
+		emailCampaignReport(ctx.Err() != nil)
+	}
+
+	event, title := notifyFinished, "Campaign finished"
+	summary := fmt.Sprintf("%d sent, %d failed, %d skipped", progress.Successful, progress.Failed, progress.Skipped)
+	if ctx.Err() != nil {
+		event, title = notifyAborted, "Campaign stopped"
+		summary += fmt.Sprintf(", %d remaining", progress.Remaining)
+	}
+	notifyOperator(event, title, summary)
+	emitEvent(eventCampaignFinished, map[string]interface{}{
+		"successful": progress.Successful,
+		"failed":     progress.Failed,
+		"skipped":    progress.Skipped,
+		"remaining":  progress.Remaining,
+		"cancelled":  ctx.Err() != nil,
+	})
+
+	if progress.Remaining > 0 {
+		shutdownInterrupted()
+		return
+	}
+	if progress.Failed > 0 {
+		failWith(exitCompletedWithFailures)
+	}
+	closeResultsCSV()
+	log.Success("Bulk messaging completed")
+}
+
+// setupShutdownContext returns a context that is cancelled on SIGINT/SIGTERM.
+// While a campaign is sending in a terminal, Ctrl+C opens the interrupt menu
+// instead, and SIGHUP reloads the delay and limit settings.
+func setupShutdownContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interruptCancel = cancel
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				requestConfigReload()
+				continue
+			}
+			if sig == os.Interrupt && interruptMenuEnabled() {
+				showInterruptMenu()
+				if ctx.Err() == nil {
+					continue
+				}
+				return
+			}
+			log.Warning("Shutdown signal received, cleaning up...")
+			cancel()
+			return
+		}
+	}()
+
+	return ctx, cancel
 }
 
+// errLoginRequired means no WhatsApp account was linked, e.g. the QR code
+// expired without being scanned
+var errLoginRequired = errors.New("WhatsApp login required: scan the QR code to link a device")
+
 // initializeWhatsApp initializes the WhatsApp client
 func initializeWhatsApp(ctx context.Context) (*whatsmeow.Client, error) {
+	return connectWhatsApp(ctx, config.Session.Profile)
+}
+
+// connectWhatsApp connects the linked number profile names (see selectDevice)
+func connectWhatsApp(ctx context.Context, profile string) (*whatsmeow.Client, error) {
 	log.Info("Initializing WhatsApp client...")
 
-	// Setup database for session storage
-	dbLog := waLog.Stdout("Database", "ERROR", true)
-	container, err := sqlstore.New(ctx, "sqlite3", "file:whatsapp_session.db?_foreign_keys=on", dbLog)
-	if err != nil {
-		return nil, err
+	// Setup database for session storage, shared by every number this run connects
+	container := sessionContainer
+	if container == nil {
+		var err error
+		if container, err = openSessionStore(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	// Get first device or create new
-	deviceStore, err := container.GetFirstDevice(ctx)
+	// Pick the linked number to use, or create a new device
+	deviceStore, err := selectDevice(ctx, container, profile)
 	if err != nil {
 		return nil, err
 	}
 
+	// Name this installation in the phone's linked devices list
+	applyDeviceProps()
+
 	clientLog := waLog.Stdout("Client", "ERROR", true)
 	client := whatsmeow.NewClient(deviceStore, clientLog)
 
-	// Register event handlers
-	client.AddEventHandler(func(evt interface{}) {
-		// Handle events if needed
-	})
+	// Route events to the subsystems that handle them
+	client.AddEventHandler(newEventRouter(client).dispatch)
 
 	// Connect
 	if client.Store.ID == nil {
@@ -888,6 +1740,10 @@ func initializeWhatsApp(ctx context.Context) (*whatsmeow.Client, error) {
 				log.Info(fmt.Sprintf("QR channel result: %s", evt.Event))
 			}
 		}
+		if client.Store.ID == nil {
+			client.Disconnect()
+			return nil, errLoginRequired
+		}
 	} else {
 		// Already logged in
 		err = client.Connect()
@@ -901,15 +1757,14 @@ func initializeWhatsApp(ctx context.Context) (*whatsmeow.Client, error) {
 }
 
 // preCheckWhatsAppNumbers checks all numbers on WhatsApp and updates the HasWhatsApp field
-func preCheckWhatsAppNumbers(ctx context.Context, client *whatsmeow.Client, customers []Customer) []Customer {
+func preCheckWhatsAppNumbers(ctx context.Context, client Sender, customers []Customer) []Customer {
 	total := len(customers)
 	onWhatsApp := 0
 	notOnWhatsApp := 0
 	alreadyChecked := 0
-	batchSize := 50 // Check 50 numbers at a time
 
-	fmt.Println(colorCyan + "\n🔍 Checking WhatsApp Status (Batch Mode)..." + colorReset)
-	fmt.Println(strings.Repeat("─", 60))
+	fmt.Fprintln(console, colorCyan+"\n🔍 Checking WhatsApp Status (Batch Mode)..."+colorReset)
+	fmt.Fprintln(console, strings.Repeat("─", 60))
 
 	// Prepare batch data
 	type checkItem struct {
@@ -938,8 +1793,8 @@ func preCheckWhatsAppNumbers(ctx context.Context, client *whatsmeow.Client, cust
 		cleaned := cleanPhoneNumber(phone)
 		formatted := formatPhoneNumber(cleaned)
 
-		// Validate format
-		if len(formatted) != config.PhoneLength {
+		// Validate format and prefix
+		if len(formatted) != config.PhoneLength || !hasAllowedPrefix(formatted) {
 			customers[i].HasWhatsApp = "no"
 			notOnWhatsApp++
 			continue
@@ -952,80 +1807,198 @@ func preCheckWhatsAppNumbers(ctx context.Context, client *whatsmeow.Client, cust
 		})
 	}
 
-	// Process in batches
-	totalBatches := (len(toCheck) + batchSize - 1) / batchSize
+	// Process in batches on a few workers, shrinking the batch and backing off
+	// when checks start failing. Each worker waits the check delay between its
+	// own batches; failed batches go back on the queue to be retried.
+	var (
+		mu                sync.Mutex
+		next              int         // First number in toCheck no worker has taken
+		retry             []checkItem // Numbers of failed batches, taken first
+		checked           int
+		batchSize         = config.CheckBatchSize
+		checkDelay        = config.CheckDelay
+		batchNum          int // Batches taken, retries included
+		batchesDone       int
+		consecutiveErrors int
+		cleanBatches      int
+		rateLimited       bool
+		stopped           bool
+	)
 
-	for batchNum := 0; batchNum < totalBatches; batchNum++ {
-		start := batchNum * batchSize
-		end := start + batchSize
-		if end > len(toCheck) {
-			end = len(toCheck)
+	// take hands out the next batch, or nil when there is none
+	take := func() []checkItem {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return nil
 		}
-
-		batch := toCheck[start:end]
-
-		// Prepare phone list for batch check
-		phoneList := make([]string, len(batch))
-		for i, item := range batch {
-			phoneList[i] = item.formatted
+		var batch []checkItem
+		if len(retry) > 0 {
+			n := min(batchSize, len(retry))
+			batch, retry = retry[:n:n], retry[n:]
+		} else if next < len(toCheck) {
+			end := min(next+batchSize, len(toCheck))
+			batch, next = toCheck[next:end], end
+		}
+		if batch != nil {
+			batchNum++
 		}
+		return batch
+	}
 
-		// Batch check on WhatsApp
-		exists, err := client.IsOnWhatsApp(phoneList)
+	// finish records a batch's outcome and returns the delay before the worker's next check
+	finish := func(batch []checkItem, exists []types.IsOnWhatsAppResponse, err error) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
 		if err != nil {
-			log.Warning(fmt.Sprintf("Batch check failed: %v", err))
-			// Mark all in batch as unchecked on error
-			time.Sleep(time.Duration(config.CheckDelay) * time.Millisecond)
-			continue
+			consecutiveErrors++
+			cleanBatches = 0
+			retry = append(retry, batch...)
+
+			if consecutiveErrors >= config.CheckMaxErrors {
+				if !stopped {
+					stopped = true
+					fmt.Fprintln(console)
+					displayWarning("Pre-Check Stopped",
+						fmt.Sprintf("WhatsApp rejected %d checks in a row (last error: %v)", consecutiveErrors, err),
+						[]string{
+							fmt.Sprintf("%d numbers were left unchecked", len(toCheck)-checked),
+							"Unchecked numbers are still sent to, but not filtered",
+							"Wait a while and run 'venom check' again to finish the list",
+						})
+				}
+				return 0
+			}
+
+			// Back off: halve the batch and double the delay before retrying the same numbers
+			batchSize = max(batchSize/2, config.CheckBatchMin)
+			checkDelay = min(checkDelay*2, config.CheckDelayMax)
+
+			if !rateLimited {
+				rateLimited = true
+				fmt.Fprintln(console)
+				displayWarning("Possible Rate Limiting",
+					fmt.Sprintf("WhatsApp check failed: %v", err),
+					[]string{
+						fmt.Sprintf("Reducing batch size to %d and waiting %ds between checks", batchSize, checkDelay/1000),
+						"Failed batches are retried, not skipped",
+					})
+			} else {
+				log.Warning(fmt.Sprintf("Batch check failed (%d in a row): %v - retrying with batch size %d after %ds",
+					consecutiveErrors, err, batchSize, checkDelay/1000))
+			}
+			return time.Duration(checkDelay) * time.Millisecond
 		}
 
-		// Update results
-		for i, item := range batch {
-			if i < len(exists) && exists[i].IsIn {
+		consecutiveErrors = 0
+
+		// Update results, matching each answer to its number; numbers the
+		// server didn't answer stay unchecked and are still sent to
+		byPhone := onWhatsAppByPhone(exists)
+		for _, item := range batch {
+			result, ok := byPhone[item.formatted]
+			switch {
+			case !ok:
+				log.Debug(fmt.Sprintf("No WhatsApp check result for %s", item.formatted))
+			case result.IsIn:
 				customers[item.index].HasWhatsApp = "yes"
 				onWhatsApp++
-			} else {
+			default:
 				customers[item.index].HasWhatsApp = "no"
 				notOnWhatsApp++
 			}
 		}
+		checked += len(batch)
+		batchesDone++
+
+		// Recover gradually after a run of clean batches
+		cleanBatches++
+		if rateLimited && cleanBatches >= 3 {
+			cleanBatches = 0
+			batchSize = min(batchSize*2, config.CheckBatchSize)
+			checkDelay = max(checkDelay/2, config.CheckDelay)
+			if batchSize == config.CheckBatchSize && checkDelay == config.CheckDelay {
+				rateLimited = false
+				log.Info("WhatsApp checks recovered, back to normal batch size")
+			}
+		}
 
 		// Display progress
-		checked := end
 		percentage := float64(checked+alreadyChecked) / float64(total) * 100
-		fmt.Printf("\r  Progress: %.1f%% (%d/%d) - ✓ %d  ✗ %d  ⊙ %d  [Batch %d/%d]",
-			percentage, checked+alreadyChecked, total, onWhatsApp, notOnWhatsApp, alreadyChecked, batchNum+1, totalBatches)
-
-		// Delay between batches to avoid rate limiting
-		if batchNum < totalBatches-1 {
-			time.Sleep(time.Duration(config.CheckDelay) * time.Millisecond)
+		line := fmt.Sprintf("  Progress: %.1f%% (%d/%d) - ✓ %d  ✗ %d  ⊙ %d  [Batch %d, size %d]",
+			percentage, checked+alreadyChecked, total, onWhatsApp, notOnWhatsApp, alreadyChecked, batchNum, len(batch))
+		if plainOutput {
+			fmt.Fprintln(console, line)
+		} else {
+			fmt.Fprint(console, "\r"+line)
 		}
+		return time.Duration(checkDelay) * time.Millisecond
+	}
 
-		// Check for cancellation
-		select {
-		case <-ctx.Done():
-			fmt.Println("\n" + colorYellow + "Check cancelled by user" + colorReset)
-			return customers
-		default:
-		}
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(config.CheckWorkers, 1), len(toCheck)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				batch := take()
+				if batch == nil {
+					return
+				}
+
+				// Batch check on WhatsApp
+				phoneList := make([]string, len(batch))
+				for i, item := range batch {
+					phoneList[i] = item.formatted
+				}
+				exists, err := client.IsOnWhatsApp(phoneList)
+				if err == nil {
+					rememberLIDs(client, exists)
+				}
+
+				// Delay between batches to avoid rate limiting
+				if !sleepWithContext(ctx, finish(batch, exists, err)) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		fmt.Fprintln(console, "\n"+colorYellow+"Check cancelled by user"+colorReset)
+		return customers
 	}
 
-	fmt.Println() // New line after progress
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf(colorGreen+"✓ Check complete: %d on WhatsApp, %d not on WhatsApp, %d already checked\n"+colorReset,
+	fmt.Fprintln(console) // New line after progress
+	fmt.Fprintln(console, strings.Repeat("─", 60))
+	fmt.Fprintf(console, colorGreen+"✓ Check complete: %d on WhatsApp, %d not on WhatsApp, %d already checked\n"+colorReset,
 		onWhatsApp, notOnWhatsApp, alreadyChecked)
-	fmt.Printf(colorCyan+"  Checked in %d batches of up to %d numbers\n"+colorReset, totalBatches, batchSize)
+	fmt.Fprintf(console, colorCyan+"  Checked in %d batches of up to %d numbers, %d at a time\n"+colorReset,
+		batchNum, config.CheckBatchSize, max(config.CheckWorkers, 1))
 
 	return customers
 }
 
+// sleepWithContext sleeps for d, returning false early if ctx is cancelled
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // saveCustomersWithWhatsAppStatus saves customers CSV with has_whatsapp column
-func saveCustomersWithWhatsAppStatus(customers []Customer) error {
-	// Create data directory if it doesn't exist
-	os.MkdirAll("data", 0755)
+func saveCustomersWithWhatsAppStatus(customers []Customer, path string) error {
+	// Create output directory if it doesn't exist
+	os.MkdirAll(filepath.Dir(path), 0755)
 
 	// Create new CSV file
-	file, err := os.Create("data/customers_checked.csv")
+	file, err := createDataFile(path)
 	if err != nil {
 		return err
 	}
@@ -1034,8 +2007,9 @@ func saveCustomersWithWhatsAppStatus(customers []Customer) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	// Write header with has_whatsapp column
-	writer.Write([]string{"Code", "CustomerName", "Phone", "Mobile", "HasWhatsApp"})
+	// Write header with has_whatsapp column, keeping any extra columns
+	header := []string{"Code", "CustomerName", "Phone", "Mobile", "HasWhatsApp"}
+	writer.Write(append(header, csvExtraColumns...))
 
 	// Write customers
 	for _, c := range customers {
@@ -1043,7 +2017,11 @@ func saveCustomersWithWhatsAppStatus(customers []Customer) error {
 		if hasWhatsApp == "" {
 			hasWhatsApp = "unchecked"
 		}
-		writer.Write([]string{c.Code, c.CustomerName, c.Phone, c.Mobile, hasWhatsApp})
+		row := []string{c.Code, c.CustomerName, c.Phone, c.Mobile, hasWhatsApp}
+		for _, name := range csvExtraColumns {
+			row = append(row, c.Fields[name])
+		}
+		writer.Write(row)
 	}
 
 	return nil
@@ -1051,65 +2029,185 @@ func saveCustomersWithWhatsAppStatus(customers []Customer) error {
 
 // loadCSV loads customers from CSV file
 func loadCSV(filename string) ([]Customer, error) {
-	file, err := os.Open(filename)
+	customers := make([]Customer, 0)
+	err := streamCSV(filename, func(line int, customer Customer) error {
+		customers = append(customers, customer)
+		if eventStream != nil {
+			fields := customerEventFields(customer)
+			fields["line"] = line
+			emitEvent(eventRowLoaded, fields)
+		}
+		return nil
+	}, nil)
 	if err != nil {
 		return nil, err
 	}
+
+	if len(customers) == 0 {
+		return nil, fmt.Errorf("CSV file is empty or has no data rows")
+	}
+
+	return customers, nil
+}
+
+// streamCSV reads customers one row at a time and passes each to fn with its
+// line number, so memory use doesn't grow with the file. Malformed rows are
+// reported with their line number (and to onBadRow, if set) and skipped; an
+// error from fn stops the read.
+func streamCSV(filename string, fn func(line int, customer Customer) error, onBadRow func(line int, problem string)) error {
+	if isVCF(filename) {
+		return streamVCF(filename, fn, onBadRow)
+	}
+	if isJSONInput(filename) {
+		return streamJSON(filename, fn, onBadRow)
+	}
+
+	file, err := openDataFile(filename)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	// Convert UTF-16 / Windows-1256 exports to UTF-8
+	input, charset, err := decodeCSVInput(file)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if charset != "utf-8" {
+		log.Info(fmt.Sprintf("Converting %s from %s to UTF-8", filename, strings.ToUpper(charset)))
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("CSV file is empty or has no data rows")
+	// Detect semicolon/tab-delimited exports
+	buffered := bufio.NewReader(input)
+	delimiter, err := csvDelimiter(buffered)
+	if err != nil {
+		return err
 	}
+	if delimiter != ',' {
+		log.Info(fmt.Sprintf("Reading %s as %s-delimited", filename, delimiterName(delimiter)))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1 // Row lengths are checked below
+	reader.ReuseRecord = true
+
+	headerRecord, err := reader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("CSV file is empty or has no data rows")
+	}
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(headerRecord))
+	for j, name := range headerRecord {
+		header[j] = strings.TrimSpace(name)
+	}
+	csvHeader = header
 
 	// Check if HasWhatsApp column exists
 	hasWhatsAppCol := false
-	if len(records[0]) >= 5 {
-		header := strings.ToLower(strings.TrimSpace(records[0][4]))
-		if header == "haswhatsapp" || header == "has_whatsapp" {
+	if len(header) >= 5 {
+		name := strings.ToLower(header[4])
+		if name == "haswhatsapp" || name == "has_whatsapp" {
 			hasWhatsAppCol = true
 		}
 	}
 
-	// Parse customers (skip header)
-	customers := make([]Customer, 0)
-	for i := 1; i < len(records); i++ {
-		if len(records[i]) < 4 {
+	// Remember any extra columns after the fixed ones
+	firstExtra := 4
+	if hasWhatsAppCol {
+		firstExtra = 5
+	}
+	csvExtraColumns = nil
+	for j := firstExtra; j < len(header); j++ {
+		csvExtraColumns = append(csvExtraColumns, header[j])
+	}
+
+	// Parse customers row by row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				log.Warning(fmt.Sprintf("%s line %d: skipping malformed row: %v", filename, parseErr.StartLine, parseErr.Err))
+				if onBadRow != nil {
+					onBadRow(parseErr.StartLine, fmt.Sprintf("Malformed row: %v", parseErr.Err))
+				}
+				continue
+			}
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) < 4 {
+			log.Warning(fmt.Sprintf("%s line %d: skipping row with %d columns, expected at least 4", filename, line, len(record)))
+			if onBadRow != nil {
+				onBadRow(line, fmt.Sprintf("Only %d columns, expected at least 4", len(record)))
+			}
 			continue
 		}
 
 		customer := Customer{
-			Code:         strings.TrimSpace(records[i][0]),
-			CustomerName: strings.TrimSpace(records[i][1]),
-			Phone:        strings.TrimSpace(records[i][2]),
-			Mobile:       strings.TrimSpace(records[i][3]),
+			Code:         strings.TrimSpace(record[0]),
+			CustomerName: strings.TrimSpace(sanitizeText(record[1])),
+			Phone:        strings.TrimSpace(record[2]),
+			Mobile:       strings.TrimSpace(record[3]),
 		}
 
 		// Load HasWhatsApp status if column exists
-		if hasWhatsAppCol && len(records[i]) >= 5 {
-			customer.HasWhatsApp = strings.ToLower(strings.TrimSpace(records[i][4]))
+		if hasWhatsAppCol && len(record) >= 5 {
+			customer.HasWhatsApp = strings.ToLower(strings.TrimSpace(record[4]))
 		}
 
-		customers = append(customers, customer)
+		// Load extra columns
+		if len(csvExtraColumns) > 0 {
+			customer.Fields = make(map[string]string, len(csvExtraColumns))
+			for j, name := range csvExtraColumns {
+				if firstExtra+j < len(record) {
+					customer.Fields[name] = strings.TrimSpace(record[firstExtra+j])
+				}
+			}
+		}
+
+		if err := fn(line, customer); err != nil {
+			return err
+		}
 	}
 
-	return customers, nil
+	return nil
+}
+
+// seenCustomers tracks the customers already accepted, to skip duplicates
+type seenCustomers struct {
+	phones map[string]bool              // Track seen phone numbers to avoid duplicates
+	keys   map[string]ProcessedCustomer // First row of each DedupeColumn value
+}
+
+func newSeenCustomers() *seenCustomers {
+	return &seenCustomers{phones: make(map[string]bool), keys: make(map[string]ProcessedCustomer)}
 }
 
 // processCustomers validates and processes customers
 func processCustomers(customers []Customer) []ProcessedCustomer {
+	return validateCustomers(customers, newSeenCustomers())
+}
+
+// validateCustomers validates and processes customers, skipping those seen
+// in an earlier call with the same seen (a list sent in chunks)
+func validateCustomers(customers []Customer, seen *seenCustomers) []ProcessedCustomer {
 	processed := make([]ProcessedCustomer, 0)
-	seenPhones := make(map[string]bool) // Track seen phone numbers to avoid duplicates
+	seenPhones, seenKeys := seen.phones, seen.keys
+	var merged []string
 
 	for _, customer := range customers {
-		// Skip if already checked and not on WhatsApp
-		if customer.HasWhatsApp == "no" {
+		// Skip if already checked and not on WhatsApp, unless routed to SMS or email
+		if customer.HasWhatsApp == "no" && isWhatsAppTransport(channelFor(customer)) {
 			log.Warning(fmt.Sprintf("Skipping %s - Not on WhatsApp (pre-checked)", customer.CustomerName))
+			recordSkip(customer, skipNotOnWhatsApp, "")
 			progress.Skipped++
 			continue
 		}
@@ -1117,6 +2215,7 @@ func processCustomers(customers []Customer) []ProcessedCustomer {
 		// Skip special entries
 		if shouldSkipCustomer(customer) {
 			log.Warning(fmt.Sprintf("Skipping customer: %s", customer.CustomerName))
+			recordSkip(customer, skipSpecialEntry, "")
 			progress.Skipped++
 			continue
 		}
@@ -1124,6 +2223,7 @@ func processCustomers(customers []Customer) []ProcessedCustomer {
 		// Validate customer data
 		if !validateCustomerData(customer) {
 			log.Warning(fmt.Sprintf("Invalid customer data: %s", customer.CustomerName))
+			recordSkip(customer, skipMissingData, "Name, code or phone is empty")
 			progress.Skipped++
 			continue
 		}
@@ -1144,24 +2244,76 @@ func processCustomers(customers []Customer) []ProcessedCustomer {
 
 		if !isValid && config.SkipInvalid {
 			log.Warning(fmt.Sprintf("Skipping %s - Invalid phone: %s", customer.CustomerName, validationError))
+			recordSkip(customer, skipInvalidPhone, validationError)
 			progress.Skipped++
 			continue
 		}
 
+		// Never message numbers on the suppression list
+		if isValid && isSuppressed(formattedPhone) {
+			log.Warning(fmt.Sprintf("Skipping %s - Opted out (suppression list)", customer.CustomerName))
+			recordSkip(customer, skipSuppressed, "On suppression list")
+			progress.Skipped++
+			continue
+		}
+
+		// Only message customers with recorded consent, unless overridden
+		basis, ok := checkConsent(customer, formattedPhone)
+		if !ok {
+			log.Warning(fmt.Sprintf("Skipping %s - No recorded consent", customer.CustomerName))
+			recordSkip(customer, skipNoConsent, fmt.Sprintf("No %s/%s and not in the consents table",
+				config.Consent.DateColumn, config.Consent.SourceColumn))
+			progress.Skipped++
+			continue
+		}
+		pc.ConsentBasis = basis
+
 		// Check for duplicate phone numbers (if enabled)
 		if config.SkipDuplicates {
 			if seenPhones[formattedPhone] {
 				log.Warning(fmt.Sprintf("Skipping %s - Duplicate phone number: %s", customer.CustomerName, formattedPhone))
+				recordSkip(customer, skipDuplicate, "Phone "+formattedPhone+" already used")
 				progress.Skipped++
 				progress.Duplicates++
 				continue
 			}
 
+			// One account listed under two numbers is still one customer
+			if config.DedupeColumn != "" {
+				key := strings.ToUpper(strings.TrimSpace(customerField(customer, config.DedupeColumn)))
+				if first, seen := seenKeys[key]; seen && key != "" {
+					detail := fmt.Sprintf("Same %s as %s (%s)", config.DedupeColumn, first.CustomerName, first.FormattedPhone)
+					log.Warning(fmt.Sprintf("Skipping %s (%s) - %s", customer.CustomerName, formattedPhone, detail))
+					recordSkip(customer, skipDuplicate, detail)
+					progress.Skipped++
+					progress.Duplicates++
+					merged = append(merged, fmt.Sprintf("%s: kept %s, skipped %s", key, first.FormattedPhone, formattedPhone))
+					continue
+				}
+				seenKeys[key] = pc
+			}
+
 			// Mark phone as seen
 			seenPhones[formattedPhone] = true
 		}
 
 		processed = append(processed, pc)
+		if eventStream != nil {
+			fields := customerEventFields(customer)
+			fields["formatted_phone"] = pc.FormattedPhone
+			emitEvent(eventValidated, fields)
+		}
+	}
+
+	if len(merged) > 0 {
+		details := merged
+		if len(details) > 10 {
+			details = append(details[:10:10], fmt.Sprintf("...and %d more", len(merged)-10))
+		}
+		details = append(details, "All merged rows are listed in "+skippedCustomersFile)
+		displayWarning("Rows Merged by "+config.DedupeColumn,
+			fmt.Sprintf("%d rows repeat the %s of an earlier row and will not be messaged", len(merged), config.DedupeColumn),
+			details)
 	}
 
 	return processed
@@ -1228,9 +2380,27 @@ func validateAndFormatPhone(phone string) (string, bool, string) {
 		return "", false, fmt.Sprintf("Must start with %s", config.CountryCode)
 	}
 
+	// Validate mobile prefix (catches landlines before they cost a check or send)
+	if !hasAllowedPrefix(formatted) {
+		return "", false, fmt.Sprintf("Not a mobile number, must start with %s", strings.Join(config.AllowedPrefixes, "/"))
+	}
+
 	return formatted, true, ""
 }
 
+// hasAllowedPrefix checks a formatted number against the configured prefix rules
+func hasAllowedPrefix(formatted string) bool {
+	if len(config.AllowedPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range config.AllowedPrefixes {
+		if strings.HasPrefix(formatted, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanPhoneNumber removes non-digit characters
 func cleanPhoneNumber(phone string) string {
 	result := ""
@@ -1256,20 +2426,47 @@ func formatPhoneNumber(phone string) string {
 }
 
 // sendMessagesToCustomers sends messages to all customers with anti-blocking features
-func sendMessagesToCustomers(ctx context.Context, client *whatsmeow.Client, customers []ProcessedCustomer) {
+func sendMessagesToCustomers(ctx context.Context, client Sender, customers []ProcessedCustomer) {
 	log.Info(fmt.Sprintf("Starting to send messages to %d customers", len(customers)))
+	sendStartedAt = time.Now()
+	if sendCustomers(ctx, client, customers, 0, &stallWatch{}) {
+		finishSending()
+	}
+}
+
+// sendCustomers sends to customers, the first of whom is number offset of
+// the campaign's progress.Total. It returns false if the campaign stopped.
+func sendCustomers(ctx context.Context, client Sender, customers []ProcessedCustomer, offset int, stalls *stallWatch) bool {
+	for j, customer := range customers {
+		i := offset + j
+		progress.Remaining = progress.Total - i
 
-	for i, customer := range customers {
 		// Check for cancellation first
 		select {
 		case <-ctx.Done():
 			log.Warning("Operation cancelled by user")
-			return
+			return false
 		default:
 		}
 
+		// Hold here while paused from the dashboard or the interrupt menu
+		if !waitWhilePaused(ctx) {
+			log.Warning("Operation cancelled by user")
+			return false
+		}
+
+		// Stay off the number while staff are on a call or chatting from the phone
+		if !manual.waitCoolDown(ctx) {
+			log.Warning("Operation cancelled by user")
+			return false
+		}
+
+		// Pick up delay and limit changes requested by SIGHUP or the reload key
+		applyPendingReload()
+
 		// Check business hours
 		if !isBusinessHours() {
+			emitEvent(eventBusinessHours, nil)
 			displayWarning("Outside Business Hours",
 				"Current time is outside business hours (9 AM - 9 PM)",
 				[]string{
@@ -1279,11 +2476,8 @@ func sendMessagesToCustomers(ctx context.Context, client *whatsmeow.Client, cust
 
 			// Wait until business hours
 			for !isBusinessHours() {
-				time.Sleep(5 * time.Minute)
-				select {
-				case <-ctx.Done():
-					return
-				default:
+				if !sleepWithContext(ctx, 5*time.Minute) {
+					return false
 				}
 			}
 			log.Info("Business hours resumed, continuing...")
@@ -1292,6 +2486,15 @@ func sendMessagesToCustomers(ctx context.Context, client *whatsmeow.Client, cust
 		// Check rate limits
 		canSend, limitMsg := checkRateLimits()
 		if !canSend {
+			if progress.DailySent >= config.DailyLimit {
+				notifyOperator(notifyDailyLimit, "Daily limit reached",
+					fmt.Sprintf("Sent %d today; sending resumes tomorrow", progress.DailySent))
+			}
+			emitEvent(eventRateLimitWait, map[string]interface{}{
+				"reason":      limitMsg,
+				"hourly_sent": progress.HourlySent,
+				"daily_sent":  progress.DailySent,
+			})
 			displayWarning("Rate Limit Reached", limitMsg,
 				[]string{
 					"Pausing to respect rate limits",
@@ -1301,34 +2504,189 @@ func sendMessagesToCustomers(ctx context.Context, client *whatsmeow.Client, cust
 
 			// Wait and check again
 			for {
-				time.Sleep(5 * time.Minute)
+				if !sleepWithContext(ctx, 5*time.Minute) {
+					failWith(exitRateLimited)
+					return false
+				}
 				canSend, _ = checkRateLimits()
 				if canSend {
 					break
 				}
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
 			}
 			log.Info("Rate limits reset, continuing...")
 		}
 
-		isWarmup := i < 5
+		// Customer may have opted out since the list was loaded
+		if isSuppressed(customer.FormattedPhone) {
+			log.Warning(fmt.Sprintf("Skipping %s - Opted out during campaign", customer.CustomerName))
+			recordSkip(customer.Customer, skipSuppressed, "Opted out during campaign")
+			progress.Skipped++
+			if checkpoint != nil {
+				checkpoint.markDone(customer.FormattedPhone)
+			}
+			continue
+		}
+
+		// Customer wrote to us lately; staff should answer, not a template
+		if last := history.openConversation(customer.FormattedPhone); last != nil {
+			detail := fmt.Sprintf("Messaged us on %s", last.receivedAt.Format("2006-01-02 15:04"))
+			log.Warning(fmt.Sprintf("Skipping %s - %s, left for manual follow-up", customer.CustomerName, detail))
+			recordSkip(customer.Customer, skipOpenChat, detail)
+			addFollowUp(customer, last)
+			progress.Skipped++
+			if checkpoint != nil {
+				checkpoint.markDone(customer.FormattedPhone)
+			}
+			continue
+		}
+
+		// Pause here if the connection dropped; resume once it's back
+		if !waitForConnection(ctx, client) {
+			log.Warning("Campaign stopped while offline, progress saved")
+			return false
+		}
+
+		// In a shared campaign another worker may already have this customer
+		claimed, err := workers.claim(customer.FormattedPhone)
+		if err != nil {
+			log.Error("Shared campaign unreachable, stopping; progress saved", err)
+			failWith(exitError)
+			return false
+		}
+		if !claimed {
+			log.Debug(fmt.Sprintf("Skipping %s - handled by another worker", customer.CustomerName))
+			if checkpoint != nil {
+				checkpoint.markDone(customer.FormattedPhone)
+			}
+			continue
+		}
+
+		isWarmup := i < 5 || standbySession.warmingUp()
 
 		// Display progress
-		displayProgress(i+1, len(customers), customer.CustomerName)
+		displayProgress(i+1, progress.Total, customer.CustomerName)
+
+		// Take a slot in the account's quota, shared with other processes
+		reservation, ok := waitForQuota(ctx)
+		if !ok {
+			workers.release(customer.FormattedPhone)
+			failWith(exitRateLimited)
+			return false
+		}
 
 		// Send message with retry
-		result := sendMessageWithRetry(client, customer, isWarmup)
+		started := time.Now()
+		sendCtx, sendSpan := startSpan(ctx, "send", attribute.String("customer.code", customer.Code))
+		result := sendMessageWithRetry(sendCtx, client, customer, isWarmup)
+		result.SentFrom = sentFrom(client)
+		latency := time.Since(started)
+		sendSpan.SetAttributes(attribute.Int("retries", result.RetryCount), attribute.Bool("fallback", result.UsedFallback))
+		if result.Success {
+			endSpan(sendSpan, nil)
+		} else {
+			endSpan(sendSpan, errors.New(result.Error))
+		}
+		if !result.Success {
+			quota.release(reservation)
+		}
+
+		// Nothing was sent; the customer got this exact message recently
+		if strings.HasPrefix(result.Error, errAlreadyDelivered) {
+			clearProgress()
+			log.Warning(fmt.Sprintf("Skipping %s - %s", customer.CustomerName, result.Error))
+			recordSkip(customer.Customer, skipAlreadySent, result.Error)
+			progress.Skipped++
+			workers.finish(customer.FormattedPhone, true)
+			if checkpoint != nil {
+				checkpoint.markDone(customer.FormattedPhone)
+			}
+			progress.Remaining = progress.Total - i - 1
+			continue
+		}
+
+		// Gave up waiting for the connection; leave this customer for the resumed run
+		if !result.Success && !isOnline(client) {
+			workers.release(customer.FormattedPhone)
+			log.Warning("Campaign stopped while offline, progress saved")
+			return false
+		}
+
+		// A blank render means a broken template, not a bad number
+		if result.Error == errEmptyMessage {
+			clearProgress()
+			log.Warning(fmt.Sprintf("Rendered message for %s is empty, not sent", customer.CustomerName))
+			if config.AbortOnEmpty {
+				recordResult(result)
+				workers.release(customer.FormattedPhone)
+				displayError("Empty Message",
+					fmt.Sprintf("The message rendered for %s (%s) is empty", customer.CustomerName, customer.Code),
+					"Check the selected templates and placeholders, then run again",
+					[]string{"Set AbortOnEmpty to false in config.json to skip such customers instead"})
+				failWith(exitConfig)
+				return false
+			}
+		}
+
+		// Follow up with the media album
+		if result.Success && albumEnabled() {
+			if err := sendAlbum(client, customer, result.SentTo); err != nil {
+				log.Error(fmt.Sprintf("Failed to send album to %s", customer.CustomerName), err)
+			}
+		}
+
+		// Follow up with the voice note
+		if result.Success && voiceNoteEnabled() {
+			if err := sendVoiceNote(client, customer, result.SentTo); err != nil {
+				log.Error(fmt.Sprintf("Failed to send voice note to %s", customer.CustomerName), err)
+			}
+		}
+
+		// Whose number was it?
+		if result.Success && config.CheckProfileNames {
+			checkProfileName(client, &result)
+		}
+
+		// Follow up with the customer's own copy of the promo image
+		if result.Success && personalImageEnabled() {
+			if err := sendPersonalImage(client, customer, result.SentTo); err != nil {
+				log.Error(fmt.Sprintf("Failed to send image to %s", customer.CustomerName), err)
+			}
+		}
+
+		// Follow up with the customer's voucher
+		if result.Success && voucherEnabled() {
+			if err := sendVoucher(client, customer, result.SentTo); err != nil {
+				log.Error(fmt.Sprintf("Failed to send voucher to %s", customer.CustomerName), err)
+			}
+		}
+
+		// Follow up with the customer's document
+		if result.Success && documentEnabled() {
+			if err := sendDocument(client, customer, result.SentTo); err != nil {
+				log.Error(fmt.Sprintf("Failed to send document to %s", customer.CustomerName), err)
+			}
+		}
+
+		// Follow up with the branch location pin
+		if result.Success && config.Location.Enabled {
+			time.Sleep(time.Duration(1000+rand.Intn(2000)) * time.Millisecond)
+			sendBranchLocation(client, customer, result.SentTo)
+		}
 
 		// Calculate delay with anti-blocking features
-		delay := getRandomDelay(isWarmup)
-		progress.Delays = append(progress.Delays, delay)
+		delay := manual.slowDown(getRandomDelay(isWarmup))
+		progress.DelayTotal += delay
+		progress.DelayCount++
 
 		// Record result
 		recordResult(result)
+		timeline.record(i, started, latency, result.Success)
+		workers.finish(customer.FormattedPhone, result.Success)
+		stalls.record(result.Success, result.Error)
+		if checkpoint != nil {
+			checkpoint.markDone(customer.FormattedPhone)
+		}
+		progress.Remaining = progress.Total - i - 1
 
 		// Increment rate limiters only on successful send
 		if result.Success {
@@ -1338,82 +2696,281 @@ func sendMessagesToCustomers(ctx context.Context, client *whatsmeow.Client, cust
 		// Check for batch break
 		if shouldTakeBatchBreak(i + 1) {
 			clearProgress()
-			log.Info(fmt.Sprintf("Batch completed. Taking %d second break...", config.BatchDelay/1000))
+			log.Info(fmt.Sprintf("Batch completed. Taking %d second break... %s", config.BatchDelay/1000, formatETA(i+1, progress.Total)))
+			emitEvent(eventBatchBreak, map[string]interface{}{
+				"after":      i + 1,
+				"seconds":    config.BatchDelay / 1000,
+				"successful": progress.Successful,
+				"failed":     progress.Failed,
+			})
 			displayStats()
 
 			// Show rate limit status
 			displayInfo("Rate Limit Status",
 				fmt.Sprintf("Sent %d/%d this hour, %d/%d today",
-					progress.HourlySent, config.HourlyLimit,
+					progress.HourlySent, campaignHourlyLimit(),
 					progress.DailySent, config.DailyLimit),
 				nil)
 
-			time.Sleep(time.Duration(config.BatchDelay) * time.Millisecond)
+			if !takeBatchBreak(ctx, time.Duration(config.BatchDelay)*time.Millisecond) {
+				log.Warning("Operation cancelled by user")
+				return false
+			}
 			log.Info("Resuming...")
 		} else {
+			dashboard.setDelay(time.Duration(delay)*time.Millisecond, "")
+			web.setDelay(time.Duration(delay)*time.Millisecond, "")
+			status.refresh(time.Duration(delay)*time.Millisecond, "waiting")
 			time.Sleep(time.Duration(delay) * time.Millisecond)
 		}
 	}
 
+	return true
+}
+
+// finishSending wraps up once every customer was handled
+func finishSending() {
 	clearProgress()
+	progress.Remaining = 0
+	if checkpoint != nil {
+		checkpoint.clear()
+	}
 	log.Success("All messages processed")
 }
 
-// sendMessageWithRetry sends message with retry logic
-func sendMessageWithRetry(client *whatsmeow.Client, customer ProcessedCustomer, isWarmup bool) MessageResult {
-	var lastError string
+// sendMessageWithRetry sends message with retry logic, falling back to the
+// customer's other number when the selected one is not on WhatsApp
+func sendMessageWithRetry(ctx context.Context, client Sender, customer ProcessedCustomer, isWarmup bool) MessageResult {
+	// Render message once so a fallback send uses the same text
+	var message, template string
+	if isPollCampaign() {
+		template = config.Poll.Question
+		message = renderPlaceholders(template, customer)
+	} else {
+		message, template = renderMessageTemplate(customer)
+	}
 
-	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
-		// Render message
-		message := renderMessage(customer)
+	// Never send a blank message
+	if strings.TrimSpace(message) == "" {
+		return MessageResult{
+			Customer:  customer,
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     errEmptyMessage,
+			Template:  template,
+		}
+	}
+	if isInviteCampaign() {
+		message = withInviteLink(message)
+	}
 
-		// Format WhatsApp JID
-		jid := types.NewJID(customer.FormattedPhone, types.DefaultUserServer)
+	// Don't repeat a message the customer already got
+	if when, ok := history.alreadyDelivered(customer.FormattedPhone, message); ok {
+		return MessageResult{
+			Customer:  customer,
+			Success:   false,
+			Timestamp: time.Now(),
+			Error:     fmt.Sprintf("%s on %s", errAlreadyDelivered, when.Format("2006-01-02 15:04")),
+			Template:  template,
+		}
+	}
 
-		// Send message directly (WhatsApp will return error if number doesn't exist)
-		_, err := client.SendMessage(context.Background(), jid, &waE2E.Message{
-			Conversation: proto.String(message),
-		})
+	result, err := sendToNumber(ctx, client, customer, customer.FormattedPhone, message)
+	result.Template, result.Message = template, message
+	if result.Success || !config.PhoneFallback || !isNotOnWhatsAppError(err) {
+		return result
+	}
 
-		if err != nil {
-			lastError = err.Error()
-			if attempt < config.MaxRetries {
-				log.Warning(fmt.Sprintf("Attempt %d failed for %s, retrying...", attempt+1, customer.CustomerName))
-				time.Sleep(time.Duration(config.RetryDelay) * time.Millisecond)
-				continue
+	otherPhone, ok := alternatePhone(customer)
+	if !ok {
+		return result
+	}
+
+	log.Warning(fmt.Sprintf("%s is not on WhatsApp at %s, trying other number %s",
+		customer.CustomerName, customer.FormattedPhone, otherPhone))
+
+	fallback, _ := sendToNumber(ctx, client, customer, otherPhone, message)
+	fallback.Template, fallback.Message = template, message
+	fallback.UsedFallback = true
+	fallback.RetryCount += result.RetryCount
+	if !fallback.Success {
+		fallback.Error = fmt.Sprintf("%s: %s; %s: %s",
+			customer.FormattedPhone, result.Error, otherPhone, fallback.Error)
+	}
+	return fallback
+}
+
+// sendToNumber sends message to a single formatted phone number, retrying
+// transient errors. It returns the last send error alongside the result.
+func sendToNumber(ctx context.Context, client Sender, customer ProcessedCustomer, phone, message string) (MessageResult, error) {
+	var lastErr error
+	attempt := 0
+
+	// Long messages go out as consecutive parts; a retry resumes at the part that failed
+	parts := splitLongMessage(message)
+	next := 0
+	var firstID types.MessageID
+
+	for ; attempt <= config.MaxRetries; attempt++ {
+		// Format WhatsApp JID
+		jid := recipientJID(client, phone)
+
+		var err error
+		for next < len(parts) {
+			// Send message directly (WhatsApp will return error if number doesn't exist)
+			var resp whatsmeow.SendResponse
+			outgoing := buildOutgoingMessage(client, parts[next])
+			if next == 0 {
+				outgoing = quoteLastInbound(outgoing, phone)
 			}
-		} else {
+			outgoing = withExpiration(outgoing)
+			callStart := time.Now()
+			resp, err = client.SendMessage(withCustomer(context.Background(), customer.Customer), jid, outgoing)
+			latencies.observe(time.Since(callStart))
+			sendDurationHis.Record(ctx, time.Since(callStart).Seconds())
+			audit.attempt(customer, phone, parts[next], attempt, err)
+			if err != nil {
+				break
+			}
+			if polls != nil {
+				polls.track(resp.ID, customer.Customer)
+			}
+			if invites != nil {
+				invites.track(customer.Customer, phone)
+			}
+			deliveries.track(resp.ID, customer.Customer)
+			if next == 0 {
+				firstID = resp.ID
+			}
+
+			next++
+			if next < len(parts) {
+				time.Sleep(time.Duration(1500+rand.Intn(2000)) * time.Millisecond)
+			}
+		}
+
+		if err == nil {
 			return MessageResult{
 				Customer:   customer,
 				Success:    true,
 				Timestamp:  time.Now(),
 				RetryCount: attempt,
+				SentTo:     phone,
+				MessageID:  string(firstID),
+			}, nil
+		}
+
+		lastErr = err
+
+		// Retrying won't help if the number isn't registered
+		if isNotOnWhatsAppError(err) {
+			break
+		}
+
+		// A dropped connection isn't the customer's fault; wait it out without using up a retry
+		if !isOnline(client) {
+			if !waitForConnection(ctx, client) {
+				break
 			}
+			attempt--
+			continue
+		}
+
+		if attempt < config.MaxRetries {
+			log.Warning(fmt.Sprintf("Attempt %d failed for %s, retrying...", attempt+1, customer.CustomerName))
+			trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+				attribute.Int("attempt", attempt+1), attribute.String("error", err.Error())))
+			retryCounter.Add(ctx, 1)
+			time.Sleep(time.Duration(config.RetryDelay) * time.Millisecond)
 		}
 	}
 
 	return MessageResult{
-		Customer:   customer,
-		Success:    false,
-		Timestamp:  time.Now(),
-		Error:      lastError,
-		RetryCount: config.MaxRetries,
+		Customer:      customer,
+		Success:       false,
+		Timestamp:     time.Now(),
+		Error:         lastErr.Error(),
+		FailureReason: classifyFailure(lastErr),
+		RetryCount:    min(attempt, config.MaxRetries),
+		SentTo:        phone,
+	}, lastErr
+}
+
+// buildOutgoingMessage wraps rendered text in the message type for this campaign
+func buildOutgoingMessage(client Sender, text string) *waE2E.Message {
+	if isPollCampaign() {
+		return client.BuildPollCreation(text, config.Poll.Options, config.Poll.SelectableCount)
+	}
+	if invites != nil {
+		return invites.inviteMessage(text)
 	}
+	return &waE2E.Message{
+		Conversation: proto.String(text),
+	}
+}
+
+// isNotOnWhatsAppError reports whether a send error means the number has no WhatsApp account
+func isNotOnWhatsAppError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, whatsmeow.ErrNoSession) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"not on whatsapp", "no devices", "item-not-found"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// alternatePhone returns the customer's other number, formatted, if it is
+// valid and differs from the one already selected
+func alternatePhone(customer ProcessedCustomer) (string, bool) {
+	other := customer.Phone
+	if customer.SelectedPhone == customer.Phone {
+		other = customer.Mobile
+	}
+	if other == "" {
+		return "", false
+	}
+
+	formatted, isValid, _ := validateAndFormatPhone(other)
+	if !isValid || formatted == customer.FormattedPhone || isSuppressed(formatted) {
+		return "", false
+	}
+	return formatted, true
 }
 
 // renderMessage renders message template using permutation
 func renderMessage(customer ProcessedCustomer) string {
-	// Get next template in permutation order
-	template := getNextTemplateInPermutation()
+	message, _ := renderMessageTemplate(customer)
+	return message
+}
 
-	// Replace placeholders
-	message := template
-	message = strings.ReplaceAll(message, "{CustomerName}", customer.CustomerName)
-	message = strings.ReplaceAll(message, "{Code}", customer.Code)
-	message = strings.ReplaceAll(message, "{Phone}", customer.Phone)
-	message = strings.ReplaceAll(message, "{Mobile}", customer.Mobile)
+// renderMessageTemplate renders the next message and also returns the
+// template it came from, for the send history
+func renderMessageTemplate(customer ProcessedCustomer) (string, string) {
+	// Get next template in permutation order, in the customer's language if available
+	template := templateForCustomer(customer.Customer)
+	source := template
 
-	return message
+	// Convert Markdown templates to WhatsApp formatting
+	if markdownTemplates[template] {
+		template = markdownToWhatsApp(template)
+	}
+
+	message := renderPlaceholders(template, customer)
+
+	// Append the unsubscribe footer
+	if config.OptOut.AppendFooter {
+		message += "\n\n" + optOutFooter()
+	}
+
+	return message, source
 }
 
 // getRandomDelay returns random delay with anti-blocking enhancements
@@ -1441,6 +2998,12 @@ func getRandomDelay(isWarmup bool) int {
 	return baseDelay
 }
 
+// Business hours, as hours of the day
+const (
+	businessHoursStart = 9
+	businessHoursEnd   = 21
+)
+
 // isBusinessHours checks if current time is within business hours
 func isBusinessHours() bool {
 	if !config.BusinessHoursOnly {
@@ -1451,7 +3014,7 @@ func isBusinessHours() bool {
 	hour := now.Hour()
 
 	// Business hours: 9 AM to 9 PM
-	if hour < 9 || hour >= 21 {
+	if hour < businessHoursStart || hour >= businessHoursEnd {
 		return false
 	}
 
@@ -1474,11 +3037,16 @@ func checkRateLimits() (bool, string) {
 		progress.LastDayReset = now
 	}
 
-	// Check hourly limit
-	if progress.HourlySent >= config.HourlyLimit {
+	// Sends by other processes from the same account count too
+	if hourly, daily, ok := quota.counts(); ok {
+		progress.HourlySent, progress.DailySent = hourly, daily
+	}
+
+	// Check hourly limit, less what is kept free for staff
+	if hourlyLimit := campaignHourlyLimit(); progress.HourlySent >= hourlyLimit {
 		minutesLeft := 60 - int(now.Sub(progress.LastHourReset).Minutes())
-		return false, fmt.Sprintf("Hourly limit reached (%d/%d). Wait %d minutes.",
-			progress.HourlySent, config.HourlyLimit, minutesLeft)
+		return false, fmt.Sprintf("Hourly limit reached (%d/%d%s). Wait %d minutes.",
+			progress.HourlySent, hourlyLimit, describeHeadroom(hourlyLimit), minutesLeft)
 	}
 
 	// Check daily limit
@@ -1488,6 +3056,12 @@ func checkRateLimits() (bool, string) {
 			progress.DailySent, config.DailyLimit, hoursLeft)
 	}
 
+	// Check the limit shared by every worker of a distributed campaign
+	if ok, sent := workers.quotaLeft(); !ok {
+		return false, fmt.Sprintf("Shared daily limit reached (%d/%d across all workers).",
+			sent, config.Distributed.DailyLimit)
+	}
+
 	return true, ""
 }
 
@@ -1495,6 +3069,7 @@ func checkRateLimits() (bool, string) {
 func incrementRateLimiters() {
 	progress.HourlySent++
 	progress.DailySent++
+	workers.countSend()
 }
 
 // simulateTypingDelay calculates and applies typing delay based on message length
@@ -1529,52 +3104,113 @@ func shouldTakeBatchBreak(count int) bool {
 
 // recordResult records message result
 func recordResult(result MessageResult) {
+	if !result.Success && result.FailureReason == "" {
+		result.FailureReason = failureUnknown
+	}
+	result.TemplateLabel = templateLabel(result.Template)
+	if eventStream != nil {
+		fields := customerEventFields(result.Customer.Customer)
+		fields["sent_to"] = result.SentTo
+		fields["retries"] = result.RetryCount
+		fields["fallback_number"] = result.UsedFallback
+		if result.Success {
+			emitEvent(eventSent, fields)
+		} else {
+			fields["error"] = result.Error
+			fields["reason"] = result.FailureReason
+			emitEvent(eventFailed, fields)
+		}
+	}
+
+	if !streamResults {
+		campaignResults = append(campaignResults, result)
+	}
+	recordSendMetrics(context.Background(), result)
+	appendResultCSV(result)
+	history.record(result)
+	web.record(result)
 	progress.Processed++
 	if result.Success {
 		progress.Successful++
-		log.Success(fmt.Sprintf("Message sent to %s (%s)", result.Customer.CustomerName, result.Customer.FormattedPhone))
+		if result.UsedFallback {
+			log.Success(fmt.Sprintf("Message sent to %s (%s, fallback number)", result.Customer.CustomerName, result.SentTo))
+		} else {
+			log.Success(fmt.Sprintf("Message sent to %s (%s)", result.Customer.CustomerName, result.SentTo))
+		}
 	} else {
 		progress.Failed++
-		failedCustomers = append(failedCustomers, result.Customer.Customer)
+		if streamResults {
+			appendFailedCustomer(result.Customer.Customer)
+		} else {
+			failedCustomers = append(failedCustomers, result.Customer.Customer)
+		}
 		log.Error(fmt.Sprintf("Failed to send to %s: %s", result.Customer.CustomerName, result.Error), nil)
 	}
 }
 
 // Helper functions for display
-func displayExecutionPlan(count int) {
+func displayExecutionPlan(count int, unchecked float64) {
 	avgDelay := (config.DelayMin + config.DelayMax) / 2
 	batchCount := (count + config.BatchSize - 1) / config.BatchSize
 	totalMs := count*avgDelay + (batchCount-1)*config.BatchDelay
 	minutes := totalMs / 60000
 	seconds := (totalMs % 60000) / 1000
 
-	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("EXECUTION PLAN")
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Printf("Total Customers:        %d\n", count)
-	fmt.Printf("Batch Size:             %d messages\n", config.BatchSize)
-	fmt.Printf("Number of Batches:      %d\n", batchCount)
-	fmt.Printf("Delay Between Messages: %d-%ds\n", config.DelayMin/1000, config.DelayMax/1000)
-	fmt.Printf("Delay Between Batches:  %ds\n", config.BatchDelay/1000)
-	fmt.Printf("Estimated Duration:     %dm %ds\n", minutes, seconds)
-	fmt.Printf("Max Retries:            %d\n", config.MaxRetries)
-	fmt.Println(strings.Repeat("=", 60) + "\n")
+	fmt.Fprintln(console, "\n"+strings.Repeat("=", 60))
+	fmt.Fprintln(console, "EXECUTION PLAN")
+	fmt.Fprintln(console, strings.Repeat("=", 60))
+	fmt.Fprintf(console, "Total Customers:        %d\n", count)
+	fmt.Fprintf(console, "Batch Size:             %d messages\n", config.BatchSize)
+	fmt.Fprintf(console, "Number of Batches:      %d\n", batchCount)
+	fmt.Fprintf(console, "Delay Between Messages: %d-%ds\n", config.DelayMin/1000, config.DelayMax/1000)
+	fmt.Fprintf(console, "Delay Between Batches:  %ds\n", config.BatchDelay/1000)
+	fmt.Fprintf(console, "Estimated Duration:     %dm %ds\n", minutes, seconds)
+	fmt.Fprintf(console, "Max Retries:            %d\n", config.MaxRetries)
+	plan.display()
+	displayRiskAssessment(assessCampaignRisk(count, unchecked))
+	fmt.Fprintln(console, strings.Repeat("=", 60)+"\n")
 }
 
 func previewMessage(customer ProcessedCustomer) {
 	message := renderMessage(customer)
-	fmt.Println("\n" + strings.Repeat("─", 60))
-	fmt.Println("MESSAGE PREVIEW")
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf("To: %s\n", customer.CustomerName)
-	fmt.Printf("Phone: %s\n", customer.FormattedPhone)
-	fmt.Printf("Length: %d characters\n", len(message))
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Println(message)
-	fmt.Println(strings.Repeat("─", 60) + "\n")
+	if isPollCampaign() {
+		message = "📊 " + renderPlaceholders(config.Poll.Question, customer)
+		for _, option := range config.Poll.Options {
+			message += "\n  ○ " + option
+		}
+	}
+	if isInviteCampaign() {
+		message = withInviteLink(message)
+	}
+	fmt.Fprintln(console, "\n"+strings.Repeat("─", 60))
+	fmt.Fprintln(console, tr("MESSAGE PREVIEW"))
+	fmt.Fprintln(console, strings.Repeat("─", 60))
+	fmt.Fprint(console, trf("To: %s\n", customer.CustomerName))
+	fmt.Fprint(console, trf("Phone: %s\n", customer.FormattedPhone))
+	fmt.Fprint(console, trf("Length: %d characters\n", len(message)))
+	if parts := splitLongMessage(message); len(parts) > 1 {
+		fmt.Fprint(console, trf("Parts:  %d (longer than %d characters)\n", len(parts), config.SplitLength))
+	}
+	fmt.Fprintln(console, strings.Repeat("─", 60))
+	fmt.Fprintln(console, layoutRTL(message, 60))
+	fmt.Fprintln(console, strings.Repeat("─", 60)+"\n")
 }
 
 func displayProgress(current, total int, name string) {
+	web.update(current, total, name)
+	status.refresh(0, "sending")
+	if dashboard != nil {
+		dashboard.update(current, total, name)
+		return
+	}
+	if plainOutput {
+		fmt.Fprint(console, trf("[%d/%d] Processing: %s\n", current, total, name))
+		if current%10 == 1 {
+			fmt.Fprintf(console, "  %s\n", formatETA(current-1, total))
+		}
+		return
+	}
+
 	percentage := float64(current) / float64(total) * 100
 	barLength := 30
 	filled := int(float64(barLength) * float64(current) / float64(total))
@@ -1584,11 +3220,15 @@ func displayProgress(current, total int, name string) {
 		name = name[:30]
 	}
 
-	fmt.Printf("\r[%s] %.1f%% (%d/%d) - Processing: %-30s", bar, percentage, current, total, name)
+	fmt.Fprint(console, trf("\r[%s] %.1f%% (%d/%d) - Processing: %-30s", bar, percentage, current, total, name))
+	fmt.Fprint(console, " "+dim+formatETA(current-1, total)+colorReset)
 }
 
 func clearProgress() {
-	fmt.Print("\r" + strings.Repeat(" ", 120) + "\r")
+	if dashboard != nil || plainOutput {
+		return
+	}
+	fmt.Fprint(console, "\r"+strings.Repeat(" ", 160)+"\r")
 }
 
 func displayStats() {
@@ -1597,18 +3237,18 @@ func displayStats() {
 		successRate = float64(progress.Successful) / float64(progress.Successful+progress.Failed) * 100
 	}
 
-	fmt.Println("\n" + strings.Repeat("─", 60))
-	fmt.Println("CURRENT STATISTICS")
-	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf("Processed:     %d/%d\n", progress.Processed, progress.Total)
-	fmt.Printf("Successful:    %d\n", progress.Successful)
-	fmt.Printf("Failed:        %d\n", progress.Failed)
-	fmt.Printf("Skipped:       %d\n", progress.Skipped)
+	fmt.Fprintln(console, "\n"+strings.Repeat("─", 60))
+	fmt.Fprintln(console, tr("CURRENT STATISTICS"))
+	fmt.Fprintln(console, strings.Repeat("─", 60))
+	fmt.Fprint(console, trf("Processed:     %d/%d\n", progress.Processed, progress.Total))
+	fmt.Fprint(console, trf("Successful:    %d\n", progress.Successful))
+	fmt.Fprint(console, trf("Failed:        %d\n", progress.Failed))
+	fmt.Fprint(console, trf("Skipped:       %d\n", progress.Skipped))
 	if progress.Duplicates > 0 {
-		fmt.Printf("  - Duplicates: %d\n", progress.Duplicates)
+		fmt.Fprint(console, trf("  - Duplicates: %d\n", progress.Duplicates))
 	}
-	fmt.Printf("Success Rate:  %.2f%%\n", successRate)
-	fmt.Println(strings.Repeat("─", 60) + "\n")
+	fmt.Fprint(console, trf("Success Rate:  %.2f%%\n", successRate))
+	fmt.Fprintln(console, strings.Repeat("─", 60)+"\n")
 }
 
 func generateReport() {
@@ -1619,33 +3259,34 @@ func generateReport() {
 	}
 
 	avgDelay := 0
-	if len(progress.Delays) > 0 {
-		sum := 0
-		for _, d := range progress.Delays {
-			sum += d
-		}
-		avgDelay = sum / len(progress.Delays)
-	}
-
-	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("EXECUTION SUMMARY")
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Printf("Start Time:         %s\n", progress.StartTime.Format("2006-01-02 15:04:05"))
-	fmt.Printf("End Time:           %s\n", time.Now().Format("2006-01-02 15:04:05"))
-	fmt.Printf("Duration:           %s\n", duration.Round(time.Second))
-	fmt.Printf("Total Customers:    %d\n", progress.Total)
-	fmt.Printf("Successful Sends:   %d (%.2f%%)\n", progress.Successful, successRate)
-	fmt.Printf("Failed Sends:       %d\n", progress.Failed)
-	fmt.Printf("Skipped Customers:  %d\n", progress.Skipped)
+	if progress.DelayCount > 0 {
+		avgDelay = progress.DelayTotal / progress.DelayCount
+	}
+
+	fmt.Fprintln(console, "\n"+strings.Repeat("=", 60))
+	fmt.Fprintln(console, tr("EXECUTION SUMMARY"))
+	fmt.Fprintln(console, strings.Repeat("=", 60))
+	fmt.Fprint(console, trf("Start Time:         %s\n", progress.StartTime.Format("2006-01-02 15:04:05")))
+	fmt.Fprint(console, trf("End Time:           %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	fmt.Fprint(console, trf("Duration:           %s\n", duration.Round(time.Second)))
+	fmt.Fprint(console, trf("Total Customers:    %d\n", progress.Total))
+	fmt.Fprint(console, trf("Successful Sends:   %d (%.2f%%)\n", progress.Successful, successRate))
+	fmt.Fprint(console, trf("Failed Sends:       %d\n", progress.Failed))
+	displayFailureBreakdown()
+	fmt.Fprint(console, trf("Skipped Customers:  %d\n", progress.Skipped))
 	if progress.Duplicates > 0 {
-		fmt.Printf("  - Duplicates:     %d\n", progress.Duplicates)
-	}
-	fmt.Printf("Average Delay:      %.2fs\n", float64(avgDelay)/1000)
-	fmt.Println(strings.Repeat("=", 60) + "\n")
+		fmt.Fprint(console, trf("  - Duplicates:     %d\n", progress.Duplicates))
+	}
+	fmt.Fprint(console, trf("Average Delay:      %.2fs\n", float64(avgDelay)/1000))
+	deliveries.displaySummary()
+	displayTemplateStats()
+	timeline.displaySummary()
+	latencies.displaySummary()
+	fmt.Fprintln(console, strings.Repeat("=", 60)+"\n")
 }
 
 func saveFailedCustomers(customers []Customer) {
-	file, err := os.Create("data/failed-customers.csv")
+	file, err := createDataFile(failedCustomersFile)
 	if err != nil {
 		log.Error("Failed to create failed customers file", err)
 		return
@@ -1655,13 +3296,68 @@ func saveFailedCustomers(customers []Customer) {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	// Write header
-	writer.Write([]string{"Code", "CustomerName", "Phone", "Mobile"})
+	// Write header, keeping extra columns so placeholders still fill on retry
+	writer.Write(failedCustomersHeader())
 
 	// Write customers
 	for _, c := range customers {
-		writer.Write([]string{c.Code, c.CustomerName, c.Phone, c.Mobile})
+		writer.Write(failedCustomerRow(c))
+	}
+
+	log.Info(fmt.Sprintf("Saved %d failed customers to %s (run 'venom retry-failed' to resend)", len(customers), failedCustomersFile))
+}
+
+// failedCustomersHeader is the failed-customers CSV header
+func failedCustomersHeader() []string {
+	return append([]string{"Code", "CustomerName", "Phone", "Mobile"}, csvExtraColumns...)
+}
+
+// failedCustomerRow is one customer's failed-customers CSV row
+func failedCustomerRow(c Customer) []string {
+	row := []string{c.Code, c.CustomerName, c.Phone, c.Mobile}
+	for _, name := range csvExtraColumns {
+		row = append(row, c.Fields[name])
 	}
+	return row
+}
 
-	log.Info(fmt.Sprintf("Saved %d failed customers to data/failed-customers.csv", len(customers)))
+// failedFile is the failed-customers CSV a chunked run writes as sends fail
+var failedFile struct {
+	file   io.WriteCloser
+	writer *csv.Writer
+	count  int
+	failed bool // Couldn't be created
+}
+
+// appendFailedCustomer counts a chunked run's failed customer and, with
+// SaveFailed, writes it to the failed-customers CSV
+func appendFailedCustomer(c Customer) {
+	failedFile.count++
+	if !config.SaveFailed || config.Simulate.Enabled || failedFile.failed {
+		return
+	}
+	if failedFile.writer == nil {
+		file, err := createDataFile(failedCustomersFile)
+		if err != nil {
+			log.Error("Failed to create failed customers file", err)
+			failedFile.failed = true
+			return
+		}
+		failedFile.file, failedFile.writer = file, csv.NewWriter(file)
+		failedFile.writer.Write(failedCustomersHeader())
+	}
+	failedFile.writer.Write(failedCustomerRow(c))
+	failedFile.writer.Flush()
+}
+
+// closeFailedCustomers finishes the failed-customers CSV and returns how
+// many customers failed
+func closeFailedCustomers() int {
+	if failedFile.writer != nil {
+		failedFile.writer.Flush()
+		failedFile.file.Close()
+		failedFile.file, failedFile.writer = nil, nil
+		log.Info(fmt.Sprintf("Saved %d failed customers to %s (run 'venom retry-failed' to resend)", failedFile.count, failedCustomersFile))
+	}
+	return failedFile.count
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in a scratch directory, since much of the package
+// reads and writes under data/ and logs/, with a logger that only prints
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "venom-test-*")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	log = &logger{}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
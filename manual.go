@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// manualQuietPeriod is how long the campaign keeps slowed down after the
+// last sign of staff using the phone
+const manualQuietPeriod = 5 * time.Minute
+
+// A burst of staff activity is manualBurst messages sent from the phone
+// within manualBurstWindow; it pauses sending for ManualCoolDown
+const (
+	manualBurst       = 3
+	manualBurstWindow = 2 * time.Minute
+)
+
+// manualCallLimit is how long a call keeps sending paused if its end is
+// never seen
+const manualCallLimit = time.Hour
+
+// manualTraffic tracks staff using the sending number from the phone, so
+// the campaign leaves them room (ReserveHeadroom)
+type manualTraffic struct {
+	mu          sync.Mutex
+	outbound    []time.Time // Messages staff sent in the last hour
+	slowed      bool
+	callStarted time.Time // Call in progress on the number, zero when none
+	pausedUntil time.Time // End of the cool-down after a call or a burst
+}
+
+var manual = &manualTraffic{}
+
+// handleMessage notes messages staff sent from the phone or another linked
+// device. Customer messages aren't counted: they come in whether or not
+// anyone is at the phone, and replies would keep the campaign slowed down.
+func (m *manualTraffic) handleMessage(client *whatsmeow.Client, evt *events.Message) {
+	if (config.ReserveHeadroom <= 0 && config.ManualCoolDown <= 0) || evt.Info.Chat.Server == types.BroadcastServer {
+		return
+	}
+	// The campaign's own sends aren't echoed back; these came from another device
+	if !evt.Info.IsFromMe || (client.Store.ID != nil && evt.Info.Sender.Device == client.Store.ID.Device) {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outbound = append(m.outbound, evt.Info.Timestamp)
+	m.checkBurst()
+}
+
+// checkBurst starts a cool-down when staff sent several messages in a row.
+// The caller holds m.mu.
+func (m *manualTraffic) checkBurst() {
+	if config.ManualCoolDown <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-manualBurstWindow)
+	recent := 0
+	for _, t := range m.outbound {
+		if t.After(cutoff) {
+			recent++
+		}
+	}
+	if recent >= manualBurst {
+		m.coolDown(fmt.Sprintf("%d messages sent from the phone", recent))
+	}
+}
+
+// coolDown pauses sending for ManualCoolDown from now. The caller holds m.mu.
+func (m *manualTraffic) coolDown(reason string) {
+	until := time.Now().Add(time.Duration(config.ManualCoolDown) * time.Second)
+	if until.Before(m.pausedUntil) {
+		return
+	}
+	if !m.pausedUntil.After(time.Now()) && m.callStarted.IsZero() {
+		log.Info(fmt.Sprintf("%s: pausing sending until %s", reason, until.Format("15:04:05")))
+	}
+	m.pausedUntil = until
+}
+
+// handleCall pauses sending while a call rings or is answered on the
+// number, and for ManualCoolDown after it ends
+func (m *manualTraffic) handleCall(evt any) {
+	if config.ManualCoolDown <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch evt := evt.(type) {
+	case *events.CallOffer:
+		m.coolDown("Incoming call from +" + evt.From.User)
+	case *events.CallOfferNotice:
+		m.coolDown("Incoming group call")
+	case *events.CallAccept:
+		m.callStarted = time.Now()
+	case *events.CallTerminate, *events.CallReject:
+		m.callStarted = time.Time{}
+		m.coolDown("Call ended")
+	}
+}
+
+// waitCoolDown holds sending during a call and its cool-down. It returns
+// false if the campaign was aborted meanwhile.
+func (m *manualTraffic) waitCoolDown(ctx context.Context) bool {
+	if config.ManualCoolDown <= 0 {
+		return true
+	}
+	waited := false
+	for {
+		m.mu.Lock()
+		inCall := !m.callStarted.IsZero() && time.Since(m.callStarted) < manualCallLimit
+		left := time.Until(m.pausedUntil)
+		m.mu.Unlock()
+		if !inCall && left <= 0 {
+			break
+		}
+		waited = true
+		status.refresh(max(left, 0), "cool-down")
+		if !sleepWithContext(ctx, time.Second) {
+			return false
+		}
+	}
+	if waited {
+		log.Info("Cool-down over, resuming sending")
+	}
+	return ctx.Err() == nil
+}
+
+// sentLastHour counts the messages staff sent in the last hour
+func (m *manualTraffic) sentLastHour() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := time.Now().Add(-time.Hour)
+	kept := m.outbound[:0]
+	for _, t := range m.outbound {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	m.outbound = kept
+	return len(kept)
+}
+
+// busy reports whether staff sent a message lately
+func (m *manualTraffic) busy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := time.Now().Add(-manualQuietPeriod)
+	return len(m.outbound) > 0 && m.outbound[len(m.outbound)-1].After(cutoff)
+}
+
+// slowDown doubles a delay while staff are using the phone
+func (m *manualTraffic) slowDown(delay int) int {
+	if config.ReserveHeadroom <= 0 {
+		return delay
+	}
+	busy := m.busy()
+	m.mu.Lock()
+	changed := busy != m.slowed
+	m.slowed = busy
+	m.mu.Unlock()
+	if changed && busy {
+		log.Info("Staff are using the phone, slowing down")
+	} else if changed {
+		log.Info("No manual activity for a while, back to normal speed")
+	}
+	if busy {
+		return delay * 2
+	}
+	return delay
+}
+
+// campaignHourlyLimit is the part of HourlyLimit the campaign may use: the
+// headroom is kept free, and more is given up if staff send beyond it
+func campaignHourlyLimit() int {
+	if config.ReserveHeadroom <= 0 {
+		return config.HourlyLimit
+	}
+	reserved := int(float64(config.HourlyLimit) * min(config.ReserveHeadroom, 1))
+	return max(config.HourlyLimit-max(reserved, manual.sentLastHour()), 0)
+}
+
+// checkReserveHeadroom rejects a headroom that would leave the campaign
+// no sends at all
+func checkReserveHeadroom(headroom float64) error {
+	if headroom < 0 || headroom >= 1 {
+		return fmt.Errorf("ReserveHeadroom %.2f must be from 0 up to, but not including, 1", headroom)
+	}
+	return nil
+}
+
+// describeHeadroom explains an hourly limit reduced for manual use
+func describeHeadroom(limit int) string {
+	if limit == config.HourlyLimit {
+		return ""
+	}
+	return fmt.Sprintf(", %d kept free for staff", config.HourlyLimit-limit)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownTemplates marks templates loaded from .md files, which are
+// converted to WhatsApp formatting when rendered
+var markdownTemplates = map[string]bool{}
+
+// boldMarker stands in for WhatsApp's '*' while italics are converted,
+// so bold text isn't mistaken for Markdown italics
+const boldMarker = "\x01"
+
+var markdownRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+(.+?)[ \t#]*$`), boldMarker + "$1" + boldMarker}, // # Heading
+	{regexp.MustCompile(`(?m)^([ \t]*)[-*+][ \t]+`), "$1• "},                                     // - bullet
+	{regexp.MustCompile(`\*\*(.+?)\*\*`), boldMarker + "$1" + boldMarker},                        // **bold**
+	{regexp.MustCompile(`__(.+?)__`), boldMarker + "$1" + boldMarker},                            // __bold__
+	{regexp.MustCompile(`\*([^*\n]+?)\*`), "_${1}_"},                                             // *italic*
+	{regexp.MustCompile(`~~(.+?)~~`), "~$1~"},                                                    // ~~strike~~
+	{regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`), "$1 ($2)"},                                 // [text](url)
+}
+
+// markdownToWhatsApp translates basic Markdown into WhatsApp formatting:
+// **bold** → *bold*, *italic* → _italic_, ~~strike~~ → ~strike~,
+// headings → bold lines, list items → "• " bullets, links → "text (url)"
+func markdownToWhatsApp(text string) string {
+	for _, rule := range markdownRules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return strings.ReplaceAll(text, boldMarker, "*")
+}
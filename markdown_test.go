@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestMarkdownToWhatsApp(t *testing.T) {
+	tests := []struct {
+		name, markdown, want string
+	}{
+		{"bold", "**Sale** today", "*Sale* today"},
+		{"underscore bold", "__Sale__ today", "*Sale* today"},
+		{"italic", "*only* today", "_only_ today"},
+		{"bold and italic", "**Sale** on *everything*", "*Sale* on _everything_"},
+		{"strikethrough", "~~100~~ 80 EGP", "~100~ 80 EGP"},
+		{"heading", "# Offers", "*Offers*"},
+		{"closed heading", "## Offers ##", "*Offers*"},
+		{"hash without space is text", "#1 pharmacy", "#1 pharmacy"},
+		{"bullets", "- vitamins\n* masks\n+ gloves", "• vitamins\n• masks\n• gloves"},
+		{"indented bullet", "  - masks", "  • masks"},
+		{"link", "[our site](https://example.com)", "our site (https://example.com)"},
+		{"lone asterisk", "5 * 3", "5 * 3"},
+		{"Arabic", "**خصم** على *كل* المنتجات", "*خصم* على _كل_ المنتجات"},
+		{"plain text", "Hello {CustomerName}", "Hello {CustomerName}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownToWhatsApp(tt.markdown); got != tt.want {
+				t.Errorf("markdownToWhatsApp(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// uploadedMedia is a media file already uploaded to WhatsApp's servers.
+// Uploads can be reused for every recipient, so a cached file isn't uploaded again.
+type uploadedMedia struct {
+	whatsmeow.UploadResponse
+	Data []byte // Plaintext file contents
+}
+
+// The cache keeps the most recently used uploads only: files chosen per
+// customer (a voice note column, say) would otherwise stay in memory for the
+// whole campaign. Media shared by every message is used on every send, so it
+// is never the one evicted.
+const (
+	mediaCacheEntries = 32
+	mediaCacheBytes   = 128 << 20
+)
+
+var (
+	mediaCacheMu    sync.Mutex
+	mediaCache      = map[string]*list.Element{} // Values are *cachedMedia
+	mediaCacheOrder = list.New()                 // Most recently used first
+	mediaCacheSize  int                          // Bytes of Data held
+)
+
+// cachedMedia is an upload in mediaCacheOrder
+type cachedMedia struct {
+	key   string
+	media *uploadedMedia
+}
+
+// uploadMediaFile uploads a file, or returns its cached upload for the media type
+func uploadMediaFile(client Sender, path string, mediaType whatsmeow.MediaType) (*uploadedMedia, error) {
+	key := string(mediaType) + ":" + path
+
+	mediaCacheMu.Lock()
+	defer mediaCacheMu.Unlock()
+
+	if elem, ok := mediaCache[key]; ok {
+		mediaCacheOrder.MoveToFront(elem)
+		return elem.Value.(*cachedMedia).media, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("media file %s is empty", path)
+	}
+
+	resp, err := client.Upload(context.Background(), data, mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+
+	media := &uploadedMedia{UploadResponse: resp, Data: data}
+	mediaCache[key] = mediaCacheOrder.PushFront(&cachedMedia{key: key, media: media})
+	mediaCacheSize += len(data)
+	for mediaCacheOrder.Len() > 1 && (mediaCacheOrder.Len() > mediaCacheEntries || mediaCacheSize > mediaCacheBytes) {
+		oldest := mediaCacheOrder.Remove(mediaCacheOrder.Back()).(*cachedMedia)
+		delete(mediaCache, oldest.key)
+		mediaCacheSize -= len(oldest.media.Data)
+	}
+	log.Info(fmt.Sprintf("Uploaded %s (%d bytes)", path, len(data)))
+	return media, nil
+}
+
+// resetMediaCache forgets earlier uploads, e.g. after switching transports
+func resetMediaCache() {
+	mediaCacheMu.Lock()
+	defer mediaCacheMu.Unlock()
+	mediaCache = map[string]*list.Element{}
+	mediaCacheOrder.Init()
+	mediaCacheSize = 0
+}
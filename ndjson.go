@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// eventStream writes lifecycle events to stdout as one JSON object per line
+// with --output ndjson; nil when output is for people
+var eventStream *json.Encoder
+
+var eventStreamMu sync.Mutex
+
+// Event names emitted on the stream
+const (
+	eventCampaignStarted  = "campaign_started"
+	eventRowLoaded        = "row_loaded"
+	eventValidated        = "validated"
+	eventSkipped          = "skipped"
+	eventSent             = "sent"
+	eventFailed           = "failed"
+	eventBatchBreak       = "batch_break"
+	eventRateLimitWait    = "rate_limit_wait"
+	eventBusinessHours    = "business_hours_wait"
+	eventCampaignFinished = "campaign_finished"
+)
+
+// enableEventStream sends events to stdout and everything printed for
+// people to stderr, so wrappers can read stdout line by line
+func enableEventStream() {
+	eventStream = json.NewEncoder(os.Stdout)
+	eventStream.SetEscapeHTML(false)
+	os.Stdout = os.Stderr
+	enablePlainOutput()
+}
+
+// emitEvent writes one event with its fields; a no-op without --output ndjson
+func emitEvent(name string, fields map[string]interface{}) {
+	if eventStream == nil {
+		return
+	}
+
+	event := map[string]interface{}{
+		"event": name,
+		"time":  time.Now().Format(time.RFC3339),
+	}
+	for key, value := range fields {
+		event[key] = value
+	}
+
+	eventStreamMu.Lock()
+	defer eventStreamMu.Unlock()
+	eventStream.Encode(event)
+}
+
+// customerEventFields identifies a customer in an event
+func customerEventFields(customer Customer) map[string]interface{} {
+	return map[string]interface{}{
+		"code":   customer.Code,
+		"name":   customer.CustomerName,
+		"phone":  customer.Phone,
+		"mobile": customer.Mobile,
+	}
+}
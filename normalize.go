@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisibleRunes are zero-width and formatting characters that sneak in
+// from copy-pasted names and break matching and rendering
+var invisibleRunes = map[rune]bool{
+	'\u00AD': true, // soft hyphen
+	'\u200B': true, // zero width space
+	'\u200C': true, // zero width non-joiner
+	'\u200D': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark / zero width no-break space
+}
+
+// sanitizeText cleans customer names and templates before rendering:
+// repairs Windows-1256/1252 mojibake, folds Arabic presentation forms back
+// to regular letters and strips zero-width characters
+func sanitizeText(text string) string {
+	if !config.SanitizeText {
+		return text
+	}
+
+	text = repairMojibake(text)
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case invisibleRunes[r]:
+			continue
+		case isArabicPresentationForm(r):
+			b.WriteString(norm.NFKC.String(string(r)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isArabicPresentationForm reports whether r is in the Arabic Presentation Forms-A/B blocks
+func isArabicPresentationForm(r rune) bool {
+	return (r >= 0xFB50 && r <= 0xFDFF) || (r >= 0xFE70 && r <= 0xFEFC)
+}
+
+// repairMojibake undoes UTF-8 Arabic that was decoded as Windows-1256
+// ("ط£ط­ظ…ط¯") or Windows-1252 ("Ø£Ø­Ù…Ø¯") and saved again as UTF-8
+func repairMojibake(text string) string {
+	// UTF-8 Arabic lead bytes 0xD8/0xD9 show up as these pairs of characters
+	candidates := []struct {
+		markers string
+		decoder *charmap.Charmap
+	}{
+		{"طظ", charmap.Windows1256},
+		{"ØÙ", charmap.Windows1252},
+	}
+
+	for _, c := range candidates {
+		if !looksLikeMojibake(text, c.markers) {
+			continue
+		}
+		raw, err := c.decoder.NewEncoder().String(text)
+		if err != nil || !utf8.ValidString(raw) {
+			continue
+		}
+		if containsArabic(raw) {
+			return raw
+		}
+	}
+	return text
+}
+
+// looksLikeMojibake reports whether at least a third of the letters are lead-byte markers
+func looksLikeMojibake(text, markers string) bool {
+	letters, hits := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		letters++
+		if strings.ContainsRune(markers, r) {
+			hits++
+		}
+	}
+	return letters > 0 && hits*3 >= letters
+}
+
+// containsArabic reports whether text has any Arabic letters
+func containsArabic(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Arabic, r) {
+			return true
+		}
+	}
+	return false
+}
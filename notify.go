@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// NotifyConfig controls operator notifications
+type NotifyConfig struct {
+	Desktop     bool    // Show an OS notification
+	Bell        bool    // Ring the terminal bell
+	StallErrors int     // Failed sends in a row that count as a stall (0 = never)
+	SpikeRate   float64 // Share of the last 20 sends failing that counts as a spike (0 = never)
+
+	// Chat alerts for people away from the terminal
+	SlackWebhook string         // Slack incoming webhook URL
+	Telegram     TelegramConfig // Telegram bot and chat
+	Events       []string       // Events to post to chat (empty = all)
+}
+
+// Events the operator is notified about
+const (
+	notifyStarted      = "started"
+	notifyFinished     = "finished"
+	notifyAborted      = "aborted"
+	notifyDailyLimit   = "daily_limit"
+	notifyStalled      = "stalled"
+	notifyFailureSpike = "failure_spike"
+	notifyLoggedOut    = "logged_out"
+	notifyCSVRejected  = "csv_rejected"
+)
+
+// notifyOperator tells the operator about a campaign event without them
+// watching the terminal: bell, desktop and chat alerts. Failures to notify
+// are only logged.
+func notifyOperator(event, title, message string) {
+	log.Info(fmt.Sprintf("Notify (%s): %s — %s", event, title, message))
+	sendRemoteAlert(event, title, message)
+
+	if config.Notify.Bell {
+		// stderr reaches the terminal even while the dashboard or --output ndjson owns stdout
+		fmt.Fprint(os.Stderr, "\a")
+	}
+	if config.Notify.Desktop {
+		if err := desktopNotification(title, message); err != nil {
+			log.Debug(fmt.Sprintf("Desktop notification failed: %v", err))
+		}
+	}
+}
+
+// desktopNotification shows a native notification on Linux, macOS or Windows
+func desktopNotification(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=Venom", title, message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms;`+
+			`$n = New-Object System.Windows.Forms.NotifyIcon;`+
+			`$n.Icon = [System.Drawing.SystemIcons]::Information;`+
+			`$n.Visible = $true;`+
+			`$n.ShowBalloonTip(10000, %s, %s, 'Info');`+
+			`Start-Sleep -Seconds 10; $n.Dispose()`,
+			powerShellString(title), powerShellString(message))
+		// The balloon needs the process alive; don't hold up the campaign for it
+		return exec.Command("powershell", "-NoProfile", "-Command", script).Start()
+	default:
+		return fmt.Errorf("no desktop notifications on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
+
+// appleScriptString quotes text for AppleScript
+func appleScriptString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
+
+// powerShellString quotes text for PowerShell
+func powerShellString(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// spikeWindow is how many recent sends the failure spike check looks at
+const spikeWindow = 20
+
+// stallWatch notices when sends keep failing, in a row or in bulk
+type stallWatch struct {
+	failures int
+	notified bool
+
+	recent       []bool // Last spikeWindow results, true = failed
+	spikeAlerted bool
+}
+
+// record counts a send result and notifies once per run of failures
+func (w *stallWatch) record(success bool, lastError string) {
+	w.recordSpike(success, lastError)
+
+	if success {
+		w.failures, w.notified = 0, false
+		return
+	}
+	w.failures++
+	if config.Notify.StallErrors > 0 && w.failures >= config.Notify.StallErrors && !w.notified {
+		w.notified = true
+		notifyOperator(notifyStalled, "Campaign stalled",
+			fmt.Sprintf("%d sends failed in a row; last error: %s", w.failures, lastError))
+	}
+}
+
+// recordSpike notifies when the share of recent failures crosses SpikeRate,
+// and again only after it has dropped back below it
+func (w *stallWatch) recordSpike(success bool, lastError string) {
+	w.recent = append(w.recent, !success)
+	if len(w.recent) > spikeWindow {
+		w.recent = w.recent[1:]
+	}
+	if config.Notify.SpikeRate <= 0 || len(w.recent) < spikeWindow {
+		return
+	}
+
+	failed := 0
+	for _, f := range w.recent {
+		if f {
+			failed++
+		}
+	}
+	rate := float64(failed) / float64(len(w.recent))
+	switch {
+	case rate >= config.Notify.SpikeRate && !w.spikeAlerted:
+		w.spikeAlerted = true
+		notifyOperator(notifyFailureSpike, "Failure spike",
+			fmt.Sprintf("%d of the last %d sends failed; last error: %s", failed, len(w.recent), lastError))
+	case rate < config.Notify.SpikeRate:
+		w.spikeAlerted = false
+	}
+}
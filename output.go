@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// plainOutput disables colors, progress bars and carriage-return rewriting so
+// output reads cleanly in tee, nohup logs and the systemd journal
+var plainOutput bool
+
+// applyOutputFlags turns on plain output for --plain or --quiet, the event
+// stream for --output ndjson and the console language for --ui-lang
+// (anywhere on the command line, or from the config), honours NO_COLOR, and
+// returns the arguments without those flags
+func applyOutputFlags(args []string) []string {
+	if os.Getenv("NO_COLOR") != "" {
+		disableColors()
+	}
+
+	kept := make([]string, 0, len(args))
+	plain := config.PlainOutput
+	format := config.Output
+	lang := config.UILanguage
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case (arg == "--ui-lang" || arg == "-ui-lang") && i+1 < len(args):
+			i++
+			lang = args[i]
+		case strings.HasPrefix(arg, "--ui-lang=") || strings.HasPrefix(arg, "-ui-lang="):
+			lang = arg[strings.Index(arg, "=")+1:]
+		case arg == "--plain" || arg == "-plain" || arg == "--quiet" || arg == "-quiet":
+			plain = true
+		case (arg == "--output" || arg == "-output") && i+1 < len(args):
+			i++
+			format = args[i]
+		case strings.HasPrefix(arg, "--output=") || strings.HasPrefix(arg, "-output="):
+			format = arg[strings.Index(arg, "=")+1:]
+		default:
+			kept = append(kept, arg)
+		}
+	}
+
+	if lang != "" {
+		setUILanguage(lang)
+	}
+
+	switch strings.ToLower(format) {
+	case "ndjson", "json":
+		enableEventStream()
+	case "", "text":
+		if plain {
+			enablePlainOutput()
+		}
+	default:
+		log.Warning(fmt.Sprintf("Unknown output format %q, using text", format))
+	}
+	return kept
+}
+
+// enablePlainOutput clears every color code and turns off the dashboard
+func enablePlainOutput() {
+	plainOutput = true
+	config.Dashboard.Enabled = false
+	disableColors()
+}
+
+// disableColors clears every color and style code
+func disableColors() {
+	for _, code := range []*string{
+		&colorReset, &colorRed, &colorGreen, &colorYellow, &colorBlue, &colorMagenta,
+		&colorCyan, &colorWhite, &colorGray,
+		&colorBrightRed, &colorBrightGreen, &colorBrightYellow, &colorBrightCyan,
+		&bgRed, &bgGreen, &bgYellow,
+		&bold, &dim, &underline, &blink,
+	} {
+		*code = ""
+	}
+}
+
+// console is where the logger and the send loop print. The dashboard points
+// it at its log pane while a campaign runs, so os.Stdout itself never changes
+// under the goroutines writing to it.
+var console = &consoleWriter{}
+
+// consoleWriter serializes writes and forwards them to the current target,
+// or to os.Stdout when none is set
+type consoleWriter struct {
+	mu     sync.Mutex
+	target io.Writer
+}
+
+// Write forwards p to the current target
+func (c *consoleWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.target != nil {
+		return c.target.Write(p)
+	}
+	return os.Stdout.Write(p)
+}
+
+// redirect sends console output to w, or back to os.Stdout when w is nil
+func (c *consoleWriter) redirect(w io.Writer) {
+	c.mu.Lock()
+	c.target = w
+	c.mu.Unlock()
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mau.fi/whatsmeow"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"google.golang.org/protobuf/proto"
+	"rsc.io/qr"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// PersonalImageConfig draws each customer's details onto a promo image and
+// sends them their own copy after the message
+type PersonalImageConfig struct {
+	Base     string         // PNG or JPEG promo image to draw on
+	Font     string         // TrueType/OpenType font file; Go Bold if empty
+	Caption  string         // Text under the image; placeholders allowed
+	Overlays []ImageOverlay // What to draw where
+}
+
+// ImageOverlay is one piece of text or QR code drawn on the promo image
+type ImageOverlay struct {
+	Text  string  // Placeholder text, e.g. "{CustomerName}", or the QR code's content; no Arabic or Hebrew
+	QR    bool    // Draw Text as a QR code
+	X, Y  int     // Top-left corner in pixels; X is the center or right edge with Align
+	Size  float64 // Font size in pixels, or the QR code's width
+	Color string  // "#RRGGBB"; black if empty
+	Align string  // "left" (default), "center" or "right"
+}
+
+// personalImageEnabled reports whether personalized images are configured
+func personalImageEnabled() bool {
+	return config.PersonalImage.Base != ""
+}
+
+// personalImageBase and personalImageFont are loaded once by preparePersonalImage
+var (
+	personalImageBase image.Image
+	personalImageFont *opentype.Font
+)
+
+// preparePersonalImage loads the base image and font and checks the
+// overlays before anything is sent
+func preparePersonalImage() error {
+	file, err := os.Open(config.PersonalImage.Base)
+	if err != nil {
+		return fmt.Errorf("base image: %w", err)
+	}
+	defer file.Close()
+	base, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("base image %s: %w", config.PersonalImage.Base, err)
+	}
+
+	fontData := gobold.TTF
+	if config.PersonalImage.Font != "" {
+		if fontData, err = os.ReadFile(config.PersonalImage.Font); err != nil {
+			return fmt.Errorf("font: %w", err)
+		}
+	}
+	parsed, err := opentype.Parse(fontData)
+	if err != nil {
+		return fmt.Errorf("font %s: %w", config.PersonalImage.Font, err)
+	}
+
+	if len(config.PersonalImage.Overlays) == 0 {
+		return fmt.Errorf("PersonalImage.Overlays is empty; nothing would be drawn")
+	}
+	for i, overlay := range config.PersonalImage.Overlays {
+		if _, err := parseHexColor(overlay.Color); err != nil {
+			return fmt.Errorf("overlay %d: %w", i+1, err)
+		}
+		if overlay.Size <= 0 {
+			return fmt.Errorf("overlay %d: Size must be above 0", i+1)
+		}
+		if !image.Pt(overlay.X, overlay.Y).In(base.Bounds()) {
+			return fmt.Errorf("overlay %d: (%d, %d) is outside the %dx%d image",
+				i+1, overlay.X, overlay.Y, base.Bounds().Dx(), base.Bounds().Dy())
+		}
+		if !overlay.QR {
+			if reason := undrawableText(parsed, overlay.Text); reason != "" {
+				return fmt.Errorf("overlay %d: %s", i+1, reason)
+			}
+		}
+	}
+	personalImageBase, personalImageFont = base, parsed
+	return nil
+}
+
+// renderPersonalImage draws a customer's overlays on a copy of the base image
+func renderPersonalImage(customer ProcessedCustomer) ([]byte, error) {
+	canvas := image.NewRGBA(personalImageBase.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), personalImageBase, personalImageBase.Bounds().Min, draw.Src)
+
+	for i, overlay := range config.PersonalImage.Overlays {
+		text := renderPlaceholders(overlay.Text, customer)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		ink, _ := parseHexColor(overlay.Color)
+		if overlay.QR {
+			if err := drawQR(canvas, text, overlay); err != nil {
+				return nil, fmt.Errorf("overlay %d: %w", i+1, err)
+			}
+			continue
+		}
+		// A name in Arabic would come out as boxes or unjoined letters in
+		// reverse order; better no image than a garbled one
+		if reason := undrawableText(personalImageFont, text); reason != "" {
+			return nil, fmt.Errorf("overlay %d: %s", i+1, reason)
+		}
+		if err := drawText(canvas, text, overlay, ink); err != nil {
+			return nil, fmt.Errorf("overlay %d: %w", i+1, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// undrawableText says why text can't be drawn with f, or returns "". The
+// renderer draws glyphs one by one left to right, without the shaping and
+// reordering Arabic and Hebrew need.
+func undrawableText(f *opentype.Font, text string) string {
+	var buf sfnt.Buffer
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Arabic, unicode.Hebrew):
+			return fmt.Sprintf("can't draw right-to-left text %q; use a Latin-script overlay or leave it out", text)
+		case unicode.IsSpace(r) || unicode.IsControl(r):
+		default:
+			if index, err := f.GlyphIndex(&buf, r); err != nil || index == 0 {
+				return fmt.Sprintf("the font has no glyph for %q in %q", r, text)
+			}
+		}
+	}
+	return ""
+}
+
+// drawText writes one line of text with its top at overlay.Y
+func drawText(canvas *image.RGBA, text string, overlay ImageOverlay, ink color.Color) error {
+	face, err := opentype.NewFace(personalImageFont, &opentype.FaceOptions{
+		Size:    overlay.Size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{Dst: canvas, Src: image.NewUniform(ink), Face: face}
+	x := fixed.I(overlay.X)
+	switch overlay.Align {
+	case "center":
+		x -= drawer.MeasureString(text) / 2
+	case "right":
+		x -= drawer.MeasureString(text)
+	}
+	drawer.Dot = fixed.Point26_6{X: x, Y: fixed.I(overlay.Y) + face.Metrics().Ascent}
+	drawer.DrawString(text)
+	return nil
+}
+
+// drawQR draws a QR code of text, with its quiet zone, overlay.Size pixels wide
+func drawQR(canvas *image.RGBA, text string, overlay ImageOverlay) error {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return err
+	}
+	const quiet = 4 // Modules of white border scanners need
+	modules := code.Size + 2*quiet
+	scale := int(overlay.Size) / modules
+	if scale < 1 {
+		return fmt.Errorf("a %d pixel QR code is too small for %q", int(overlay.Size), text)
+	}
+
+	x0, y0 := overlay.X, overlay.Y
+	switch overlay.Align {
+	case "center":
+		x0 -= modules * scale / 2
+	case "right":
+		x0 -= modules * scale
+	}
+	draw.Draw(canvas, image.Rect(x0, y0, x0+modules*scale, y0+modules*scale), image.White, image.Point{}, draw.Src)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				px, py := x0+(x+quiet)*scale, y0+(y+quiet)*scale
+				draw.Draw(canvas, image.Rect(px, py, px+scale, py+scale), image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return nil
+}
+
+// parseHexColor reads "#RRGGBB"; empty is black
+func parseHexColor(value string) (color.Color, error) {
+	if value == "" {
+		return color.Black, nil
+	}
+	hex := strings.TrimPrefix(value, "#")
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("invalid color %q (use #RRGGBB)", value)
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}, nil
+}
+
+// sendPersonalImage renders the customer's copy of the promo image and sends it
+func sendPersonalImage(client Sender, customer ProcessedCustomer, phone string) error {
+	data, err := renderPersonalImage(customer)
+	if err != nil {
+		return fmt.Errorf("failed to render image: %w", err)
+	}
+
+	caption := renderPlaceholders(config.PersonalImage.Caption, customer)
+	return sendGeneratedImage(client, phone, data, personalImageBase.Bounds(), caption)
+}
+
+// sendGeneratedImage uploads a JPEG made for one customer and sends it
+func sendGeneratedImage(client Sender, phone string, data []byte, bounds image.Rectangle, caption string) error {
+	// Every image is different, so uploads aren't cached like shared media
+	upload, err := client.Upload(context.Background(), data, whatsmeow.MediaImage)
+	if err != nil {
+		return fmt.Errorf("failed to upload image: %w", err)
+	}
+	message := &waE2E.ImageMessage{
+		URL:           proto.String(upload.URL),
+		DirectPath:    proto.String(upload.DirectPath),
+		MediaKey:      upload.MediaKey,
+		Mimetype:      proto.String("image/jpeg"),
+		FileEncSHA256: upload.FileEncSHA256,
+		FileSHA256:    upload.FileSHA256,
+		FileLength:    proto.Uint64(upload.FileLength),
+		Width:         proto.Uint32(uint32(bounds.Dx())),
+		Height:        proto.Uint32(uint32(bounds.Dy())),
+	}
+	if caption != "" {
+		message.Caption = proto.String(caption)
+	}
+
+	jid := recipientJID(client, phone)
+	if _, err := client.SendMessage(context.Background(), jid, &waE2E.Message{ImageMessage: message}); err != nil {
+		return fmt.Errorf("failed to send image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestUndrawableText(t *testing.T) {
+	f, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{"Ahmed Hassan", true},
+		{"Dear Zoë, 20% off!", true},
+		{"أحمد حسن", false},
+		{"Ahmed أحمد", false},
+		{"שלום", false},
+		{"王小明", false},
+	}
+	for _, tt := range tests {
+		if got := undrawableText(f, tt.text); (got == "") != tt.ok {
+			t.Errorf("undrawableText(%q) = %q, want ok=%v", tt.text, got, tt.ok)
+		}
+	}
+}
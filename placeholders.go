@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// placeholderPattern matches template placeholders such as {CustomerName}
+// or {CustomerName|عميلنا العزيز}, where the text after '|' is a fallback.
+// Extra columns are placeholders too, including dotted JSON keys ({address.city}).
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_.]*)(?:\|([^{}]*))?\}`)
+
+// renderPlaceholders fills customer placeholders into a template. A blank
+// value uses the placeholder's fallback, if it has one, and logs a warning.
+func renderPlaceholders(template string, customer ProcessedCustomer) string {
+	values := map[string]string{
+		"CustomerName": customer.CustomerName,
+		"Code":         customer.Code,
+		"Phone":        customer.Phone,
+		"Mobile":       customer.Mobile,
+	}
+	for name, value := range customer.Fields {
+		if _, fixed := values[name]; !fixed {
+			values[name] = value
+		}
+	}
+	for name, value := range timePlaceholders(customer.Customer, time.Now()) {
+		values[name] = value
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		name, hasFallback := parts[1], strings.Contains(match, "|")
+
+		value, known := values[name]
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+		if hasFallback {
+			log.Warning(fmt.Sprintf("Customer %s has no %s, using fallback %q", customer.Code, name, parts[2]))
+			return parts[2]
+		}
+		if !known {
+			return match // not a placeholder we fill, leave the text alone
+		}
+		return value
+	})
+}
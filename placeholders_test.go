@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestRenderPlaceholders(t *testing.T) {
+	customer := ProcessedCustomer{Customer: Customer{
+		Code:         "C7",
+		CustomerName: "Mona",
+		Phone:        "",
+		Mobile:       "01001234567",
+		Fields:       map[string]string{"branch": "Maadi", "address.city": "Cairo", "CustomerName": "Overridden", "note": "  "},
+	}}
+	tests := []struct {
+		name, template, want string
+	}{
+		{"fixed column", "Hi {CustomerName} ({Code})", "Hi Mona (C7)"},
+		{"extra column", "Your branch: {branch}", "Your branch: Maadi"},
+		{"dotted JSON key", "{address.city}", "Cairo"},
+		{"extra column can't replace a fixed one", "{CustomerName}", "Mona"},
+		{"filled value ignores fallback", "{CustomerName|عميلنا العزيز}", "Mona"},
+		{"blank value uses fallback", "{Phone|no phone}", "no phone"},
+		{"whitespace value uses fallback", "{note|none}", "none"},
+		{"Arabic fallback", "مرحباً {title|عميلنا العزيز}", "مرحباً عميلنا العزيز"},
+		{"empty fallback", "Dear {title|}customer", "Dear customer"},
+		{"blank value without fallback", "[{Phone}]", "[]"},
+		{"unknown placeholder kept", "{Unknown} stays", "{Unknown} stays"},
+		{"not a placeholder", "{1st} {} {a b}", "{1st} {} {a b}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderPlaceholders(tt.template, customer); got != tt.want {
+				t.Errorf("renderPlaceholders(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// PollConfig configures poll campaigns (CampaignType "poll")
+type PollConfig struct {
+	Question        string   // Poll question; supports the same placeholders as templates
+	Options         []string // Answer options (2-12)
+	SelectableCount int      // How many options a customer may pick (0 = any)
+	ResultsFile     string   // CSV that votes are appended to
+	CollectMinutes  int      // Keep listening for votes this long after the last send
+}
+
+// pollTracker maps sent polls back to customers and records incoming votes
+type pollTracker struct {
+	mu         sync.Mutex
+	recipients map[types.MessageID]Customer
+	optionOf   map[string]string // hex SHA-256 of option name -> option name
+	tally      map[string]int
+	voters     map[types.MessageID][]string // latest selection per poll message
+	file       io.WriteCloser
+	writer     *csv.Writer
+}
+
+var polls *pollTracker
+
+// isPollCampaign reports whether the campaign sends polls instead of text
+func isPollCampaign() bool {
+	return config.CampaignType == "poll"
+}
+
+// validatePollConfig checks the poll section before any sends happen
+func validatePollConfig() error {
+	if strings.TrimSpace(config.Poll.Question) == "" {
+		return fmt.Errorf("Poll.Question is required for poll campaigns")
+	}
+	if len(config.Poll.Options) < 2 || len(config.Poll.Options) > 12 {
+		return fmt.Errorf("Poll.Options must have between 2 and 12 entries, got %d", len(config.Poll.Options))
+	}
+	if config.Poll.SelectableCount < 0 || config.Poll.SelectableCount > len(config.Poll.Options) {
+		return fmt.Errorf("Poll.SelectableCount must be between 0 and %d", len(config.Poll.Options))
+	}
+	return nil
+}
+
+// newPollTracker opens the results CSV and prepares option hash lookups
+func newPollTracker(resultsFile string) (*pollTracker, error) {
+	os.MkdirAll(filepath.Dir(resultsFile), 0755)
+
+	_, statErr := os.Stat(resultsFile)
+	file, err := appendDataFile(resultsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(file)
+	if os.IsNotExist(statErr) {
+		writer.Write([]string{"Timestamp", "Code", "CustomerName", "Phone", "Options", "PollMessageID"})
+		writer.Flush()
+	}
+
+	t := &pollTracker{
+		recipients: make(map[types.MessageID]Customer),
+		optionOf:   make(map[string]string),
+		tally:      make(map[string]int),
+		voters:     make(map[types.MessageID][]string),
+		file:       file,
+		writer:     writer,
+	}
+	for i, hash := range whatsmeow.HashPollOptions(config.Poll.Options) {
+		t.optionOf[hex.EncodeToString(hash)] = config.Poll.Options[i]
+	}
+	return t, nil
+}
+
+// track remembers which customer a poll message was sent to
+func (t *pollTracker) track(id types.MessageID, customer Customer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recipients[id] = customer
+}
+
+// handleVote decrypts a poll vote event and appends it to the results CSV
+func (t *pollTracker) handleVote(client *whatsmeow.Client, evt *events.Message) {
+	update := evt.Message.GetPollUpdateMessage()
+	pollID := types.MessageID(update.GetPollCreationMessageKey().GetID())
+
+	t.mu.Lock()
+	customer, ours := t.recipients[pollID]
+	t.mu.Unlock()
+	if !ours {
+		return // vote on a poll from another campaign or chat
+	}
+
+	vote, err := client.DecryptPollVote(context.Background(), evt)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to decrypt poll vote from %s", customer.CustomerName), err)
+		return
+	}
+
+	selected := []string{}
+	for _, hash := range vote.GetSelectedOptions() {
+		if name, ok := t.optionOf[hex.EncodeToString(hash)]; ok {
+			selected = append(selected, name)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A changed vote replaces the customer's previous selection in the tally
+	for _, name := range t.voters[pollID] {
+		t.tally[name]--
+	}
+	for _, name := range selected {
+		t.tally[name]++
+	}
+	t.voters[pollID] = selected
+
+	t.writer.Write([]string{
+		evt.Info.Timestamp.Format("2006-01-02 15:04:05"),
+		customer.Code,
+		customer.CustomerName,
+		evt.Info.Sender.User,
+		strings.Join(selected, "; "),
+		string(pollID),
+	})
+	t.writer.Flush()
+
+	if len(selected) == 0 {
+		log.Info(fmt.Sprintf("Poll vote retracted by %s", customer.CustomerName))
+	} else {
+		log.Info(fmt.Sprintf("Poll vote from %s: %s", customer.CustomerName, strings.Join(selected, ", ")))
+	}
+}
+
+// collectVotes keeps the client connected for CollectMinutes so late votes are recorded
+func (t *pollTracker) collectVotes(ctx context.Context) {
+	if config.Poll.CollectMinutes <= 0 {
+		return
+	}
+
+	displayInfo("Collecting Poll Votes",
+		fmt.Sprintf("Listening for votes for %d minutes", config.Poll.CollectMinutes),
+		[]string{"Press Ctrl+C to stop early", "Votes are saved to " + config.Poll.ResultsFile})
+
+	sleepWithContext(ctx, time.Duration(config.Poll.CollectMinutes)*time.Minute)
+}
+
+// displayResults prints the vote tally and closes the results file
+func (t *pollTracker) displayResults() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	options := append([]string{}, config.Poll.Options...)
+	sort.SliceStable(options, func(i, j int) bool { return t.tally[options[i]] > t.tally[options[j]] })
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Println("POLL RESULTS")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("Question:   %s\n", config.Poll.Question)
+	fmt.Printf("Polls Sent: %d\n", len(t.recipients))
+	fmt.Printf("Voters:     %d\n", len(t.voters))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, option := range options {
+		fmt.Printf("  %-40s %d\n", option, t.tally[option])
+	}
+	fmt.Println(strings.Repeat("=", 60) + "\n")
+
+	t.writer.Flush()
+	t.file.Close()
+}
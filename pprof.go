@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// startProfiling serves Go's runtime profiles on config.Pprof, for looking
+// into memory and CPU use during very large campaigns
+func startProfiling() {
+	if config.Pprof == "" {
+		return
+	}
+	listener, err := net.Listen("tcp", config.Pprof)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Profiling unavailable: %v", err))
+		return
+	}
+	if host, _, _ := net.SplitHostPort(config.Pprof); !isLoopbackHost(host) {
+		log.Warning("Profiles are served to the whole network; they can reveal message contents in memory")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warning(fmt.Sprintf("Profiling stopped: %v", err))
+		}
+	}()
+	log.Info(fmt.Sprintf("Profiles on http://%s/debug/pprof/", listener.Addr()))
+}
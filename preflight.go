@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// PreflightConfig controls the account health check run before sending
+type PreflightConfig struct {
+	Enabled  bool
+	SelfPing bool   // Send a message to our own number to prove sending works
+	Message  string // Text of the self-ping
+}
+
+// runPreflightCheck verifies the session can actually send before the
+// campaign starts, so a dead session fails once with a clear reason instead
+// of once per customer
+func runPreflightCheck(ctx context.Context, client *whatsmeow.Client) error {
+	log.Info("Running pre-flight checks...")
+
+	// Login completes shortly after the websocket connects
+	deadline := time.Now().Add(20 * time.Second)
+	for !isOnline(client) {
+		if time.Now().After(deadline) {
+			if !client.IsConnected() {
+				return fmt.Errorf("not connected to WhatsApp")
+			}
+			return fmt.Errorf("connected but not logged in; the device may have been unlinked")
+		}
+		if !sleepWithContext(ctx, 500*time.Millisecond) {
+			return ctx.Err()
+		}
+	}
+
+	own := client.Store.ID
+	if own == nil {
+		return fmt.Errorf("session has no account; scan the QR code to link one")
+	}
+	ownJID := own.ToNonAD()
+
+	info, err := client.GetUserInfo([]types.JID{ownJID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch own profile: %w", err)
+	}
+	details := []string{
+		fmt.Sprintf("Account: +%s", ownJID.User),
+		fmt.Sprintf("Name: %s", client.Store.PushName),
+	}
+	if profile, ok := info[ownJID]; ok {
+		if profile.VerifiedName != nil && profile.VerifiedName.Details != nil {
+			details = append(details, fmt.Sprintf("Business name: %s", profile.VerifiedName.Details.GetVerifiedName()))
+		}
+		details = append(details, fmt.Sprintf("Linked devices: %d", len(profile.Devices)))
+	}
+
+	if config.Preflight.SelfPing {
+		text := fmt.Sprintf("%s (%s)", config.Preflight.Message, time.Now().Format("2006-01-02 15:04:05"))
+		resp, err := client.SendMessage(ctx, ownJID, &waE2E.Message{Conversation: proto.String(text)})
+		if err != nil {
+			return fmt.Errorf("self-ping message failed: %w", err)
+		}
+		details = append(details, fmt.Sprintf("Self-ping delivered to server at %s", resp.Timestamp.Format("15:04:05")))
+	}
+
+	displayInfo("Pre-flight Checks Passed", "Session is connected and able to send", details)
+	return nil
+}
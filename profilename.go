@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// whatsAppProfileName returns the name a number uses on WhatsApp: the push
+// name this device has seen, or the verified business name. It is "" when
+// unknown, e.g. on other transports or for someone who never wrote to us.
+func whatsAppProfileName(client Sender, phone string) string {
+	cli, ok := whatsAppClient(client)
+	if !ok {
+		return ""
+	}
+	jid := types.NewJID(phone, types.DefaultUserServer)
+
+	// FullName is what we saved in our own address book, so it proves nothing
+	contact, err := cli.Store.Contacts.GetContact(context.Background(), jid)
+	if err == nil && contact.Found {
+		if contact.PushName != "" {
+			return contact.PushName
+		}
+		if contact.BusinessName != "" {
+			return contact.BusinessName
+		}
+	}
+
+	info, err := cli.GetUserInfo([]types.JID{jid})
+	if err != nil {
+		log.Debug(fmt.Sprintf("Failed to get WhatsApp profile of %s: %v", phone, err))
+		return ""
+	}
+	if verified := info[jid].VerifiedName; verified != nil && verified.Details != nil {
+		return verified.Details.GetVerifiedName()
+	}
+	return ""
+}
+
+// namesMismatch reports whether a WhatsApp name has nothing in common with
+// the customer's name. Names in different scripts can't be compared and
+// never count as a mismatch; neither do nicknames sharing a first name.
+func namesMismatch(customerName, profileName string) bool {
+	ours, theirs := normalizeArabicName(customerName), normalizeArabicName(profileName)
+	if ours == "" || theirs == "" || containsArabic(ours) != containsArabic(theirs) {
+		return false
+	}
+	for _, a := range strings.Fields(ours) {
+		for _, b := range strings.Fields(theirs) {
+			if a == b {
+				return false
+			}
+			// "Moh" for "Mohamed", "Abdo" for "Abdelrahman"
+			if len([]rune(a)) >= 3 && len([]rune(b)) >= 3 && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkProfileName records who a number belongs to on WhatsApp and warns
+// when it doesn't look like the customer we meant to message
+func checkProfileName(client Sender, result *MessageResult) {
+	result.ProfileName = whatsAppProfileName(client, result.SentTo)
+	if !namesMismatch(result.Customer.CustomerName, result.ProfileName) {
+		return
+	}
+	result.NameMismatch = true
+	log.Warning(fmt.Sprintf("+%s is %q on WhatsApp, not %s (%s); the number may belong to someone else",
+		result.SentTo, result.ProfileName, result.Customer.CustomerName, result.Customer.Code))
+}
+
+// reportNameMismatches lists the customers whose number showed a different
+// WhatsApp name, so their records can be checked
+func reportNameMismatches() {
+	var lines []string
+	mismatches := 0
+	forEachResult(func(r MessageResult) {
+		if r.NameMismatch {
+			mismatches++
+			if len(lines) < 10 {
+				lines = append(lines, fmt.Sprintf("%s (%s): +%s is %q", r.Customer.CustomerName, r.Customer.Code, r.SentTo, r.ProfileName))
+			}
+		}
+	})
+	if len(lines) == 0 {
+		return
+	}
+	if mismatches > len(lines) {
+		lines = append(lines, fmt.Sprintf("... and %d more (NameMismatch in the results CSV)", mismatches-len(lines)))
+	}
+	displayWarning("WhatsApp Names Don't Match",
+		"These numbers may belong to someone else; check the customer records", lines)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+)
+
+// newProfile links an additional number instead of using a stored one
+const newProfile = "new"
+
+// resolveProfile turns a profile name from Session.Profiles into the phone
+// number it stands for; anything else is taken as a number or JID as given
+func resolveProfile(profile string) string {
+	for name, phone := range config.Session.Profiles {
+		if strings.EqualFold(name, profile) {
+			return phone
+		}
+	}
+	return profile
+}
+
+// profileName returns the configured name for a linked number, if any
+func profileName(device *store.Device) string {
+	for name, phone := range config.Session.Profiles {
+		if device.ID != nil && strings.TrimPrefix(phone, "+") == device.ID.User {
+			return name
+		}
+	}
+	return ""
+}
+
+// describeDevice formats a linked device for lists and prompts
+func describeDevice(device *store.Device) string {
+	label := "+" + device.ID.User
+	if name := profileName(device); name != "" {
+		label = name + "  " + label
+	}
+	if device.PushName != "" {
+		label += " (" + device.PushName + ")"
+	}
+	return label
+}
+
+// selectDevice picks the session to use from the store: the one named by
+// profile (Session.Profile or --profile), the only one stored, or one chosen
+// interactively when several numbers are linked
+func selectDevice(ctx context.Context, container *sqlstore.Container, profile string) (*store.Device, error) {
+	devices, err := container.GetAllDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile = strings.TrimSpace(profile)
+	if profile == newProfile {
+		log.Info("Linking a new number to this installation")
+		return container.NewDevice(), nil
+	}
+
+	if profile != "" {
+		want := strings.TrimPrefix(resolveProfile(profile), "+")
+		for _, device := range devices {
+			if device.ID.User == want || device.ID.String() == want {
+				log.Info(fmt.Sprintf("Using profile %s", describeDevice(device)))
+				return device, nil
+			}
+		}
+
+		details := []string{}
+		for _, device := range devices {
+			details = append(details, describeDevice(device))
+		}
+		details = append(details, "Use --profile new to link another number")
+		displayError("Profile Not Found",
+			fmt.Sprintf("No linked number matches %q", profile),
+			"Pick one of the linked numbers below",
+			details)
+		return nil, fmt.Errorf("profile %q not found", profile)
+	}
+
+	switch len(devices) {
+	case 0:
+		return container.NewDevice(), nil
+	case 1:
+		return devices[0], nil
+	}
+
+	items := make([]string, 0, len(devices)+1)
+	for _, device := range devices {
+		items = append(items, describeDevice(device))
+	}
+	items = append(items, "Link a new number")
+
+	prompt := promptui.Select{
+		Label: "Which number should send?",
+		Items: items,
+	}
+	idx, _, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	if idx == len(devices) {
+		return container.NewDevice(), nil
+	}
+	return devices[idx], nil
+}
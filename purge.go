@@ -0,0 +1,521 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+)
+
+const purgeCertificateDir = "data/purge-certificates"
+
+// purgeKeyFile keys the phone hashes in deletion certificates. Phone numbers
+// are few enough to brute-force a plain hash; without this key they can't be.
+const purgeKeyFile = purgeCertificateDir + "/subject.key"
+
+// purgeRequest says what to erase: one customer's number, or everything
+// older than a cutoff
+type purgeRequest struct {
+	phone  string    // Formatted phone, or "" when purging by age
+	before time.Time // Zero when purging by phone
+}
+
+// purgeItem is one line of the deletion certificate
+type purgeItem struct {
+	Store   string
+	Removed string
+}
+
+// matchesPhone reports whether a value is the requested phone number
+func (r purgeRequest) matchesPhone(value string) bool {
+	cleaned := cleanPhoneNumber(value)
+	return cleaned != "" && len(cleaned) >= 7 && formatPhoneNumber(cleaned) == r.phone
+}
+
+// mentionsPhone reports whether free text (a log line, a report) contains
+// the number in international or national form
+func (r purgeRequest) mentionsPhone(text string) bool {
+	national := strings.TrimPrefix(r.phone, config.CountryCode)
+	return strings.Contains(text, r.phone) || (len(national) >= 7 && strings.Contains(text, national))
+}
+
+// parseAge parses a retention age such as "180d" or "72h"
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 180d or 72h)", value)
+	}
+	return d, nil
+}
+
+// runPurgeCommand erases a customer's data, or data past its retention age,
+// from every local store and writes a deletion certificate
+func runPurgeCommand(args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	phone := fs.String("phone", "", "erase everything held about this phone number")
+	olderThan := fs.String("older-than", "", "erase records older than this age, e.g. 180d")
+	yes := fs.Bool("yes", false, "don't ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*phone == "") == (*olderThan == "") {
+		fmt.Println("Usage: venom purge --phone <number> | --older-than <age> [--yes]")
+		return fmt.Errorf("give either --phone or --older-than")
+	}
+
+	var req purgeRequest
+	var description string
+	if *phone != "" {
+		cleaned := cleanPhoneNumber(*phone)
+		if len(cleaned) < 7 {
+			return fmt.Errorf("%q is not a phone number", *phone)
+		}
+		req.phone = formatPhoneNumber(cleaned)
+		description = "all data for +" + req.phone
+	} else {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		req.before = time.Now().Add(-age)
+		description = "all records from before " + req.before.Format("2006-01-02 15:04")
+	}
+
+	if !*yes && term.IsTerminal(int(os.Stdin.Fd())) {
+		prompt := promptui.Select{
+			Label: fmt.Sprintf("Permanently erase %s?", description),
+			Items: []string{"No, keep it", "Yes, erase"},
+		}
+		idx, _, err := prompt.Run()
+		if err != nil || idx != 1 {
+			return fmt.Errorf("purge cancelled")
+		}
+	}
+
+	var items []purgeItem
+	add := func(store string, removed int, unit string, err error) {
+		if err != nil {
+			items = append(items, purgeItem{store, "FAILED: " + err.Error()})
+			log.Error(fmt.Sprintf("Purge of %s failed", store), err)
+			return
+		}
+		if removed > 0 {
+			items = append(items, purgeItem{store, fmt.Sprintf("%d %s", removed, unit)})
+		}
+	}
+
+	n, err := purgeHistory(req)
+	add(config.History.File, n, "records", err)
+	n, err = purgeCheckpoint(req)
+	add(checkpointFile, n, "entries", err)
+
+	// Local copies of fetched lists (https, sftp, Google Sheets, SQL), lists
+	// uploaded from the web dashboard, each number's share of a split
+	// campaign, and files the watch folder has handled (with their
+	// validation reports) hold whole customer lists too
+	var files []string
+	for _, dir := range []string{"data", downloadDir, uploadDir, allocationDir, config.Watch.ProcessedDir, config.Watch.RejectedDir} {
+		if dir == "" {
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.csv"))
+		files = append(files, matches...)
+	}
+	for _, path := range files {
+		if path == filepath.Clean(config.OptOut.SuppressionFile) {
+			continue // Kept so the number is never messaged again
+		}
+		n, err := purgeCSV(path, req)
+		add(path, n, "rows", err)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join("data", "report-*.html"))
+	for _, path := range reports {
+		n, err := purgeWholeFile(path, req)
+		add(path, n, "file", err)
+	}
+
+	n, err = purgeWorkCopies()
+	add(workRoot, n, "working copies", err)
+
+	logs, _ := filepath.Glob(filepath.Join("logs", "*.log"))
+	for _, path := range logs {
+		n, err := purgeLogFile(path, req)
+		add(path, n, "lines", err)
+	}
+
+	if !req.before.IsZero() && config.AuditDir != "" {
+		audits, _ := filepath.Glob(filepath.Join(config.AuditDir, "audit-*.jsonl"))
+		for _, path := range audits {
+			n, err := purgeWholeFile(path, req)
+			add(path, n, "file", err)
+		}
+	}
+
+	path, err := writePurgeCertificate(req, description, items)
+	if err != nil {
+		return fmt.Errorf("data was erased but the certificate could not be written: %w", err)
+	}
+	displaySuccess("Purge Complete", fmt.Sprintf("Erased %s from %d store(s); deletion certificate: %s",
+		description, len(items), path))
+	return nil
+}
+
+// purgeHistory deletes sends (and, for a phone, the consent record) from the history database
+func purgeHistory(req purgeRequest) (int, error) {
+	if _, err := os.Stat(config.History.File); os.IsNotExist(err) {
+		return 0, nil
+	}
+	h, err := openHistory(config.History.File)
+	if err != nil {
+		return 0, err
+	}
+	defer h.close()
+
+	rows, err := h.db.Query(`SELECT id, sent_at, IFNULL(phone, ''), IFNULL(mobile, ''), IFNULL(sent_to, '') FROM sends`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var sentAt, phone, mobile, sentTo string
+		if err := rows.Scan(&id, &sentAt, &phone, &mobile, &sentTo); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if req.phone != "" {
+			if req.matchesPhone(phone) || req.matchesPhone(mobile) || req.matchesPhone(sentTo) {
+				ids = append(ids, id)
+			}
+		} else if t, err := time.Parse(time.RFC3339, sentAt); err == nil && t.Before(req.before) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	removed := 0
+	err = withTx(h.db, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(`DELETE FROM sends WHERE id = ?`, id); err != nil {
+				return err
+			}
+			removed++
+		}
+		if req.phone == "" {
+			return nil
+		}
+		consentRows, err := tx.Query(`SELECT phone FROM consents`)
+		if err != nil {
+			return err
+		}
+		var phones []string
+		for consentRows.Next() {
+			var phone string
+			if consentRows.Scan(&phone) == nil && req.matchesPhone(phone) {
+				phones = append(phones, phone)
+			}
+		}
+		consentRows.Close()
+		for _, phone := range phones {
+			if _, err := tx.Exec(`DELETE FROM consents WHERE phone = ?`, phone); err != nil {
+				return err
+			}
+			removed++
+		}
+		// Replies and scores are keyed by the number WhatsApp reported
+		for _, table := range []string{"inbound", "engagement"} {
+			res, err := tx.Exec(`DELETE FROM `+table+` WHERE phone = ?`, req.phone)
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			removed += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if removed > 0 {
+		h.db.Exec(`VACUUM`) // Don't leave the deleted rows in free pages
+	}
+	return removed, nil
+}
+
+// withTx runs fn in a transaction, committing only if it succeeds
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// purgeCheckpoint removes the number from the checkpoint, or the whole
+// checkpoint if it is older than the cutoff
+func purgeCheckpoint(req purgeRequest) (int, error) {
+	cp, err := loadCheckpoint()
+	if err != nil || cp == nil {
+		return 0, err
+	}
+	if req.phone == "" {
+		if cp.UpdatedAt.Before(req.before) {
+			return len(cp.Done), cp.clear()
+		}
+		return 0, nil
+	}
+	if !cp.Done[req.phone] {
+		return 0, nil
+	}
+	delete(cp.Done, req.phone)
+	return 1, cp.save()
+}
+
+// purgeWorkCopies deletes decrypted database copies left behind by runs
+// that stopped without sealing them. Whatever was erased from the sealed
+// database is still in them. Copies a running instance is using are left.
+func purgeWorkCopies() (int, error) {
+	dirs, _ := filepath.Glob(filepath.Join(workRoot, "venom-data-*"))
+	legacy, _ := filepath.Glob(filepath.Join(os.TempDir(), "venom-data-*"))
+	removed := 0
+	for _, dir := range append(dirs, legacy...) {
+		if time.Since(lastModified(dir)) < 3*sessionSealInterval {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// lastModified returns when a directory or anything directly in it last changed
+func lastModified(dir string) time.Time {
+	var latest time.Time
+	if info, err := os.Stat(dir); err == nil {
+		latest = info.ModTime()
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// purgeCSV drops rows holding the number from a CSV written by this tool,
+// or the whole file if it is older than the cutoff
+func purgeCSV(path string, req purgeRequest) (int, error) {
+	if req.phone == "" {
+		return purgeWholeFile(path, req)
+	}
+
+	file, err := openDataFile(path)
+	if err != nil {
+		return 0, err
+	}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	file.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([][]string, 0, len(records))
+	removed := 0
+	for i, record := range records {
+		drop := false
+		if i > 0 {
+			for _, value := range record {
+				if req.matchesPhone(value) {
+					drop = true
+					break
+				}
+			}
+		}
+		if drop {
+			removed++
+			continue
+		}
+		kept = append(kept, record)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(kept); err != nil {
+		return 0, err
+	}
+	return removed, writeDataFile(path, buf.Bytes())
+}
+
+// purgeLogFile drops log lines mentioning the number, or the whole log if
+// it is older than the cutoff
+func purgeLogFile(path string, req purgeRequest) (int, error) {
+	if req.phone == "" {
+		return purgeWholeFile(path, req)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	removed := 0
+	var kept strings.Builder
+	for _, line := range lines {
+		if req.mentionsPhone(line) {
+			removed++
+			continue
+		}
+		kept.WriteString(line)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, rewriteFile(path, func(w *bufio.Writer) error {
+		_, err := w.WriteString(kept.String())
+		return err
+	})
+}
+
+// purgeWholeFile deletes a file that mentions the number, or that was last
+// written before the cutoff
+func purgeWholeFile(path string, req purgeRequest) (int, error) {
+	if req.phone != "" {
+		data, err := os.ReadFile(path)
+		if err != nil || !req.mentionsPhone(string(data)) {
+			return 0, err
+		}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(req.before) {
+			return 0, err
+		}
+	}
+	return 1, os.Remove(path)
+}
+
+// rewriteFile replaces a file's contents atomically
+func rewriteFile(path string, write func(w *bufio.Writer) error) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err := write(w); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// purgeKey returns the key for phone hashes in certificates, creating it
+// the first time
+func purgeKey() ([]byte, error) {
+	key, err := os.ReadFile(purgeKeyFile)
+	if err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	os.MkdirAll(purgeCertificateDir, 0755)
+	return key, os.WriteFile(purgeKeyFile, key, 0600)
+}
+
+// writePurgeCertificate records what was erased. A phone number is only
+// identified by its keyed hash so the certificate doesn't hold it again;
+// hashing a number with the key in purgeKeyFile shows whether it was the
+// subject.
+func writePurgeCertificate(req purgeRequest, description string, items []purgeItem) (string, error) {
+	operator := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		operator = u.Username
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+
+	var b strings.Builder
+	b.WriteString("DELETION CERTIFICATE\n")
+	b.WriteString(strings.Repeat("=", 60) + "\n")
+	b.WriteString(fmt.Sprintf("Issued:    %s\n", now.Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("Operator:  %s@%s\n", operator, host))
+	if req.phone != "" {
+		key, err := purgeKey()
+		if err != nil {
+			return "", err
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(req.phone))
+		b.WriteString(fmt.Sprintf("Subject:   phone number with HMAC-SHA256 %s (key %s)\n", hex.EncodeToString(mac.Sum(nil)), purgeKeyFile))
+	} else {
+		b.WriteString(fmt.Sprintf("Scope:     %s\n", description))
+	}
+	b.WriteString(strings.Repeat("-", 60) + "\n")
+	if len(items) == 0 {
+		b.WriteString("No matching data was held.\n")
+	}
+	for _, item := range items {
+		b.WriteString(fmt.Sprintf("%-40s %s\n", item.Store, item.Removed))
+	}
+	b.WriteString(strings.Repeat("-", 60) + "\n")
+	b.WriteString("Retained:\n")
+	if req.phone != "" {
+		b.WriteString("  Suppression list entry, if any, so the number is never messaged again\n")
+		b.WriteString("  Audit log lines (append-only compliance record), until they age out\n")
+	}
+	b.WriteString("  Messages already delivered to the customer's WhatsApp\n")
+
+	os.MkdirAll(purgeCertificateDir, 0755)
+	path := filepath.Join(purgeCertificateDir, "purge-"+now.Format("20060102-150405")+".txt")
+	return path, os.WriteFile(path, []byte(b.String()), 0600)
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// queueFile holds the campaigns waiting to run, in order
+const queueFile = "data/queue.json"
+
+// queueLockFile is locked while the queue is read, changed and saved, so
+// 'venom queue' commands, 'queue run' and the web dashboard don't undo each
+// other's changes
+const queueLockFile = "data/queue.lock"
+
+// Job states
+const (
+	jobQueued      = "queued"
+	jobRunning     = "running"
+	jobDone        = "done"
+	jobFailed      = "failed"
+	jobInterrupted = "interrupted"
+	jobCancelled   = "cancelled"
+)
+
+// queuedJob is one campaign profile waiting in (or run from) the queue
+type queuedJob struct {
+	ID         int
+	Profile    string
+	AddedAt    time.Time
+	Status     string
+	StartedAt  time.Time `json:",omitempty"`
+	FinishedAt time.Time `json:",omitempty"`
+	ExitCode   int
+}
+
+// campaignQueue is the saved queue. Jobs run in slice order.
+type campaignQueue struct {
+	NextID int
+	Jobs   []*queuedJob
+}
+
+// loadQueue reads the queue; a missing file is an empty queue
+func loadQueue() (*campaignQueue, error) {
+	q := &campaignQueue{NextID: 1}
+	data, err := os.ReadFile(queueFile)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("invalid queue %s: %w", queueFile, err)
+	}
+	return q, nil
+}
+
+// save writes the queue atomically, so 'queue run' never reads half a file
+func (q *campaignQueue) save() error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(queueFile), 0755)
+	tmp := queueFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, queueFile)
+}
+
+// updateQueue loads the queue under the queue lock, lets change modify it
+// and saves it. Nothing is saved if change returns an error.
+func updateQueue(change func(q *campaignQueue) error) error {
+	os.MkdirAll(filepath.Dir(queueLockFile), 0755)
+	unlock, err := lockFile(queueLockFile)
+	if err != nil {
+		return fmt.Errorf("cannot lock the queue: %w", err)
+	}
+	defer unlock()
+
+	q, err := loadQueue()
+	if err != nil {
+		return err
+	}
+	if err := change(q); err != nil {
+		return err
+	}
+	return q.save()
+}
+
+// job finds a job by ID
+func (q *campaignQueue) job(id int) *queuedJob {
+	for _, j := range q.Jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// next returns the first job still waiting to run, or nil
+func (q *campaignQueue) next() *queuedJob {
+	for _, j := range q.Jobs {
+		if j.Status == jobQueued {
+			return j
+		}
+	}
+	return nil
+}
+
+// runQueueCommand manages campaigns queued to run one after another
+func runQueueCommand(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "add":
+		return queueAdd(args[1:])
+	case "list":
+		return queueList()
+	case "move":
+		return queueMove(args[1:])
+	case "cancel":
+		return queueCancel(args[1:])
+	case "run":
+		return queueRun(args[1:])
+	}
+	fmt.Println("Usage: venom queue [add <profile>... | list | move <id> <position> | cancel <id> | run [-simulate]]")
+	failWith(exitUsage)
+	return fmt.Errorf("unknown queue command %q", args[0])
+}
+
+// queueAdd appends saved campaign profiles to the queue
+func queueAdd(names []string) error {
+	if len(names) == 0 {
+		failWith(exitUsage)
+		return fmt.Errorf("give the campaign profile(s) to queue")
+	}
+	for _, name := range names {
+		profile, err := loadCampaignProfile(name)
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			failWith(exitConfig)
+			return fmt.Errorf("no campaign profile named %q (save one with --save-profile)", name)
+		}
+	}
+	var jobs []*queuedJob
+	err := updateQueue(func(q *campaignQueue) error {
+		for _, name := range names {
+			jobs = append(jobs, q.add(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		log.Success(fmt.Sprintf("Queued %s as job %d", job.Profile, job.ID))
+	}
+	return nil
+}
+
+// add appends a waiting job for a campaign profile
+func (q *campaignQueue) add(profile string) *queuedJob {
+	job := &queuedJob{ID: q.NextID, Profile: profile, AddedAt: time.Now(), Status: jobQueued}
+	q.NextID++
+	q.Jobs = append(q.Jobs, job)
+	return job
+}
+
+// enqueueCampaign adds one campaign profile to the saved queue
+func enqueueCampaign(profile string) (*queuedJob, error) {
+	var job *queuedJob
+	err := updateQueue(func(q *campaignQueue) error {
+		job = q.add(profile)
+		return nil
+	})
+	return job, err
+}
+
+// queueList shows every job, waiting ones with their position
+func queueList() error {
+	q, err := loadQueue()
+	if err != nil {
+		return err
+	}
+	if len(q.Jobs) == 0 {
+		displayInfo("Queue Empty", "No campaigns are queued", []string{"Add one with: venom queue add <profile>"})
+		return nil
+	}
+
+	fmt.Println(bold + fmt.Sprintf("%-4s %-4s %-24s %-12s %s", "Pos", "ID", "Profile", "Status", "When") + colorReset)
+	fmt.Println(strings.Repeat("─", 60))
+	position := 0
+	for _, j := range q.Jobs {
+		pos, when := "", "added "+j.AddedAt.Format("2006-01-02 15:04")
+		switch j.Status {
+		case jobQueued:
+			position++
+			pos = strconv.Itoa(position)
+		case jobRunning:
+			when = "started " + j.StartedAt.Format("2006-01-02 15:04")
+		case jobCancelled:
+		default:
+			when = "finished " + j.FinishedAt.Format("2006-01-02 15:04")
+			if j.ExitCode != exitOK {
+				when += fmt.Sprintf(" (exit %d)", j.ExitCode)
+			}
+		}
+		fmt.Printf("%-4s %-4d %-24s %-12s %s\n", pos, j.ID, j.Profile, j.Status, dim+when+colorReset)
+	}
+	return nil
+}
+
+// queueMove puts a waiting job at a new position among the waiting jobs
+func queueMove(args []string) error {
+	if len(args) != 2 {
+		failWith(exitUsage)
+		return fmt.Errorf("usage: venom queue move <id> <position>")
+	}
+	id, err1 := strconv.Atoi(args[0])
+	position, err2 := strconv.Atoi(args[1])
+	if err1 != nil || err2 != nil || position < 1 {
+		failWith(exitUsage)
+		return fmt.Errorf("job ID and position must be numbers, position from 1")
+	}
+
+	var job *queuedJob
+	err := updateQueue(func(q *campaignQueue) error {
+		job = q.job(id)
+		if job == nil || job.Status != jobQueued {
+			return fmt.Errorf("no waiting job with ID %d", id)
+		}
+
+		// Finished jobs keep their place; only the waiting ones are reordered
+		var waiting, others []*queuedJob
+		for _, j := range q.Jobs {
+			switch {
+			case j == job:
+			case j.Status == jobQueued:
+				waiting = append(waiting, j)
+			default:
+				others = append(others, j)
+			}
+		}
+		position = min(position, len(waiting)+1)
+		waiting = append(waiting[:position-1], append([]*queuedJob{job}, waiting[position-1:]...)...)
+		q.Jobs = append(others, waiting...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Success(fmt.Sprintf("Job %d (%s) is now number %d in the queue", job.ID, job.Profile, position))
+	return nil
+}
+
+// queueCancel removes a waiting job, or stops it if it is running
+func queueCancel(args []string) error {
+	if len(args) != 1 {
+		failWith(exitUsage)
+		return fmt.Errorf("usage: venom queue cancel <id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		failWith(exitUsage)
+		return fmt.Errorf("job ID must be a number")
+	}
+
+	var job *queuedJob
+	running := false
+	err = updateQueue(func(q *campaignQueue) error {
+		job = q.job(id)
+		if job == nil || (job.Status != jobQueued && job.Status != jobRunning) {
+			return fmt.Errorf("no waiting or running job with ID %d", id)
+		}
+		running = job.Status == jobRunning
+		job.Status = jobCancelled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if running {
+		log.Success(fmt.Sprintf("Job %d (%s) will stop; its checkpoint is kept", job.ID, job.Profile))
+	} else {
+		log.Success(fmt.Sprintf("Job %d (%s) cancelled", job.ID, job.Profile))
+	}
+	return nil
+}
+
+// queueRun runs the waiting jobs one at a time until none are left. Each
+// runs as its own process, like 'venom --profile <name>'. The daily limit
+// is shared because every run counts today's sends in the history database.
+// Ctrl+C stops the current job and the queue; it is re-queued to resume.
+func queueRun(args []string) error {
+	fs := flag.NewFlagSet("queue run", flag.ContinueOnError)
+	simulate := fs.Bool("simulate", false, "run every job against a simulated WhatsApp")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// The child gets the terminal's Ctrl+C itself; just note that it happened
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+
+	ran := 0
+	for {
+		var job *queuedJob
+		err := updateQueue(func(q *campaignQueue) error {
+			if job = q.next(); job != nil {
+				job.Status, job.StartedAt = jobRunning, time.Now()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			break
+		}
+
+		log.Info(fmt.Sprintf("Queue: starting job %d (%s)", job.ID, job.Profile))
+		cmd := campaignCommand(exe, job.Profile, *simulate)
+		code, stopped := runCampaignProcess(cmd, interrupted, func() bool {
+			q, err := loadQueue()
+			return err == nil && q.job(job.ID) != nil && q.job(job.ID).Status == jobCancelled
+		})
+		ran++
+
+		// Re-read: the job may have been cancelled or moved meanwhile
+		err = updateQueue(func(q *campaignQueue) error {
+			if current := q.job(job.ID); current != nil {
+				job = current
+			}
+			job.FinishedAt, job.ExitCode = time.Now(), code
+			switch {
+			case job.Status == jobCancelled:
+			case code == exitOK || code == exitCompletedWithFailures:
+				job.Status = jobDone
+			case code == exitInterrupted || code == exitRateLimited:
+				job.Status = jobInterrupted
+			default:
+				job.Status = jobFailed
+			}
+			// An operator who continued at the interrupt menu keeps the queue going
+			stopped = stopped && job.Status == jobInterrupted
+			if stopped {
+				job.Status = jobQueued // resumes from its checkpoint next time
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Queue: job %d (%s) %s (exit %d)", job.ID, job.Profile, job.Status, code))
+
+		if stopped {
+			log.Warning("Queue stopped; run 'venom queue run' to continue")
+			failWith(exitInterrupted)
+			return nil
+		}
+	}
+
+	if ran == 0 {
+		displayInfo("Queue Empty", "No campaigns are waiting", []string{"Add one with: venom queue add <profile>"})
+	} else {
+		log.Success(fmt.Sprintf("Queue finished: ran %d campaign(s)", ran))
+	}
+	return nil
+}
+
+// campaignCommand builds 'venom --profile <name> --non-interactive' with
+// this run's output settings, plus any extra flags
+func campaignCommand(exe, profile string, simulate bool, extra ...string) *exec.Cmd {
+	args := []string{"--ui-lang", uiLanguage, "--profile", profile, "--non-interactive"}
+	if plainOutput {
+		args = append(args, "--plain")
+	}
+	if eventStream != nil {
+		args = append(args, "--output", "ndjson")
+	}
+	if simulate {
+		args = append(args, "--simulate")
+	}
+	cmd := exec.Command(exe, append(args, extra...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd
+}
+
+// runCampaignProcess runs a campaign process to the end and returns its exit
+// status. It is stopped with SIGTERM once cancelled (if given) reports true;
+// stopped reports an operator interrupt.
+func runCampaignProcess(cmd *exec.Cmd, interrupted <-chan os.Signal, cancelled func() bool) (code int, stopped bool) {
+	if err := cmd.Start(); err != nil {
+		log.Error("Failed to start campaign", err)
+		return exitError, false
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	terminated := false
+	for {
+		select {
+		case err := <-done:
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return exitErr.ExitCode(), stopped
+			}
+			if err != nil {
+				return exitError, stopped
+			}
+			return exitOK, stopped
+		case sig := <-interrupted:
+			stopped = true
+			if sig == syscall.SIGTERM {
+				cmd.Process.Signal(syscall.SIGTERM)
+			}
+		case <-ticker.C:
+			if cancelled != nil && !terminated && cancelled() {
+				cmd.Process.Signal(syscall.SIGTERM)
+				terminated = true
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+const quotaSchema = `
+CREATE TABLE IF NOT EXISTS quota_sends (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	account TEXT NOT NULL,
+	sent_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS quota_sends_account ON quota_sends(account, sent_at);
+`
+
+// accountQuota counts sends per sending account in a database every process
+// on the machine shares, so two campaigns from the same number can't
+// together go over HourlyLimit or DailyLimit
+type accountQuota struct {
+	db      *sql.DB
+	account string
+}
+
+// quota is the shared quota of the sending account, or nil when off
+var quota *accountQuota
+
+// senderAccount names the account a transport sends from, or "" if unknown
+func senderAccount(client Sender) string {
+	switch c := client.(type) {
+	case *whatsmeow.Client:
+		if c.Store.ID != nil {
+			return c.Store.ID.ToNonAD().String()
+		}
+	case *standbySender:
+		return senderAccount(c.current())
+	case *cloudSender:
+		return "cloud:" + c.settings.PhoneNumberID
+	case *twilioSender:
+		if c.sms {
+			return "sms:" + c.settings.SMSFrom
+		}
+		return "twilio:" + c.settings.From
+	}
+	return ""
+}
+
+// openAccountQuota opens the shared quota database for account. Writes take
+// the database lock up front so a check and its reservation can't interleave
+// with another process.
+func openAccountQuota(path, account string) (*accountQuota, error) {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=10000&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(quotaSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare quota database %s: %w", path, err)
+	}
+	// Only the last day matters
+	db.Exec(`DELETE FROM quota_sends WHERE sent_at < ?`, time.Now().Add(-48*time.Hour).Unix())
+	return &accountQuota{db: db, account: account}, nil
+}
+
+// counts returns the account's sends in the last hour and day, by every process
+func (q *accountQuota) counts() (hourly, daily int, ok bool) {
+	if q == nil {
+		return 0, 0, false
+	}
+	hourly, daily, err := q.countIn(q.db)
+	if err != nil {
+		log.Debug(fmt.Sprintf("Failed to read shared quota: %v", err))
+		return 0, 0, false
+	}
+	return hourly, daily, true
+}
+
+// rowQuerier is a *sql.DB or *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// countIn counts the account's sends in the last hour and day through db
+func (q *accountQuota) countIn(db rowQuerier) (hourly, daily int, err error) {
+	now := time.Now()
+	err = db.QueryRow(`SELECT
+		IFNULL(SUM(CASE WHEN sent_at >= ? THEN 1 ELSE 0 END), 0), COUNT(*)
+		FROM quota_sends WHERE account = ? AND sent_at >= ?`,
+		now.Add(-time.Hour).Unix(), q.account, now.Add(-24*time.Hour).Unix()).Scan(&hourly, &daily)
+	return hourly, daily, err
+}
+
+// reserve takes a slot for one send if the account is under both limits.
+// The slot is given back with release if the send fails.
+func (q *accountQuota) reserve() (int64, bool, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	hourly, daily, err := q.countIn(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	if hourly >= campaignHourlyLimit() || daily >= config.DailyLimit {
+		return 0, false, nil
+	}
+	res, err := tx.Exec(`INSERT INTO quota_sends (account, sent_at) VALUES (?, ?)`, q.account, time.Now().Unix())
+	if err != nil {
+		return 0, false, err
+	}
+	id, _ := res.LastInsertId()
+	return id, true, tx.Commit()
+}
+
+// release gives back the slot of a send that failed
+func (q *accountQuota) release(id int64) {
+	if q == nil || id == 0 {
+		return
+	}
+	if _, err := q.db.Exec(`DELETE FROM quota_sends WHERE id = ?`, id); err != nil {
+		log.Debug(fmt.Sprintf("Failed to release quota slot: %v", err))
+	}
+}
+
+// waitForQuota reserves a slot for the next send, waiting while other
+// processes sending from the same account have used up the limits. It
+// returns false if the campaign was cancelled meanwhile.
+func waitForQuota(ctx context.Context) (int64, bool) {
+	if quota == nil {
+		return 0, true
+	}
+	warned := false
+	for {
+		id, ok, err := quota.reserve()
+		if err != nil {
+			log.Warning(fmt.Sprintf("Shared quota unavailable, sending without it: %v", err))
+			return 0, true
+		}
+		if ok {
+			if warned {
+				log.Info("Account quota available again, continuing...")
+			}
+			return id, true
+		}
+		if !warned {
+			clearProgress()
+			log.Warning("The account's hourly or daily limit was used up by another process sending from this number; waiting")
+			warned = true
+		}
+		if !sleepWithContext(ctx, time.Minute) {
+			return 0, false
+		}
+	}
+}
+
+// close closes the quota database
+func (q *accountQuota) close() {
+	if q == nil {
+		return
+	}
+	q.db.Close()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// QuoteRepliesConfig sends each campaign message as a reply quoting the
+// customer's last message to us, which reads more naturally than a cold
+// message for customers who recently got in touch
+type QuoteRepliesConfig struct {
+	Enabled    bool
+	WithinDays int // Only quote messages received in this many days
+}
+
+// inboundMessage is a text message a customer sent us
+type inboundMessage struct {
+	receivedAt time.Time
+	phone      string
+	sender     string // Sender JID, quoted as the participant
+	messageID  string
+	text       string
+}
+
+// pendingInbound holds messages that arrive before the send history is open,
+// e.g. offline messages delivered as soon as the client connects
+var (
+	pendingInbound   []inboundMessage
+	pendingInboundMu sync.Mutex
+)
+
+// maxPendingInbound caps pendingInbound when the history never opens
+const maxPendingInbound = 1000
+
+// recordInbound keeps customers' text messages in the send history so a
+// later campaign can quote them
+func recordInbound(client *whatsmeow.Client, evt *events.Message) {
+	if evt.Info.IsFromMe || evt.Info.IsGroup {
+		return
+	}
+	text := messageText(evt.Message)
+	phone := senderPhone(client, evt.Info)
+	if text == "" || phone == "" {
+		return
+	}
+	msg := inboundMessage{
+		receivedAt: evt.Info.Timestamp,
+		phone:      phone,
+		sender:     evt.Info.Sender.ToNonAD().String(),
+		messageID:  string(evt.Info.ID),
+		text:       text,
+	}
+
+	if history == nil {
+		pendingInboundMu.Lock()
+		if len(pendingInbound) < maxPendingInbound {
+			pendingInbound = append(pendingInbound, msg)
+		}
+		pendingInboundMu.Unlock()
+		return
+	}
+	history.recordInbound(msg)
+}
+
+// flushPendingInbound stores the messages received before the history opened
+func (h *sendHistory) flushPendingInbound() {
+	pendingInboundMu.Lock()
+	pending := pendingInbound
+	pendingInbound = nil
+	pendingInboundMu.Unlock()
+	for _, msg := range pending {
+		h.recordInbound(msg)
+	}
+}
+
+// recordInbound stores one inbound message, once
+func (h *sendHistory) recordInbound(msg inboundMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.db.Exec(`INSERT OR IGNORE INTO inbound (message_id, received_at, phone, sender, text)
+		VALUES (?, ?, ?, ?, ?)`,
+		msg.messageID, msg.receivedAt.Format(time.RFC3339), msg.phone, msg.sender, msg.text)
+	if err != nil {
+		log.Debug(fmt.Sprintf("Failed to record inbound message: %v", err))
+	}
+}
+
+// lastInbound returns the newest message phone sent us within
+// QuoteReplies.WithinDays, or nil
+func (h *sendHistory) lastInbound(phone string) *inboundMessage {
+	if h == nil {
+		return nil
+	}
+	since := time.Now().AddDate(0, 0, -config.QuoteReplies.WithinDays)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var msg inboundMessage
+	var receivedAt string
+	err := h.db.QueryRow(`SELECT message_id, received_at, phone, sender, text FROM inbound
+		WHERE phone = ? AND received_at >= ? ORDER BY received_at DESC LIMIT 1`,
+		phone, since.Format(time.RFC3339)).Scan(&msg.messageID, &receivedAt, &msg.phone, &msg.sender, &msg.text)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Debug(fmt.Sprintf("Failed to look up inbound messages: %v", err))
+		}
+		return nil
+	}
+	msg.receivedAt, _ = time.Parse(time.RFC3339, receivedAt)
+	return &msg
+}
+
+// quoteLastInbound turns a text message into a reply to the customer's last
+// message, if quoting is on and they wrote to us recently
+func quoteLastInbound(message *waE2E.Message, phone string) *waE2E.Message {
+	if !config.QuoteReplies.Enabled || message.GetConversation() == "" {
+		return message
+	}
+	last := history.lastInbound(phone)
+	if last == nil {
+		return message
+	}
+	log.Debug(fmt.Sprintf("Quoting %s's message from %s", phone, last.receivedAt.Format("2006-01-02 15:04")))
+	return &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(message.GetConversation()),
+			ContextInfo: &waE2E.ContextInfo{
+				StanzaID:      proto.String(last.messageID),
+				Participant:   proto.String(last.sender),
+				QuotedMessage: &waE2E.Message{Conversation: proto.String(last.text)},
+			},
+		},
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// reloadRequested is set by SIGHUP, the reload hotkey or the web dashboard;
+// the send loop applies it before the next message so settings never change
+// mid-send
+var reloadRequested atomic.Bool
+
+// pendingSettings holds settings an admin sent from the web dashboard; the
+// next reload applies them instead of reading the config file
+var (
+	pendingMu       sync.Mutex
+	pendingSettings []byte
+)
+
+// throttleSettings are the settings a running campaign can reload. Keys
+// missing from the config file keep their current values.
+type throttleSettings struct {
+	DelayMin        *int
+	DelayMax        *int
+	BatchSize       *int
+	BatchDelay      *int
+	WarmupDelay     *int
+	RetryDelay      *int
+	HourlyLimit     *int
+	DailyLimit      *int
+	LongPauseChance *float32
+	ManualCoolDown  *int
+	ReserveHeadroom *float64
+	Channels        *struct {
+		Limits map[string]ChannelLimit
+	}
+}
+
+// requestConfigReload asks the running campaign to reload its delay and
+// limit settings from the config file
+func requestConfigReload() {
+	if !sendingCampaign.Load() {
+		return
+	}
+	reloadRequested.Store(true)
+	log.Info("Config reload requested; it applies before the next message")
+}
+
+// requestSettingsChange asks the running campaign to apply delay and limit
+// settings given in the config file's JSON form
+func requestSettingsChange(data []byte) error {
+	if !sendingCampaign.Load() {
+		return errors.New("no campaign is sending")
+	}
+	var fresh throttleSettings
+	if err := json.Unmarshal(data, &fresh); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	if err := checkThrottling(&fresh); err != nil {
+		return err
+	}
+	pendingMu.Lock()
+	pendingSettings = data
+	pendingMu.Unlock()
+	reloadRequested.Store(true)
+	log.Info("Settings change requested from the web dashboard; it applies before the next message")
+	return nil
+}
+
+// applyPendingReload reloads the throttling settings if a reload was requested
+func applyPendingReload() {
+	if !reloadRequested.Swap(false) {
+		return
+	}
+	pendingMu.Lock()
+	data := pendingSettings
+	pendingSettings = nil
+	pendingMu.Unlock()
+
+	source := configFilePath()
+	var changes []string
+	var err error
+	if data != nil {
+		source = "settings from the web dashboard"
+		changes, err = applyThrottling(data)
+	} else {
+		changes, err = reloadThrottling(source)
+	}
+	if err != nil {
+		log.Error("Config reload failed, keeping the current settings", err)
+		return
+	}
+	if len(changes) == 0 {
+		log.Info(fmt.Sprintf("Reloaded %s: delay and limit settings unchanged", source))
+		return
+	}
+	log.Success(fmt.Sprintf("Reloaded %s: %s", source, strings.Join(changes, ", ")))
+}
+
+// reloadThrottling reads delay and limit settings from path and applies them
+// if they are valid, returning a description of each change
+func reloadThrottling(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := applyThrottling(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return changes, nil
+}
+
+// checkThrottling rejects settings that would leave the running config invalid
+func checkThrottling(fresh *throttleSettings) error {
+	delayMin, delayMax := valueOr(fresh.DelayMin, config.DelayMin), valueOr(fresh.DelayMax, config.DelayMax)
+	if delayMin < 0 || delayMax < delayMin {
+		return fmt.Errorf("DelayMin %d and DelayMax %d are not a valid range", delayMin, delayMax)
+	}
+	if valueOr(fresh.BatchSize, config.BatchSize) <= 0 {
+		return fmt.Errorf("BatchSize must be positive")
+	}
+	if fresh.ReserveHeadroom != nil {
+		return checkReserveHeadroom(*fresh.ReserveHeadroom)
+	}
+	return nil
+}
+
+// applyThrottling applies the delay and limit settings in data, a config
+// file or part of one, if they are valid
+func applyThrottling(data []byte) ([]string, error) {
+	var fresh throttleSettings
+	if err := json.Unmarshal(data, &fresh); err != nil {
+		return nil, err
+	}
+	// Check the result before touching the running config
+	if err := checkThrottling(&fresh); err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	set := func(name string, target *int, value *int) {
+		if value != nil && *value != *target {
+			changes = append(changes, fmt.Sprintf("%s %d → %d", name, *target, *value))
+			*target = *value
+		}
+	}
+	set("DelayMin", &config.DelayMin, fresh.DelayMin)
+	set("DelayMax", &config.DelayMax, fresh.DelayMax)
+	set("BatchSize", &config.BatchSize, fresh.BatchSize)
+	set("BatchDelay", &config.BatchDelay, fresh.BatchDelay)
+	set("WarmupDelay", &config.WarmupDelay, fresh.WarmupDelay)
+	set("RetryDelay", &config.RetryDelay, fresh.RetryDelay)
+	set("HourlyLimit", &config.HourlyLimit, fresh.HourlyLimit)
+	set("DailyLimit", &config.DailyLimit, fresh.DailyLimit)
+	set("ManualCoolDown", &config.ManualCoolDown, fresh.ManualCoolDown)
+	if fresh.LongPauseChance != nil && *fresh.LongPauseChance != config.LongPauseChance {
+		changes = append(changes, fmt.Sprintf("LongPauseChance %.2f → %.2f", config.LongPauseChance, *fresh.LongPauseChance))
+		config.LongPauseChance = *fresh.LongPauseChance
+	}
+	if fresh.ReserveHeadroom != nil && *fresh.ReserveHeadroom != config.ReserveHeadroom {
+		changes = append(changes, fmt.Sprintf("ReserveHeadroom %.2f → %.2f", config.ReserveHeadroom, *fresh.ReserveHeadroom))
+		config.ReserveHeadroom = *fresh.ReserveHeadroom
+	}
+	if fresh.Channels != nil && fresh.Channels.Limits != nil {
+		config.Channels.Limits = fresh.Channels.Limits
+		changes = append(changes, "channel limits")
+	}
+	return changes, nil
+}
+
+// valueOr returns *p, or fallback when p is nil
+func valueOr(p *int, fallback int) int {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportEmailConfig emails the campaign outcome to management when a run
+// finishes. It sends through the SMTP server configured under Email.
+type ReportEmailConfig struct {
+	Enabled bool
+	To      []string // Recipients, e.g. "manager@example.com"
+	Subject string   // Subject line; the date and sent/failed counts are appended
+}
+
+// campaignResults holds every send result of this run for the HTML report.
+// Chunked runs leave it empty; see streamResults.
+var campaignResults []MessageResult
+
+// streamResults is set for lists sent a chunk at a time. Their results,
+// skipped and failed customers are only written to their CSV files, and
+// the summaries read them back from there, so memory doesn't grow with the
+// list.
+var streamResults bool
+
+// resultsFile is the results CSV written row by row while the campaign
+// sends, so an interrupted or crashed run keeps what it did
+var resultsFile struct {
+	path      string
+	file      io.WriteCloser
+	writer    *csv.Writer
+	rows      int
+	temporary bool // Simulated chunked run; removed with removeTemporaryResults
+}
+
+var resultsHeader = []string{"Code", "CustomerName", "Phone", "Mobile", "SentTo", "Status", "Error", "Retries", "Time", "MessageID", "WhatsAppName", "NameMismatch", "FailureReason", "Template", "SentFrom"}
+
+// resultRow formats a send result for the results CSV
+func resultRow(r MessageResult) []string {
+	status := "sent"
+	if !r.Success {
+		status = "failed"
+	}
+	mismatch := ""
+	if r.NameMismatch {
+		mismatch = "yes"
+	}
+	return []string{
+		r.Customer.Code, r.Customer.CustomerName, r.Customer.Phone, r.Customer.Mobile,
+		r.SentTo, status, r.Error, strconv.Itoa(r.RetryCount), r.Timestamp.Format("2006-01-02 15:04:05"),
+		r.MessageID, r.ProfileName, mismatch, r.FailureReason, r.TemplateLabel, r.SentFrom,
+	}
+}
+
+// openResultsCSV starts data/results-<time>.csv for this run
+func openResultsCSV() error {
+	path := filepath.Join("data", "results-"+time.Now().Format("20060102-150405")+".csv")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	return startResultsCSV(path, file)
+}
+
+// openTemporaryResultsCSV keeps a simulated chunked run's results in a
+// temporary file, since they aren't held in memory
+func openTemporaryResultsCSV() error {
+	file, err := os.CreateTemp("", "venom-results-*.csv")
+	if err != nil {
+		return err
+	}
+	resultsFile.temporary = true
+	return startResultsCSV(file.Name(), file)
+}
+
+// removeTemporaryResults deletes the results of a simulated chunked run
+func removeTemporaryResults() {
+	if resultsFile.temporary {
+		closeResultsCSV()
+		os.Remove(resultsFile.path)
+	}
+}
+
+// startResultsCSV writes the header to a new results file
+func startResultsCSV(path string, file io.WriteCloser) error {
+	resultsFile.path, resultsFile.file, resultsFile.rows = path, file, 0
+	resultsFile.writer = csv.NewWriter(file)
+	resultsFile.writer.Write(resultsHeader)
+	resultsFile.writer.Flush()
+	return resultsFile.writer.Error()
+}
+
+// appendResultCSV writes one send result and flushes it to disk
+func appendResultCSV(r MessageResult) {
+	if resultsFile.writer == nil {
+		return
+	}
+	resultsFile.writer.Write(resultRow(r))
+	resultsFile.writer.Flush()
+	resultsFile.rows++
+	if err := resultsFile.writer.Error(); err != nil {
+		log.Error("Failed to write results CSV", err)
+	}
+}
+
+// closeResultsCSV flushes and closes the results CSV; safe to call twice
+func closeResultsCSV() {
+	if resultsFile.file == nil {
+		return
+	}
+	resultsFile.writer.Flush()
+	if err := resultsFile.file.Close(); err != nil {
+		log.Error("Failed to close results CSV", err)
+	} else {
+		if !resultsFile.temporary {
+			log.Info(fmt.Sprintf("Saved %d results to %s", resultsFile.rows, resultsFile.path))
+		}
+	}
+	resultsFile.file, resultsFile.writer = nil, nil
+}
+
+// forEachResult calls fn with every send result of this run, in order. A
+// chunked run's are read back from the results CSV, with the fields it has.
+func forEachResult(fn func(MessageResult)) error {
+	if !streamResults {
+		for _, r := range campaignResults {
+			fn(r)
+		}
+		return nil
+	}
+	if resultsFile.path == "" {
+		return nil
+	}
+	if resultsFile.writer != nil {
+		resultsFile.writer.Flush()
+	}
+	file, err := openDataFile(resultsFile.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if _, err := reader.Read(); err != nil {
+		return nil // Nothing recorded yet
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(resultFromRow(record))
+	}
+}
+
+// resultFromRow reverses resultRow
+func resultFromRow(record []string) MessageResult {
+	field := func(i int) string {
+		if i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	r := MessageResult{
+		SentTo:        field(4),
+		Success:       field(5) == "sent",
+		Error:         field(6),
+		MessageID:     field(9),
+		ProfileName:   field(10),
+		NameMismatch:  field(11) == "yes",
+		FailureReason: field(12),
+		TemplateLabel: field(13),
+		SentFrom:      field(14),
+	}
+	r.Customer.Code, r.Customer.CustomerName, r.Customer.Phone, r.Customer.Mobile = field(0), field(1), field(2), field(3)
+	r.RetryCount, _ = strconv.Atoi(field(7))
+	r.Timestamp, _ = time.ParseInLocation("2006-01-02 15:04:05", field(8), time.Local)
+	r.Template = templateForLabel(r.TemplateLabel)
+	return r
+}
+
+// writeResultsCSV saves one row per send attempt to path
+func writeResultsCSV(path string) error {
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write(resultsHeader)
+	for _, r := range campaignResults {
+		writer.Write(resultRow(r))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// reportSummary is what the HTML report shows
+type reportSummary struct {
+	Host                        string
+	Start, End                  string
+	Duration                    string
+	Total                       int
+	Successful, Failed, Skipped int
+	SuccessRate                 string
+	Delivered, Read             int
+	Latency                     string // p50/p95/p99 of the SendMessage calls
+	Aborted                     bool
+	Failures                    []MessageResult
+	MoreFailures                int // Failures left out of the list, see reportMaxFailures
+}
+
+// reportMaxFailures is how many failed sends the HTML report lists; the
+// results CSV has them all
+const reportMaxFailures = 500
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Campaign report</title></head>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<h2>Campaign report{{if .Aborted}} (stopped early){{end}}</h2>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr><td>Host</td><td>{{.Host}}</td></tr>
+<tr><td>Started</td><td>{{.Start}}</td></tr>
+<tr><td>Finished</td><td>{{.End}}</td></tr>
+<tr><td>Duration</td><td>{{.Duration}}</td></tr>
+<tr><td>Customers</td><td>{{.Total}}</td></tr>
+<tr><td>Sent</td><td style="color: #1a7f37;"><b>{{.Successful}}</b> ({{.SuccessRate}})</td></tr>
+<tr><td>Failed</td><td style="color: #cf222e;"><b>{{.Failed}}</b></td></tr>
+<tr><td>Skipped</td><td>{{.Skipped}}</td></tr>
+<tr><td>Delivered</td><td>{{.Delivered}}</td></tr>
+<tr><td>Read</td><td>{{.Read}}</td></tr>
+{{if .Latency}}<tr><td>Send latency</td><td>{{.Latency}}</td></tr>{{end}}
+</table>
+{{if .Failures}}
+<h3>Failed sends</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Code</th><th>Customer</th><th>Number</th><th>Reason</th><th>Error</th></tr>
+{{range .Failures}}<tr><td>{{.Customer.Code}}</td><td>{{.Customer.CustomerName}}</td><td>{{.SentTo}}</td><td>{{.FailureReason}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{if .MoreFailures}}<p>... and {{.MoreFailures}} more failed sends in the results CSV.</p>{{end}}
+{{end}}
+<p style="color: #888;">The full results are attached as CSV.</p>
+</body></html>
+`))
+
+// renderHTMLReport renders the campaign summary as an HTML page
+func renderHTMLReport(aborted bool) ([]byte, error) {
+	host, _ := os.Hostname()
+	_, delivered, read := deliveries.counts()
+	rate := "0%"
+	if progress.Successful+progress.Failed > 0 {
+		rate = fmt.Sprintf("%.1f%%", float64(progress.Successful)/float64(progress.Successful+progress.Failed)*100)
+	}
+
+	summary := reportSummary{
+		Host:        host,
+		Start:       progress.StartTime.Format("2006-01-02 15:04:05"),
+		End:         time.Now().Format("2006-01-02 15:04:05"),
+		Duration:    time.Since(progress.StartTime).Round(time.Second).String(),
+		Total:       progress.Total,
+		Successful:  progress.Successful,
+		Failed:      progress.Failed,
+		Skipped:     progress.Skipped,
+		SuccessRate: rate,
+		Delivered:   delivered,
+		Read:        read,
+		Aborted:     aborted,
+	}
+	if p50, p95, p99 := latencies.percentiles(); p50 > 0 {
+		summary.Latency = fmt.Sprintf("p50 %s, p95 %s, p99 %s", formatLatency(p50), formatLatency(p95), formatLatency(p99))
+	}
+	err := forEachResult(func(r MessageResult) {
+		switch {
+		case r.Success:
+		case len(summary.Failures) < reportMaxFailures:
+			summary.Failures = append(summary.Failures, r)
+		default:
+			summary.MoreFailures++
+		}
+	})
+	if err != nil {
+		log.Warning(fmt.Sprintf("Could not read back the results CSV: %v", err))
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, summary); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emailCampaignReport saves the HTML report under data/ and emails it with
+// the results CSV to the configured recipients. Failures are only logged.
+func emailCampaignReport(aborted bool) {
+	settings := config.ReportEmail
+	if !settings.Enabled || len(settings.To) == 0 {
+		return
+	}
+	if config.Email.Host == "" || config.Email.From == "" {
+		log.Warning("Report email skipped: set Email.Host and Email.From for the SMTP server")
+		return
+	}
+
+	// Attach the CSV written during the run, or write one if it couldn't be opened
+	stamp := time.Now().Format("20060102-150405")
+	closeResultsCSV()
+	csvPath := resultsFile.path
+	if csvPath == "" {
+		csvPath = filepath.Join("data", "results-"+stamp+".csv")
+		if err := writeResultsCSV(csvPath); err != nil {
+			log.Error("Failed to save results CSV", err)
+			return
+		}
+	}
+	html, err := renderHTMLReport(aborted)
+	if err != nil {
+		log.Error("Failed to render HTML report", err)
+		return
+	}
+	htmlPath := filepath.Join("data", "report-"+stamp+".html")
+	if err := writeDataFile(htmlPath, html); err != nil {
+		log.Error("Failed to save HTML report", err)
+		return
+	}
+	csvData, err := readDataFile(csvPath)
+	if err != nil {
+		log.Error("Failed to read results CSV", err)
+		return
+	}
+
+	subject := settings.Subject
+	if subject == "" {
+		subject = "Campaign report"
+	}
+	subject = fmt.Sprintf("%s — %s: %d sent, %d failed", subject, time.Now().Format("2006-01-02"), progress.Successful, progress.Failed)
+
+	msg, err := buildReportMessage(config.Email.From, settings.To, subject, html, filepath.Base(csvPath), csvData)
+	if err != nil {
+		log.Error("Failed to build report email", err)
+		return
+	}
+	if err := sendSMTP(config.Email, smtpPassword(config.Email), settings.To, msg); err != nil {
+		log.Error("Failed to email campaign report", err)
+		return
+	}
+	log.Success(fmt.Sprintf("Campaign report emailed to %s", strings.Join(settings.To, ", ")))
+}
+
+// buildReportMessage assembles a multipart email with the HTML report as the
+// body and the results CSV attached
+func buildReportMessage(from string, to []string, subject string, html []byte, csvName string, csvData []byte) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/html; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := parts.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	writeBase64(&encoded, html)
+	part.Write(encoded.Bytes())
+
+	header = textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/csv; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": csvName}))
+	part, err = parts.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	encoded.Reset()
+	writeBase64(&encoded, csvData)
+	part.Write(encoded.Bytes())
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: multipart/mixed; boundary=" + parts.Boundary() + "\r\n\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	failedCustomersFile = "data/failed-customers.csv"
+	lastCampaignFile    = "data/last-campaign.json"
+)
+
+// campaignCSV is the customer list the campaign sends to
+var campaignCSV = "customers.csv"
+
+// retryFailedRun is set by 'venom retry-failed': the wizard reuses the last
+// campaign's templates instead of asking for them
+var retryFailedRun bool
+
+// campaignRecord remembers what the last campaign sent, so failed customers
+// can be retried with the same messages
+type campaignRecord struct {
+	CSVFile   string
+	Templates []string
+	SavedAt   time.Time
+}
+
+// saveCampaignRecord stores the selected templates for a later retry-failed
+func saveCampaignRecord(csvFile string) error {
+	data, err := json.MarshalIndent(campaignRecord{
+		CSVFile:   csvFile,
+		Templates: selectedTemplates,
+		SavedAt:   time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(lastCampaignFile), 0755)
+	return os.WriteFile(lastCampaignFile, data, 0600)
+}
+
+// loadCampaignRecord reads what the last campaign sent
+func loadCampaignRecord() (*campaignRecord, error) {
+	data, err := os.ReadFile(lastCampaignFile)
+	if err != nil {
+		return nil, err
+	}
+	var record campaignRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", lastCampaignFile, err)
+	}
+	if len(record.Templates) == 0 {
+		return nil, fmt.Errorf("%s lists no templates", lastCampaignFile)
+	}
+	return &record, nil
+}
+
+// runRetryFailedCommand re-runs the last campaign against the customers it
+// failed to reach, with its templates and a fresh retry budget
+func runRetryFailedCommand(args []string) error {
+	fs := flag.NewFlagSet("retry-failed", flag.ContinueOnError)
+	csvPath := fs.String("csv", failedCustomersFile, "failed customers CSV from the previous run")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name to send from")
+	fs.BoolVar(&config.Simulate.Enabled, "simulate", config.Simulate.Enabled, "run against a simulated WhatsApp; nothing is sent")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+
+	if _, err := os.Stat(*csvPath); os.IsNotExist(err) {
+		failWith(exitCSV)
+		return fmt.Errorf("no failed customers to retry: %s not found", *csvPath)
+	}
+	record, err := loadCampaignRecord()
+	if err != nil {
+		failWith(exitConfig)
+		return fmt.Errorf("templates of the previous campaign not found: %w", err)
+	}
+
+	selectedTemplates = record.Templates
+	campaignCSV = *csvPath
+	retryFailedRun = true
+	log.Info(fmt.Sprintf("Retrying failed customers of the campaign from %s (%s)",
+		record.SavedAt.Format("2006-01-02 15:04"), record.CSVFile))
+
+	runCampaign()
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// revokeWindow is how long after sending a message can still be deleted for
+// everyone (WhatsApp allows about two days)
+const revokeWindow = 48 * time.Hour
+
+// statusRevoked marks a send deleted for everyone in the send history
+const statusRevoked = "revoked"
+
+// runRevokeCommand deletes for everyone the messages a past campaign sent,
+// e.g. when the wrong price list went out
+func runRevokeCommand(args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	resultsPath := fs.String("results", "", "results CSV of the campaign to recall (default: the newest in data/)")
+	yes := fs.Bool("yes", false, "delete without asking for confirmation")
+	dbPath := fs.String("db", config.History.File, "send history database to mark the messages revoked in")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name the campaign was sent from")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+
+	if *resultsPath == "" {
+		latest, err := latestResultsCSV()
+		if err != nil {
+			failWith(exitCSV)
+			return err
+		}
+		*resultsPath = latest
+	}
+	sent, err := readSentMessages(*resultsPath)
+	if err != nil {
+		failWith(exitCSV)
+		return err
+	}
+
+	var recall []sentMessage
+	expired := 0
+	for _, m := range sent {
+		if time.Since(m.sentAt) > revokeWindow {
+			expired++
+			continue
+		}
+		recall = append(recall, m)
+	}
+
+	// The history is only needed to record the recall and spot split messages
+	h, err := openHistory(*dbPath)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Send history unavailable: %v", err))
+		h = nil
+	}
+	defer h.close()
+	multipart := 0
+	if h != nil {
+		for _, m := range recall {
+			if text, ok := h.sentText(string(m.messageID)); ok && len(splitLongMessage(text)) > 1 {
+				multipart++
+			}
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("RECALL PREVIEW")
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Results: %s (%d sent)\n", *resultsPath, len(sent))
+	fmt.Printf("To delete for everyone: %d message(s)\n", len(recall))
+	if expired > 0 {
+		fmt.Printf("%sToo old to delete (over %d hours): %d%s\n", colorYellow, int(revokeWindow.Hours()), expired, colorReset)
+	}
+	if multipart > 0 {
+		fmt.Printf("%sSent in several parts, only the first is deleted: %d%s\n", colorYellow, multipart, colorReset)
+	}
+	fmt.Println(strings.Repeat("─", 60) + "\n")
+
+	if len(recall) == 0 {
+		displayInfo("Nothing To Recall", "No sent message can still be deleted", nil)
+		return nil
+	}
+	if !*yes {
+		confirmPrompt := promptui.Select{
+			Label: fmt.Sprintf("Delete %d message(s) for everyone? This can't be undone", len(recall)),
+			Items: []string{"No, exit", "Yes, delete them"},
+		}
+		confirmIdx, _, err := confirmPrompt.Run()
+		if err != nil {
+			return err
+		}
+		if confirmIdx != 1 {
+			return fmt.Errorf("user cancelled")
+		}
+	}
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	defer closeSessionStore()
+	client, err := initializeWhatsApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+	}
+	defer client.Disconnect()
+
+	revoked, failed := 0, 0
+	for i, m := range recall {
+		if ctx.Err() != nil {
+			break
+		}
+		jid := recipientJID(client, m.phone)
+		_, err := client.SendMessage(context.Background(), jid, client.BuildRevoke(jid, types.EmptyJID, m.messageID))
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to delete message to %s (+%s)", m.customer, m.phone), err)
+			failed++
+			continue
+		}
+		h.recordRevoke(string(m.messageID))
+		revoked++
+		log.Success(fmt.Sprintf("Deleted message to %s (+%s)", m.customer, m.phone))
+		if i < len(recall)-1 {
+			time.Sleep(time.Duration(1000+rand.Intn(1500)) * time.Millisecond)
+		}
+	}
+
+	remaining := len(recall) - revoked - failed
+	if failed > 0 || remaining > 0 {
+		failWith(exitCompletedWithFailures)
+		displayWarning("Recall Incomplete",
+			fmt.Sprintf("Deleted %d message(s); %d failed, %d not attempted", revoked, failed, remaining),
+			[]string{"Run the same command again to retry; deleted messages fail harmlessly"})
+		return nil
+	}
+	displaySuccess("Campaign Recalled", fmt.Sprintf("Deleted %d message(s) for everyone", revoked))
+	return nil
+}
+
+// recordRevoke marks a sent message as deleted for everyone
+func (h *sendHistory) recordRevoke(messageID string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.db.Exec(`UPDATE sends SET status = ? WHERE message_id = ?`, statusRevoked, messageID); err != nil {
+		log.Debug(fmt.Sprintf("Failed to record recall in send history: %v", err))
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Risk levels of the execution plan's assessment, worst last
+const (
+	riskGreen = iota
+	riskYellow
+	riskRed
+)
+
+// riskFinding is one concern about a campaign and what to do about it
+type riskFinding struct {
+	level   int
+	problem string
+	advice  string
+}
+
+// projectedHourlyRate is how many messages an hour the delays and batch
+// breaks allow, before HourlyLimit is applied
+func projectedHourlyRate() int {
+	avgDelay := (config.DelayMin + config.DelayMax) / 2
+	batchMs := config.BatchSize*avgDelay + config.BatchDelay
+	if batchMs <= 0 {
+		return 0
+	}
+	return config.BatchSize * 3600000 / batchMs
+}
+
+// uncheckedShare is the share of customers no pre-check has looked up yet
+func uncheckedShare(customers []Customer) float64 {
+	if len(customers) == 0 {
+		return 0
+	}
+	unchecked := 0
+	for _, c := range customers {
+		if c.HasWhatsApp == "" {
+			unchecked++
+		}
+	}
+	return float64(unchecked) / float64(len(customers))
+}
+
+// sendingBudget finds the Accounts entry of the number this run sends from
+func sendingBudget() (AccountBudget, bool) {
+	want := strings.TrimPrefix(resolveProfile(config.Session.Profile), "+")
+	for _, account := range config.Accounts {
+		if strings.TrimPrefix(resolveProfile(account.Profile), "+") == want ||
+			(config.Session.Profile == "" && len(config.Accounts) == 1) {
+			return account, true
+		}
+	}
+	return AccountBudget{}, false
+}
+
+// assessCampaignRisk looks for settings likely to get the number blocked:
+// sending faster than the limits, messaging numbers nobody checked, and
+// pushing a newly linked number too hard
+func assessCampaignRisk(count int, unchecked float64) []riskFinding {
+	var findings []riskFinding
+
+	avgDelay := (config.DelayMin + config.DelayMax) / 2
+	switch {
+	case avgDelay < 3000:
+		findings = append(findings, riskFinding{riskRed,
+			fmt.Sprintf("Average delay of %.1fs between messages looks automated", float64(avgDelay)/1000),
+			"Set DelayMin/DelayMax to at least 5000/12000"})
+	case avgDelay < 5000:
+		findings = append(findings, riskFinding{riskYellow,
+			fmt.Sprintf("Average delay of %.1fs between messages is on the fast side", float64(avgDelay)/1000),
+			"Set DelayMin to 5000 or more"})
+	}
+
+	if rate, limit := projectedHourlyRate(), campaignHourlyLimit(); rate > limit {
+		findings = append(findings, riskFinding{riskYellow,
+			fmt.Sprintf("Projected %d messages/hour, over HourlyLimit (%d%s); sending will stop and start every hour", rate, limit, describeHeadroom(limit)),
+			fmt.Sprintf("Raise DelayMin/DelayMax to about %ds to send evenly", 3600/max(limit, 1))})
+	}
+	if count > config.DailyLimit {
+		days := (count + config.DailyLimit - 1) / max(config.DailyLimit, 1)
+		findings = append(findings, riskFinding{riskYellow,
+			fmt.Sprintf("%d customers at DailyLimit %d takes %d days", count, config.DailyLimit, days),
+			"Keep the session running, or split the list across Accounts"})
+	}
+
+	if !config.PreCheckNumbers && unchecked > 0.1 {
+		level := riskYellow
+		if unchecked > 0.3 {
+			level = riskRed
+		}
+		findings = append(findings, riskFinding{level,
+			fmt.Sprintf("%.0f%% of numbers were never checked on WhatsApp", unchecked*100),
+			"Turn on PreCheckNumbers, or run 'venom check' on the CSV first"})
+	}
+
+	if budget, ok := sendingBudget(); ok {
+		limit, stage := accountCap(budget)
+		switch {
+		case stage != "warmed up" && count > limit:
+			findings = append(findings, riskFinding{riskRed,
+				fmt.Sprintf("%s is on %s and should send at most %d a day, not %d", budget.Profile, stage, limit, count),
+				"Send the rest over the coming days, or from a warmed-up number"})
+		case stage != "warmed up":
+			findings = append(findings, riskFinding{riskYellow,
+				fmt.Sprintf("%s is on %s (up to %d a day)", budget.Profile, stage, limit),
+				"Prefer customers who know the number; replies build its reputation"})
+		}
+	}
+	return findings
+}
+
+// displayRiskAssessment prints the findings and a red/yellow/green verdict
+func displayRiskAssessment(findings []riskFinding) {
+	verdict := riskGreen
+	for _, f := range findings {
+		verdict = max(verdict, f.level)
+	}
+	labels := []string{colorGreen + "GREEN" + colorReset, colorYellow + "YELLOW" + colorReset, colorRed + "RED" + colorReset}
+	marks := []string{"", colorYellow + "!" + colorReset, colorRed + "✗" + colorReset}
+
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Risk Assessment:        %s\n", labels[verdict])
+	if len(findings) == 0 {
+		fmt.Println("  Delays, limits and numbers look safe")
+	}
+	for _, f := range findings {
+		fmt.Printf("  %s %s\n", marks[f.level], f.problem)
+		fmt.Println(dim + "    → " + f.advice + colorReset)
+	}
+	if verdict == riskRed {
+		fmt.Println(colorRed + "  Consider stopping now (Ctrl+C) and fixing the above" + colorReset)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// Sender is the part of the WhatsApp client the campaign uses to send.
+// *whatsmeow.Client implements it; mockSender stands in for it in --simulate mode.
+type Sender interface {
+	SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error)
+	IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error)
+	SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error
+	Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+	BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message
+	BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey
+	IsConnected() bool
+	IsLoggedIn() bool
+	Connect() error
+}
+
+var _ Sender = (*whatsmeow.Client)(nil)
+
+// whatsAppClient returns the linked-device client behind a transport, if
+// it has one: the client itself, or the number a standby pair sends from
+func whatsAppClient(client Sender) (*whatsmeow.Client, bool) {
+	switch c := client.(type) {
+	case *whatsmeow.Client:
+		return c, true
+	case *standbySender:
+		return c.current(), true
+	}
+	return nil, false
+}
+
+// SimulateConfig shapes the fake transport used by --simulate
+type SimulateConfig struct {
+	Enabled           bool
+	LatencyMin        int     // Fastest simulated send (milliseconds)
+	LatencyMax        int     // Slowest simulated send (milliseconds)
+	FailureRate       float64 // Chance a send fails with a transient error (0.0-1.0)
+	NotOnWhatsAppRate float64 // Share of numbers treated as not on WhatsApp (0.0-1.0)
+}
+
+// mockSender pretends to be a connected WhatsApp client. Nothing leaves the
+// machine; sends succeed or fail according to Simulate settings.
+type mockSender struct {
+	settings SimulateConfig
+	sent     atomic.Int64
+}
+
+var _ Sender = (*mockSender)(nil)
+
+// newMockSender returns a simulated transport
+func newMockSender(settings SimulateConfig) *mockSender {
+	return &mockSender{settings: settings}
+}
+
+// registered decides deterministically whether a number "has WhatsApp", so
+// the pre-check and the send agree about the same number
+func (m *mockSender) registered(phone string) bool {
+	sum := sha256.Sum256([]byte(phone))
+	return float64(binary.BigEndian.Uint32(sum[:4]))/float64(^uint32(0)) >= m.settings.NotOnWhatsAppRate
+}
+
+// latency sleeps for a random simulated round trip
+func (m *mockSender) latency(ctx context.Context) error {
+	spread := max(m.settings.LatencyMax-m.settings.LatencyMin, 0)
+	delay := time.Duration(m.settings.LatencyMin+rand.Intn(spread+1)) * time.Millisecond
+	if !sleepWithContext(ctx, delay) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (m *mockSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if err := m.latency(ctx); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if to.Server == types.DefaultUserServer && !m.registered(to.User) {
+		return whatsmeow.SendResponse{}, fmt.Errorf("simulated: %s is not on WhatsApp", to.User)
+	}
+	if rand.Float64() < m.settings.FailureRate {
+		return whatsmeow.SendResponse{}, fmt.Errorf("simulated: server returned error 500")
+	}
+
+	n := m.sent.Add(1)
+	return whatsmeow.SendResponse{
+		Timestamp: time.Now(),
+		ID:        types.MessageID(fmt.Sprintf("SIM%013d", n)),
+	}, nil
+}
+
+func (m *mockSender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	if err := m.latency(context.Background()); err != nil {
+		return nil, err
+	}
+	results := make([]types.IsOnWhatsAppResponse, 0, len(phones))
+	for _, phone := range phones {
+		user := strings.TrimPrefix(phone, "+")
+		results = append(results, types.IsOnWhatsAppResponse{
+			Query: phone,
+			JID:   types.NewJID(user, types.DefaultUserServer),
+			IsIn:  m.registered(user),
+		})
+	}
+	return results, nil
+}
+
+func (m *mockSender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+func (m *mockSender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	if err := m.latency(ctx); err != nil {
+		return whatsmeow.UploadResponse{}, err
+	}
+	sum := sha256.Sum256(plaintext)
+	return whatsmeow.UploadResponse{
+		URL:        "https://simulated.invalid/media",
+		DirectPath: "/simulated",
+		MediaKey:   sum[:],
+		FileSHA256: sum[:],
+		FileLength: uint64(len(plaintext)),
+	}, nil
+}
+
+func (m *mockSender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	// Building a poll needs no connection, so an unconnected client can do it
+	return (&whatsmeow.Client{}).BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (m *mockSender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return &waCommon.MessageKey{
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(id),
+		RemoteJID: proto.String(chat.String()),
+	}
+}
+
+func (m *mockSender) IsConnected() bool { return true }
+func (m *mockSender) IsLoggedIn() bool  { return true }
+func (m *mockSender) Connect() error    { return nil }
+
+// openSender connects the transport selected by Transport, or the simulated
+// one with --simulate. The returned func disconnects it.
+func openSender(ctx context.Context) (Sender, func(), error) {
+	if config.Simulate.Enabled {
+		displayWarning("Simulation Mode",
+			"WhatsApp is simulated — no messages will be sent",
+			[]string{
+				fmt.Sprintf("%.0f%% of sends fail, %.0f%% of numbers aren't on WhatsApp",
+					config.Simulate.FailureRate*100, config.Simulate.NotOnWhatsAppRate*100),
+				"Checkpoints, checked and failed CSVs are not written",
+			})
+		return newMockSender(config.Simulate), func() {}, nil
+	}
+
+	if config.Channels.Enabled {
+		return openChannelRouter(ctx)
+	}
+
+	primary, closePrimary, err := openTransport(ctx, config.Transport)
+	if err == nil && config.Session.Standby != "" {
+		primary, closePrimary, err = openStandby(ctx, primary, closePrimary)
+	}
+	if err != nil || config.FallbackTransport == "" {
+		return primary, closePrimary, err
+	}
+
+	fallback, closeFallback, err := openTransport(ctx, config.FallbackTransport)
+	if err != nil {
+		closePrimary()
+		return nil, nil, fmt.Errorf("fallback transport: %w", err)
+	}
+	log.Info(fmt.Sprintf("Fallback transport %s is ready if %s stops working", config.FallbackTransport, config.Transport))
+
+	sender := &fallbackSender{
+		primary:      primary,
+		fallback:     fallback,
+		primaryName:  config.Transport,
+		fallbackName: config.FallbackTransport,
+	}
+	return sender, func() {
+		closePrimary()
+		closeFallback()
+	}, nil
+}
+
+// transport is a registered way of delivering messages
+type transport struct {
+	open     func(ctx context.Context) (Sender, func(), error) // Connects it; the func closes it
+	whatsApp bool                                              // Recipients need a WhatsApp account
+}
+
+// transports holds every registered transport by name
+var transports = map[string]transport{}
+
+// registerTransport makes a transport selectable by Transport,
+// FallbackTransport and the channel rules
+func registerTransport(name string, whatsApp bool, open func(ctx context.Context) (Sender, func(), error)) {
+	transports[name] = transport{open: open, whatsApp: whatsApp}
+}
+
+func init() {
+	registerTransport("whatsmeow", true, func(ctx context.Context) (Sender, func(), error) {
+		client, err := initializeWhatsApp(ctx)
+		if err != nil {
+			closeSessionStore()
+			return nil, nil, err
+		}
+		return client, func() {
+			client.Disconnect()
+			closeSessionStore()
+		}, nil
+	})
+}
+
+// transportNames lists the registered transports, sorted
+func transportNames() []string {
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isWhatsAppTransport reports whether a transport only reaches WhatsApp users
+func isWhatsAppTransport(name string) bool {
+	if name == "" {
+		name = "whatsmeow"
+	}
+	t, ok := transports[strings.ToLower(name)]
+	return !ok || t.whatsApp
+}
+
+// openTransport connects one named transport
+func openTransport(ctx context.Context, name string) (Sender, func(), error) {
+	if name == "" {
+		name = "whatsmeow"
+	}
+	t, ok := transports[strings.ToLower(name)]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown transport %q (use %s)", name, strings.Join(transportNames(), ", "))
+	}
+	return t.open(ctx)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// recordingSender is a mockSender that keeps the text of every message it sent
+type recordingSender struct {
+	*mockSender
+	mu   sync.Mutex
+	sent map[string]string // Phone -> text
+}
+
+func (r *recordingSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	resp, err := r.mockSender.SendMessage(ctx, to, message, extra...)
+	if err == nil {
+		r.mu.Lock()
+		r.sent[to.User] = message.GetConversation()
+		r.mu.Unlock()
+	}
+	return resp, err
+}
+
+// startTestCampaign resets the campaign state sendCustomers works on and
+// sets config up to send without delays, until the test ends
+func startTestCampaign(t *testing.T, total int) {
+	savedConfig, savedTemplates, savedProgress := config, selectedTemplates, progress
+	t.Cleanup(func() {
+		config, selectedTemplates, templatePermutationIdx = savedConfig, savedTemplates, 0
+		progress = savedProgress
+		campaignResults, failedCustomers, skippedCustomers = nil, nil, nil
+		suppressions = nil
+	})
+
+	config.DelayMin, config.DelayMax, config.WarmupDelay, config.LongPauseChance = 0, 0, 0, 0
+	config.AddJitter, config.BusinessHoursOnly = false, false
+	config.BatchSize, config.HourlyLimit, config.DailyLimit = 1000, 1000, 1000
+	config.MaxRetries, config.SplitLength = 0, 0
+	selectedTemplates, templatePermutationIdx = []string{"Hello {CustomerName}, your code is {Code}"}, 0
+
+	now := time.Now()
+	progress = &ProgressTracker{Total: total, StartTime: now, LastHourReset: now, LastDayReset: now}
+	campaignResults, failedCustomers = nil, nil
+	skippedCustomers, skippedSeen = nil, make(map[string]bool)
+	deliveries = &deliveryTracker{customer: make(map[types.MessageID]Customer), status: make(map[types.MessageID]string)}
+	timeline, latencies = &sendTimeline{}, &sendLatencies{}
+}
+
+// testCustomers returns n valid customers with distinct numbers
+func testCustomers(n int) []ProcessedCustomer {
+	customers := make([]ProcessedCustomer, n)
+	for i := range customers {
+		phone := "2010012345" + string(rune('0'+i/10)) + string(rune('0'+i%10))
+		customers[i] = ProcessedCustomer{
+			Customer:       Customer{Code: "C" + phone[len(phone)-2:], CustomerName: "Customer " + phone[len(phone)-2:], Mobile: phone},
+			SelectedPhone:  phone,
+			FormattedPhone: phone,
+			IsValid:        true,
+		}
+	}
+	return customers
+}
+
+func TestSendCustomers(t *testing.T) {
+	customers := testCustomers(6)
+	startTestCampaign(t, len(customers))
+	client := &recordingSender{mockSender: newMockSender(SimulateConfig{}), sent: make(map[string]string)}
+
+	if !sendCustomers(context.Background(), client, customers, 0, &stallWatch{}) {
+		t.Fatal("sendCustomers stopped early")
+	}
+	if progress.Successful != len(customers) || progress.Failed != 0 || progress.Remaining != 0 {
+		t.Errorf("progress %d sent, %d failed, %d remaining; want %d, 0, 0",
+			progress.Successful, progress.Failed, progress.Remaining, len(customers))
+	}
+	if len(campaignResults) != len(customers) {
+		t.Fatalf("%d results, want %d", len(campaignResults), len(customers))
+	}
+	for i, r := range campaignResults {
+		c := customers[i]
+		if !r.Success || r.SentTo != c.FormattedPhone || r.Customer.Code != c.Code {
+			t.Errorf("result %d = %+v, want a send to %s", i, r, c.FormattedPhone)
+		}
+		if want := "Hello " + c.CustomerName + ", your code is " + c.Code; client.sent[c.FormattedPhone] != want {
+			t.Errorf("sent %q to %s, want %q", client.sent[c.FormattedPhone], c.FormattedPhone, want)
+		}
+		if status := deliveries.statusOf(types.MessageID(r.MessageID)); status != statusSent {
+			t.Errorf("message %q to %s has delivery status %q", r.MessageID, c.FormattedPhone, status)
+		}
+	}
+}
+
+func TestSendCustomersFailures(t *testing.T) {
+	customers := testCustomers(3)
+	startTestCampaign(t, len(customers))
+	config.SaveFailed = false
+	client := newMockSender(SimulateConfig{FailureRate: 1})
+
+	if !sendCustomers(context.Background(), client, customers, 0, &stallWatch{}) {
+		t.Fatal("sendCustomers stopped early")
+	}
+	if progress.Successful != 0 || progress.Failed != len(customers) {
+		t.Errorf("progress %d sent, %d failed; want 0, %d", progress.Successful, progress.Failed, len(customers))
+	}
+	if len(failedCustomers) != len(customers) {
+		t.Errorf("%d failed customers kept for retry, want %d", len(failedCustomers), len(customers))
+	}
+	for _, r := range campaignResults {
+		if r.Success || !strings.Contains(r.Error, "error 500") || r.FailureReason == "" {
+			t.Errorf("result for %s = success %v, error %q, reason %q", r.SentTo, r.Success, r.Error, r.FailureReason)
+		}
+	}
+}
+
+func TestSendCustomersSkipsOptedOut(t *testing.T) {
+	customers := testCustomers(3)
+	startTestCampaign(t, len(customers))
+	suppressions = &suppressionList{path: t.TempDir() + "/suppression.csv", phones: map[string]string{customers[1].FormattedPhone: "opt-out"}}
+	client := &recordingSender{mockSender: newMockSender(SimulateConfig{}), sent: make(map[string]string)}
+
+	if !sendCustomers(context.Background(), client, customers, 0, &stallWatch{}) {
+		t.Fatal("sendCustomers stopped early")
+	}
+	if _, sent := client.sent[customers[1].FormattedPhone]; sent {
+		t.Error("message sent to a customer who opted out")
+	}
+	if progress.Successful != 2 || progress.Skipped != 1 {
+		t.Errorf("progress %d sent, %d skipped; want 2, 1", progress.Successful, progress.Skipped)
+	}
+	if len(skippedCustomers) != 1 || skippedCustomers[0].Reason != skipSuppressed {
+		t.Errorf("skipped %+v, want customer %s as %q", skippedCustomers, customers[1].Code, skipSuppressed)
+	}
+}
+
+func TestSendCustomersStopsWhenCancelled(t *testing.T) {
+	customers := testCustomers(3)
+	startTestCampaign(t, len(customers))
+	client := &recordingSender{mockSender: newMockSender(SimulateConfig{}), sent: make(map[string]string)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if sendCustomers(ctx, client, customers, 0, &stallWatch{}) {
+		t.Error("sendCustomers finished after the campaign was cancelled")
+	}
+	if len(client.sent) != 0 || progress.Remaining != len(customers) {
+		t.Errorf("%d sent, %d remaining after cancelling; want 0, %d", len(client.sent), progress.Remaining, len(customers))
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/lib/pq"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	waCompanionReg "go.mau.fi/whatsmeow/proto/waCompanionReg"
+)
+
+// SessionConfig selects where the WhatsApp session (device keys) is stored
+type SessionConfig struct {
+	Driver string // "sqlite3" (default) or "postgres"
+	DSN    string // Connection string; VENOM_SESSION_DSN overrides it
+
+	Encrypt       bool   // Keep the sqlite3 session encrypted at rest with a passphrase
+	EncryptedFile string // Where the encrypted session is stored
+
+	Profile  string            // Linked number (or profile name) to send from; "new" links another
+	Profiles map[string]string // Friendly names for linked numbers, e.g. "downtown": "201012345678"
+
+	Standby            string // Linked number (or profile name) that takes over if Profile is logged out or banned
+	StandbyHourlyLimit int    // Warm-up caps while the standby sends (0 = HourlyLimit / DailyLimit)
+	StandbyDailyLimit  int
+
+	DeviceName string // Name shown in the phone's "Linked devices" list ("" = "Venom (<hostname>)")
+	Platform   string // Icon shown next to it: "desktop", "chrome", "firefox", "safari", "edge", ...
+}
+
+var (
+	sessionContainer *sqlstore.Container
+	sessionCrypt     *encryptedSession
+)
+
+// sessionDSN returns the configured connection string, preferring the
+// environment so database passwords can stay out of config.json
+func sessionDSN() string {
+	if dsn := os.Getenv("VENOM_SESSION_DSN"); dsn != "" {
+		return dsn
+	}
+	return config.Session.DSN
+}
+
+// openSessionStore connects to the configured session database and
+// upgrades its schema if needed
+func openSessionStore(ctx context.Context) (*sqlstore.Container, error) {
+	driver := strings.ToLower(config.Session.Driver)
+	dsn := sessionDSN()
+
+	switch driver {
+	case "", "sqlite", "sqlite3":
+		driver = "sqlite3"
+		if dsn == "" {
+			dsn = "file:whatsapp_session.db?_foreign_keys=on"
+		}
+		if config.Session.Encrypt {
+			enc, workDSN, err := openEncryptedSession(dsn)
+			if err != nil {
+				return nil, err
+			}
+			sessionCrypt, dsn = enc, workDSN
+		}
+	case "postgres", "postgresql":
+		driver = "postgres"
+		if dsn == "" {
+			return nil, fmt.Errorf("Session.DSN (or VENOM_SESSION_DSN) is required for the postgres session store")
+		}
+	case "mysql", "mariadb":
+		return nil, fmt.Errorf("the WhatsApp session store only supports sqlite3 and postgres, not %s", driver)
+	default:
+		return nil, fmt.Errorf("unknown session driver %q (use sqlite3 or postgres)", config.Session.Driver)
+	}
+
+	dbLog := waLog.Stdout("Database", "ERROR", true)
+	container, err := sqlstore.New(ctx, driver, dsn, dbLog)
+	if err != nil {
+		closeSessionStore()
+		return nil, fmt.Errorf("failed to open %s session store: %w", driver, err)
+	}
+	sessionContainer = container
+	if driver != "sqlite3" {
+		log.Info(fmt.Sprintf("Using %s session store", driver))
+	}
+	return container, nil
+}
+
+// closeSessionStore closes the session database and, if it is encrypted,
+// seals it again. Call it after the client has disconnected.
+func closeSessionStore() {
+	if sessionContainer != nil {
+		sessionContainer.Close()
+		sessionContainer = nil
+	}
+	if sessionCrypt != nil {
+		sessionCrypt.close()
+		sessionCrypt = nil
+	}
+}
+
+// applyDeviceProps sets the name and platform shown under "Linked devices"
+// on the phone. WhatsApp records them when a number is linked, so changing
+// them only affects sessions linked afterwards.
+func applyDeviceProps() {
+	name := config.Session.DeviceName
+	if name == "" {
+		hostname, _ := os.Hostname()
+		name = "Venom (" + hostname + ")"
+	}
+	store.SetOSInfo(name, [3]uint32{1, 0, 0})
+
+	platform := strings.ToUpper(config.Session.Platform)
+	if value, ok := waCompanionReg.DeviceProps_PlatformType_value[platform]; ok {
+		store.DeviceProps.PlatformType = waCompanionReg.DeviceProps_PlatformType(value).Enum()
+	} else if platform != "" {
+		log.Warning(fmt.Sprintf("Unknown Session.Platform %q, using the default", config.Session.Platform))
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionManifest describes an exported session archive
+type sessionManifest struct {
+	JIDs       []string  // Linked accounts in the session database
+	ExportedAt time.Time // When the archive was made
+	Hostname   string    // Machine it was exported from
+}
+
+// runSessionCommand dispatches the session export/import subcommands
+func runSessionCommand(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: venom session <list|export|import> [flags]")
+		return fmt.Errorf("missing session command")
+	}
+
+	switch args[0] {
+	case "list":
+		return runSessionList()
+	case "export":
+		return runSessionExport(args[1:])
+	case "import":
+		return runSessionImport(args[1:])
+	}
+	return fmt.Errorf("unknown session command %q (use list, export or import)", args[0])
+}
+
+// runSessionList prints the numbers linked in the session store
+func runSessionList() error {
+	defer closeSessionStore()
+	container, err := openSessionStore(context.Background())
+	if err != nil {
+		return err
+	}
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if len(devices) == 0 {
+		displayWarning("No Linked Numbers", "Start the tool to link a number by QR code", nil)
+		return nil
+	}
+	details := make([]string, 0, len(devices))
+	for _, device := range devices {
+		details = append(details, describeDevice(device))
+	}
+	displayInfo("Linked Numbers", "Pick one with --profile <number or name>", details)
+	return nil
+}
+
+// runSessionExport archives the session database into an encrypted file
+func runSessionExport(args []string) error {
+	fs := flag.NewFlagSet("session export", flag.ContinueOnError)
+	outPath := fs.String("out", "session-backup.venom", "encrypted archive to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	snapshot, jids, err := sessionSnapshot()
+	if err != nil {
+		return err
+	}
+	if len(jids) == 0 {
+		return fmt.Errorf("session database has no linked account to export")
+	}
+
+	hostname, _ := os.Hostname()
+	manifest, _ := json.MarshalIndent(sessionManifest{JIDs: jids, ExportedAt: time.Now(), Hostname: hostname}, "", "  ")
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{
+		{"manifest.json", manifest},
+		{"session.db", snapshot},
+	} {
+		header := &tar.Header{Name: entry.name, Mode: 0600, Size: int64(len(entry.data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	passphrase, err := sessionPassphrase("Backup passphrase", true)
+	if err != nil {
+		return err
+	}
+	sealed, err := encryptWithPassphrase(archive.Bytes(), passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*outPath, sealed, 0600); err != nil {
+		return err
+	}
+
+	displaySuccess("Session Exported",
+		fmt.Sprintf("Saved %s for %s — keep the passphrase separate from the file", *outPath, strings.Join(jids, ", ")))
+	return nil
+}
+
+// runSessionImport restores a session archive made by session export
+func runSessionImport(args []string) error {
+	fs := flag.NewFlagSet("session import", flag.ContinueOnError)
+	inPath := fs.String("in", "session-backup.venom", "encrypted archive to restore")
+	force := fs.Bool("force", false, "replace an existing session")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := checkSQLiteSession(); err != nil {
+		return err
+	}
+
+	plainFile := sqlitePath(sessionDSN())
+	target := plainFile
+	if config.Session.Encrypt {
+		target = config.Session.EncryptedFile
+	}
+	if _, err := os.Stat(target); err == nil && !*force {
+		displayError("Session Already Exists",
+			fmt.Sprintf("%s already holds a linked account", target),
+			"Run with --force to replace it",
+			[]string{"Replacing unlinks this installation from its current account"})
+		return fmt.Errorf("session already exists")
+	}
+
+	sealed, err := os.ReadFile(*inPath)
+	if err != nil {
+		return err
+	}
+	passphrase, err := sessionPassphrase("Backup passphrase", false)
+	if err != nil {
+		return err
+	}
+	archive, err := decryptWithPassphrase(sealed, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", *inPath, err)
+	}
+
+	var manifest sessionManifest
+	var snapshot []byte
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("invalid session archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid session archive: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		switch header.Name {
+		case "manifest.json":
+			json.Unmarshal(data, &manifest)
+		case "session.db":
+			snapshot = data
+		}
+	}
+	if len(snapshot) == 0 {
+		return fmt.Errorf("session archive has no session database")
+	}
+
+	if config.Session.Encrypt {
+		sessionPass, err := sessionPassphrase("Session passphrase", true)
+		if err != nil {
+			return err
+		}
+		resealed, err := encryptWithPassphrase(snapshot, sessionPass)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(target, resealed, 0600)
+	} else {
+		os.MkdirAll(filepath.Dir(target), 0755)
+		err = os.WriteFile(target, snapshot, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+
+	displaySuccess("Session Imported",
+		fmt.Sprintf("Restored %s (exported %s from %s)",
+			strings.Join(manifest.JIDs, ", "), manifest.ExportedAt.Format("2006-01-02 15:04"), manifest.Hostname))
+	displayWarning("Don't Run Both Copies",
+		"WhatsApp logs out a linked device whose keys are used from two places",
+		[]string{"Stop the tool on the machine the session came from"})
+	return nil
+}
+
+// checkSQLiteSession rejects backup commands for server-side session stores
+func checkSQLiteSession() error {
+	switch strings.ToLower(config.Session.Driver) {
+	case "", "sqlite", "sqlite3":
+		return nil
+	}
+	return fmt.Errorf("session export/import only works with the sqlite3 store; back up %s with its own tools", config.Session.Driver)
+}
+
+// sessionSnapshot returns a consistent copy of the session database and the
+// accounts linked in it
+func sessionSnapshot() ([]byte, []string, error) {
+	if err := checkSQLiteSession(); err != nil {
+		return nil, nil, err
+	}
+
+	dsn := sessionDSN()
+	if config.Session.Encrypt {
+		_, encErr := os.Stat(config.Session.EncryptedFile)
+		_, plainErr := os.Stat(sqlitePath(dsn))
+		if encErr != nil && plainErr != nil {
+			return nil, nil, fmt.Errorf("no session found at %s", config.Session.EncryptedFile)
+		}
+		enc, workDSN, err := openEncryptedSession(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer enc.discard()
+		dsn = workDSN
+	} else if _, err := os.Stat(sqlitePath(dsn)); err != nil {
+		return nil, nil, fmt.Errorf("no session found at %s", sqlitePath(dsn))
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	jids := []string{}
+	rows, err := db.Query("SELECT jid FROM whatsmeow_device")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	for rows.Next() {
+		var jid string
+		if rows.Scan(&jid) == nil {
+			jids = append(jids, jid)
+		}
+	}
+	rows.Close()
+
+	// VACUUM INTO writes a consistent copy even if the database is in use
+	tmpDir, err := os.MkdirTemp("", "venom-export-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	copyPath := filepath.Join(tmpDir, "session.db")
+	if _, err := db.Exec("VACUUM INTO ?", copyPath); err != nil {
+		return nil, nil, fmt.Errorf("failed to snapshot session: %w", err)
+	}
+
+	snapshot, err := os.ReadFile(copyPath)
+	return snapshot, jids, err
+}
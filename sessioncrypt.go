@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptedMagic starts every file written by encryptWithPassphrase
+var encryptedMagic = []byte("VENOMENC1")
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// deriveKey stretches a passphrase into an AES-256 key
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+}
+
+// encryptWithPassphrase seals data with AES-256-GCM under a scrypt-derived key.
+// Output layout: magic | salt | nonce | ciphertext.
+func encryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return sealWithKey(data, key, salt)
+}
+
+// sealWithKey encrypts data with a key already derived from salt
+func sealWithKey(data, key, salt []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, encryptedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, encryptedMagic), nil
+}
+
+// sealedSalt returns the salt of an encrypted file and the rest after it
+func sealedSalt(data []byte) (salt, rest []byte, err error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return nil, nil, fmt.Errorf("not an encrypted file")
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < saltSize {
+		return nil, nil, fmt.Errorf("encrypted file is truncated")
+	}
+	return data[:saltSize], data[saltSize:], nil
+}
+
+// decryptWithPassphrase reverses encryptWithPassphrase
+func decryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	salt, data, err := sealedSalt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return openWithKey(data, key)
+}
+
+// openWithKey decrypts what follows the salt of an encrypted file
+func openWithKey(data, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted file is truncated")
+	}
+	nonce, data := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, data, encryptedMagic)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted file")
+	}
+	return plain, nil
+}
+
+// sessionPassphrase reads the passphrase from VENOM_SESSION_PASSPHRASE or
+// asks for it. When confirm is set the passphrase must be typed twice.
+func sessionPassphrase(label string, confirm bool) (string, error) {
+	if passphrase := os.Getenv("VENOM_SESSION_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	return promptPassphrase(label, confirm)
+}
+
+// promptPassphrase asks for a passphrase, twice when confirm is set
+func promptPassphrase(label string, confirm bool) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+		Validate: func(input string) error {
+			if len(input) < 8 {
+				return fmt.Errorf("use at least 8 characters")
+			}
+			return nil
+		},
+	}
+	passphrase, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		again := promptui.Prompt{Label: "Repeat passphrase", Mask: '*'}
+		repeated, err := again.Run()
+		if err != nil {
+			return "", err
+		}
+		if repeated != passphrase {
+			return "", fmt.Errorf("passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// sqlitePath extracts the database file path from a sqlite3 DSN
+func sqlitePath(dsn string) string {
+	path := strings.TrimPrefix(dsn, "file:")
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// encryptedSession keeps the session database encrypted at rest. sqlite
+// can't open it encrypted, so while the tool runs the database is decrypted
+// into a private directory under workRoot, readable by anyone with the
+// owner's access to the machine. It is sealed back every sessionSealInterval,
+// so a crash loses at most that much of the key updates, and on close the
+// plaintext copy is removed. A copy left by a killed run is removed by the
+// next run that opens the session.
+type encryptedSession struct {
+	encryptedFile string
+	workDir       string
+	workFile      string
+	passphrase    string
+
+	mu   sync.Mutex // Serializes seals
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// sessionSealInterval is how often the working copy is sealed back. It also
+// serves as the copy's heartbeat: one not sealed for three intervals was
+// left behind by a run that was killed.
+const sessionSealInterval = 2 * time.Minute
+
+// workRoot holds the decrypted working copies of encrypted databases. It is
+// only accessible to the owner and sits with the data it was decrypted
+// from, not in a temporary directory shared with other users.
+const workRoot = "data/work"
+
+// makeWorkDir creates a private working directory under workRoot
+func makeWorkDir(prefix string) (string, error) {
+	if err := os.MkdirAll(workRoot, 0700); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(workRoot, 0700); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(workRoot, prefix)
+}
+
+// sessionOwnerFile records, in each working directory, which encrypted
+// session the copy belongs to
+const sessionOwnerFile = "owner"
+
+// openEncryptedSession decrypts the session (or encrypts an existing
+// plaintext one on first use) and returns a sqlite DSN for the working copy
+func openEncryptedSession(plainDSN string) (*encryptedSession, string, error) {
+	plainFile := sqlitePath(plainDSN)
+	s := &encryptedSession{encryptedFile: config.Session.EncryptedFile}
+
+	_, encErr := os.Stat(s.encryptedFile)
+	exists := encErr == nil
+
+	passphrase, err := sessionPassphrase("Session passphrase", !exists)
+	if err != nil {
+		return nil, "", err
+	}
+	s.passphrase = passphrase
+
+	var data []byte
+	switch {
+	case exists:
+		sealed, err := os.ReadFile(s.encryptedFile)
+		if err != nil {
+			return nil, "", err
+		}
+		data, err = decryptWithPassphrase(sealed, passphrase)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decrypt %s: %w", s.encryptedFile, err)
+		}
+	default:
+		// Migrate an existing unencrypted session so the account stays linked
+		data, err = os.ReadFile(plainFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, "", err
+		}
+		if err == nil {
+			log.Info(fmt.Sprintf("Encrypting existing session %s", plainFile))
+		}
+	}
+
+	// The passphrase is known good now, so a killed run's newer copy can be sealed in
+	if recovered := removeStaleSessions(s.encryptedFile); recovered != nil {
+		data = recovered
+	}
+
+	s.workDir, err = makeWorkDir("venom-session-")
+	if err != nil {
+		return nil, "", err
+	}
+	s.workFile = filepath.Join(s.workDir, "session.db")
+	if owner, err := filepath.Abs(s.encryptedFile); err == nil {
+		os.WriteFile(filepath.Join(s.workDir, sessionOwnerFile), []byte(owner), 0600)
+	}
+
+	if len(data) > 0 {
+		if err := os.WriteFile(s.workFile, data, 0600); err != nil {
+			s.discard()
+			return nil, "", err
+		}
+	}
+
+	// Seal straight away so a migrated plaintext file can be removed
+	if err := s.seal(); err != nil {
+		s.discard()
+		return nil, "", err
+	}
+	if !exists {
+		if err := os.Remove(plainFile); err == nil {
+			log.Success(fmt.Sprintf("Removed unencrypted session %s", plainFile))
+		}
+	}
+
+	s.stop = make(chan struct{})
+	s.done.Add(1)
+	go s.keepSealed()
+
+	return s, "file:" + s.workFile + "?_foreign_keys=on", nil
+}
+
+// keepSealed seals the working copy every sessionSealInterval until close
+func (s *encryptedSession) keepSealed() {
+	defer s.done.Done()
+	ticker := time.NewTicker(sessionSealInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.seal(); err != nil {
+				log.Warning(fmt.Sprintf("Failed to seal session database: %v", err))
+			}
+		}
+	}
+}
+
+// seal encrypts the working copy back to the encrypted session file. It
+// seals a VACUUM INTO snapshot, which is consistent even while the session
+// is being written, and marks the working directory as alive.
+func (s *encryptedSession) seal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	os.Chtimes(s.workDir, now, now)
+
+	if _, err := os.Stat(s.workFile); os.IsNotExist(err) {
+		return nil // nothing linked yet
+	}
+	data, err := snapshotSQLite(s.workFile, filepath.Join(s.workDir, "snapshot.db"))
+	if err != nil {
+		return err
+	}
+
+	sealed, err := encryptWithPassphrase(data, s.passphrase)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a half-written session
+	tmp := s.encryptedFile + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.encryptedFile)
+}
+
+// snapshotSQLite reads a consistent copy of a database that may be in use
+func snapshotSQLite(path, snapshotPath string) ([]byte, error) {
+	os.Remove(snapshotPath)
+	defer os.Remove(snapshotPath)
+
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.Exec("VACUUM INTO ?", snapshotPath); err != nil {
+		return nil, fmt.Errorf("failed to snapshot session: %w", err)
+	}
+	return os.ReadFile(snapshotPath)
+}
+
+// removeStaleSessions deletes the plaintext working copies of runs that
+// were killed before they could clean up, including ones earlier versions
+// left in the temporary directory. If one belongs to encryptedFile and is
+// newer than it, its contents are returned: they hold key updates the
+// encrypted file missed and should be sealed in.
+func removeStaleSessions(encryptedFile string) []byte {
+	dirs, _ := filepath.Glob(filepath.Join(workRoot, "venom-session-*"))
+	legacy, _ := filepath.Glob(filepath.Join(os.TempDir(), "venom-session-*"))
+	dirs = append(dirs, legacy...)
+	if len(dirs) == 0 {
+		return nil
+	}
+	owner, _ := filepath.Abs(encryptedFile)
+	sealedAt := time.Time{}
+	if info, err := os.Stat(encryptedFile); err == nil {
+		sealedAt = info.ModTime()
+	}
+
+	var recovered []byte
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < 3*sessionSealInterval {
+			continue // Still in use by a running instance
+		}
+
+		belongs, _ := os.ReadFile(filepath.Join(dir, sessionOwnerFile))
+		workFile := filepath.Join(dir, "session.db")
+		if string(belongs) == owner {
+			if copyInfo, err := os.Stat(workFile); err == nil && copyInfo.ModTime().After(sealedAt) {
+				if data, err := snapshotSQLite(workFile, filepath.Join(dir, "snapshot.db")); err == nil {
+					recovered, sealedAt = data, copyInfo.ModTime()
+					log.Info(fmt.Sprintf("Recovered session updates left in %s by an interrupted run", dir))
+				}
+			}
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warning(fmt.Sprintf("Could not remove stale session copy %s: %v", dir, err))
+			continue
+		}
+		log.Info(fmt.Sprintf("Removed stale unencrypted session copy %s", dir))
+	}
+	return recovered
+}
+
+// stopSealing stops the periodic seals
+func (s *encryptedSession) stopSealing() {
+	if s.stop != nil {
+		close(s.stop)
+		s.done.Wait()
+		s.stop = nil
+	}
+}
+
+// discard removes the plaintext working copy
+func (s *encryptedSession) discard() {
+	s.stopSealing()
+	os.RemoveAll(s.workDir)
+}
+
+// close seals the session and removes the plaintext working copy
+func (s *encryptedSession) close() {
+	s.stopSealing()
+	if err := s.seal(); err != nil {
+		log.Error("Failed to encrypt session database, keeping working copy in "+s.workDir, err)
+		return
+	}
+	s.discard()
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SheetsConfig reads the customer list from a Google Sheet, given as the CSV
+// path in the form gsheet://<spreadsheet id>/<tab name> or as the sheet's
+// browser URL, and writes each row's send status back to it
+type SheetsConfig struct {
+	CredentialsFile string // Service account key (JSON); VENOM_SHEETS_CREDENTIALS overrides
+	StatusColumn    string // Header of the column the status is written to, added if missing
+}
+
+// sheetsAPI is the Google Sheets REST endpoint
+const sheetsAPI = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// sheetSource is the Google Sheet the campaign's customers came from, or nil
+var sheetSource *googleSheet
+
+// googleSheet is one tab of a spreadsheet and the status column of its rows
+type googleSheet struct {
+	id    string
+	title string
+
+	http        *http.Client
+	account     serviceAccount
+	key         *rsa.PrivateKey
+	token       string
+	tokenExpiry time.Time
+
+	statusColumn int              // Zero-based index of the status column
+	statuses     []string         // Status column values by row, header first
+	rowsByKey    map[string][]int // Rows of each customer, by sheetRowKey
+}
+
+// serviceAccount is the part of a Google service account key that's needed
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// parseSheetURL recognises gsheet://<id>/<tab> and
+// https://docs.google.com/spreadsheets/d/<id>/edit#gid=<n> URLs. For the
+// latter the tab is "#<gid>", resolved once the sheet's tabs are known.
+func parseSheetURL(u *url.URL) (spreadsheetID, sheet string, ok bool) {
+	switch {
+	case strings.EqualFold(u.Scheme, "gsheet"):
+		return u.Host, strings.Trim(u.Path, "/"), u.Host != ""
+	case strings.EqualFold(u.Scheme, "https") && u.Host == "docs.google.com":
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 3 || parts[0] != "spreadsheets" || parts[1] != "d" {
+			return "", "", false
+		}
+		if gid, found := strings.CutPrefix(u.Fragment, "gid="); found {
+			sheet = "#" + gid
+		} else if gid := u.Query().Get("gid"); gid != "" {
+			sheet = "#" + gid
+		}
+		return parts[2], sheet, true
+	}
+	return "", "", false
+}
+
+// fetchSheet downloads a tab of a Google Sheet into data/downloads as CSV,
+// remembers its rows for writing status back, and returns the local path
+func fetchSheet(spreadsheetID, sheet string) (string, error) {
+	s, err := openGoogleSheet(spreadsheetID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.resolveTitle(sheet); err != nil {
+		return "", err
+	}
+	log.Info(fmt.Sprintf("Reading customer list from Google Sheet tab %q...", s.title))
+
+	rows, err := s.readRows()
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("Google Sheet tab %q is empty", s.title)
+	}
+
+	// Rows come back without their trailing empty cells; pad them so every
+	// row has the same columns as the widest one
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, row := range rows {
+		if isBlankRow(row) {
+			continue // Blank rows separating sections of the list
+		}
+		record := make([]string, width)
+		copy(record, row)
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	local := filepath.Join(downloadDir, "gsheet-"+spreadsheetID+"-"+safeFileName(s.title)+".csv")
+	if err := os.MkdirAll(downloadDir, 0700); err != nil {
+		return "", err
+	}
+	if err := writeDataFile(local, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to save Google Sheet rows: %w", err)
+	}
+	sheetSource = s
+	log.Success(fmt.Sprintf("Read %d rows from Google Sheet tab %q", len(rows)-1, s.title))
+	return local, nil
+}
+
+// readRows reads every row of the tab and indexes its status column and
+// the rows of each customer
+func (s *googleSheet) readRows() ([][]string, error) {
+	var values struct {
+		Values [][]string `json:"values"`
+	}
+	if err := s.call(http.MethodGet, "/values/"+url.PathEscape(s.rangeName("")), nil, &values); err != nil {
+		return nil, err
+	}
+	s.indexRows(values.Values)
+	return values.Values, nil
+}
+
+// indexRows finds the status column, its current values and the rows each
+// customer is on
+func (s *googleSheet) indexRows(rows [][]string) {
+	s.statusColumn = 0
+	if len(rows) > 0 {
+		header := rows[0]
+		s.statusColumn = len(header)
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), config.Sheets.StatusColumn) {
+				s.statusColumn = i
+				break
+			}
+		}
+	}
+
+	s.statuses = make([]string, len(rows))
+	s.rowsByKey = make(map[string][]int)
+	for i, row := range rows {
+		if s.statusColumn < len(row) {
+			s.statuses[i] = row[s.statusColumn]
+		}
+		if i == 0 || isBlankRow(row) {
+			continue
+		}
+		cell := func(column int) string {
+			if column < len(row) {
+				return strings.TrimSpace(row[column])
+			}
+			return ""
+		}
+		key := sheetRowKey(Customer{Code: cell(0), Phone: cell(2), Mobile: cell(3)})
+		s.rowsByKey[key] = append(s.rowsByKey[key], i)
+	}
+}
+
+// isBlankRow reports whether a sheet row has no values
+func isBlankRow(row []string) bool {
+	return strings.TrimSpace(strings.Join(row, "")) == ""
+}
+
+// openGoogleSheet loads the service account key used to reach a spreadsheet
+func openGoogleSheet(spreadsheetID string) (*googleSheet, error) {
+	credentials := config.Sheets.CredentialsFile
+	if env := os.Getenv("VENOM_SHEETS_CREDENTIALS"); env != "" {
+		credentials = env
+	} else if env := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); credentials == "" && env != "" {
+		credentials = env
+	}
+	if credentials == "" {
+		return nil, fmt.Errorf("no Google service account key: set Sheets.CredentialsFile or VENOM_SHEETS_CREDENTIALS")
+	}
+
+	data, err := os.ReadFile(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google service account key: %w", err)
+	}
+	s := &googleSheet{id: spreadsheetID, http: &http.Client{Timeout: 60 * time.Second}}
+	if err := json.Unmarshal(data, &s.account); err != nil {
+		return nil, fmt.Errorf("invalid Google service account key %s: %w", credentials, err)
+	}
+	if s.account.TokenURI == "" {
+		s.account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	block, _ := pem.Decode([]byte(s.account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("Google service account key %s has no private key", credentials)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s: %w", credentials, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is not an RSA key", credentials)
+	}
+	s.key = key
+	return s, nil
+}
+
+// resolveTitle finds the tab to read: the named one, the one with the given
+// "#gid", or the first tab when none is named
+func (s *googleSheet) resolveTitle(sheet string) error {
+	if sheet != "" && !strings.HasPrefix(sheet, "#") {
+		s.title = sheet
+		return nil
+	}
+	var meta struct {
+		Sheets []struct {
+			Properties struct {
+				SheetID int    `json:"sheetId"`
+				Title   string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := s.call(http.MethodGet, "?fields=sheets.properties", nil, &meta); err != nil {
+		return err
+	}
+	for _, tab := range meta.Sheets {
+		if sheet == "" || sheet == "#"+strconv.Itoa(tab.Properties.SheetID) {
+			s.title = tab.Properties.Title
+			return nil
+		}
+	}
+	return fmt.Errorf("Google Sheet %s has no tab %q", s.id, sheet)
+}
+
+// writeStatuses writes the outcome of every sent, failed or skipped customer
+// into the status column; rows the campaign didn't reach keep their value.
+// The sheet is read again first, since rows may have been sorted, inserted
+// or deleted while the campaign ran.
+func (s *googleSheet) writeStatuses() error {
+	outcomes := make(map[string]string)
+	set := func(customer Customer, status string) {
+		outcomes[sheetRowKey(customer)] = status
+	}
+	err := forEachSkipped(func(skipped SkippedCustomer) {
+		set(skipped.Customer, "Skipped: "+skipped.Reason)
+	})
+	if err != nil {
+		return err
+	}
+	err = forEachResult(func(result MessageResult) {
+		if result.Success {
+			set(result.Customer.Customer, "Sent "+result.Timestamp.Format("2006-01-02 15:04"))
+		} else {
+			set(result.Customer.Customer, "Failed: "+result.Error)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	rows, err := s.readRows()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("Google Sheet tab %q is empty now; no status written", s.title)
+	}
+	changed, missing := 0, 0
+	for key, status := range outcomes {
+		found := s.rowsByKey[key]
+		if len(found) == 0 {
+			missing++
+		}
+		for _, row := range found {
+			s.statuses[row] = status
+			changed++
+		}
+	}
+	if missing > 0 {
+		log.Warning(fmt.Sprintf("%d customer(s) are no longer in Google Sheet tab %q; their status wasn't written", missing, s.title))
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	s.statuses[0] = config.Sheets.StatusColumn
+	column := sheetColumnName(s.statusColumn)
+	cells := s.rangeName(fmt.Sprintf("%s1:%s%d", column, column, len(s.statuses)))
+	body := map[string]interface{}{
+		"range":          cells,
+		"majorDimension": "COLUMNS",
+		"values":         [][]string{s.statuses},
+	}
+	if err := s.call(http.MethodPut, "/values/"+url.PathEscape(cells)+"?valueInputOption=RAW", body, nil); err != nil {
+		return err
+	}
+	log.Success(fmt.Sprintf("Wrote send status of %d rows to Google Sheet tab %q", changed, s.title))
+	return nil
+}
+
+// call sends a request to the spreadsheet's REST endpoint
+func (s *googleSheet) call(method, path string, body, out interface{}) error {
+	token, err := s.accessToken()
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, sheetsAPI+"/"+s.id+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if apiErr.Error.Message != "" {
+			return fmt.Errorf("google sheets: %s (HTTP %d)", apiErr.Error.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("google sheets: HTTP %d", resp.StatusCode)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// accessToken exchanges a signed service account assertion for an OAuth
+// token, reusing it until shortly before it expires
+func (s *googleSheet) accessToken() (string, error) {
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.account.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/spreadsheets",
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.http.PostForm(s.account.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("google sign-in failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}
+	json.NewDecoder(resp.Body).Decode(&token)
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		if token.Error != "" {
+			return "", fmt.Errorf("google sign-in failed: %s", token.Error)
+		}
+		return "", fmt.Errorf("google sign-in failed: HTTP %d", resp.StatusCode)
+	}
+	s.token = token.AccessToken
+	s.tokenExpiry = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// rangeName qualifies an A1 range with the tab, quoting the tab's name
+func (s *googleSheet) rangeName(cells string) string {
+	name := "'" + strings.ReplaceAll(s.title, "'", "''") + "'"
+	if cells == "" {
+		return name
+	}
+	return name + "!" + cells
+}
+
+// sheetRowKey identifies a customer's row by the columns that don't change
+// between reading the sheet and writing the status back
+func sheetRowKey(customer Customer) string {
+	return customer.Code + "|" + customer.Phone + "|" + customer.Mobile
+}
+
+// sheetColumnName converts a zero-based column index to its letters (0 = A, 26 = AA)
+func sheetColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// fileNameUnsafePattern matches runs of characters kept out of file names
+var fileNameUnsafePattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// safeFileName makes a tab or query name safe to use in a file name
+func safeFileName(title string) string {
+	return strings.Trim(fileNameUnsafePattern.ReplaceAllString(title, "_"), "_")
+}
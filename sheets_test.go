@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestSheetColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := sheetColumnName(tt.index); got != tt.want {
+			t.Errorf("sheetColumnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestSheetIndexRows(t *testing.T) {
+	config.Sheets.StatusColumn = "Status"
+	s := &googleSheet{}
+	s.indexRows([][]string{
+		{"Code", "Name", "Phone", "Mobile", "Status"},
+		{"C2", "Mona", "201000000002"},
+		{},
+		{"C1", "Ali", "201000000001", "", "Sent 2026-01-01 10:00"},
+	})
+
+	if s.statusColumn != 4 {
+		t.Errorf("statusColumn = %d, want 4", s.statusColumn)
+	}
+	if got := s.rowsByKey[sheetRowKey(Customer{Code: "C1", Phone: "201000000001"})]; len(got) != 1 || got[0] != 3 {
+		t.Errorf("rows of C1 = %v, want [3]", got)
+	}
+	if got := s.rowsByKey[sheetRowKey(Customer{Code: "C2", Phone: "201000000002"})]; len(got) != 1 || got[0] != 1 {
+		t.Errorf("rows of C2 = %v, want [1]", got)
+	}
+	if s.statuses[3] != "Sent 2026-01-01 10:00" || len(s.statuses) != 4 {
+		t.Errorf("statuses = %q", s.statuses)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// shutdownInterrupted leaves an interrupted campaign in a resumable state:
+// it flushes the checkpoint and results CSV, prints one final stats line and
+// records exitInterrupted. The caller returns so its deferred cleanup
+// disconnects the transport, and main exits with the recorded cause.
+func shutdownInterrupted() {
+	if checkpoint != nil {
+		if err := checkpoint.save(); err != nil {
+			log.Error("Failed to save checkpoint", err)
+		}
+	}
+	closeResultsCSV()
+
+	fmt.Fprintf(os.Stderr, "INTERRUPTED sent=%d failed=%d skipped=%d remaining=%d\n",
+		progress.Successful, progress.Failed, progress.Skipped, progress.Remaining)
+	log.Warning(fmt.Sprintf("Interrupted with %d customers remaining; run again to resume", progress.Remaining))
+	failWith(exitInterrupted)
+}
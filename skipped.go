@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Reasons recorded in the skipped-customers report
+const (
+	skipInvalidPhone  = "invalid phone"
+	skipDuplicate     = "duplicate"
+	skipNotOnWhatsApp = "not on WhatsApp"
+	skipSuppressed    = "suppressed"
+	skipMissingData   = "missing data"
+	skipSpecialEntry  = "special entry"
+	skipNoConsent     = "no consent"
+	skipAlreadySent   = "already sent"
+	skipGhostNumber   = "no profile"
+	skipOpenChat      = "open conversation"
+)
+
+const skippedCustomersFile = "data/skipped-customers.csv"
+
+// SkippedCustomer is a customer left out of the campaign and why
+type SkippedCustomer struct {
+	Customer
+	Reason string
+	Detail string
+}
+
+var (
+	skippedCustomers []SkippedCustomer
+	skippedSeen      = make(map[string]bool)
+)
+
+// recordSkip adds a customer to the skipped report. Customers are re-processed
+// after the pre-check, so the same skip is only recorded once.
+func recordSkip(customer Customer, reason, detail string) {
+	key := customer.Code + "|" + customer.Phone + "|" + customer.Mobile + "|" + reason
+	if skippedSeen[key] {
+		return
+	}
+	skippedSeen[key] = true
+	if streamResults {
+		appendSkippedCustomer(SkippedCustomer{customer, reason, detail})
+	} else {
+		skippedCustomers = append(skippedCustomers, SkippedCustomer{customer, reason, detail})
+	}
+
+	if eventStream != nil {
+		fields := customerEventFields(customer)
+		fields["reason"] = reason
+		fields["detail"] = detail
+		emitEvent(eventSkipped, fields)
+	}
+}
+
+// saveSkippedCustomers writes the skipped customers with their reasons so the
+// source records can be fixed
+func saveSkippedCustomers(skipped []SkippedCustomer) {
+	os.MkdirAll(filepath.Dir(skippedCustomersFile), 0755)
+
+	file, err := createDataFile(skippedCustomersFile)
+	if err != nil {
+		log.Error("Failed to create skipped customers file", err)
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write(skippedHeader)
+	for _, s := range skipped {
+		writer.Write([]string{s.Code, s.CustomerName, s.Phone, s.Mobile, s.Reason, s.Detail})
+	}
+
+	log.Info(fmt.Sprintf("Saved %d skipped customers to %s", len(skipped), skippedCustomersFile))
+}
+
+var skippedHeader = []string{"Code", "CustomerName", "Phone", "Mobile", "Reason", "Detail"}
+
+// skippedFile is the skipped-customers CSV a chunked run writes as
+// customers are skipped
+var skippedFile struct {
+	file   io.WriteCloser
+	writer *csv.Writer
+	count  int
+	failed bool // Couldn't be created
+}
+
+// appendSkippedCustomer writes one skipped customer of a chunked run
+func appendSkippedCustomer(s SkippedCustomer) {
+	if skippedFile.writer == nil {
+		if skippedFile.failed {
+			return
+		}
+		os.MkdirAll(filepath.Dir(skippedCustomersFile), 0755)
+		file, err := createDataFile(skippedCustomersFile)
+		if err != nil {
+			log.Error("Failed to create skipped customers file", err)
+			skippedFile.failed = true
+			return
+		}
+		skippedFile.file, skippedFile.writer = file, csv.NewWriter(file)
+		skippedFile.writer.Write(skippedHeader)
+	}
+	skippedFile.writer.Write([]string{s.Code, s.CustomerName, s.Phone, s.Mobile, s.Reason, s.Detail})
+	skippedFile.writer.Flush()
+	skippedFile.count++
+}
+
+// closeSkippedCustomers finishes the skipped-customers CSV of a chunked run
+func closeSkippedCustomers() {
+	if skippedFile.writer == nil {
+		return
+	}
+	skippedFile.writer.Flush()
+	skippedFile.file.Close()
+	skippedFile.file, skippedFile.writer = nil, nil
+	log.Info(fmt.Sprintf("Saved %d skipped customers to %s", skippedFile.count, skippedCustomersFile))
+}
+
+// forEachSkipped calls fn with every customer skipped this run. A chunked
+// run's are read back from the skipped-customers CSV.
+func forEachSkipped(fn func(SkippedCustomer)) error {
+	if !streamResults {
+		for _, s := range skippedCustomers {
+			fn(s)
+		}
+		return nil
+	}
+	if skippedFile.count == 0 {
+		return nil
+	}
+	if skippedFile.writer != nil {
+		skippedFile.writer.Flush()
+	}
+	file, err := openDataFile(skippedCustomersFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil {
+		return nil
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for len(record) < len(skippedHeader) {
+			record = append(record, "")
+		}
+		s := SkippedCustomer{Reason: record[4], Detail: record[5]}
+		s.Code, s.CustomerName, s.Phone, s.Mobile = record[0], record[1], record[2], record[3]
+		fn(s)
+	}
+}
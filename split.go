@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// errEmptyMessage is the MessageResult error for a template that rendered to nothing
+const errEmptyMessage = "rendered message is empty"
+
+// splitLongMessage splits a message longer than SplitLength characters into
+// two parts at the paragraph, line, sentence or word break nearest its middle
+func splitLongMessage(message string) []string {
+	length := utf8.RuneCountInString(message)
+	if config.SplitLength <= 0 || length <= config.SplitLength || isPollCampaign() || isInviteCampaign() {
+		return []string{message}
+	}
+
+	runes := []rune(message)
+	middle := len(runes) / 2
+
+	for _, sep := range []string{"\n\n", "\n", ". ", "، ", " "} {
+		cut := nearestBreak(runes, middle, []rune(sep))
+		if cut > 0 {
+			first := strings.TrimSpace(string(runes[:cut]))
+			second := strings.TrimSpace(string(runes[cut:]))
+			if first != "" && second != "" {
+				return []string{first, second}
+			}
+		}
+	}
+
+	// No natural break, cut in the middle
+	return []string{string(runes[:middle]), string(runes[middle:])}
+}
+
+// nearestBreak returns the index just after the occurrence of sep closest
+// to middle, or -1 if sep doesn't occur
+func nearestBreak(runes []rune, middle int, sep []rune) int {
+	best := -1
+	for i := 0; i+len(sep) <= len(runes); i++ {
+		if string(runes[i:i+len(sep)]) != string(sep) {
+			continue
+		}
+		cut := i + len(sep)
+		if best == -1 || abs(cut-middle) < abs(best-middle) {
+			best = cut
+		}
+	}
+	return best
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitLongMessage(t *testing.T) {
+	defer func(length int, campaignType string) {
+		config.SplitLength, config.CampaignType = length, campaignType
+	}(config.SplitLength, config.CampaignType)
+
+	tests := []struct {
+		name         string
+		splitLength  int
+		campaignType string
+		message      string
+		want         []string
+	}{
+		{"short message", 20, "", "Hello", []string{"Hello"}},
+		{"exactly the limit", 5, "", "Hello", []string{"Hello"}},
+		{"splitting off", 0, "", "First paragraph.\n\nSecond paragraph.", []string{"First paragraph.\n\nSecond paragraph."}},
+		{"paragraph break", 20, "", "First paragraph.\n\nSecond paragraph.", []string{"First paragraph.", "Second paragraph."}},
+		{"line break", 20, "", "line one here\nline two there", []string{"line one here", "line two there"}},
+		{"sentence break", 20, "", "Hello there. How are you today", []string{"Hello there.", "How are you today"}},
+		{"Arabic comma", 20, "", "مرحباً بك، نتمنى لك يوماً سعيداً", []string{"مرحباً بك،", "نتمنى لك يوماً سعيداً"}},
+		{"word nearest the middle", 20, "", "aaaa bbbb cccc dddd eeee", []string{"aaaa bbbb", "cccc dddd eeee"}},
+		{"no break", 20, "", strings.Repeat("x", 30), []string{strings.Repeat("x", 15), strings.Repeat("x", 15)}},
+		{"counts characters, not bytes", 30, "", strings.Repeat("ب", 25), []string{strings.Repeat("ب", 25)}},
+		{"polls aren't split", 20, "poll", "First paragraph.\n\nSecond paragraph.", []string{"First paragraph.\n\nSecond paragraph."}},
+		{"invites aren't split", 20, "invite", "First paragraph.\n\nSecond paragraph.", []string{"First paragraph.\n\nSecond paragraph."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SplitLength, config.CampaignType = tt.splitLength, tt.campaignType
+			if got := splitLongMessage(tt.message); !slices.Equal(got, tt.want) {
+				t.Errorf("splitLongMessage(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/microsoft/go-mssqldb"
+)
+
+// SQLSourceConfig reads customers straight from the pharmacy database. A
+// campaign picks one of the named queries with sql://<name> as its CSV path.
+type SQLSourceConfig struct {
+	Driver  string            // "postgres", "sqlite3" or "sqlserver"
+	DSN     string            // Connection string; VENOM_SOURCE_DSN overrides it
+	Queries map[string]string // Named SELECTs, e.g. "refill-due": "SELECT ... FROM patients WHERE ..."
+	Columns map[string]string // Customer field to result column, e.g. "CustomerName": "full_name"
+}
+
+// customerFields are the fixed CSV columns a query's result is mapped to
+var customerFields = []string{"Code", "CustomerName", "Phone", "Mobile", "HasWhatsApp"}
+
+// isSQLSource reports whether a CSV path names a database query
+func isSQLSource(csvPath string) bool {
+	return strings.HasPrefix(strings.ToLower(csvPath), "sql://")
+}
+
+// fetchSQLSource runs the named query and writes its rows to data/downloads
+// as CSV with the usual columns; result columns that aren't mapped to a
+// customer field become extra columns, usable as placeholders
+func fetchSQLSource(source string) (string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid database source: %w", err)
+	}
+	name := u.Host
+	query, ok := config.SQLSource.Queries[name]
+	if !ok {
+		return "", fmt.Errorf("no query named %q in SQLSource.Queries", name)
+	}
+
+	driver := strings.ToLower(config.SQLSource.Driver)
+	switch driver {
+	case "postgres", "postgresql":
+		driver = "postgres"
+	case "sqlite", "sqlite3":
+		driver = "sqlite3"
+	case "sqlserver", "mssql":
+		driver = "sqlserver"
+	default:
+		return "", fmt.Errorf("unknown SQLSource.Driver %q (use postgres, sqlite3 or sqlserver)", config.SQLSource.Driver)
+	}
+	dsn := config.SQLSource.DSN
+	if env := os.Getenv("VENOM_SOURCE_DSN"); env != "" {
+		dsn = env
+	}
+	if dsn == "" {
+		return "", fmt.Errorf("SQLSource.DSN (or VENOM_SOURCE_DSN) is required")
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	log.Info(fmt.Sprintf("Running database query %q...", name))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("query %q failed: %w", name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	fields, extras, err := mapSQLColumns(columns)
+	if err != nil {
+		return "", fmt.Errorf("query %q: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	fixed := len(customerFields)
+	if fields[fixed-1] < 0 {
+		fixed-- // No HasWhatsApp column
+	}
+	header := append([]string{}, customerFields[:fixed]...)
+	for _, i := range extras {
+		header = append(header, columns[i])
+	}
+	writer.Write(header)
+
+	values := make([]interface{}, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return "", fmt.Errorf("query %q: %w", name, err)
+		}
+		record := make([]string, 0, len(header))
+		for _, i := range fields[:fixed] {
+			if i >= 0 {
+				record = append(record, sqlValueString(values[i]))
+			} else {
+				record = append(record, "")
+			}
+		}
+		for _, i := range extras {
+			record = append(record, sqlValueString(values[i]))
+		}
+		writer.Write(record)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("query %q: %w", name, err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	local := filepath.Join(downloadDir, "sql-"+safeFileName(name)+".csv")
+	if err := os.MkdirAll(downloadDir, 0700); err != nil {
+		return "", err
+	}
+	if err := writeDataFile(local, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to save query results: %w", err)
+	}
+	log.Success(fmt.Sprintf("Query %q returned %d customers", name, count))
+	return local, nil
+}
+
+// mapSQLColumns finds the result column of each customer field (-1 if
+// absent), using SQLSource.Columns or else a column of the same name, and
+// returns the remaining columns as extras
+func mapSQLColumns(columns []string) (fields []int, extras []int, err error) {
+	used := make(map[int]bool)
+	for _, field := range customerFields {
+		want := field
+		if mapped := config.SQLSource.Columns[field]; mapped != "" {
+			want = mapped
+		}
+		index := -1
+		for i, column := range columns {
+			if strings.EqualFold(column, want) {
+				index = i
+				break
+			}
+		}
+		if index < 0 && config.SQLSource.Columns[field] != "" {
+			return nil, nil, fmt.Errorf("result has no column %q for %s", want, field)
+		}
+		if index >= 0 {
+			used[index] = true
+		}
+		fields = append(fields, index)
+	}
+	if fields[2] < 0 && fields[3] < 0 {
+		return nil, nil, fmt.Errorf("result has neither a Phone nor a Mobile column; map one in SQLSource.Columns")
+	}
+	for i := range columns {
+		if !used[i] {
+			extras = append(extras, i)
+		}
+	}
+	return fields, extras, nil
+}
+
+// sqlValueString formats a result value for the CSV; dates without a time
+// of day are written as 2006-01-02 so they read well in messages
+func sqlValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprint(value)
+}
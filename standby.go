@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// standbyPause is how long sending stops when the standby number takes
+// over, so it doesn't start at full speed the moment the primary is lost
+const standbyPause = 30 * time.Second
+
+// standbyWarmup is how many sends after a switch use the warm-up delay
+const standbyWarmup = 5
+
+// standbySender sends from the primary linked number until it is logged out
+// or banned, then from the standby number for the rest of the campaign.
+// Both stay connected so replies and receipts to either are handled.
+type standbySender struct {
+	primary, standby *whatsmeow.Client
+	ctx              context.Context // The campaign's; cancelling it ends the pause after a switch
+
+	mu       sync.Mutex
+	switched bool
+	lost     bool // The standby was logged out before it was needed
+	warmup   int  // Sends left at the warm-up delay after the switch
+}
+
+var _ Sender = (*standbySender)(nil)
+
+// standbySession is the running standby pair, or nil without Session.Standby
+var standbySession *standbySender
+
+// openStandby connects the Session.Standby number next to primary
+func openStandby(ctx context.Context, primary Sender, closePrimary func()) (Sender, func(), error) {
+	client, ok := primary.(*whatsmeow.Client)
+	if !ok {
+		log.Warning("Session.Standby only applies to the whatsmeow transport, ignoring it")
+		return primary, closePrimary, nil
+	}
+	if strings.TrimSpace(config.Session.Standby) == newProfile {
+		closePrimary()
+		return nil, nil, fmt.Errorf("Session.Standby must be a number that is already linked")
+	}
+
+	standby, err := connectWhatsApp(ctx, config.Session.Standby)
+	if err != nil {
+		closePrimary()
+		return nil, nil, fmt.Errorf("standby session: %w", err)
+	}
+	if standby.Store.ID == nil || standby.Store.ID.User == client.Store.ID.User {
+		standby.Disconnect()
+		closePrimary()
+		return nil, nil, fmt.Errorf("Session.Standby is the same number as the primary session")
+	}
+	log.Info(fmt.Sprintf("Standby number +%s takes over if +%s is logged out", standby.Store.ID.User, client.Store.ID.User))
+
+	standbySession = &standbySender{primary: client, standby: standby, ctx: ctx}
+	return standbySession, func() {
+		standby.Disconnect()
+		closePrimary()
+	}, nil
+}
+
+// isIdleStandby reports whether client is the standby number and isn't
+// sending yet. Its logout then only means there is no standby any more.
+func (s *standbySender) isIdleStandby(client *whatsmeow.Client) bool {
+	if s == nil || client != s.standby {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.switched {
+		return false
+	}
+	s.lost = true
+	return true
+}
+
+// current returns the client sending now, without switching
+func (s *standbySender) current() *whatsmeow.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.switched {
+		return s.standby
+	}
+	return s.primary
+}
+
+// active returns the client to send from, switching to the standby if the
+// primary session has been logged out
+func (s *standbySender) active() *whatsmeow.Client {
+	if sessionLoggedOut.Load() {
+		s.switchOver("was logged out")
+	}
+	return s.current()
+}
+
+// switchOver pauses, then moves sending to the standby number with its own
+// rate limits and a fresh warm-up, once
+func (s *standbySender) switchOver(reason string) {
+	s.mu.Lock()
+	if s.switched || s.lost {
+		s.mu.Unlock()
+		return
+	}
+	s.switched = true
+	s.warmup = standbyWarmup
+	s.mu.Unlock()
+
+	// The standby is a different session; its logout is a new event
+	sessionLoggedOut.Store(false)
+
+	// Media uploaded from the primary can't be reused by the standby
+	resetMediaCache()
+
+	// The standby starts with its own counts, capped lower while it warms up
+	if config.Session.StandbyHourlyLimit > 0 {
+		config.HourlyLimit = config.Session.StandbyHourlyLimit
+	}
+	if config.Session.StandbyDailyLimit > 0 {
+		config.DailyLimit = config.Session.StandbyDailyLimit
+	}
+	progress.HourlySent, progress.DailySent = 0, 0
+	progress.LastHourReset, progress.LastDayReset = time.Now(), time.Now()
+	if quota != nil {
+		quota.account = senderAccount(s.standby)
+	}
+
+	clearProgress()
+	displayWarning("Switching to Standby Number",
+		fmt.Sprintf("+%s %s, continuing from +%s", s.primary.Store.ID.User, reason, s.standby.Store.ID.User),
+		[]string{
+			fmt.Sprintf("Pausing %s before the standby starts sending", standbyPause),
+			fmt.Sprintf("Limits for the standby: %d/hour, %d/day", config.HourlyLimit, config.DailyLimit),
+			"The results CSV shows which number sent each message",
+		})
+	go notifyOperator(notifyLoggedOut, "Switched to standby number",
+		fmt.Sprintf("+%s %s; the campaign continues from +%s", s.primary.Store.ID.User, reason, s.standby.Store.ID.User))
+	sleepWithContext(s.ctx, standbyPause)
+}
+
+// warmingUp reports whether the next send is one of the first after a
+// switch, counting it
+func (s *standbySender) warmingUp() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.warmup == 0 {
+		return false
+	}
+	s.warmup--
+	return true
+}
+
+// sentFrom names the number a message went out from, for the results
+func sentFrom(client Sender) string {
+	return strings.TrimSuffix(senderAccount(client), "@"+types.DefaultUserServer)
+}
+
+func (s *standbySender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	client := s.active()
+	resp, err := client.SendMessage(ctx, to, message, extra...)
+	if err == nil || client == s.standby || !isBanError(err) {
+		return resp, err
+	}
+
+	s.switchOver(fmt.Sprintf("can no longer send (%v)", err))
+	if s.current() == s.primary {
+		return resp, err // No standby left to switch to
+	}
+	return s.standby.SendMessage(ctx, to, message, extra...)
+}
+
+func (s *standbySender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	return s.active().IsOnWhatsApp(phones)
+}
+
+func (s *standbySender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return s.active().SendChatPresence(jid, state, media)
+}
+
+func (s *standbySender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return s.active().Upload(ctx, plaintext, mediaType)
+}
+
+func (s *standbySender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return s.active().BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (s *standbySender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return s.active().BuildMessageKey(chat, sender, id)
+}
+
+func (s *standbySender) IsConnected() bool { return s.active().IsConnected() }
+func (s *standbySender) IsLoggedIn() bool  { return s.active().IsLoggedIn() }
+func (s *standbySender) Connect() error    { return s.active().Connect() }
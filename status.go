@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StatusConfig publishes the campaign's progress as JSON for monitoring
+// screens and for following a campaign when the dashboard is unusable
+type StatusConfig struct {
+	Listen   string // Address to serve GET /status on, e.g. "127.0.0.1:9100" ("" = off, --status)
+	File     string // Also write it to this file, e.g. "data/progress.json" ("" = off)
+	Interval int    // Seconds between file writes
+}
+
+// progressStatus is the JSON GET /status returns. It holds counts only, no
+// customer details, so a monitoring screen can poll it without a token.
+type progressStatus struct {
+	State       string `json:"state"` // sending, waiting, batch break, paused or finished
+	StartedAt   string `json:"started_at"`
+	Elapsed     string `json:"elapsed"`
+	Total       int    `json:"total"`
+	Processed   int    `json:"processed"`
+	Successful  int    `json:"successful"`
+	Failed      int    `json:"failed"`
+	Skipped     int    `json:"skipped"`
+	Remaining   int    `json:"remaining"`
+	ETA         string `json:"eta"`
+	DelayMs     int64  `json:"delay_ms"`
+	HourlySent  int    `json:"hourly_sent"`
+	HourlyLimit int    `json:"hourly_limit"`
+	DailySent   int    `json:"daily_sent"`
+	DailyLimit  int    `json:"daily_limit"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// statusServer publishes snapshots the send loop pushes into it, like the
+// web dashboard, so readers never see the progress counters while they change
+type statusServer struct {
+	server *http.Server // nil when only writing the file
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	snapshot progressStatus
+}
+
+// status is the running status publisher, or nil when it is off
+var status *statusServer
+
+// startStatusServer serves GET /status and writes the progress file, if
+// either is configured. Simulated runs leave no progress file.
+func startStatusServer() *statusServer {
+	file := config.Status.File
+	if config.Simulate.Enabled {
+		file = ""
+	}
+	if config.Status.Listen == "" && file == "" {
+		return nil
+	}
+
+	s := &statusServer{done: make(chan struct{})}
+	s.refresh(0, "sending")
+	if file != "" {
+		s.wg.Add(1)
+		go s.writeLoop(file, time.Duration(max(config.Status.Interval, 1))*time.Second)
+	}
+	if config.Status.Listen == "" {
+		return s
+	}
+
+	listener, err := net.Listen("tcp", config.Status.Listen)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Status endpoint unavailable: %v", err))
+		return s
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Status endpoint stopped", err)
+		}
+	}()
+	log.Info(fmt.Sprintf("Progress status on http://%s/status", listener.Addr()))
+	return s
+}
+
+// stop shuts the server down, letting open requests finish, and writes the
+// final state to the progress file
+func (s *statusServer) stop() {
+	if s == nil {
+		return
+	}
+	close(s.done)
+	s.wg.Wait()
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+// writeLoop writes the snapshot to path every interval until stopped
+func (s *statusServer) writeLoop(path string, interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.writeFile(path)
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			s.writeFile(path)
+			return
+		}
+	}
+}
+
+// writeFile replaces path with the latest snapshot. It renames a temporary
+// file into place so a reader never sees half a file.
+func (s *statusServer) writeFile(path string) {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.snapshot, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		log.Debug(fmt.Sprintf("Failed to write progress file: %v", err))
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Debug(fmt.Sprintf("Failed to write progress file: %v", err))
+	}
+}
+
+// refresh copies the progress counters; called from the send loop
+func (s *statusServer) refresh(delay time.Duration, state string) {
+	if s == nil {
+		return
+	}
+	snapshot := progressStatus{
+		State:       state,
+		StartedAt:   progress.StartTime.Format(time.RFC3339),
+		Elapsed:     time.Since(progress.StartTime).Round(time.Second).String(),
+		Total:       progress.Total,
+		Processed:   progress.Processed,
+		Successful:  progress.Successful,
+		Failed:      progress.Failed,
+		Skipped:     progress.Skipped,
+		Remaining:   progress.Remaining,
+		ETA:         formatETA(progress.Successful+progress.Failed, progress.Total),
+		DelayMs:     delay.Milliseconds(),
+		HourlySent:  progress.HourlySent,
+		HourlyLimit: config.HourlyLimit,
+		DailySent:   progress.DailySent,
+		DailyLimit:  config.DailyLimit,
+		UpdatedAt:   time.Now().Format(time.RFC3339),
+	}
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+}
+
+// handleStatus returns the latest snapshot
+func (s *statusServer) handleStatus(rw http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	snapshot := s.snapshot
+	s.mu.Unlock()
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(snapshot)
+}
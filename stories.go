@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// storiesFile holds the statuses waiting to be posted, by ID
+const storiesFile = "data/stories.json"
+
+// storyPollInterval is how often 'story run' re-reads the schedule, so
+// statuses added or cancelled meanwhile are picked up
+const storyPollInterval = time.Minute
+
+// scheduledStory is one text or image status and when to post it. It uses
+// the queue's job states.
+type scheduledStory struct {
+	ID         int
+	Text       string // Status text, or the caption of Media
+	Media      string `json:",omitempty"` // Image or video file
+	Background string `json:",omitempty"` // Text status background, #RRGGBB
+	Due        time.Time
+	Every      string `json:",omitempty"` // Repeat interval, e.g. "24h" ("" = once)
+	Status     string
+	PostedAt   time.Time `json:",omitempty"`
+	Posts      int       `json:",omitempty"`
+	Error      string    `json:",omitempty"`
+}
+
+// storySchedule is the saved schedule
+type storySchedule struct {
+	NextID  int
+	Stories []*scheduledStory
+}
+
+// loadStories reads the schedule; a missing file is an empty schedule
+func loadStories() (*storySchedule, error) {
+	s := &storySchedule{NextID: 1}
+	data, err := os.ReadFile(storiesFile)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid schedule %s: %w", storiesFile, err)
+	}
+	return s, nil
+}
+
+// save writes the schedule atomically
+func (s *storySchedule) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(storiesFile), 0755)
+	tmp := storiesFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, storiesFile)
+}
+
+// story finds a status by ID
+func (s *storySchedule) story(id int) *scheduledStory {
+	for _, story := range s.Stories {
+		if story.ID == id {
+			return story
+		}
+	}
+	return nil
+}
+
+// due returns the waiting statuses whose time has come, and when the next
+// one after them is due
+func (s *storySchedule) due(now time.Time) ([]*scheduledStory, time.Time) {
+	var ready []*scheduledStory
+	var next time.Time
+	for _, story := range s.Stories {
+		switch {
+		case story.Status != jobQueued:
+		case !story.Due.After(now):
+			ready = append(ready, story)
+		case next.IsZero() || story.Due.Before(next):
+			next = story.Due
+		}
+	}
+	return ready, next
+}
+
+// runStoryCommand manages scheduled statuses (stories)
+func runStoryCommand(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "add":
+		return storyAdd(args[1:])
+	case "list":
+		return storyList()
+	case "cancel":
+		return storyCancel(args[1:])
+	case "run":
+		return storyRun(args[1:])
+	}
+	fmt.Println("Usage: venom story [add [-template file] [-media file] [-at time] [-every 24h] | list | cancel <id> | run [-simulate]]")
+	failWith(exitUsage)
+	return fmt.Errorf("unknown story command %q", args[0])
+}
+
+// storyAdd schedules a status from a template, an image or video, or both
+func storyAdd(args []string) error {
+	fs := flag.NewFlagSet("story add", flag.ContinueOnError)
+	templateFile := fs.String("template", "", "template file with the status text (caption with -media)")
+	media := fs.String("media", "", "image or video to post as the status")
+	at := fs.String("at", "", "when to post, e.g. \"2026-10-20 09:30\" (default now)")
+	every := fs.String("every", "", "post again at this interval, e.g. 24h or 168h")
+	background := fs.String("background", "#128C7E", "background color of a text status")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+
+	story := &scheduledStory{Media: *media, Due: time.Now(), Every: *every, Status: jobQueued}
+	if *media != "" {
+		if albumMediaType(*media) == "" {
+			failWith(exitUsage)
+			return fmt.Errorf("unsupported status file type: %s", *media)
+		}
+		if _, err := os.Stat(*media); err != nil {
+			return err
+		}
+	} else {
+		if _, err := parseHexColor(*background); err != nil {
+			failWith(exitUsage)
+			return err
+		}
+		story.Background = *background
+	}
+	if *media == "" || *templateFile != "" {
+		text, err := loadBroadcastPost(*templateFile, "Statuses")
+		if err != nil {
+			return err
+		}
+		story.Text = text
+	}
+	if *at != "" {
+		due, err := time.ParseInLocation("2006-01-02 15:04", *at, time.Local)
+		if err != nil {
+			failWith(exitUsage)
+			return fmt.Errorf("invalid -at %q (use 2006-01-02 15:04)", *at)
+		}
+		story.Due = due
+	}
+	if *every != "" {
+		interval, err := time.ParseDuration(*every)
+		if err != nil || interval < time.Hour {
+			failWith(exitUsage)
+			return fmt.Errorf("invalid -every %q (an interval of an hour or more, e.g. 24h)", *every)
+		}
+	}
+
+	s, err := loadStories()
+	if err != nil {
+		return err
+	}
+	story.ID = s.NextID
+	s.NextID++
+	s.Stories = append(s.Stories, story)
+	if err := s.save(); err != nil {
+		return err
+	}
+	log.Success(fmt.Sprintf("Status %d scheduled for %s; 'venom story run' posts it", story.ID, story.Due.Format("2006-01-02 15:04")))
+	return nil
+}
+
+// storyList shows every status, waiting ones with when they are due
+func storyList() error {
+	s, err := loadStories()
+	if err != nil {
+		return err
+	}
+	if len(s.Stories) == 0 {
+		displayInfo("No Statuses", "Nothing is scheduled", []string{"Add one with: venom story add -template promo.txt"})
+		return nil
+	}
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("SCHEDULED STATUSES")
+	fmt.Println(strings.Repeat("─", 60))
+	for _, story := range s.Stories {
+		kind := "text"
+		if story.Media != "" {
+			kind = filepath.Base(story.Media)
+		}
+		when := story.Due.Format("2006-01-02 15:04")
+		if story.Every != "" {
+			when += " every " + story.Every
+		}
+		fmt.Printf("  %3d  %-10s  %-28s %s\n", story.ID, story.Status, when, truncateRunes(kind, 20))
+		if story.Text != "" {
+			fmt.Println(dim + "       " + truncateRunes(strings.ReplaceAll(story.Text, "\n", " "), 50) + colorReset)
+		}
+		if story.Error != "" {
+			fmt.Println(colorRed + "       " + story.Error + colorReset)
+		}
+	}
+	fmt.Println(strings.Repeat("─", 60))
+	return nil
+}
+
+// storyCancel stops a status from being posted (again)
+func storyCancel(args []string) error {
+	if len(args) != 1 {
+		failWith(exitUsage)
+		return fmt.Errorf("usage: venom story cancel <id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		failWith(exitUsage)
+		return fmt.Errorf("invalid status ID %q", args[0])
+	}
+	s, err := loadStories()
+	if err != nil {
+		return err
+	}
+	story := s.story(id)
+	if story == nil {
+		return fmt.Errorf("no status %d", id)
+	}
+	if story.Status != jobQueued {
+		return fmt.Errorf("status %d is already %s", id, story.Status)
+	}
+	story.Status = jobCancelled
+	if err := s.save(); err != nil {
+		return err
+	}
+	log.Success(fmt.Sprintf("Cancelled status %d", id))
+	return nil
+}
+
+// storyRun posts statuses as they fall due, until none are waiting
+func storyRun(args []string) error {
+	fs := flag.NewFlagSet("story run", flag.ContinueOnError)
+	fs.BoolVar(&config.Simulate.Enabled, "simulate", config.Simulate.Enabled, "post to a simulated WhatsApp; nothing is posted")
+	fs.StringVar(&config.Session.Profile, "profile", config.Session.Profile, "linked number or profile name to post from")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+
+	ctx, cancel := setupShutdownContext()
+	defer cancel()
+
+	var client Sender
+	if config.Simulate.Enabled {
+		client = newMockSender(config.Simulate)
+	} else {
+		defer closeSessionStore()
+		real, err := initializeWhatsApp(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to initialize WhatsApp: %w", err)
+		}
+		defer real.Disconnect()
+		client = real
+	}
+
+	posted := 0
+	var announced time.Time
+	for {
+		s, err := loadStories()
+		if err != nil {
+			return err
+		}
+		ready, next := s.due(time.Now())
+		for _, story := range ready {
+			if ctx.Err() != nil {
+				break
+			}
+			err := postStory(ctx, client, story)
+			story.PostedAt = time.Now()
+			if err != nil {
+				story.Status, story.Error = jobFailed, err.Error()
+				log.Error(fmt.Sprintf("Failed to post status %d", story.ID), err)
+			} else {
+				posted++
+				story.Posts++
+				story.Status, story.Error = jobDone, ""
+				log.Success(fmt.Sprintf("Posted status %d", story.ID))
+			}
+			story.reschedule()
+			if story.Status == jobQueued && (next.IsZero() || story.Due.Before(next)) {
+				next = story.Due
+			}
+		}
+		if err := s.save(); err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil || next.IsZero() {
+			break
+		}
+		if !next.Equal(announced) {
+			log.Info(fmt.Sprintf("Next status due at %s", next.Format("2006-01-02 15:04")))
+			announced = next
+		}
+		if !sleepWithContext(ctx, min(time.Until(next), storyPollInterval)) {
+			break
+		}
+	}
+
+	if posted == 0 && ctx.Err() == nil {
+		displayInfo("No Statuses Due", "Nothing is waiting to be posted", []string{"Add one with: venom story add -template promo.txt"})
+	} else {
+		log.Success(fmt.Sprintf("Posted %d status(es)", posted))
+	}
+	return nil
+}
+
+// reschedule moves a repeating status to its next time after now, even if
+// this post failed
+func (story *scheduledStory) reschedule() {
+	interval, err := time.ParseDuration(story.Every)
+	if story.Every == "" || err != nil || interval <= 0 {
+		return
+	}
+	for !story.Due.After(time.Now()) {
+		story.Due = story.Due.Add(interval)
+	}
+	story.Status = jobQueued
+}
+
+// postStory posts one status from the linked account to its contacts
+func postStory(ctx context.Context, client Sender, story *scheduledStory) error {
+	var message *waE2E.Message
+	if story.Media != "" {
+		item, err := buildAlbumItem(client, story.Media)
+		if err != nil {
+			return err
+		}
+		if story.Text != "" && item.ImageMessage != nil {
+			item.ImageMessage.Caption = proto.String(story.Text)
+		}
+		if story.Text != "" && item.VideoMessage != nil {
+			item.VideoMessage.Caption = proto.String(story.Text)
+		}
+		message = item
+	} else {
+		if strings.TrimSpace(story.Text) == "" {
+			return errors.New("status text is empty")
+		}
+		background, err := parseHexColor(story.Background)
+		if err != nil {
+			return err
+		}
+		message = &waE2E.Message{
+			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+				Text:           proto.String(story.Text),
+				BackgroundArgb: proto.Uint32(argb(background)),
+				TextArgb:       proto.Uint32(0xFFFFFFFF),
+				Font:           waE2E.ExtendedTextMessage_SYSTEM.Enum(),
+			},
+		}
+	}
+	_, err := client.SendMessage(ctx, types.StatusBroadcastJID, message)
+	return err
+}
+
+// argb packs an opaque color the way statuses store it
+func argb(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return 0xFF000000 | (r>>8)<<16 | (g>>8)<<8 | b>>8
+}
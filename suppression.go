@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// OptOutConfig configures the unsubscribe footer and opt-out keywords
+type OptOutConfig struct {
+	AppendFooter    bool     // Append Footer to every rendered message
+	Footer          string   // Footer text; {Keyword} becomes the first opt-out keyword
+	Keywords        []string // Replies that add the sender to the suppression list
+	Confirmation    string   // Reply sent after an opt-out ("" = no reply)
+	SuppressionFile string   // CSV of numbers that must never be messaged
+	IndexAbove      int      // Lists longer than this are looked up in an SQLite index beside the CSV (0 = always in memory)
+}
+
+// suppressionList holds numbers that opted out or were blocked, keyed by
+// formatted phone. Long lists live in an SQLite index next to the CSV
+// instead of memory.
+type suppressionList struct {
+	mu     sync.Mutex
+	path   string
+	phones map[string]string // formatted phone -> reason, while not indexed
+	index  *sql.DB           // set once the list outgrows IndexAbove
+	lookup *sql.Stmt
+}
+
+var suppressions *suppressionList
+
+const suppressionSchema = `
+CREATE TABLE IF NOT EXISTS suppressed (
+	phone  TEXT PRIMARY KEY,
+	reason TEXT
+) WITHOUT ROWID;
+CREATE TABLE IF NOT EXISTS source (
+	size     INTEGER NOT NULL,
+	modified INTEGER NOT NULL
+);
+`
+
+// suppressionIndexPath is the index kept for a suppression CSV
+func suppressionIndexPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".db"
+}
+
+// loadSuppressionList reads the suppression CSV (Phone,Reason,Date). A missing file is an empty list.
+// Rows past OptOut.IndexAbove go to the SQLite index; an index already
+// built from the same CSV is reused without reading the file.
+func loadSuppressionList(path string) (*suppressionList, error) {
+	list := &suppressionList{path: path, phones: make(map[string]string)}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// An index would keep the numbers unencrypted on disk
+	indexable := config.OptOut.IndexAbove > 0 && !dataEncryptionEnabled()
+	if indexable && list.openIndex(info, false) {
+		return list, nil
+	}
+
+	file, err := openDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	var indexing *sql.Tx
+	var insert *sql.Stmt
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if indexing != nil {
+				indexing.Rollback()
+			}
+			list.closeIndex()
+			return nil, err
+		}
+		if i == 0 || len(record) == 0 {
+			continue // header
+		}
+		cleaned := cleanPhoneNumber(record[0])
+		if cleaned == "" {
+			continue
+		}
+		reason := ""
+		if len(record) > 1 {
+			reason = strings.TrimSpace(record[1])
+		}
+		phone := formatPhoneNumber(cleaned)
+
+		if insert == nil {
+			list.phones[phone] = reason
+			if !indexable || len(list.phones) <= config.OptOut.IndexAbove {
+				continue
+			}
+			// Too long to keep in memory: move what was read so far to the index
+			if !list.openIndex(info, true) {
+				indexable = false
+				continue
+			}
+			if indexing, insert, err = list.beginIndexing(); err != nil {
+				list.closeIndex()
+				return nil, err
+			}
+			continue
+		}
+		if _, err := insert.Exec(phone, reason); err != nil {
+			indexing.Rollback()
+			list.closeIndex()
+			return nil, err
+		}
+	}
+
+	if indexing != nil {
+		if err := finishIndexing(indexing, info); err != nil {
+			list.closeIndex()
+			return nil, err
+		}
+		log.Info(fmt.Sprintf("Suppression list indexed in %s", suppressionIndexPath(path)))
+	}
+	return list, nil
+}
+
+// openIndex opens the list's SQLite index. Unless rebuild is set it only
+// succeeds when the index was built from the CSV as it is now.
+func (s *suppressionList) openIndex(info os.FileInfo, rebuild bool) bool {
+	indexPath := suppressionIndexPath(s.path)
+	if !rebuild {
+		if _, err := os.Stat(indexPath); err != nil {
+			return false
+		}
+	}
+	db, err := sql.Open("sqlite3", "file:"+indexPath+"?_busy_timeout=5000")
+	if err != nil {
+		log.Warning(fmt.Sprintf("Suppression index unavailable: %v", err))
+		return false
+	}
+	if _, err := db.Exec(suppressionSchema); err != nil {
+		log.Warning(fmt.Sprintf("Suppression index unavailable: %v", err))
+		db.Close()
+		return false
+	}
+	if !rebuild {
+		var size, modified int64
+		err := db.QueryRow(`SELECT size, modified FROM source`).Scan(&size, &modified)
+		if err != nil || size != info.Size() || modified != info.ModTime().UnixNano() {
+			db.Close()
+			return false
+		}
+	}
+	lookup, err := db.Prepare(`SELECT 1 FROM suppressed WHERE phone = ?`)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Suppression index unavailable: %v", err))
+		db.Close()
+		return false
+	}
+	s.index, s.lookup = db, lookup
+	if !rebuild {
+		s.phones = nil
+	}
+	return true
+}
+
+// beginIndexing empties the index and fills it with the numbers read so
+// far, returning the transaction and statement that add the rest. The rows
+// go in one transaction, since one per row would take hours for millions.
+func (s *suppressionList) beginIndexing() (*sql.Tx, *sql.Stmt, error) {
+	tx, err := s.index.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO suppressed (phone, reason) VALUES (?, ?)`)
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM suppressed; DELETE FROM source`)
+	}
+	for phone, reason := range s.phones {
+		if err != nil {
+			break
+		}
+		_, err = insert.Exec(phone, reason)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	s.phones = nil
+	return tx, insert, nil
+}
+
+// finishIndexing commits the index and records which CSV it was built from
+func finishIndexing(tx *sql.Tx, info os.FileInfo) error {
+	if _, err := tx.Exec(`INSERT INTO source (size, modified) VALUES (?, ?)`,
+		info.Size(), info.ModTime().UnixNano()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// closeIndex closes the index, if the list has one
+func (s *suppressionList) closeIndex() {
+	if s.index != nil {
+		s.index.Close()
+		s.index, s.lookup = nil, nil
+	}
+}
+
+// Contains reports whether a formatted phone number is suppressed
+func (s *suppressionList) Contains(phone string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil {
+		var found int
+		err := s.lookup.QueryRow(phone).Scan(&found)
+		if err != nil && err != sql.ErrNoRows {
+			// Failing open would message someone who opted out
+			log.Error(fmt.Sprintf("Failed to look up %s in the suppression index", phone), err)
+			return true
+		}
+		return err == nil
+	}
+	_, ok := s.phones[phone]
+	return ok
+}
+
+// Add suppresses a formatted phone number and appends it to the suppression file
+func (s *suppressionList) Add(phone, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index != nil {
+		result, err := s.index.Exec(`INSERT OR IGNORE INTO suppressed (phone, reason) VALUES (?, ?)`, phone, reason)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil
+		}
+	} else {
+		if _, ok := s.phones[phone]; ok {
+			return nil
+		}
+		s.phones[phone] = reason
+	}
+
+	os.MkdirAll(filepath.Dir(s.path), 0755)
+	_, statErr := os.Stat(s.path)
+	file, err := appendDataFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if os.IsNotExist(statErr) {
+		writer.Write([]string{"Phone", "Reason", "Date"})
+	}
+	writer.Write([]string{phone, reason, time.Now().Format("2006-01-02 15:04:05")})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// The index already has the number; keep it matching the grown CSV
+	if s.index != nil {
+		if info, err := os.Stat(s.path); err == nil {
+			s.index.Exec(`UPDATE source SET size = ?, modified = ?`, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return nil
+}
+
+// isSuppressed reports whether a formatted phone number must not be messaged
+func isSuppressed(phone string) bool {
+	return suppressions != nil && suppressions.Contains(phone)
+}
+
+// optOutFooter returns the footer text with the opt-out keyword filled in
+func optOutFooter() string {
+	keyword := ""
+	if len(config.OptOut.Keywords) > 0 {
+		keyword = config.OptOut.Keywords[0]
+	}
+	return strings.ReplaceAll(config.OptOut.Footer, "{Keyword}", keyword)
+}
+
+// isOptOutKeyword reports whether a message body is one of the opt-out keywords
+func isOptOutKeyword(text string) bool {
+	normalized := normalizeArabicName(text)
+	if normalized == "" {
+		return false
+	}
+	for _, keyword := range config.OptOut.Keywords {
+		if normalized == normalizeArabicName(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageText returns the plain text body of an incoming message
+func messageText(msg *waE2E.Message) string {
+	if text := msg.GetConversation(); text != "" {
+		return text
+	}
+	return msg.GetExtendedTextMessage().GetText()
+}
+
+// senderPhone returns the sender's phone number, using the alternate
+// address or the stored mapping when the message was addressed by LID
+func senderPhone(client *whatsmeow.Client, info types.MessageInfo) string {
+	if info.Sender.Server == types.DefaultUserServer {
+		return info.Sender.User
+	}
+	if info.SenderAlt.Server == types.DefaultUserServer {
+		return info.SenderAlt.User
+	}
+	return phoneForLID(client, info.Sender)
+}
+
+// handleOptOut adds the sender of an opt-out keyword to the suppression list
+func handleOptOut(client *whatsmeow.Client, evt *events.Message) {
+	if suppressions == nil || evt.Info.IsFromMe || evt.Info.IsGroup {
+		return
+	}
+	if !isOptOutKeyword(messageText(evt.Message)) {
+		return
+	}
+
+	phone := senderPhone(client, evt.Info)
+	if phone == "" {
+		log.Warning(fmt.Sprintf("Opt-out received from %s but its phone number is unknown", evt.Info.Sender))
+		return
+	}
+
+	if err := suppressions.Add(phone, "opt-out"); err != nil {
+		log.Error(fmt.Sprintf("Failed to save opt-out for %s", phone), err)
+		return
+	}
+	log.Info(fmt.Sprintf("%s opted out, added to suppression list", phone))
+
+	if config.OptOut.Confirmation != "" {
+		_, err := client.SendMessage(context.Background(), evt.Info.Chat, &waE2E.Message{
+			Conversation: proto.String(config.OptOut.Confirmation),
+		})
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to confirm opt-out to %s", phone), err)
+		}
+	}
+}
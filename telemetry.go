@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TelemetryConfig exports campaign traces and metrics over OTLP/HTTP. The
+// standard OTEL_EXPORTER_OTLP_* variables apply when Endpoint is empty.
+type TelemetryConfig struct {
+	Enabled     bool
+	Endpoint    string // Collector host:port, e.g. "otel-collector:4318"
+	Insecure    bool   // Plain HTTP instead of HTTPS
+	ServiceName string // service.name of the exported data
+}
+
+// instrumentationName names the tracer and meter
+const instrumentationName = "bulk-whatsapp-messaging"
+
+// tracer creates the pipeline's spans. Until startTelemetry installs a
+// provider it is OpenTelemetry's no-op tracer, so spans cost nothing when off.
+var tracer = otel.Tracer(instrumentationName)
+
+// Campaign metrics. Like tracer, they forward to the provider startTelemetry
+// installs and are no-ops until then.
+var (
+	sentCounter     metric.Int64Counter
+	retryCounter    metric.Int64Counter
+	sendDurationHis metric.Float64Histogram
+)
+
+func init() {
+	meter := otel.Meter(instrumentationName)
+	sentCounter, _ = meter.Int64Counter("venom.messages",
+		metric.WithDescription("Messages handled, by result and failure reason"))
+	retryCounter, _ = meter.Int64Counter("venom.retries",
+		metric.WithDescription("Send attempts repeated after a failure"))
+	sendDurationHis, _ = meter.Float64Histogram("venom.send.duration",
+		metric.WithDescription("Duration of each SendMessage call"), metric.WithUnit("s"))
+}
+
+// startTelemetry installs the OTLP trace and metric exporters. The returned
+// function flushes and stops them; it is a no-op when telemetry is off.
+func startTelemetry(ctx context.Context) func() {
+	if !config.Telemetry.Enabled {
+		return func() {}
+	}
+
+	var traceOpts []otlptracehttp.Option
+	var metricOpts []otlpmetrichttp.Option
+	if config.Telemetry.Endpoint != "" {
+		traceOpts = append(traceOpts, otlptracehttp.WithEndpoint(config.Telemetry.Endpoint))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithEndpoint(config.Telemetry.Endpoint))
+	}
+	if config.Telemetry.Insecure {
+		traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+	}
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Telemetry unavailable: %v", err))
+		return func() {}
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Telemetry unavailable: %v", err))
+		traceExporter.Shutdown(ctx)
+		return func() {}
+	}
+
+	res := resource.NewSchemaless(semconv.ServiceName(config.Telemetry.ServiceName))
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res))
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(15*time.Second))),
+		sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	log.Info("Exporting traces and metrics over OTLP")
+
+	return func() {
+		// The campaign context may already be cancelled; flushing needs its own
+		flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := tracerProvider.Shutdown(flushCtx); err != nil {
+			log.Debug(fmt.Sprintf("Failed to flush traces: %v", err))
+		}
+		if err := meterProvider.Shutdown(flushCtx); err != nil {
+			log.Debug(fmt.Sprintf("Failed to flush metrics: %v", err))
+		}
+	}
+}
+
+// startSpan starts a child span of the one in ctx
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends a span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordSendMetrics counts a finished send by result and failure reason
+func recordSendMetrics(ctx context.Context, result MessageResult) {
+	outcome := statusSent
+	if !result.Success {
+		outcome = "failed"
+	}
+	sentCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("result", outcome),
+		attribute.String("reason", result.FailureReason)))
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// templateLabel names the template a message was rendered from by its place
+// in this campaign's rotation: "#2", or "en #1" for a language template
+func templateLabel(template string) string {
+	if template == "" {
+		return ""
+	}
+	if isPollCampaign() {
+		return "poll"
+	}
+	for i, t := range selectedTemplates {
+		if t == template {
+			return fmt.Sprintf("#%d", i+1)
+		}
+	}
+	langs := make([]string, 0, len(languageTemplates))
+	for lang := range languageTemplates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		for i, t := range languageTemplates[lang] {
+			if t == template {
+				return fmt.Sprintf("%s #%d", lang, i+1)
+			}
+		}
+	}
+	return ""
+}
+
+// templateForLabel returns the template templateLabel gave label, or ""
+func templateForLabel(label string) string {
+	if label == "" || label == "poll" {
+		return ""
+	}
+	for _, t := range selectedTemplates {
+		if templateLabel(t) == label {
+			return t
+		}
+	}
+	for _, templates := range languageTemplates {
+		for _, t := range templates {
+			if templateLabel(t) == label {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// templateStats is how one template's messages fared
+type templateStats struct {
+	label, template            string
+	sent, delivered, read      int
+	failed, replied, campaigns int
+}
+
+// rate formats n as a share of the template's sent messages
+func (s templateStats) rate(n int) string {
+	if s.sent == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)/float64(s.sent)*100)
+}
+
+// displayTemplateStats prints delivery and read rates per template of this
+// campaign. Receipts arriving after the report aren't counted; 'venom
+// template-stats' reads the rates from the send history later.
+func displayTemplateStats() {
+	byLabel := make(map[string]*templateStats)
+	var order []string
+	forEachResult(func(r MessageResult) {
+		if r.TemplateLabel == "" {
+			return
+		}
+		s := byLabel[r.TemplateLabel]
+		if s == nil {
+			s = &templateStats{label: r.TemplateLabel, template: r.Template}
+			byLabel[r.TemplateLabel] = s
+			order = append(order, r.TemplateLabel)
+		}
+		if !r.Success {
+			s.failed++
+			return
+		}
+		s.sent++
+		switch deliveries.statusOf(types.MessageID(r.MessageID)) {
+		case statusRead:
+			s.read++
+			s.delivered++
+		case statusDelivered:
+			s.delivered++
+		}
+	})
+	// One template has nothing to compare against
+	if len(order) < 2 {
+		return
+	}
+	sort.Strings(order)
+
+	fmt.Println("TEMPLATES")
+	fmt.Printf("  %-8s %6s %6s %10s %6s  %s\n", "", "Sent", "Failed", "Delivered", "Read", "Starts with")
+	for _, label := range order {
+		s := byLabel[label]
+		fmt.Printf("  %-8s %6d %6d %10s %6s  %s\n", label, s.sent, s.failed, s.rate(s.delivered), s.rate(s.read),
+			truncateRunes(templateSummary(s.template), 30))
+	}
+	fmt.Println(strings.Repeat("─", 60))
+}
+
+// truncateRunes shortens s to n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// runTemplateStatsCommand compares templates across every campaign in the
+// send history, once receipts and replies have had time to come in
+func runTemplateStatsCommand(args []string) error {
+	fs := flag.NewFlagSet("template-stats", flag.ContinueOnError)
+	dbPath := fs.String("db", config.History.File, "send history database")
+	days := fs.Int("days", 90, "only count messages sent in this many days (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		failWith(exitConfig)
+		return fmt.Errorf("no send history at %s", *dbPath)
+	}
+	h, err := openHistory(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer h.close()
+
+	since := ""
+	if *days > 0 {
+		since = time.Now().AddDate(0, 0, -*days).Format(time.RFC3339)
+	}
+	rows, err := h.db.Query(`SELECT template,
+		COUNT(DISTINCT campaign),
+		SUM(s.status NOT IN ('failed', ?)),
+		SUM(s.status IN (?, ?)),
+		SUM(s.status = ?),
+		SUM(s.status = 'failed'),
+		SUM(s.status NOT IN ('failed', ?) AND EXISTS (SELECT 1 FROM inbound i WHERE i.phone = s.sent_to
+			AND julianday(i.received_at) BETWEEN julianday(s.sent_at) AND julianday(s.sent_at) + ?))
+		FROM sends s
+		WHERE IFNULL(template, '') != '' AND sent_at >= ?
+		GROUP BY template`,
+		statusRevoked, statusDelivered, statusRead, statusRead, statusRevoked, engagementReplyDays, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var stats []templateStats
+	for rows.Next() {
+		var s templateStats
+		if err := rows.Scan(&s.template, &s.campaigns, &s.sent, &s.delivered, &s.read, &s.failed, &s.replied); err != nil {
+			return err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(stats) == 0 {
+		displayInfo("No Templates Found", "No messages in the send history for this period", nil)
+		return nil
+	}
+
+	// Best read rate first; rates of a handful of sends mean little
+	sort.Slice(stats, func(i, j int) bool {
+		ri := float64(stats[i].read) / float64(max(stats[i].sent, 1))
+		rj := float64(stats[j].read) / float64(max(stats[j].sent, 1))
+		if ri != rj {
+			return ri > rj
+		}
+		return stats[i].sent > stats[j].sent
+	})
+
+	fmt.Println("\n" + strings.Repeat("─", 60))
+	fmt.Println("TEMPLATE RESULTS")
+	fmt.Println(strings.Repeat("─", 60))
+	for _, s := range stats {
+		fmt.Println(bold + templateSummary(s.template) + colorReset)
+		fmt.Printf("  %d sent in %d campaign(s), %d failed\n", s.sent, s.campaigns, s.failed)
+		fmt.Printf("  Delivered %s   Read %s   Replied %s\n", s.rate(s.delivered), s.rate(s.read), s.rate(s.replied))
+	}
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println(dim + fmt.Sprintf("Rates count receipts received while a campaign was running, and replies within %d days.",
+		engagementReplyDays) + colorReset)
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timelineMaxBatches is how many batches the report lists before it only
+// shows the ones with failures
+const timelineMaxBatches = 20
+
+// timelineHour is the sends that started within one hour
+type timelineHour struct {
+	hour         time.Time
+	sent, failed int
+}
+
+// timelineBatch is one batch's sends, failures and total latency
+type timelineBatch struct {
+	sends, failures int
+	latency         time.Duration
+}
+
+// timelinePause is an idle stretch between two sends
+type timelinePause struct {
+	after    time.Time
+	duration time.Duration
+}
+
+// timelineMaxPauses is how many of the longest pauses the report lists
+const timelineMaxPauses = 3
+
+// sendTimeline tallies the campaign's sends for the timeline breakdown in
+// the report, to tune DelayMin/Max and BatchSize from real data. It keeps
+// counts rather than every send, so a long list doesn't grow it.
+type sendTimeline struct {
+	hours   []timelineHour  // In the order sends started
+	batches []timelineBatch // Indexed by batch number - 1
+	pauses  []timelinePause // Longest first, at most timelineMaxPauses
+	lastEnd time.Time       // When the previous send finished
+}
+
+// timeline is the current campaign's send timeline
+var timeline = &sendTimeline{}
+
+// record adds the send to the customer at index i (0-based)
+func (t *sendTimeline) record(i int, start time.Time, latency time.Duration, success bool) {
+	batch := 1
+	if config.BatchSize > 0 {
+		batch = i/config.BatchSize + 1
+	}
+
+	hour := start.Truncate(time.Hour)
+	if len(t.hours) == 0 || !t.hours[len(t.hours)-1].hour.Equal(hour) {
+		t.hours = append(t.hours, timelineHour{hour: hour})
+	}
+	h := &t.hours[len(t.hours)-1]
+	for len(t.batches) < batch {
+		t.batches = append(t.batches, timelineBatch{})
+	}
+	b := &t.batches[batch-1]
+	b.sends++
+	b.latency += latency
+	if success {
+		h.sent++
+	} else {
+		h.failed++
+		b.failures++
+	}
+
+	if !t.lastEnd.IsZero() {
+		if gap := start.Sub(t.lastEnd); gap >= time.Second {
+			t.addPause(timelinePause{t.lastEnd, gap})
+		}
+	}
+	t.lastEnd = start.Add(latency)
+}
+
+// addPause keeps p if it is one of the longest pauses so far
+func (t *sendTimeline) addPause(p timelinePause) {
+	i := sort.Search(len(t.pauses), func(i int) bool { return t.pauses[i].duration < p.duration })
+	if i >= timelineMaxPauses {
+		return
+	}
+	t.pauses = slices.Insert(t.pauses, i, p)
+	if len(t.pauses) > timelineMaxPauses {
+		t.pauses = t.pauses[:timelineMaxPauses]
+	}
+}
+
+// displaySummary prints messages per hour, each batch's failures and
+// latency, and the longest pauses between sends
+func (t *sendTimeline) displaySummary() {
+	if len(t.hours) == 0 {
+		return
+	}
+
+	// Follows the delivery summary, which ends with a rule when shown
+	if sent, delivered, read := deliveries.counts(); sent+delivered+read == 0 {
+		fmt.Println(strings.Repeat("─", 60))
+	}
+	fmt.Println("TIMELINE")
+
+	// Messages per hour
+	fmt.Println("Per hour:")
+	for _, h := range t.hours {
+		fmt.Printf("  %s  %4d sent  %3d failed\n", h.hour.Format("2006-01-02 15:00"), h.sent, h.failed)
+	}
+
+	// Failures and latency per batch
+	fmt.Println("Per batch:")
+	hidden := 0
+	for i, b := range t.batches {
+		if b.sends == 0 {
+			continue
+		}
+		if len(t.batches) > timelineMaxBatches && b.failures == 0 {
+			hidden++
+			continue
+		}
+		avg := b.latency / time.Duration(b.sends)
+		line := fmt.Sprintf("  Batch %-3d %4d sends  %3d failed  avg %.1fs", i+1, b.sends, b.failures, avg.Seconds())
+		if b.failures > 0 {
+			line = colorYellow + line + colorReset
+		}
+		fmt.Println(line)
+	}
+	if hidden > 0 {
+		fmt.Printf("  %d other batch(es) had no failures\n", hidden)
+	}
+
+	// Longest pauses, e.g. batch breaks, rate limits or lost connections
+	if len(t.pauses) > 0 {
+		fmt.Println("Longest pauses:")
+		for _, p := range t.pauses {
+			fmt.Printf("  %s after %s\n", p.duration.Round(time.Second), p.after.Format("15:04:05"))
+		}
+	}
+	fmt.Println(strings.Repeat("─", 60))
+}
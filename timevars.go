@@ -0,0 +1,38 @@
+package main
+
+import "time"
+
+// greetings holds morning/evening greetings per language code
+var greetings = map[string][2]string{
+	"ar": {"صباح الخير", "مساء الخير"},
+	"en": {"Good morning", "Good evening"},
+	"fr": {"Bonjour", "Bonsoir"},
+}
+
+// dayNames holds weekday names per language code, Sunday first
+var dayNames = map[string][7]string{
+	"ar": {"الأحد", "الإثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+	"en": {"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	"fr": {"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+}
+
+// timePlaceholders computes {Greeting}, {Date} and {DayName} for the send
+// time, in the customer's language (Arabic if unknown)
+func timePlaceholders(customer Customer, now time.Time) map[string]string {
+	lang := customerLanguage(customer)
+	if _, ok := greetings[lang]; !ok {
+		lang = "ar"
+	}
+
+	// Morning runs from 5 AM until noon
+	greeting := greetings[lang][1]
+	if now.Hour() >= 5 && now.Hour() < 12 {
+		greeting = greetings[lang][0]
+	}
+
+	return map[string]string{
+		"Greeting": greeting,
+		"Date":     now.Format(config.DateFormat),
+		"DayName":  dayNames[lang][now.Weekday()],
+	}
+}
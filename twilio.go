@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waCommon "go.mau.fi/whatsmeow/proto/waCommon"
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// TwilioConfig configures sending through Twilio's WhatsApp API
+type TwilioConfig struct {
+	AccountSID string            // Twilio account SID (AC...)
+	AuthToken  string            // Auth token; VENOM_TWILIO_TOKEN overrides it
+	From       string            // Approved WhatsApp sender, e.g. "+14155238886"
+	SMSFrom    string            // Number or sender ID for plain SMS (the sms channel)
+	ContentSID string            // Approved content template (HX...); empty sends free-form text
+	Variables  map[string]string // Template variables with placeholders, e.g. "1": "{CustomerName}"
+}
+
+// twilioSender sends through Twilio's Messages API, as WhatsApp or as plain
+// SMS. Like the Cloud API it can't check numbers, show presence or send polls.
+type twilioSender struct {
+	settings TwilioConfig
+	token    string
+	sms      bool // Send plain SMS from SMSFrom instead of WhatsApp
+	http     *http.Client
+}
+
+var _ Sender = (*twilioSender)(nil)
+
+func init() {
+	registerTransport("twilio", true, func(ctx context.Context) (Sender, func(), error) {
+		sender, err := newTwilioSender(config.Twilio)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info(fmt.Sprintf("Sending through Twilio from %s", config.Twilio.From))
+		return sender, func() {}, nil
+	})
+	registerTransport("sms", false, func(ctx context.Context) (Sender, func(), error) {
+		sender, err := newTwilioSMSSender(config.Twilio)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info(fmt.Sprintf("Sending SMS through Twilio from %s", config.Twilio.SMSFrom))
+		return sender, func() {}, nil
+	})
+}
+
+// newTwilioSender checks the Twilio settings and returns the transport
+func newTwilioSender(settings TwilioConfig) (*twilioSender, error) {
+	token := settings.AuthToken
+	if env := os.Getenv("VENOM_TWILIO_TOKEN"); env != "" {
+		token = env
+	}
+	if settings.AccountSID == "" || token == "" || settings.From == "" {
+		return nil, fmt.Errorf("Twilio.AccountSID, Twilio.AuthToken (or VENOM_TWILIO_TOKEN) and Twilio.From are required for the twilio transport")
+	}
+	return &twilioSender{
+		settings: settings,
+		token:    token,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// newTwilioSMSSender returns a Twilio transport that sends plain SMS
+func newTwilioSMSSender(settings TwilioConfig) (*twilioSender, error) {
+	if settings.SMSFrom == "" {
+		return nil, fmt.Errorf("Twilio.SMSFrom is required for the sms channel")
+	}
+	settings.From = settings.SMSFrom
+	sender, err := newTwilioSender(settings)
+	if err != nil {
+		return nil, err
+	}
+	sender.sms = true
+	return sender, nil
+}
+
+// twilioError is the error body returned by the Twilio API
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Twilio error codes meaning the recipient can't receive WhatsApp messages
+var twilioUndeliverableCodes = map[int]bool{
+	63003: true, // Channel could not find the destination
+	63024: true, // Invalid message recipient
+}
+
+// SendMessage posts a text or location message to Twilio
+func (t *twilioSender) SendMessage(ctx context.Context, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	form := url.Values{}
+	if t.sms {
+		form.Set("From", t.settings.From)
+		form.Set("To", "+"+to.User)
+	} else {
+		form.Set("From", "whatsapp:"+t.settings.From)
+		form.Set("To", "whatsapp:+"+to.User)
+	}
+
+	switch {
+	case message.GetConversation() != "" || message.GetExtendedTextMessage() != nil:
+		// Content templates are for WhatsApp; SMS always carries the rendered text
+		if t.settings.ContentSID == "" || t.sms {
+			text := message.GetConversation()
+			if text == "" {
+				text = message.GetExtendedTextMessage().GetText()
+			}
+			form.Set("Body", text)
+			break
+		}
+
+		// Business-initiated messages must use an approved content template
+		customer, _ := customerFromContext(ctx)
+		variables := make(map[string]string, len(t.settings.Variables))
+		for key, value := range t.settings.Variables {
+			variables[key] = renderPlaceholders(value, ProcessedCustomer{Customer: customer})
+		}
+		encoded, _ := json.Marshal(variables)
+		form.Set("ContentSid", t.settings.ContentSID)
+		form.Set("ContentVariables", string(encoded))
+	case message.GetLocationMessage() != nil:
+		loc := message.GetLocationMessage()
+		if t.sms {
+			form.Set("Body", fmt.Sprintf("%s\n%s\nhttps://maps.google.com/?q=%f,%f", loc.GetName(), loc.GetAddress(), loc.GetDegreesLatitude(), loc.GetDegreesLongitude()))
+			break
+		}
+		form.Set("PersistentAction", fmt.Sprintf("geo:%f,%f|%s", loc.GetDegreesLatitude(), loc.GetDegreesLongitude(), loc.GetName()))
+		form.Set("Body", loc.GetName()+"\n"+loc.GetAddress())
+	case message.GetAlbumMessage() != nil:
+		return whatsmeow.SendResponse{}, fmt.Errorf("twilio: albums aren't supported")
+	case message.GetPollCreationMessage() != nil:
+		return whatsmeow.SendResponse{}, fmt.Errorf("twilio: poll campaigns aren't supported")
+	default:
+		return whatsmeow.SendResponse{}, fmt.Errorf("twilio: media messages need public URLs and aren't supported")
+	}
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", t.settings.AccountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	req.SetBasicAuth(t.settings.AccountSID, t.token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		var apiErr twilioError
+		json.Unmarshal(data, &apiErr)
+		if twilioUndeliverableCodes[apiErr.Code] {
+			return whatsmeow.SendResponse{}, fmt.Errorf("twilio: recipient not on WhatsApp (%d: %s)", apiErr.Code, apiErr.Message)
+		}
+		return whatsmeow.SendResponse{}, fmt.Errorf("twilio: %s (code %d, HTTP %d)", apiErr.Message, apiErr.Code, resp.StatusCode)
+	}
+
+	var reply struct {
+		SID string `json:"sid"`
+	}
+	json.Unmarshal(data, &reply)
+	return whatsmeow.SendResponse{Timestamp: time.Now(), ID: types.MessageID(reply.SID)}, nil
+}
+
+// IsOnWhatsApp reports every number as registered; Twilio has no lookup for it
+func (t *twilioSender) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	results := make([]types.IsOnWhatsAppResponse, 0, len(phones))
+	for _, phone := range phones {
+		user := strings.TrimPrefix(phone, "+")
+		results = append(results, types.IsOnWhatsAppResponse{
+			Query: phone,
+			JID:   types.NewJID(user, types.DefaultUserServer),
+			IsIn:  true,
+		})
+	}
+	return results, nil
+}
+
+func (t *twilioSender) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+func (t *twilioSender) Upload(ctx context.Context, plaintext []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return whatsmeow.UploadResponse{}, fmt.Errorf("twilio: media uploads aren't supported")
+}
+
+func (t *twilioSender) BuildPollCreation(name string, optionNames []string, selectableOptionCount int) *waE2E.Message {
+	return (&whatsmeow.Client{}).BuildPollCreation(name, optionNames, selectableOptionCount)
+}
+
+func (t *twilioSender) BuildMessageKey(chat, sender types.JID, id types.MessageID) *waCommon.MessageKey {
+	return &waCommon.MessageKey{
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(id),
+		RemoteJID: proto.String(chat.String()),
+	}
+}
+
+func (t *twilioSender) IsConnected() bool { return true }
+func (t *twilioSender) IsLoggedIn() bool  { return true }
+func (t *twilioSender) Connect() error    { return nil }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// expectedHeader is the fixed column layout every customer CSV starts with
+var expectedHeader = []string{"Code", "CustomerName", "Phone", "Mobile"}
+
+// RowProblem is one issue found in a customer CSV row
+type RowProblem struct {
+	Line         int
+	Code         string
+	CustomerName string
+	Severity     string // "error" rows would be skipped or fail; "warning" rows still send
+	Field        string
+	Problem      string
+}
+
+// validateCSV checks a customer CSV without sending anything and returns
+// every problem found, in file order
+func validateCSV(filename string) ([]RowProblem, int, error) {
+	problems := []RowProblem{}
+	rows := 0
+	codeLines := make(map[string]int)
+	phoneLines := make(map[string]int)
+
+	err := streamCSV(filename, func(line int, customer Customer) error {
+		rows++
+		add := func(severity, field, problem string) {
+			problems = append(problems, RowProblem{
+				Line:         line,
+				Code:         customer.Code,
+				CustomerName: customer.CustomerName,
+				Severity:     severity,
+				Field:        field,
+				Problem:      problem,
+			})
+		}
+
+		if customer.Code == "" {
+			add("error", "Code", "Code is empty")
+		} else if first, seen := codeLines[customer.Code]; seen {
+			add("error", "Code", fmt.Sprintf("Duplicate code (first seen on line %d)", first))
+		} else {
+			codeLines[customer.Code] = line
+		}
+
+		if customer.CustomerName == "" {
+			add("warning", "CustomerName", "Customer name is empty")
+		}
+
+		if customer.Phone == "" && customer.Mobile == "" {
+			add("error", "Phone", "Both Phone and Mobile are empty")
+			return nil
+		}
+
+		validNumbers := 0
+		for _, field := range []struct{ name, value string }{
+			{"Mobile", customer.Mobile},
+			{"Phone", customer.Phone},
+		} {
+			if field.value == "" {
+				continue
+			}
+			formatted, valid, reason := validateAndFormatPhone(field.value)
+			if !valid {
+				add("warning", field.name, fmt.Sprintf("%q: %s", field.value, reason))
+				continue
+			}
+			validNumbers++
+			if first, seen := phoneLines[formatted]; seen && first != line {
+				add("warning", field.name, fmt.Sprintf("Number %s also used on line %d", formatted, first))
+			} else {
+				phoneLines[formatted] = line
+			}
+		}
+		if validNumbers == 0 {
+			add("error", "Phone", "No valid phone number")
+		}
+		return nil
+	}, func(line int, problem string) {
+		rows++
+		problems = append(problems, RowProblem{Line: line, Severity: "error", Problem: problem})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Header problems go first since they usually explain the row problems
+	headerProblems := []RowProblem{}
+	for i, want := range expectedHeader {
+		switch {
+		case i >= len(csvHeader):
+			headerProblems = append(headerProblems, RowProblem{Line: 1, Severity: "error", Field: want,
+				Problem: fmt.Sprintf("Missing column %d (%s)", i+1, want)})
+		case !strings.EqualFold(csvHeader[i], want):
+			headerProblems = append(headerProblems, RowProblem{Line: 1, Severity: "warning", Field: want,
+				Problem: fmt.Sprintf("Column %d is %q, expected %q", i+1, csvHeader[i], want)})
+		}
+	}
+
+	return append(headerProblems, problems...), rows, nil
+}
+
+// saveValidationReport writes the problems found by validateCSV to a CSV file
+func saveValidationReport(problems []RowProblem, path string) error {
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	file, err := createDataFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{"Line", "Code", "CustomerName", "Severity", "Field", "Problem"})
+	for _, p := range problems {
+		writer.Write([]string{strconv.Itoa(p.Line), p.Code, p.CustomerName, p.Severity, p.Field, p.Problem})
+	}
+	return writer.Error()
+}
+
+// runValidateCommand checks the customer CSV and writes a per-row problem report
+func runValidateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	csvPath := fs.String("csv", "customers.csv", "customer CSV file to validate")
+	outPath := fs.String("out", "data/validation-report.csv", "where to write the problem report")
+	fix := fs.Bool("fix", false, "interactively fix rows with repairable problems")
+	autoFix := fs.Bool("auto-fix", false, "apply every repairable fix without asking")
+	fixedPath := fs.String("fixed-out", "data/customers_fixed.csv", "where to write the cleaned CSV when fixing")
+	fs.StringVar(&config.CSVDelimiter, "delimiter", config.CSVDelimiter, "CSV delimiter: auto, ',', ';', tab or '|'")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if isRemoteCSV(*csvPath) {
+		local, err := fetchRemoteCSV(*csvPath)
+		if err != nil {
+			return err
+		}
+		*csvPath = local
+	}
+
+	if _, err := os.Stat(*csvPath); os.IsNotExist(err) {
+		displayError("File Not Found",
+			fmt.Sprintf("Cannot find CSV file: %s", *csvPath),
+			"Pass the customer file with --csv",
+			[]string{"Example: venom validate --csv customers.csv"})
+		return fmt.Errorf("CSV file not found")
+	}
+
+	// Fix what can be fixed first, so the report only lists what's left
+	if *fix || *autoFix {
+		if err := fixCSV(*csvPath, *fixedPath, *autoFix); err != nil {
+			return err
+		}
+		*csvPath = *fixedPath
+	}
+
+	problems, rows, err := validateCSV(*csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	errorLines := make(map[int]bool)
+	warnings := 0
+	for _, p := range problems {
+		if p.Severity == "error" {
+			errorLines[p.Line] = true
+		} else {
+			warnings++
+		}
+	}
+
+	if len(problems) == 0 {
+		displaySuccess("CSV Valid", fmt.Sprintf("%d rows checked, no problems found", rows))
+		return nil
+	}
+
+	if err := saveValidationReport(problems, *outPath); err != nil {
+		return fmt.Errorf("failed to save validation report: %w", err)
+	}
+
+	details := []string{
+		fmt.Sprintf("Rows checked: %d", rows),
+		fmt.Sprintf("Rows with errors: %d", len(errorLines)),
+		fmt.Sprintf("Warnings: %d", warnings),
+		"Report saved to " + *outPath,
+	}
+	if len(errorLines) > 0 {
+		displayError("CSV Has Errors",
+			fmt.Sprintf("%d rows would be skipped or fail during the campaign", len(errorLines)),
+			"Fix the rows listed in the report and run validate again",
+			details)
+		return fmt.Errorf("%d rows have errors", len(errorLines))
+	}
+	displayWarning("CSV Has Warnings", "No blocking errors, but some rows need attention", details)
+	return nil
+}
+
+// fixCSV runs the fix-up pass over a customer CSV and writes the cleaned copy
+func fixCSV(csvPath, fixedPath string, auto bool) error {
+	customers := []Customer{}
+	lines := []int{}
+	dropped := 0
+	err := streamCSV(csvPath, func(line int, customer Customer) error {
+		customers = append(customers, customer)
+		lines = append(lines, line)
+		return nil
+	}, func(line int, problem string) {
+		dropped++
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	changed, err := fixCustomers(customers, lines, auto)
+	if err != nil {
+		return fmt.Errorf("fix-up cancelled: %w", err)
+	}
+
+	if err := saveCustomersWithWhatsAppStatus(customers, fixedPath); err != nil {
+		return fmt.Errorf("failed to save fixed CSV: %w", err)
+	}
+
+	details := []string{fmt.Sprintf("Rows fixed: %d of %d", changed, len(customers))}
+	if dropped > 0 {
+		details = append(details, fmt.Sprintf("%d unreadable rows were left out", dropped))
+	}
+	displaySuccess("Cleaned CSV Saved", fmt.Sprintf("Wrote %s — validating it now", fixedPath))
+	displayInfo("Fix-up Summary", fmt.Sprintf("%d rows changed", changed), details)
+	return nil
+}
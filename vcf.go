@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"path/filepath"
+	"strings"
+)
+
+// isVCF reports whether a customer file is a vCard contact export
+func isVCF(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".vcf" || ext == ".vcard"
+}
+
+// vcardLine is one unfolded content line of a vCard
+type vcardLine struct {
+	line   int // Where it starts in the file
+	name   string
+	params map[string][]string
+	value  string
+}
+
+// streamVCF reads contacts from a vCard export (a phone's or Google
+// Contacts') and passes each to fn like a CSV row: the contact's UID or its
+// position in the file as Code, the formatted name, and its numbers, a cell
+// number as Mobile and the first other one as Phone. An email address goes
+// into the email channel's column.
+func streamVCF(filename string, fn func(line int, customer Customer) error, onBadRow func(line int, problem string)) error {
+	file, err := openDataFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input, _, err := decodeCSVInput(file)
+	if err != nil {
+		return err
+	}
+
+	emailColumn := config.Email.Column
+	if emailColumn == "" {
+		emailColumn = "Email"
+	}
+	csvHeader = append(append([]string{}, expectedHeader...), emailColumn)
+	csvExtraColumns = []string{emailColumn}
+
+	var card []vcardLine
+	cardStart, cards := 0, 0
+	err = readVCardLines(input, func(l vcardLine) error {
+		switch {
+		case l.name == "BEGIN" && strings.EqualFold(l.value, "VCARD"):
+			card, cardStart = nil, l.line
+		case l.name == "END" && strings.EqualFold(l.value, "VCARD"):
+			cards++
+			customer, ok := vcardCustomer(card, cards, emailColumn)
+			if !ok {
+				log.Warning(fmt.Sprintf("%s line %d: skipping contact without a phone number", filename, cardStart))
+				if onBadRow != nil {
+					onBadRow(cardStart, "Contact has no phone number")
+				}
+				return nil
+			}
+			return fn(cardStart, customer)
+		default:
+			card = append(card, l)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if cards == 0 {
+		return fmt.Errorf("%s has no contacts (BEGIN:VCARD ... END:VCARD)", filename)
+	}
+	return nil
+}
+
+// vcardCustomer turns the lines of one contact into a Customer
+func vcardCustomer(card []vcardLine, position int, emailColumn string) (Customer, bool) {
+	customer := Customer{Code: fmt.Sprintf("VCF-%d", position), Fields: map[string]string{emailColumn: ""}}
+	var structuredName string
+	var numbers []string
+	for _, l := range card {
+		switch l.name {
+		case "UID":
+			if l.value != "" {
+				customer.Code = l.value
+			}
+		case "FN":
+			customer.CustomerName = sanitizeText(l.value)
+		case "N":
+			// Family;Given;Additional;Prefix;Suffix
+			parts := strings.Split(l.value, ";")
+			for len(parts) < 2 {
+				parts = append(parts, "")
+			}
+			structuredName = strings.TrimSpace(parts[1] + " " + parts[0])
+		case "TEL":
+			number := strings.TrimSpace(strings.TrimPrefix(l.value, "tel:"))
+			if number == "" {
+				continue
+			}
+			if customer.Mobile == "" && vcardHasType(l, "CELL", "MOBILE", "IPHONE") {
+				customer.Mobile = number
+			} else {
+				numbers = append(numbers, number)
+			}
+		case "EMAIL":
+			if customer.Fields[emailColumn] == "" {
+				customer.Fields[emailColumn] = l.value
+			}
+		}
+	}
+	if customer.CustomerName == "" {
+		customer.CustomerName = sanitizeText(structuredName)
+	}
+
+	// Without a number marked as a cell, the first one is the likeliest
+	for _, number := range numbers {
+		switch {
+		case customer.Mobile == "":
+			customer.Mobile = number
+		case customer.Phone == "" && number != customer.Mobile:
+			customer.Phone = number
+		}
+	}
+	return customer, customer.Mobile != ""
+}
+
+// vcardHasType reports whether a property has one of the given TYPEs
+func vcardHasType(l vcardLine, types ...string) bool {
+	for _, value := range l.params["TYPE"] {
+		for _, t := range strings.Split(value, ",") {
+			for _, want := range types {
+				if strings.EqualFold(strings.Trim(t, `"`), want) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// readVCardLines unfolds content lines, decodes quoted-printable values
+// (vCard 2.1, as phones still export) and calls fn for each property
+func readVCardLines(r io.Reader, fn func(vcardLine) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	var pending string
+	pendingLine := 0
+
+	flush := func() error {
+		if pending == "" {
+			return nil
+		}
+		defer func() { pending = "" }()
+		l, ok := parseVCardLine(pending, pendingLine)
+		if !ok {
+			return nil
+		}
+		return fn(l)
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case text == "":
+			continue
+		case text[0] == ' ' || text[0] == '\t':
+			pending += text[1:] // Folded continuation
+			continue
+		case strings.HasSuffix(pending, "=") && vcardQuotedPrintable(pending):
+			pending += "\n" + text // Quoted-printable soft line break
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		pending, pendingLine = text, lineNo
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// vcardQuotedPrintable reports whether a raw content line is quoted-printable
+func vcardQuotedPrintable(raw string) bool {
+	head, _, _ := strings.Cut(raw, ":")
+	return strings.Contains(strings.ToUpper(head), "QUOTED-PRINTABLE")
+}
+
+// parseVCardLine splits "group.NAME;PARAM=x;TYPE=y:value" and decodes the value
+func parseVCardLine(raw string, lineNo int) (vcardLine, bool) {
+	head, value, found := strings.Cut(raw, ":")
+	if !found {
+		return vcardLine{}, false
+	}
+	parts := strings.Split(head, ";")
+	name := strings.ToUpper(parts[0])
+	if _, after, grouped := strings.Cut(name, "."); grouped {
+		name = after // item1.TEL from Apple exports
+	}
+
+	l := vcardLine{line: lineNo, name: name, params: make(map[string][]string)}
+	for _, param := range parts[1:] {
+		key, val, hasValue := strings.Cut(param, "=")
+		if !hasValue {
+			// vCard 2.1 bare types, e.g. TEL;CELL:...
+			key, val = "TYPE", param
+		}
+		key = strings.ToUpper(key)
+		l.params[key] = append(l.params[key], val)
+	}
+
+	if vcardQuotedPrintable(raw) {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(strings.ReplaceAll(value, "=\n", "=\r\n"))))
+		if err == nil {
+			value = string(decoded)
+		}
+	}
+	l.value = strings.TrimSpace(vcardUnescape(value))
+	return l, true
+}
+
+// vcardUnescape undoes vCard text escaping (\n, \, and \;)
+func vcardUnescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			if value[i] == 'n' || value[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
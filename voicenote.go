@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	waE2E "go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// VoiceNoteConfig attaches an OGG/Opus voice note after each message
+type VoiceNoteConfig struct {
+	File   string // Voice note sent to every customer
+	Column string // CSV column holding a per-customer file path (overrides File)
+}
+
+// voiceNoteEnabled reports whether voice notes are configured
+func voiceNoteEnabled() bool {
+	return config.VoiceNote.File != "" || config.VoiceNote.Column != ""
+}
+
+// voiceNoteForCustomer returns the voice note file for a customer, if any
+func voiceNoteForCustomer(customer Customer) string {
+	if config.VoiceNote.Column != "" {
+		if path := customer.Fields[config.VoiceNote.Column]; path != "" {
+			return path
+		}
+	}
+	return config.VoiceNote.File
+}
+
+// oggDuration reads the length of an OGG/Opus file from the granule
+// position of its last page (Opus always uses a 48 kHz granule clock)
+func oggDuration(data []byte) time.Duration {
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if last < 0 || last+14 > len(data) {
+		return 0
+	}
+	granule := binary.LittleEndian.Uint64(data[last+6 : last+14])
+	return time.Duration(granule) * time.Second / 48000
+}
+
+// sendVoiceNote uploads (once) and sends a voice note as a push-to-talk
+// message, showing "recording audio" for about as long as the note lasts
+func sendVoiceNote(client Sender, customer ProcessedCustomer, phone string) error {
+	path := voiceNoteForCustomer(customer.Customer)
+	if path == "" {
+		return nil
+	}
+
+	media, err := uploadMediaFile(client, path, whatsmeow.MediaAudio)
+	if err != nil {
+		return err
+	}
+
+	duration := oggDuration(media.Data)
+	jid := recipientJID(client, phone)
+	simulateRecording(client, jid, duration)
+
+	_, err = client.SendMessage(context.Background(), jid, &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			Mimetype:      proto.String("audio/ogg; codecs=opus"),
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Seconds:       proto.Uint32(uint32(duration.Seconds())),
+			PTT:           proto.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send voice note: %w", err)
+	}
+	return nil
+}
+
+// simulateRecording shows the "recording audio" indicator in place of typing
+func simulateRecording(client Sender, jid types.JID, duration time.Duration) {
+	if !config.SimulateTyping {
+		return
+	}
+
+	// Keep it believable but bounded: 2-15 seconds
+	duration = min(max(duration, 2*time.Second), 15*time.Second)
+
+	client.SendChatPresence(jid, types.ChatPresenceComposing, types.ChatPresenceMediaAudio)
+	time.Sleep(duration)
+	client.SendChatPresence(jid, types.ChatPresencePaused, types.ChatPresenceMediaAudio)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// VoucherConfig sends each customer their voucher code as a QR code or
+// Code128 barcode image, ready to scan at the till
+type VoucherConfig struct {
+	Format  string // "qr" or "code128" (empty = off)
+	Column  string // CSV column holding each customer's voucher code
+	Caption string // Text under the image; placeholders allowed, the code itself if empty
+}
+
+// voucherEnabled reports whether voucher images are configured
+func voucherEnabled() bool {
+	return config.Voucher.Format != ""
+}
+
+// checkVouchers makes sure the voucher settings work with the loaded CSV
+// and warns about customers who have no voucher code
+func checkVouchers(customers []Customer) error {
+	switch strings.ToLower(config.Voucher.Format) {
+	case "qr", "code128":
+	default:
+		return fmt.Errorf("Voucher.Format must be qr or code128, not %q", config.Voucher.Format)
+	}
+	found := false
+	for _, name := range csvExtraColumns {
+		found = found || name == config.Voucher.Column
+	}
+	if !found {
+		return fmt.Errorf("the CSV has no %q column with voucher codes", config.Voucher.Column)
+	}
+
+	var missing []string
+	for _, customer := range customers {
+		code := strings.TrimSpace(customer.Fields[config.Voucher.Column])
+		switch {
+		case code == "":
+			missing = append(missing, customer.Code)
+		case strings.EqualFold(config.Voucher.Format, "code128"):
+			if _, err := code128Values(code); err != nil {
+				return fmt.Errorf("customer %s: %w", customer.Code, err)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		displayWarning("Customers Without Vouchers",
+			fmt.Sprintf("%d customer(s) have an empty %s column and get the message without a voucher", len(missing), config.Voucher.Column),
+			[]string{"First: " + strings.Join(missing[:min(len(missing), 5)], ", ")})
+	}
+	return nil
+}
+
+// renderVoucher draws a customer's voucher code, or returns nil if they have none
+func renderVoucher(customer ProcessedCustomer) ([]byte, image.Rectangle, error) {
+	code := strings.TrimSpace(customer.Fields[config.Voucher.Column])
+	if code == "" {
+		return nil, image.Rectangle{}, nil
+	}
+
+	var canvas *image.RGBA
+	if strings.EqualFold(config.Voucher.Format, "code128") {
+		var err error
+		if canvas, err = drawCode128(code); err != nil {
+			return nil, image.Rectangle{}, err
+		}
+	} else {
+		const size = 480
+		canvas = image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+		if err := drawQR(canvas, code, ImageOverlay{Size: size}); err != nil {
+			return nil, image.Rectangle{}, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	return buf.Bytes(), canvas.Bounds(), nil
+}
+
+// sendVoucher sends the customer's voucher image with its caption
+func sendVoucher(client Sender, customer ProcessedCustomer, phone string) error {
+	data, bounds, err := renderVoucher(customer)
+	if err != nil {
+		return fmt.Errorf("failed to render voucher: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+	caption := renderPlaceholders(config.Voucher.Caption, customer)
+	if caption == "" {
+		caption = customer.Fields[config.Voucher.Column]
+	}
+	return sendGeneratedImage(client, phone, data, bounds, caption)
+}
+
+// code128Patterns are the bar and space widths of Code 128 symbols 0-105,
+// then the stop pattern
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// code128Values encodes text in code set B with its start, checksum and stop symbols
+func code128Values(text string) ([]int, error) {
+	values := []int{code128StartB}
+	sum := code128StartB
+	for i, r := range text {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("voucher code %q has characters a Code128 barcode can't hold; use qr", text)
+		}
+		values = append(values, int(r)-32)
+		sum += (i + 1) * (int(r) - 32)
+	}
+	return append(values, sum%103, code128Stop), nil
+}
+
+// drawCode128 draws a Code128 barcode with the code printed underneath
+func drawCode128(text string) (*image.RGBA, error) {
+	values, err := code128Values(text)
+	if err != nil {
+		return nil, err
+	}
+	const (
+		moduleWidth = 3
+		quiet       = 10 // Modules of white space on each side
+		barHeight   = 140
+		textSize    = 28
+		margin      = 20
+	)
+	modules := 2 * quiet
+	for _, v := range values {
+		for _, w := range code128Patterns[v] {
+			modules += int(w - '0')
+		}
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, modules*moduleWidth, margin+barHeight+textSize+2*margin))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	x := quiet * moduleWidth
+	for _, v := range values {
+		for i, w := range code128Patterns[v] {
+			width := int(w-'0') * moduleWidth
+			if i%2 == 0 { // Bars and spaces alternate, starting with a bar
+				draw.Draw(canvas, image.Rect(x, margin, x+width, margin+barHeight), image.Black, image.Point{}, draw.Src)
+			}
+			x += width
+		}
+	}
+
+	parsed, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: textSize, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+	drawer := &font.Drawer{Dst: canvas, Src: image.Black, Face: face}
+	drawer.Dot = fixed.Point26_6{
+		X: (fixed.I(canvas.Bounds().Dx()) - drawer.MeasureString(text)) / 2,
+		Y: fixed.I(margin + barHeight + margin/2 + textSize),
+	}
+	drawer.DrawString(text)
+	return canvas, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WatchConfig controls 'venom watch', which sends a campaign to every CSV
+// dropped into a folder, e.g. nightly ERP exports
+type WatchConfig struct {
+	Dir          string // Folder watched for new CSV files
+	ProcessedDir string // Where files go once their campaign has run
+	RejectedDir  string // Where unusable files go, with their validation report
+	Profile      string // Campaign profile run against each file
+	Interval     int    // Seconds between looks at the folder
+}
+
+// watchedFile is what a file looked like at the last poll; a file is only
+// taken once it stops changing, so half-written exports are left alone
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// runWatchCommand polls the watch folder and runs the default campaign
+// against each new CSV, one at a time
+func runWatchCommand(args []string) error {
+	settings := config.Watch
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.StringVar(&settings.Dir, "dir", settings.Dir, "folder to watch for customer CSV files")
+	fs.StringVar(&settings.Profile, "profile", settings.Profile, "campaign profile to run against each file")
+	fs.IntVar(&settings.Interval, "interval", settings.Interval, "seconds between looks at the folder")
+	simulate := fs.Bool("simulate", false, "run each campaign against a simulated WhatsApp")
+	once := fs.Bool("once", false, "handle the files already there, then exit")
+	if err := fs.Parse(args); err != nil {
+		failWith(exitUsage)
+		return err
+	}
+
+	if settings.Profile == "" {
+		failWith(exitConfig)
+		return fmt.Errorf("no campaign to run: set Watch.Profile or pass -profile")
+	}
+	profile, err := loadCampaignProfile(settings.Profile)
+	if err != nil {
+		failWith(exitConfig)
+		return err
+	}
+	if profile == nil {
+		failWith(exitConfig)
+		return fmt.Errorf("no campaign profile named %q (save one with --save-profile)", settings.Profile)
+	}
+	for _, dir := range []string{settings.Dir, settings.ProcessedDir, settings.RejectedDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+
+	log.Info(fmt.Sprintf("Watching %s/ for CSV files; each runs campaign profile %q. Press Ctrl+C to stop.",
+		settings.Dir, settings.Profile))
+	interval := time.Duration(max(settings.Interval, 1)) * time.Second
+	seen := make(map[string]watchedFile)
+	for {
+		for _, path := range stableCSVFiles(settings.Dir, seen, *once) {
+			stop, err := ingestCSV(exe, path, settings, *simulate, interrupted)
+			if err != nil || stop {
+				return err
+			}
+			delete(seen, path)
+		}
+		if *once {
+			return nil
+		}
+
+		select {
+		case <-interrupted:
+			log.Info("Stopped watching")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// stableCSVFiles returns the CSV files in dir whose size and modification
+// time haven't changed since the last poll, oldest name first
+func stableCSVFiles(dir string, seen map[string]watchedFile, takeAll bool) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Cannot read %s: %v", dir, err))
+		return nil
+	}
+
+	var ready []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		now := watchedFile{size: info.Size(), modTime: info.ModTime()}
+		if before, ok := seen[path]; takeAll || (ok && before == now) {
+			ready = append(ready, path)
+		}
+		seen[path] = now
+	}
+	sort.Strings(ready)
+	return ready
+}
+
+// ingestCSV validates one file, runs the campaign against it and files it
+// away. stop is set when watching should end, e.g. after Ctrl+C.
+func ingestCSV(exe, path string, settings WatchConfig, simulate bool, interrupted <-chan os.Signal) (stop bool, err error) {
+	name := filepath.Base(path)
+	log.Info(fmt.Sprintf("New file %s", name))
+
+	problems, rows, err := validateCSV(path)
+	errorLines := make(map[int]bool)
+	for _, p := range problems {
+		if p.Severity == "error" {
+			errorLines[p.Line] = true
+		}
+	}
+	switch {
+	case err != nil:
+		return false, rejectCSV(path, settings, problems, fmt.Sprintf("unreadable: %v", err))
+	case rows == 0:
+		return false, rejectCSV(path, settings, problems, "no customer rows")
+	case len(errorLines) >= rows:
+		return false, rejectCSV(path, settings, problems, fmt.Sprintf("all %d rows have errors", rows))
+	case len(errorLines) > 0:
+		log.Warning(fmt.Sprintf("%s: %d of %d rows have errors and will be skipped", name, len(errorLines), rows))
+	}
+
+	code, stopped := runCampaignProcess(campaignCommand(exe, settings.Profile, simulate, "--csv", path), interrupted, nil)
+	switch code {
+	case exitOK, exitCompletedWithFailures:
+		target := filepath.Join(settings.ProcessedDir, time.Now().Format("20060102-150405")+"-"+name)
+		if err := os.Rename(path, target); err != nil {
+			return true, fmt.Errorf("campaign ran but %s could not be moved: %w", path, err)
+		}
+		log.Success(fmt.Sprintf("%s done, moved to %s", name, target))
+		return stopped, nil
+	case exitCSV:
+		return stopped, rejectCSV(path, settings, problems, "the campaign could not use it")
+	case exitInterrupted, exitRateLimited:
+		// Left in place; the next run resumes it from the checkpoint
+		log.Warning(fmt.Sprintf("Campaign for %s stopped early; it stays in %s to resume", name, settings.Dir))
+		failWith(code)
+		return true, nil
+	}
+	// Login, config and other errors aren't the file's fault and would
+	// repeat for every file, so stop until someone fixes them
+	failWith(code)
+	return true, fmt.Errorf("campaign for %s failed (exit %d); it stays in %s", name, code, settings.Dir)
+}
+
+// rejectCSV moves an unusable file to the rejected folder with its report
+func rejectCSV(path string, settings WatchConfig, problems []RowProblem, reason string) error {
+	stamp := time.Now().Format("20060102-150405")
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	target := filepath.Join(settings.RejectedDir, stamp+"-"+filepath.Base(path))
+	if err := os.Rename(path, target); err != nil {
+		return fmt.Errorf("could not move rejected %s: %w", path, err)
+	}
+	if len(problems) > 0 {
+		report := filepath.Join(settings.RejectedDir, stamp+"-"+base+"-report.csv")
+		if err := saveValidationReport(problems, report); err != nil {
+			log.Error("Failed to save validation report", err)
+		}
+	}
+	log.Warning(fmt.Sprintf("Rejected %s (%s), moved to %s", filepath.Base(path), reason, target))
+	notifyOperator(notifyCSVRejected, "CSV rejected", fmt.Sprintf("%s: %s", filepath.Base(path), reason))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// WatchdogConfig controls pausing and reconnecting when the connection drops mid-campaign
+type WatchdogConfig struct {
+	BackoffMin     int // First wait before checking again (milliseconds)
+	BackoffMax     int // Longest wait between reconnect attempts (milliseconds)
+	MaxWaitMinutes int // Give up and stop the campaign after this long offline
+}
+
+// connectionChanged wakes a waiting watchdog when the client reconnects by
+// itself or is logged out, instead of at the end of its backoff
+var connectionChanged = make(chan struct{}, 1)
+
+// signalConnectionChange is called from the connection event handlers
+func signalConnectionChange() {
+	select {
+	case connectionChanged <- struct{}{}:
+	default:
+	}
+}
+
+// isOnline reports whether the client can send right now
+func isOnline(client Sender) bool {
+	return client.IsConnected() && client.IsLoggedIn()
+}
+
+// waitForConnection blocks while the client is offline, reconnecting with
+// exponential backoff. It returns false if the campaign should stop: the
+// context was cancelled, the session was logged out, or MaxWaitMinutes passed.
+func waitForConnection(ctx context.Context, client Sender) bool {
+	if isOnline(client) {
+		return true
+	}
+
+	clearProgress()
+	displayWarning("Connection Lost",
+		"Pausing sends until WhatsApp reconnects",
+		[]string{
+			"Progress is saved; sending resumes automatically",
+			"Press Ctrl+C to stop",
+		})
+	if checkpoint != nil {
+		checkpoint.save()
+	}
+
+	backoff := time.Duration(config.Watchdog.BackoffMin) * time.Millisecond
+	maxBackoff := time.Duration(config.Watchdog.BackoffMax) * time.Millisecond
+	deadline := time.Now().Add(time.Duration(config.Watchdog.MaxWaitMinutes) * time.Minute)
+	offlineSince := time.Now()
+	select {
+	case <-connectionChanged: // From before the connection dropped
+	default:
+	}
+
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-connectionChanged:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if sessionLoggedOut.Load() {
+			displayError("Logged Out",
+				"This device was unlinked from WhatsApp",
+				"Run the tool again and scan the QR code to relink",
+				nil)
+			failWith(exitLoginRequired)
+			return false
+		}
+
+		if !client.IsConnected() {
+			log.Info(fmt.Sprintf("Reconnect attempt %d...", attempt))
+			if err := client.Connect(); err != nil && !errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+				log.Warning(fmt.Sprintf("Reconnect attempt %d failed: %v", attempt, err))
+			}
+		}
+
+		if isOnline(client) {
+			log.Success(fmt.Sprintf("Reconnected after %s, resuming", time.Since(offlineSince).Round(time.Second)))
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			displayError("Connection Not Restored",
+				fmt.Sprintf("Still offline after %d minutes", config.Watchdog.MaxWaitMinutes),
+				"Check the network, then run again and choose Resume",
+				[]string{"Progress was saved to " + checkpointFile})
+			return false
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// webRole is what a user of the HTTP endpoints may do. Each role may also
+// do everything the roles before it may.
+type webRole int
+
+const (
+	roleNone     webRole = iota
+	roleViewer           // Sees progress, the recipient table and the report
+	roleOperator         // Also pauses, resumes and aborts campaigns, downloads results and queues new ones
+	roleAdmin            // Also changes delay and limit settings
+)
+
+func (r webRole) String() string {
+	switch r {
+	case roleViewer:
+		return "viewer"
+	case roleOperator:
+		return "operator"
+	case roleAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+// webAuth holds the API tokens of the HTTP endpoints. A token is given as
+// ?token=, which is kept in a cookie so a page's own requests carry it, or
+// as a bearer token.
+type webAuth struct {
+	operator, viewer, admin string
+}
+
+// newWebAuth returns the configured tokens; VENOM_WEB_TOKEN,
+// VENOM_WEB_VIEWER_TOKEN and VENOM_WEB_ADMIN_TOKEN override them
+func newWebAuth(operator, viewer, admin string) webAuth {
+	if token := os.Getenv("VENOM_WEB_TOKEN"); token != "" {
+		operator = token
+	}
+	if token := os.Getenv("VENOM_WEB_VIEWER_TOKEN"); token != "" {
+		viewer = token
+	}
+	if token := os.Getenv("VENOM_WEB_ADMIN_TOKEN"); token != "" {
+		admin = token
+	}
+	return webAuth{operator: operator, viewer: viewer, admin: admin}
+}
+
+// required reports whether requests need a token. Without one anyone who
+// can reach the server is an admin, so it should only listen on localhost.
+func (a webAuth) required() bool {
+	return a.operator != "" || a.viewer != "" || a.admin != ""
+}
+
+// roleOf returns the role of the token a request carries
+func (a webAuth) roleOf(rw http.ResponseWriter, r *http.Request) webRole {
+	if !a.required() {
+		return roleAdmin
+	}
+	given := r.URL.Query().Get("token")
+	if given != "" {
+		http.SetCookie(rw, &http.Cookie{Name: "venom_token", Value: given, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = bearer
+	} else if cookie, err := r.Cookie("venom_token"); err == nil {
+		given = cookie.Value
+	}
+	switch {
+	case given == "":
+		return roleNone
+	case a.admin != "" && subtle.ConstantTimeCompare([]byte(given), []byte(a.admin)) == 1:
+		return roleAdmin
+	case a.operator != "" && subtle.ConstantTimeCompare([]byte(given), []byte(a.operator)) == 1:
+		return roleOperator
+	case a.viewer != "" && subtle.ConstantTimeCompare([]byte(given), []byte(a.viewer)) == 1:
+		return roleViewer
+	}
+	return roleNone
+}
+
+// require rejects requests whose token doesn't grant at least role
+func (a webAuth) require(role webRole, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		switch got := a.roleOf(rw, r); {
+		case got == roleNone:
+			http.Error(rw, "token required", http.StatusUnauthorized)
+		case got < role:
+			http.Error(rw, "not allowed for a "+got.String(), http.StatusForbidden)
+		default:
+			next(rw, r.WithContext(context.WithValue(r.Context(), webRoleKey{}, got)))
+		}
+	}
+}
+
+// webRoleKey carries the caller's role in a request context
+type webRoleKey struct{}
+
+// requestRole returns the role require granted r
+func requestRole(r *http.Request) webRole {
+	role, _ := r.Context().Value(webRoleKey{}).(webRole)
+	return role
+}
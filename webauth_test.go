@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebAuthRequire(t *testing.T) {
+	auth := webAuth{operator: "op", viewer: "view", admin: "adm"}
+	tests := []struct {
+		name  string
+		token string
+		role  webRole
+		want  int
+	}{
+		{"no token", "", roleViewer, http.StatusUnauthorized},
+		{"wrong token", "nope", roleViewer, http.StatusUnauthorized},
+		{"viewer sees progress", "view", roleViewer, http.StatusOK},
+		{"viewer can't control", "view", roleOperator, http.StatusForbidden},
+		{"operator controls", "op", roleOperator, http.StatusOK},
+		{"operator can't change limits", "op", roleAdmin, http.StatusForbidden},
+		{"admin changes limits", "adm", roleAdmin, http.StatusOK},
+		{"admin sees progress", "adm", roleViewer, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rw := httptest.NewRecorder()
+			auth.require(tt.role, func(http.ResponseWriter, *http.Request) {})(rw, r)
+			if rw.Code != tt.want {
+				t.Errorf("status %d, want %d", rw.Code, tt.want)
+			}
+		})
+	}
+
+	if got := (webAuth{}).roleOf(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)); got != roleAdmin {
+		t.Errorf("role without tokens = %v, want admin", got)
+	}
+}
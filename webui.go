@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// WebConfig controls the browser dashboard served while a campaign sends
+type WebConfig struct {
+	Listen      string // Address to serve on, e.g. "127.0.0.1:8080" ("" = off, --web)
+	Token       string // Operator token: everything; VENOM_WEB_TOKEN overrides
+	ViewerToken string // Viewer token: progress and report only; VENOM_WEB_VIEWER_TOKEN overrides
+	AdminToken  string // Admin token: also delay and limit settings; VENOM_WEB_ADMIN_TOKEN overrides
+}
+
+// With no token set the dashboard is open to anyone who can reach it, so it
+// only listens on localhost. With only ViewerToken set nobody can control
+// the campaign from the browser.
+
+// webDashboard serves campaign progress to a browser, for staff who can't
+// use the terminal UI. The send loop pushes snapshots into it; handlers only
+// read those, never the campaign's own state.
+type webDashboard struct {
+	server *http.Server
+	auth   webAuth
+	paused atomic.Bool
+
+	mu       sync.Mutex
+	stats    dashboardStats
+	eta      string
+	started  time.Time
+	rows     []webRow        // Newest last, at most webMaxRows
+	dropped  int             // Older rows no longer in rows
+	failures []MessageResult // The first reportMaxFailures
+	more     int             // Failures past those
+	settings map[string]int  // Delay and limit settings, for admins
+	finished bool
+}
+
+// webMaxRows is how many recent recipients the status table keeps; the
+// results CSV has them all
+const webMaxRows = 10000
+
+// webRow is one recipient in the browser's status table
+type webRow struct {
+	Time      string `json:"time"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	SentTo    string `json:"sent_to"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	messageID types.MessageID
+}
+
+// web is the running browser dashboard, or nil when it is off
+var web *webDashboard
+
+// startWebDashboard serves the browser dashboard if an address is configured
+func startWebDashboard() *webDashboard {
+	if config.Web.Listen == "" {
+		return nil
+	}
+	w := &webDashboard{auth: newWebAuth(config.Web.Token, config.Web.ViewerToken, config.Web.AdminToken), started: time.Now()}
+	if host, _, _ := net.SplitHostPort(config.Web.Listen); !w.auth.required() && !isLoopbackHost(host) {
+		log.Error("Web dashboard not started",
+			fmt.Errorf("%s is reachable from the network; set Web.Token (or VENOM_WEB_TOKEN) or listen on 127.0.0.1", config.Web.Listen))
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", config.Web.Listen)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Web dashboard unavailable: %v", err))
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", w.handlePage)
+	mux.HandleFunc("GET /api/status", w.auth.require(roleViewer, w.handleStatus))
+	mux.HandleFunc("GET /report", w.auth.require(roleViewer, w.handleReport))
+	mux.HandleFunc("POST /api/pause", w.auth.require(roleOperator, w.handleControl))
+	mux.HandleFunc("POST /api/resume", w.auth.require(roleOperator, w.handleControl))
+	mux.HandleFunc("POST /api/abort", w.auth.require(roleOperator, w.handleControl))
+	mux.HandleFunc("GET /results.csv", w.auth.require(roleOperator, w.handleResults))
+	mux.HandleFunc("GET /api/profiles", w.auth.require(roleOperator, w.handleProfiles))
+	mux.HandleFunc("POST /api/upload", w.auth.require(roleOperator, w.handleUpload))
+	mux.HandleFunc("POST /api/settings", w.auth.require(roleAdmin, w.handleSettings))
+	mux.HandleFunc("POST /api/reload", w.auth.require(roleAdmin, w.handleSettings))
+	w.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := w.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Web dashboard stopped", err)
+		}
+	}()
+	log.Info(fmt.Sprintf("Web dashboard on http://%s/", listener.Addr()))
+	return w
+}
+
+// isLoopbackHost reports whether a listen host only accepts local connections
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// stop shuts the server down, letting open requests finish
+func (w *webDashboard) stop() {
+	if w == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	w.server.Shutdown(ctx)
+}
+
+// update records the customer being processed
+func (w *webDashboard) update(current, total int, name string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Current, w.stats.Total, w.stats.Name = current, total, name
+	w.stats.Status = ""
+	w.refresh()
+}
+
+// setDelay shows the wait before the next send
+func (w *webDashboard) setDelay(delay time.Duration, status string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Delay, w.stats.Status = delay, status
+	w.refresh()
+}
+
+// record adds a send result to the status table
+func (w *webDashboard) record(result MessageResult) {
+	if w == nil {
+		return
+	}
+	status := statusSent
+	if !result.Success {
+		status = "failed"
+	}
+	c := result.Customer
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rows = append(w.rows, webRow{
+		Time:      result.Timestamp.Format("15:04:05"),
+		Code:      c.Code,
+		Name:      c.CustomerName,
+		SentTo:    result.SentTo,
+		Status:    status,
+		Error:     result.Error,
+		messageID: types.MessageID(result.MessageID),
+	})
+	if len(w.rows) > webMaxRows {
+		w.rows = w.rows[1:] // append moves them to a new array once full
+		w.dropped++
+	}
+	if !result.Success && len(w.failures) < reportMaxFailures {
+		w.failures = append(w.failures, result)
+	} else if !result.Success {
+		w.more++
+	}
+	w.refresh()
+}
+
+// finish marks the campaign as done so the page stops offering controls
+func (w *webDashboard) finish() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+	w.stats.Status = "finished"
+	w.refresh()
+}
+
+// isPaused reports whether the campaign was paused from the browser
+func (w *webDashboard) isPaused() bool {
+	return w != nil && w.paused.Load()
+}
+
+// refresh copies the shared counters; called with mu held from the send loop
+func (w *webDashboard) refresh() {
+	w.stats.Successful, w.stats.Failed, w.stats.Skipped = progress.Successful, progress.Failed, progress.Skipped
+	w.stats.HourlySent, w.stats.DailySent = progress.HourlySent, progress.DailySent
+	w.stats.HourlyLimit, w.stats.DailyLimit = config.HourlyLimit, config.DailyLimit
+	w.settings = map[string]int{
+		"DelayMin": config.DelayMin, "DelayMax": config.DelayMax, "BatchSize": config.BatchSize,
+		"BatchDelay": config.BatchDelay, "HourlyLimit": config.HourlyLimit, "DailyLimit": config.DailyLimit,
+	}
+	w.eta = formatETA(progress.Successful+progress.Failed, progress.Total)
+}
+
+func (w *webDashboard) handlePage(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Write([]byte(webPage))
+}
+
+// webPageSize is how many recipients /api/status returns by default, and
+// webMaxPageSize the most it returns however many are asked for
+const (
+	webPageSize    = 100
+	webMaxPageSize = 500
+)
+
+// handleStatus returns the counters and one page of the recipients handled
+// so far, newest first. ?q= filters by name, code or number; ?offset= and
+// ?limit= page through the matches.
+func (w *webDashboard) handleStatus(rw http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = webPageSize
+	}
+	limit = min(limit, webMaxPageSize)
+	offset = max(offset, 0)
+
+	w.mu.Lock()
+	rows := []webRow{}
+	matched := 0
+	for i := len(w.rows) - 1; i >= 0; i-- {
+		row := w.rows[i]
+		if query != "" && !strings.Contains(strings.ToLower(row.Name), query) &&
+			!strings.Contains(strings.ToLower(row.Code), query) && !strings.Contains(row.SentTo, strings.TrimPrefix(query, "+")) {
+			continue
+		}
+		if matched >= offset && len(rows) < limit {
+			rows = append(rows, row)
+		}
+		matched++
+	}
+	body := map[string]interface{}{
+		"rows_total":   len(w.rows),
+		"rows_matched": matched,
+		"rows_dropped": w.dropped,
+		"offset":       offset,
+		"current":      w.stats.Current,
+		"total":        w.stats.Total,
+		"name":         w.stats.Name,
+		"successful":   w.stats.Successful,
+		"failed":       w.stats.Failed,
+		"skipped":      w.stats.Skipped,
+		"hourly_sent":  w.stats.HourlySent,
+		"hourly_limit": w.stats.HourlyLimit,
+		"daily_sent":   w.stats.DailySent,
+		"daily_limit":  w.stats.DailyLimit,
+		"delay_ms":     w.stats.Delay.Milliseconds(),
+		"status":       w.stats.Status,
+		"eta":          w.eta,
+		"elapsed":      time.Since(w.started).Round(time.Second).String(),
+		"finished":     w.finished,
+		"paused":       w.paused.Load() || operatorPaused.Load(),
+		"role":         requestRole(r).String(),
+	}
+	p50, p95, p99 := latencies.percentiles()
+	body["latency_ms"] = map[string]int64{"p50": p50.Milliseconds(), "p95": p95.Milliseconds(), "p99": p99.Milliseconds()}
+	if requestRole(r) == roleAdmin {
+		body["settings"] = w.settings
+	}
+	w.mu.Unlock()
+
+	// Delivery receipts arrive after the row was recorded
+	for i := range rows {
+		if status := deliveries.statusOf(rows[i].messageID); status != "" && rows[i].Status == statusSent {
+			rows[i].Status = status
+		}
+	}
+	body["rows"] = rows
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(body)
+}
+
+// handleControl pauses, resumes or aborts the campaign
+func (w *webDashboard) handleControl(rw http.ResponseWriter, r *http.Request) {
+	w.mu.Lock()
+	finished := w.finished
+	w.mu.Unlock()
+	if finished {
+		http.Error(rw, "campaign has finished", http.StatusConflict)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/pause":
+		if !w.paused.Swap(true) {
+			log.Warning("Campaign paused from the web dashboard")
+		}
+	case "/api/resume":
+		if w.paused.Swap(false) {
+			log.Info("Campaign resumed from the web dashboard")
+		}
+	case "/api/abort":
+		log.Warning("Abort requested from the web dashboard")
+		w.paused.Store(false)
+		abortCampaign()
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handleSettings changes the campaign's delay and limit settings, from the
+// JSON body or by reloading the config file. Like SIGHUP, the change
+// applies before the next message.
+func (w *webDashboard) handleSettings(rw http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/reload" {
+		if !sendingCampaign.Load() {
+			http.Error(rw, "no campaign is sending", http.StatusConflict)
+			return
+		}
+		requestConfigReload()
+		rw.WriteHeader(http.StatusAccepted)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, 64<<10))
+	if err != nil {
+		http.Error(rw, "settings too large", http.StatusBadRequest)
+		return
+	}
+	if err := requestSettingsChange(data); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// handleResults downloads the results CSV written so far
+func (w *webDashboard) handleResults(rw http.ResponseWriter, r *http.Request) {
+	if resultsFile.path == "" {
+		http.Error(rw, "no results file for this run", http.StatusNotFound)
+		return
+	}
+	data, err := readDataFile(resultsFile.path)
+	if err != nil {
+		http.Error(rw, "results unavailable", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.Header().Set("Content-Disposition", "attachment; filename=\""+filepath.Base(resultsFile.path)+"\"")
+	rw.Write(data)
+}
+
+// handleReport renders the HTML campaign report as it stands
+func (w *webDashboard) handleReport(rw http.ResponseWriter, r *http.Request) {
+	host, _ := os.Hostname()
+	_, delivered, read := deliveries.counts()
+
+	w.mu.Lock()
+	summary := reportSummary{
+		Host:         host,
+		Start:        w.started.Format("2006-01-02 15:04:05"),
+		End:          time.Now().Format("2006-01-02 15:04:05"),
+		Duration:     time.Since(w.started).Round(time.Second).String(),
+		Total:        w.stats.Total,
+		Successful:   w.stats.Successful,
+		Failed:       w.stats.Failed,
+		Skipped:      w.stats.Skipped,
+		SuccessRate:  "0%",
+		Delivered:    delivered,
+		Read:         read,
+		Failures:     append([]MessageResult(nil), w.failures...),
+		MoreFailures: w.more,
+	}
+	w.mu.Unlock()
+	if done := summary.Successful + summary.Failed; done > 0 {
+		summary.SuccessRate = fmt.Sprintf("%.1f%%", float64(summary.Successful)/float64(done)*100)
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, summary); err != nil {
+		http.Error(rw, "report unavailable", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Write(buf.Bytes())
+}
+
+// uploadDir keeps customer lists uploaded from the web dashboard
+const uploadDir = "data/uploads"
+
+// maxUploadSize caps an uploaded customer list
+const maxUploadSize = 64 << 20
+
+// handleProfiles lists the saved campaign profiles an upload can be based on
+func (w *webDashboard) handleProfiles(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(campaignProfileNames())
+}
+
+// handleUpload takes a customer CSV and queues it as a new campaign, sent
+// like the chosen saved profile (templates, delays, number). It runs after
+// the current campaign when 'venom queue run' is serving the queue.
+func (w *webDashboard) handleUpload(rw http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(rw, r.Body, maxUploadSize)
+	base, err := loadCampaignProfile(r.FormValue("profile"))
+	if err != nil || base == nil {
+		http.Error(rw, "choose a saved campaign profile", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(rw, "choose a CSV file (up to 64 MB)", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		http.Error(rw, "upload failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	rows, err := checkUploadedCSV(data)
+	if err != nil {
+		http.Error(rw, header.Filename+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := *base
+	profile.Name = base.Name + "-" + time.Now().Format("20060102-150405")
+	profile.CSVFile = filepath.Join(uploadDir, profile.Name+".csv")
+	profile.SavedAt = time.Now()
+	os.MkdirAll(uploadDir, 0700)
+	if err := writeDataFile(profile.CSVFile, data); err != nil {
+		http.Error(rw, "could not save the list", http.StatusInternalServerError)
+		return
+	}
+	if err := writeCampaignProfile(&profile); err != nil {
+		http.Error(rw, "could not save the campaign", http.StatusInternalServerError)
+		return
+	}
+	job, err := enqueueCampaign(profile.Name)
+	if err != nil {
+		http.Error(rw, "could not queue the campaign", http.StatusInternalServerError)
+		return
+	}
+	log.Info(fmt.Sprintf("Web dashboard: queued %s (%d rows from %s) as job %d", profile.Name, rows, header.Filename, job.ID))
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(map[string]interface{}{"job": job.ID, "profile": profile.Name, "rows": rows})
+}
+
+// checkUploadedCSV makes sure an upload looks like a customer list and
+// counts its rows. Full validation happens when the campaign loads it.
+func checkUploadedCSV(data []byte) (int, error) {
+	input, _, err := decodeCSVInput(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	buffered := bufio.NewReader(input)
+	delimiter, err := csvDelimiter(buffered)
+	if err != nil {
+		return 0, err
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("not a CSV file")
+	}
+	if len(header) < len(expectedHeader) {
+		return 0, fmt.Errorf("expected the columns %s", strings.Join(expectedHeader, ", "))
+	}
+	rows := 0
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %v", rows+2, err)
+		}
+		rows++
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("the file has no customers")
+	}
+	return rows, nil
+}
+
+// webPage is the browser dashboard. It polls /api/status and builds the
+// table with textContent, so customer names are never parsed as HTML.
+const webPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Venom campaign</title>
+<style>
+body { font-family: Arial, sans-serif; color: #222; margin: 24px; }
+.cards { display: flex; gap: 12px; flex-wrap: wrap; margin: 16px 0; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 10px 16px; min-width: 110px; }
+.card b { display: block; font-size: 22px; }
+.bar { background: #eee; border-radius: 4px; height: 14px; }
+.bar div { background: #1a7f37; height: 14px; border-radius: 4px; width: 0; }
+button { font-size: 15px; padding: 8px 16px; margin-right: 8px; cursor: pointer; }
+table { border-collapse: collapse; width: 100%; margin-top: 12px; }
+th, td { border-bottom: 1px solid #eee; padding: 6px; text-align: left; }
+.failed { color: #cf222e; } .sent { color: #555; } .delivered, .read { color: #1a7f37; }
+</style></head>
+<body>
+<h2>Campaign <span id="state"></span></h2>
+<div class="bar"><div id="bar"></div></div>
+<p id="now"></p>
+<div class="cards">
+<div class="card">Sent<b id="successful">0</b></div>
+<div class="card">Failed<b id="failed" class="failed">0</b></div>
+<div class="card">Skipped<b id="skipped">0</b></div>
+<div class="card">This hour<b id="hourly">0</b></div>
+<div class="card">Today<b id="daily">0</b></div>
+</div>
+<p>
+<span class="operator" style="display: none">
+<button id="pause" onclick="control('pause')">Pause</button>
+<button id="resume" onclick="control('resume')">Resume</button>
+<button id="abort" onclick="if (confirm('Stop the campaign? Progress is saved and it can be resumed later.')) control('abort')">Abort</button>
+<a href="results.csv">Download results CSV</a> &middot;
+</span>
+<a href="report" target="_blank">Open report</a>
+</p>
+<div class="operator" style="display: none">
+<h3>Queue a campaign</h3>
+<p>
+<select id="profile"></select>
+<input id="csv" type="file" accept=".csv,text/csv">
+<button onclick="upload()">Upload and queue</button>
+<span id="uploaded"></span>
+</p>
+</div>
+<div class="admin" style="display: none">
+<h3>Settings</h3>
+<p id="settings"></p>
+<p>
+<button onclick="saveSettings()">Apply</button>
+<button onclick="reloadConfig()">Reload config file</button>
+<span id="saved"></span>
+</p>
+</div>
+<input id="filter" placeholder="Search name, code or number" oninput="offset = 0; refresh()" style="padding: 6px; width: 280px;">
+<button onclick="page(-1)">Newer</button> <button onclick="page(1)">Older</button> <span id="shown"></span>
+<table><thead><tr><th>Time</th><th>Code</th><th>Name</th><th>Number</th><th>Status</th><th>Error</th></tr></thead>
+<tbody id="rows"></tbody></table>
+<script>
+const pageSize = 100;
+let rows = [], offset = 0, matched = 0, dropped = 0;
+function set(id, text) { document.getElementById(id).textContent = text; }
+function limit(sent, max) { return max > 0 ? sent + ' / ' + max : sent; }
+function page(step) {
+  const next = offset + step * pageSize;
+  if (next < 0 || next >= matched) return;
+  offset = next;
+  refresh();
+}
+function render() {
+  const body = document.getElementById('rows');
+  body.replaceChildren();
+  set('shown', (matched ? (offset + 1) + '-' + (offset + rows.length) + ' of ' + matched : '') +
+    (dropped ? ' (' + dropped + ' older in the results CSV)' : ''));
+  for (const r of rows) {
+    const tr = document.createElement('tr');
+    for (const v of [r.time, r.code, r.name, r.sent_to ? '+' + r.sent_to : '', r.status, r.error || '']) {
+      const td = document.createElement('td');
+      td.textContent = v;
+      tr.appendChild(td);
+    }
+    tr.children[4].className = r.status;
+    body.appendChild(tr);
+  }
+}
+async function refresh() {
+  try {
+    const q = encodeURIComponent(document.getElementById('filter').value);
+    const res = await fetch('api/status?limit=' + pageSize + '&offset=' + offset + '&q=' + q);
+    if (!res.ok) { set('now', 'Not authorized: open this page with ?token=...'); return; }
+    const s = await res.json();
+    const done = s.successful + s.failed;
+    document.getElementById('bar').style.width = (s.total ? 100 * done / s.total : 0) + '%';
+    set('state', s.finished ? '(finished)' : s.paused ? '(paused)' : '(sending)');
+    set('now', s.finished ? 'Finished after ' + s.elapsed :
+      s.current + ' of ' + s.total + (s.name ? ': ' + s.name : '') + (s.status ? ' (' + s.status + ')' : '') + ' · ' + s.eta);
+    set('successful', s.successful);
+    set('failed', s.failed);
+    set('skipped', s.skipped);
+    set('hourly', limit(s.hourly_sent, s.hourly_limit));
+    set('daily', limit(s.daily_sent, s.daily_limit));
+    for (const id of ['pause', 'resume', 'abort']) document.getElementById(id).disabled = s.finished;
+    for (const el of document.querySelectorAll('.operator')) el.style.display = s.role === 'operator' || s.role === 'admin' ? '' : 'none';
+    for (const el of document.querySelectorAll('.admin')) el.style.display = s.role === 'admin' ? '' : 'none';
+    if (s.settings) showSettings(s.settings);
+    document.getElementById('pause').style.display = s.paused ? 'none' : '';
+    document.getElementById('resume').style.display = s.paused ? '' : 'none';
+    rows = s.rows || [];
+    matched = s.rows_matched || 0;
+    dropped = s.rows_dropped || 0;
+    render();
+  } catch (e) {
+    set('now', 'Lost contact with the campaign');
+  }
+}
+async function control(action) {
+  await fetch('api/' + action, { method: 'POST' });
+  refresh();
+}
+async function loadProfiles() {
+  const res = await fetch('api/profiles');
+  if (!res.ok) return;
+  const select = document.getElementById('profile');
+  for (const name of await res.json()) {
+    const option = document.createElement('option');
+    option.value = option.textContent = name;
+    select.appendChild(option);
+  }
+}
+async function upload() {
+  const file = document.getElementById('csv').files[0];
+  if (!file) { set('uploaded', 'Choose a CSV file first'); return; }
+  const form = new FormData();
+  form.append('profile', document.getElementById('profile').value);
+  form.append('file', file);
+  set('uploaded', 'Uploading...');
+  const res = await fetch('api/upload', { method: 'POST', body: form });
+  if (!res.ok) { set('uploaded', await res.text()); return; }
+  const job = await res.json();
+  set('uploaded', 'Queued ' + job.rows + ' customers as job ' + job.job + ' (' + job.profile + '); venom queue run starts it after the current campaign');
+}
+function showSettings(settings) {
+  const box = document.getElementById('settings');
+  for (const [name, value] of Object.entries(settings)) {
+    let input = document.getElementById('setting-' + name);
+    if (!input) {
+      const label = document.createElement('label');
+      label.textContent = name + ' ';
+      input = document.createElement('input');
+      input.id = 'setting-' + name;
+      input.type = 'number';
+      input.min = 0;
+      input.style.width = '90px';
+      label.appendChild(input);
+      box.append(label, ' ');
+    }
+    if (document.activeElement !== input && !input.dataset.edited) input.value = value;
+    input.oninput = () => { input.dataset.edited = '1'; };
+  }
+}
+async function saveSettings() {
+  const changed = {};
+  for (const input of document.querySelectorAll('#settings input')) {
+    if (input.dataset.edited) changed[input.id.slice('setting-'.length)] = Number(input.value);
+  }
+  const res = await fetch('api/settings', { method: 'POST', body: JSON.stringify(changed) });
+  set('saved', res.ok ? 'Applies before the next message' : await res.text());
+  if (res.ok) for (const input of document.querySelectorAll('#settings input')) delete input.dataset.edited;
+}
+async function reloadConfig() {
+  const res = await fetch('api/reload', { method: 'POST' });
+  set('saved', res.ok ? 'Config file reloads before the next message' : await res.text());
+}
+loadProfiles();
+refresh();
+setInterval(refresh, 3000);
+</script>
+</body></html>
+`